@@ -6,6 +6,11 @@
 package actfile
 
 import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
 	"gopkg.in/yaml.v3"
 )
 
@@ -35,6 +40,19 @@ type ActExecStage struct {
 	 */
 	Parallel bool
 
+	/**
+	 * Max number of commands running at the same time when running
+	 * commands in parallel (zero means no limit).
+	 */
+	MaxParallel int
+
+	/**
+	 * How commands of this stage are run. By default each command
+	 * runs in its own shell process (isolated) but stages with many
+	 * tiny commands can reuse a single shell process (persistent).
+	 */
+	ExecMode string
+
 	/**
 	 * Commands to be executed in this exec stage.
 	 */
@@ -60,6 +78,160 @@ type ActExecStage struct {
 	 * Flag indicating if this stage is killed.
 	 */
 	IsKilled bool
+
+	/**
+	 * Line and column where the stage was declared in the actfile.
+	 */
+	Line   int
+	Column int
+}
+
+/**
+ * Act check probe. A probe is a declarative assertion about the
+ * state of the world (like an http endpoint answering or a file
+ * existing). Exactly one probe type should be specified.
+ */
+type ActCheckProbe struct {
+	/**
+	 * Probe name which can be referenced in the check readiness
+	 * expression.
+	 */
+	Name string
+
+	/**
+	 * Shell command which must exit with success.
+	 */
+	Cmd string
+
+	/**
+	 * Url which must answer to a GET request with a success status
+	 * (or with the status specified in Status field).
+	 */
+	Http string
+
+	/**
+	 * Expected http status code.
+	 */
+	Status int
+
+	/**
+	 * Address (like `localhost:5432`) which must accept tcp
+	 * connections.
+	 */
+	Tcp string
+
+	/**
+	 * Path of a file which must exist.
+	 */
+	File string
+
+	/**
+	 * Pid (or path of a pid file) of a process which must be alive.
+	 */
+	Process string
+
+	/**
+	 * Regex which must match the act log.
+	 */
+	Log string
+
+	/**
+	 * Log file to match against the log regex. By default we use
+	 * the log file of the act run.
+	 */
+	LogFile string `yaml:"log_file"`
+
+	/**
+	 * Max time the probe can take to run.
+	 */
+	Timeout time.Duration
+}
+
+/**
+ * Step of the timeline we follow to stop act commands. Each step
+ * sends a signal to commands still running at a given time after
+ * the stop started.
+ */
+type ActStopStep struct {
+	/**
+	 * Signal to send (like `INT`, `TERM` or `KILL`).
+	 */
+	Signal string
+
+	/**
+	 * Time after the stop started when we send the signal.
+	 */
+	After time.Duration
+
+	/**
+	 * Act to run right before sending the signal.
+	 */
+	Hook string
+}
+
+/**
+ * Act notifications. We notify a webhook (and/or a Slack incoming
+ * webhook) when the act finishes or its health checks flip.
+ */
+type ActNotify struct {
+	/**
+	 * Url we post a json payload to.
+	 */
+	Url string
+
+	/**
+	 * Slack incoming webhook url we post a message to.
+	 */
+	Slack string
+
+	/**
+	 * Events we notify about. It can contain `failure`, `success`,
+	 * `unhealthy` and `healthy` (defaults to failure and unhealthy).
+	 */
+	Events []string
+}
+
+/**
+ * Act resource limits. They apply to each command process group of
+ * the act (using cgroups v2 when available and rlimits otherwise).
+ */
+type ActLimits struct {
+	/**
+	 * Max number of cpus (like `0.5` or `2`) commands can use.
+	 */
+	Cpu float64
+
+	/**
+	 * Max memory (like `512M` or `2G`) commands can use.
+	 */
+	Memory string
+
+	/**
+	 * Max number of files each command process can keep open.
+	 */
+	OpenFiles uint64 `yaml:"open_files"`
+
+	/**
+	 * Max number of processes commands can run.
+	 */
+	Processes uint64
+}
+
+/**
+ * Act dependency. Before running an act we make sure all acts it
+ * needs are in the required condition.
+ */
+type ActNeed struct {
+	/**
+	 * Name of the needed act.
+	 */
+	Act string
+
+	/**
+	 * Condition the needed act must be in. It can be `completed`
+	 * (default), `service_started` or `service_healthy`.
+	 */
+	Condition string
 }
 
 /**
@@ -72,9 +244,21 @@ type ActCheck struct {
 	Cmds []*Cmd
 
 	/**
-	 * Interval to run checks.
+	 * Declarative probes to check if act is in success state.
 	 */
-	Interval int
+	Probes []*ActCheckProbe
+
+	/**
+	 * Readiness expression combining named probes with `&&`, `||`,
+	 * `!` and parenthesis (like `api && (db || cache)`). When not
+	 * set all probes must pass.
+	 */
+	Ready string
+
+	/**
+	 * Interval to run checks (like `5s` or `5` for seconds).
+	 */
+	Interval time.Duration
 }
 
 /**
@@ -107,6 +291,12 @@ type Act struct {
 	 */
 	Name string
 
+	/**
+	 * Compiled act name regex (anchored to match the whole name)
+	 * which we compile once when the actfile is read.
+	 */
+	NameRegex *regexp.Regexp `yaml:"-" json:"-"`
+
 	/**
 	 * Act call id is how we uniquely identify an act in a
 	 * subact chain. So, suppose we have the following:
@@ -207,8 +397,9 @@ type Act struct {
 
 	/**
 	 * Definition for act after exec stage. Commands in
-	 * this stage going to be executed when the act is
-	 * in success state (via check commands).
+	 * this stage going to be executed once when the act
+	 * first gets in success state (via checks). It can be
+	 * specified with `after` or `on-ready` field.
 	 */
 	After *ActExecStage
 
@@ -228,6 +419,26 @@ type Act struct {
 	 */
 	Final *ActExecStage
 
+	/**
+	 * Max time final stages can take to run when the execution gets
+	 * stopped (like when user hits Ctrl+C). Final commands still
+	 * running after this timeout going to be killed.
+	 */
+	FinalTimeout time.Duration
+
+	/**
+	 * This stage going to be executed just before the final stage
+	 * but only if all commands of the act succeeded.
+	 */
+	OnSuccess *ActExecStage
+
+	/**
+	 * This stage going to be executed just before the final stage
+	 * but only if some command of the act failed. Commands in this
+	 * stage receive ACT_EXIT_CODE and ACT_FAILED_CMD env vars.
+	 */
+	OnFailure *ActExecStage
+
 	/**
 	 * If we want to reuse an action with same name located in
 	 * another actfile then we can specify this another actfile
@@ -313,11 +524,210 @@ type Act struct {
 	 */
 	Log string
 
+	/**
+	 * Flag indicating if we should print separators between outputs
+	 * of commands in raw log mode.
+	 */
+	Separators *bool
+
 	/**
 	 * Set the shell to be used when running commands. By default
 	 * we use bash shell.
 	 */
 	Shell string
+
+	/**
+	 * Shell options (like `errexit`, `nounset` and `pipefail`) to
+	 * run commands with.
+	 */
+	ShellOpts []string
+
+	/**
+	 * How long we wait commands to exit after asking them to
+	 * terminate before killing them when stopping this act.
+	 */
+	StopGracePeriod *time.Duration
+
+	/**
+	 * When an act is triggered multiple times in a burst (like by
+	 * git hooks or file watchers) we can debounce the runs so only
+	 * the last one of the burst is executed after this quiet period.
+	 */
+	Debounce time.Duration
+
+	/**
+	 * Minimum interval between two runs of this act. Runs triggered
+	 * before this interval elapsed since the last one are skipped.
+	 */
+	MinInterval time.Duration
+
+	/**
+	 * When set we skip a run if another run of this act with the
+	 * same input fingerprint (sources checksum and args) is still
+	 * running.
+	 */
+	Dedupe bool
+
+	/**
+	 * When set only one run of this act can be running at a time in
+	 * the same project.
+	 */
+	Lock bool
+
+	/**
+	 * When set concurrent runs of this act are queued and executed
+	 * serially in submission order.
+	 */
+	Queue bool
+
+	/**
+	 * When set we tee stderr output of commands into a separate
+	 * file in the act data dir.
+	 */
+	StderrLog bool
+
+	/**
+	 * Max size (like 50MB) of the log file of this act when running
+	 * as daemon before we rotate it.
+	 */
+	LogMaxSize string
+
+	/**
+	 * Max age of the log file of this act when running as daemon
+	 * before we rotate it.
+	 */
+	LogMaxAge time.Duration
+
+	/**
+	 * How many rotated log files we keep.
+	 */
+	LogMaxFiles int
+
+	/**
+	 * Run all commands of this act in a pseudo-terminal (see Cmd Tty).
+	 */
+	Tty bool
+
+	/**
+	 * Compile the whole convention script (`acts/<name>.sh`) of this
+	 * act as a template (see Cmd Compile).
+	 */
+	Compile bool
+
+	/**
+	 * List of tags of this act so we can run all acts carrying a tag
+	 * at once (like `act run -tag ci`).
+	 */
+	Tags []string
+
+	/**
+	 * Static vars available to act commands (like vars of a task
+	 * in a Taskfile).
+	 */
+	Vars map[string]string
+
+	/**
+	 * List of glob patterns (relative to actfile location) of files
+	 * considered act inputs. Their content is used to compute the
+	 * run input fingerprint.
+	 */
+	Sources []string
+
+	/**
+	 * Flag indicating that when running as daemon the act accepts
+	 * input from users attached to it (with `act attach`).
+	 */
+	Interactive bool
+
+	/**
+	 * Timeline of signals we send to commands when stopping the act.
+	 * By default we send SIGTERM and then SIGKILL after the stop grace
+	 * period. Commands still running after the last step get killed
+	 * once the stop grace period passed. So we can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   server:
+	 *     start: ./server
+	 *     stop_timeline:
+	 *       - signal: INT
+	 *       - signal: TERM
+	 *         after: 5s
+	 *         hook: notify-stuck
+	 *       - signal: KILL
+	 *         after: 15s
+	 * ```
+	 */
+	StopTimeline []*ActStopStep
+
+	/**
+	 * Acts this act depends on together with the condition they
+	 * must be in before we run this act.
+	 */
+	Needs []*ActNeed
+
+	/**
+	 * Where to notify about act completion and health changes (useful
+	 * for long running daemonized acts nobody is watching).
+	 */
+	Notify *ActNotify
+
+	/**
+	 * Secrets (env var name to secret reference like
+	 * `vault:secret/data/app#password`) resolved when the act runs
+	 * and injected as env vars of its commands.
+	 */
+	Secrets map[string]string
+
+	/**
+	 * Docker container where all commands of the act run (unless a
+	 * command sets its own container).
+	 */
+	Container *CmdContainer
+
+	/**
+	 * Hosts (like `deploy@web1`) where all commands of the act run
+	 * over ssh (unless a command sets its own hosts).
+	 */
+	Hosts []string
+
+	/**
+	 * User (name or uid), umask (in octal like `027`) and nice level
+	 * of all commands of the act (unless a command sets its own).
+	 */
+	User  string
+	Umask string
+	Nice  *int
+
+	/**
+	 * Resource limits (cpu, memory, open files and processes) of the
+	 * act commands.
+	 */
+	Limits *ActLimits
+
+	/**
+	 * Restart policy for acts running as daemon. It can be `never`
+	 * (default), `on-failure` (restart start stage when it fails) or
+	 * `always` (restart start stage whenever it finishes).
+	 */
+	Restart string
+
+	/**
+	 * Max number of times we restart the act (zero means no limit).
+	 */
+	MaxRestarts int
+
+	/**
+	 * Time to wait before the first restart. This time doubles on each
+	 * consecutive restart.
+	 */
+	RestartBackoff time.Duration
+
+	/**
+	 * Line and column where the act was declared in the actfile.
+	 */
+	Line   int
+	Column int
 }
 
 //############################################################
@@ -328,28 +738,75 @@ type Act struct {
  * the acts map and convert it to an array of acts so we can
  * keep the same key order of the defined map by user.
  */
-func DecodeActs(actsNode yaml.Node) []*Act {
+func DecodeActs(actsNode yaml.Node) ([]*Act, error) {
 	var acts []*Act
 
-	for i := 0; i < len(actsNode.Content); i += 2 {
+	for i := 0; i+1 < len(actsNode.Content); i += 2 {
 		var actName string
 		var act Act
 
-		actsNode.Content[i].Decode(&actName)
-		actsNode.Content[i+1].Decode(&act)
+		if err := actsNode.Content[i].Decode(&actName); err != nil {
+			return nil, err
+		}
+
+		if err := actsNode.Content[i+1].Decode(&act); err != nil {
+			return nil, err
+		}
 
 		act.Name = actName
 
 		acts = append(acts, &act)
 	}
 
-	return acts
+	return acts, nil
+}
+
+/**
+ * This function going to decode act needs. Needs can be specified
+ * as a list of act names (which must complete) or as a map from act
+ * name to condition (keeping the order defined by user).
+ */
+func DecodeNeeds(needsNode yaml.Node) []*ActNeed {
+	var needs []*ActNeed
+	var needsArr []string
+
+	if needsNode.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(needsNode.Content); i += 2 {
+			needs = append(needs, &ActNeed{
+				Act:       needsNode.Content[i].Value,
+				Condition: needsNode.Content[i+1].Value,
+			})
+		}
+	} else if err := needsNode.Decode(&needsArr); err == nil {
+		for _, actName := range needsArr {
+			needs = append(needs, &ActNeed{Act: actName})
+		}
+	}
+
+	return needs
+}
+
+/**
+ * This function going to decode hosts where commands run over ssh.
+ * Hosts can be specified as a single host or as a list of hosts.
+ */
+func DecodeHosts(hostsNode yaml.Node) []string {
+	var host string
+	var hosts []string
+
+	if err := hostsNode.Decode(&host); err == nil && host != "" {
+		return []string{host}
+	}
+
+	hostsNode.Decode(&hosts)
+
+	return hosts
 }
 
 /**
  * This function going to decode generic cmds.
  */
-func DecodeCmds(cmdsNode yaml.Node) []*Cmd {
+func DecodeCmds(cmdsNode yaml.Node) ([]*Cmd, error) {
 	/**
 	 * Try to decode from string first, then directly
 	 * from array.
@@ -360,26 +817,29 @@ func DecodeCmds(cmdsNode yaml.Node) []*Cmd {
 	if err := cmdsNode.Decode(&cmdStr); err == nil {
 		// For some reason if we don't have cmdNode its decoding to string.
 		if cmdStr == "" {
-			return nil
+			return nil, nil
 		}
 
-		cmd := &Cmd{Cmd: cmdStr}
+		cmd := &Cmd{Cmd: cmdStr, Line: cmdsNode.Line, Column: cmdsNode.Column}
 		cmds = append(cmds, cmd)
-		return cmds
-	} else if err := cmdsNode.Decode(&cmds); err == nil {
-		return cmds
+		return cmds, nil
 	}
 
-	return nil
+	if err := cmdsNode.Decode(&cmds); err != nil {
+		return nil, err
+	}
+
+	return cmds, nil
 }
 
 /**
  * This function going to convert generic exec stages.
  */
-func DecodeExecStage(stageNode yaml.Node, name string) *ActExecStage {
+func DecodeExecStage(stageNode yaml.Node, name string) (*ActExecStage, error) {
 	var stageObj struct {
 		Name     string
 		Parallel bool
+		ExecMode string `yaml:"exec_mode"`
 		Cmds     yaml.Node
 		Script   string
 		Shell    string
@@ -396,36 +856,85 @@ func DecodeExecStage(stageNode yaml.Node, name string) *ActExecStage {
 	if err := stageNode.Decode(&stageStr); err == nil {
 		// For some reason if we don't have stageNode its decoding to string.
 		if stageStr == "" {
-			return nil
+			return nil, nil
 		}
 
-		cmd := &Cmd{Cmd: stageStr}
+		cmd := &Cmd{Cmd: stageStr, Line: stageNode.Line, Column: stageNode.Column}
 
 		return &ActExecStage{
-			Name: name,
-			Cmds: []*Cmd{cmd},
+			Name:   name,
+			Cmds:   []*Cmd{cmd},
+			Line:   stageNode.Line,
+			Column: stageNode.Column,
+		}, nil
+	}
+
+	if stageNode.Kind == yaml.SequenceNode {
+		if err := stageNode.Decode(&stageArr); err != nil {
+			return nil, err
 		}
-	} else if err := stageNode.Decode(&stageArr); err == nil {
+
 		return &ActExecStage{
-			Name: name,
-			Cmds: stageArr,
-		}
-	} else if err := stageNode.Decode(&stageObj); err == nil {
-		cmds := DecodeCmds(stageObj.Cmds)
-
-		if cmds != nil {
-			return &ActExecStage{
-				Name:     name,
-				Parallel: stageObj.Parallel,
-				Cmds:     cmds,
-				Script:   stageObj.Script,
-				Shell:    stageObj.Shell,
-				Quiet:    stageObj.Quiet,
-			}
+			Name:   name,
+			Cmds:   stageArr,
+			Line:   stageNode.Line,
+			Column: stageNode.Column,
+		}, nil
+	}
+
+	if err := stageNode.Decode(&stageObj); err != nil {
+		return nil, err
+	}
+
+	cmds, err := DecodeCmds(stageObj.Cmds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cmds != nil {
+		return &ActExecStage{
+			Name:     name,
+			Parallel: stageObj.Parallel,
+			ExecMode: stageObj.ExecMode,
+			Cmds:     cmds,
+			Script:   stageObj.Script,
+			Shell:    stageObj.Shell,
+			Quiet:    stageObj.Quiet,
+			Line:     stageNode.Line,
+			Column:   stageNode.Column,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+/**
+ * This function going to decode a duration. Besides duration strings
+ * (like `1m30s`) we accept bare numbers as seconds (like `5`).
+ */
+func DecodeDuration(node *yaml.Node) (time.Duration, error) {
+	if node.Kind == 0 {
+		return 0, nil
+	}
+
+	if node.Tag == "!!int" || node.Tag == "!!float" {
+		var seconds float64
+
+		if err := node.Decode(&seconds); err != nil {
+			return 0, err
 		}
+
+		return time.Duration(seconds * float64(time.Second)), nil
 	}
 
-	return nil
+	var duration time.Duration
+
+	if err := node.Decode(&duration); err != nil {
+		return 0, fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+
+	return duration, nil
 }
 
 //############################################################
@@ -456,48 +965,253 @@ func (act *Act) UnmarshalYAML(value *yaml.Node) error {
 		Quiet    			bool
 		Parallel 			bool
 		Log      			string
+		Separators    *bool
 		Shell    			string
+		ShellOpts     []string `yaml:"shellopts"`
 		EnvFilePath 	string `yaml:"envfile"`
 		Before   			yaml.Node
 		Start    			yaml.Node
 		After    			yaml.Node
+		OnReady       yaml.Node `yaml:"on-ready"`
 		Final 				yaml.Node
 		Teardown 			yaml.Node
+		OnSuccess     yaml.Node `yaml:"on_success"`
+		OnFailure     yaml.Node `yaml:"on_failure"`
+		FinalTimeout  time.Duration `yaml:"final_timeout"`
+		Check         *ActCheck
+		Debounce      time.Duration
+		MinInterval   time.Duration `yaml:"min_interval"`
+		Dedupe        bool
+		Lock          bool
+		Queue         bool
+		StderrLog     bool `yaml:"stderr_log"`
+		LogMaxSize    string `yaml:"log_max_size"`
+		LogMaxAge     time.Duration `yaml:"log_max_age"`
+		LogMaxFiles   int `yaml:"log_max_files"`
+		Tty           bool
+		Compile       bool
+		Tags          []string
+		StopGracePeriod *time.Duration `yaml:"stop_grace_period"`
+		Sources       []string
+		Restart       string
+		Needs         yaml.Node
+		StopTimeline  []*ActStopStep `yaml:"stop_timeline"`
+		Interactive   bool
+		MaxRestarts   int `yaml:"max_restarts"`
+		RestartBackoff time.Duration `yaml:"restart_backoff"`
+		Notify        *ActNotify
+		Secrets       map[string]string
+		Container     *CmdContainer
+		Host          yaml.Node
+		User          string
+		Umask         string
+		Nice          *int
+		Limits        *ActLimits
+	}
+
+	// Keep track of where the act was declared.
+	act.Line = value.Line
+	act.Column = value.Column
+
+	if err := value.Decode(&actObj); err != nil {
+		return err
+	}
+
+	act.Desc = actObj.Desc
+	act.Flags = actObj.Flags
+	act.EnvFilePath = actObj.EnvFilePath
+	act.Redirect = actObj.Redirect
+	act.Include = actObj.Include
+	act.Quiet = actObj.Quiet
+	act.Log = actObj.Log
+	act.Separators = actObj.Separators
+	act.Shell = actObj.Shell
+	act.ShellOpts = actObj.ShellOpts
+	act.Debounce = actObj.Debounce
+	act.MinInterval = actObj.MinInterval
+	act.Dedupe = actObj.Dedupe
+	act.Lock = actObj.Lock
+	act.Queue = actObj.Queue
+	act.StderrLog = actObj.StderrLog
+	act.LogMaxSize = actObj.LogMaxSize
+	act.LogMaxAge = actObj.LogMaxAge
+	act.LogMaxFiles = actObj.LogMaxFiles
+	act.Tty = actObj.Tty
+	act.Compile = actObj.Compile
+	act.Tags = actObj.Tags
+	act.StopGracePeriod = actObj.StopGracePeriod
+	act.Sources = actObj.Sources
+	act.Restart = actObj.Restart
+	act.Needs = DecodeNeeds(actObj.Needs)
+	act.StopTimeline = actObj.StopTimeline
+	act.Interactive = actObj.Interactive
+	act.MaxRestarts = actObj.MaxRestarts
+	act.RestartBackoff = actObj.RestartBackoff
+	act.Notify = actObj.Notify
+	act.Secrets = actObj.Secrets
+	act.Container = actObj.Container
+	act.Hosts = DecodeHosts(actObj.Host)
+	act.User = actObj.User
+	act.Umask = actObj.Umask
+	act.Nice = actObj.Nice
+	act.Limits = actObj.Limits
+
+	var err error
+
+	// Lets decode fields
+	if act.Acts, err = DecodeActs(actObj.Acts); err != nil {
+		return err
+	}
+
+	// Decode start stage
+	if act.Start, err = DecodeExecStage(actObj.Start, "start"); err != nil {
+		return err
+	}
+
+	cmds, err := DecodeCmds(actObj.Cmds)
+
+	if err != nil {
+		return err
 	}
 
-	if err := value.Decode(&actObj); err == nil {
-		act.Desc = actObj.Desc
-		act.Flags = actObj.Flags
-		act.EnvFilePath = actObj.EnvFilePath
-		act.Redirect = actObj.Redirect
-		act.Include = actObj.Include
-		act.Quiet = actObj.Quiet
-		act.Log = actObj.Log
-		act.Shell = actObj.Shell
-
-		// Lets decode fields
-		act.Acts = DecodeActs(actObj.Acts)
-
-		// Decode start stage
-		act.Start = DecodeExecStage(actObj.Start, "start")
-		cmds := DecodeCmds(actObj.Cmds)
-
-		if act.Start == nil && cmds != nil {
-			act.Start = &ActExecStage{
-				Name:     "start",
-				Cmds:     cmds,
-				Parallel: actObj.Parallel,
-				Script:   actObj.Script,
-			}
+	if act.Start == nil && cmds != nil {
+		act.Start = &ActExecStage{
+			Name:     "start",
+			Cmds:     cmds,
+			Parallel: actObj.Parallel,
+			Script:   actObj.Script,
+			Line:     actObj.Cmds.Line,
+			Column:   actObj.Cmds.Column,
 		}
+	}
+
+	if act.Before, err = DecodeExecStage(actObj.Before, "before"); err != nil {
+		return err
+	}
+
+	if act.After, err = DecodeExecStage(actObj.After, "after"); err != nil {
+		return err
+	}
+
+	if act.After == nil {
+		if act.After, err = DecodeExecStage(actObj.OnReady, "after"); err != nil {
+			return err
+		}
+	}
+
+	if act.Final, err = DecodeExecStage(actObj.Final, "final"); err != nil {
+		return err
+	}
 
-		act.Before = DecodeExecStage(actObj.Before, "before")
-		act.After = DecodeExecStage(actObj.After, "after")
-		act.Final = DecodeExecStage(actObj.Final, "final")
+	if act.OnSuccess, err = DecodeExecStage(actObj.OnSuccess, "on_success"); err != nil {
+		return err
+	}
 
-		// @deprecated
-		act.Teardown = DecodeExecStage(actObj.Teardown, "final")
+	if act.OnFailure, err = DecodeExecStage(actObj.OnFailure, "on_failure"); err != nil {
+		return err
 	}
 
+	act.FinalTimeout = actObj.FinalTimeout
+	act.Check = actObj.Check
+
+	// @deprecated
+	if act.Teardown, err = DecodeExecStage(actObj.Teardown, "final"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/**
+ * This function going to compile the act name regex.
+ */
+func (act *Act) CompileName() error {
+	re, err := regexp.Compile(fmt.Sprintf("^%s$", act.Name))
+
+	if err != nil {
+		return err
+	}
+
+	act.NameRegex = re
+
+	return nil
+}
+
+/**
+ * This function going to check if the act name regex matches a name
+ * provided by user. Acts not read from an actfile (like the ones we
+ * create at runtime) have no compiled regex so we compile it here.
+ */
+func (act *Act) MatchName(name string) bool {
+	if act.NameRegex == nil {
+		match, _ := regexp.MatchString(fmt.Sprintf("^%s$", act.Name), name)
+		return match
+	}
+
+	return act.NameRegex.MatchString(name)
+}
+
+//############################################################
+// ActCheck Struct Functions
+//############################################################
+
+/**
+ * This function going to parse act checks so interval can be set in
+ * seconds (like `interval: 5`).
+ */
+func (check *ActCheck) UnmarshalYAML(value *yaml.Node) error {
+	var checkObj struct {
+		Cmds     []*Cmd
+		Probes   []*ActCheckProbe
+		Ready    string
+		Interval yaml.Node
+	}
+
+	if err := value.Decode(&checkObj); err != nil {
+		return err
+	}
+
+	interval, err := DecodeDuration(&checkObj.Interval)
+
+	if err != nil {
+		return err
+	}
+
+	check.Cmds = checkObj.Cmds
+	check.Probes = checkObj.Probes
+	check.Ready = checkObj.Ready
+	check.Interval = interval
+
+	return nil
+}
+
+//############################################################
+// ActStopStep Struct Functions
+//############################################################
+
+/**
+ * This function going to parse a stop timeline step making sure its
+ * signal is one we can send (so a typo fails when loading the
+ * actfile instead of when stopping the act).
+ */
+func (step *ActStopStep) UnmarshalYAML(value *yaml.Node) error {
+	var stepObj struct {
+		Signal string
+		After  time.Duration
+		Hook   string
+	}
+
+	if err := value.Decode(&stepObj); err != nil {
+		return err
+	}
+
+	if _, err := utils.ParseSignal(stepObj.Signal); err != nil {
+		return fmt.Errorf("line %d: invalid stop timeline signal %q", value.Line, stepObj.Signal)
+	}
+
+	step.Signal = stepObj.Signal
+	step.After = stepObj.After
+	step.Hook = stepObj.Hook
+
 	return nil
 }
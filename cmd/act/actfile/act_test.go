@@ -0,0 +1,56 @@
+package actfile
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+ * Stop timeline signals are checked when loading the actfile.
+ */
+func TestActStopTimelineSignals(t *testing.T) {
+	var act Act
+
+	if err := yaml.Unmarshal([]byte("stop_timeline:\n  - signal: INT\n  - signal: SIGTERM\n    after: 5s\n"), &act); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(act.StopTimeline) != 2 || act.StopTimeline[1].After != 5*time.Second {
+		t.Errorf("got timeline %v, want INT and TERM after 5s", act.StopTimeline)
+	}
+
+	err := yaml.Unmarshal([]byte("stop_timeline:\n  - signal: TREM\n"), &act)
+
+	if err == nil || !strings.Contains(err.Error(), "invalid stop timeline signal") {
+		t.Errorf("got error %v, want invalid signal error", err)
+	}
+}
+
+/**
+ * Check interval can be set in seconds or as a duration.
+ */
+func TestActCheckInterval(t *testing.T) {
+	for text, want := range map[string]time.Duration{
+		"interval: 5":    5 * time.Second,
+		"interval: 0.5":  500 * time.Millisecond,
+		"interval: 1m5s": 65 * time.Second,
+		"cmds: [true]":   0,
+	} {
+		var check ActCheck
+
+		if err := yaml.Unmarshal([]byte(text), &check); err != nil {
+			t.Errorf("%s: %v", text, err)
+		} else if check.Interval != want {
+			t.Errorf("%s: got interval %v, want %v", text, check.Interval, want)
+		}
+	}
+
+	var check ActCheck
+
+	if err := yaml.Unmarshal([]byte("interval: soon"), &check); err == nil || !strings.Contains(err.Error(), "invalid duration") {
+		t.Errorf("got error %v, want invalid duration error", err)
+	}
+}
@@ -7,8 +7,10 @@
 package actfile
 
 import (
-	"os"
+	"fmt"
+	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/nosebit/act/cmd/act/utils"
 	"gopkg.in/yaml.v3"
@@ -65,6 +67,18 @@ type ActFile struct {
 	 */
 	Log string
 
+	/**
+	 * Template of the prefix of log lines in prefixed log mode. It
+	 * can use .Act, .RunId, .Time and .Stream fields.
+	 */
+	LogFormat string
+
+	/**
+	 * Format of timestamps in log lines which can be a go time layout,
+	 * none, iso8601 or relative (time since the run started).
+	 */
+	LogTimestamp string
+
 	/**
 	 * This wait groups tell parallels acts that actfile
 	 * was initialized.
@@ -76,6 +90,117 @@ type ActFile struct {
 	 * we use bash shell.
 	 */
 	Shell string
+
+	/**
+	 * Shell options (like `errexit`, `nounset` and `pipefail`) to
+	 * run commands with.
+	 */
+	ShellOpts []string
+
+	/**
+	 * Flag indicating if we should print separators between outputs
+	 * of commands in raw log mode. Enabled by default.
+	 */
+	Separators *bool
+
+	/**
+	 * Flag indicating we should expose npm scripts of the package.json
+	 * file next to the actfile as subacts of the `npm` act.
+	 */
+	NpmScripts bool
+
+	/**
+	 * Static vars available to all acts (like global vars of a
+	 * Taskfile).
+	 */
+	Vars map[string]string
+
+	/**
+	 * Foreground runs taking longer than this going to fire a
+	 * desktop notification when they finish.
+	 */
+	NotifyAfter time.Duration
+
+	/**
+	 * Secrets (env var name to secret reference) available to all
+	 * acts.
+	 */
+	Secrets map[string]string
+
+	/**
+	 * AWS settings (region and profile) used to resolve aws secrets.
+	 */
+	Aws *ActFileAws
+}
+
+/**
+ * This struct going to hold an error reading an actfile.
+ */
+type ActFileError struct {
+	/**
+	 * Message describing what failed.
+	 */
+	Msg string
+
+	/**
+	 * The underlying error.
+	 */
+	Err error
+}
+
+/**
+ * This struct going to hold AWS settings of an actfile.
+ */
+type ActFileAws struct {
+	/**
+	 * AWS region (defaults to AWS_REGION env var or profile region).
+	 */
+	Region string
+
+	/**
+	 * AWS profile of shared credentials/config files (defaults to
+	 * AWS_PROFILE env var or default profile).
+	 */
+	Profile string
+
+	/**
+	 * Custom endpoint url of AWS services (like a VPC endpoint or a
+	 * local emulator).
+	 */
+	Endpoint string
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to compile name regexes of acts (and their
+ * subacts) returning an error with the act location when a name is
+ * not a valid regex.
+ */
+func compileActNames(acts []*Act, locationPath string) error {
+	for _, act := range acts {
+		if err := act.CompileName(); err != nil {
+			source := locationPath
+
+			if relPath, relErr := filepath.Rel(utils.GetWd(), locationPath); relErr == nil {
+				source = relPath
+			}
+
+			if act.Line > 0 {
+				source = fmt.Sprintf("%s:%d", source, act.Line)
+			}
+
+			return &ActFileError{Msg: fmt.Sprintf("invalid act name '%s' (%s)", act.Name, source), Err: err}
+		}
+
+		if err := compileActNames(act.Acts, locationPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 //############################################################
@@ -102,38 +227,48 @@ func (actFile *ActFile) UnmarshalYAML(value *yaml.Node) error {
 		Acts        yaml.Node
 		EnvFilePath string `yaml:"envfile"`
 		Log         string
+		LogFormat   string `yaml:"log_format"`
+		LogTimestamp string `yaml:"log_timestamp"`
 		Shell       string
+		ShellOpts   []string `yaml:"shellopts"`
+		Separators  *bool
+		NpmScripts  bool `yaml:"npm_scripts"`
+		NotifyAfter time.Duration `yaml:"notify_after"`
+		Secrets     map[string]string
+		Aws         *ActFileAws
 	}
 
-	if err := value.Decode(&actFileObj); err == nil {
-		actFile.Version = actFileObj.Version
-		actFile.Namespace = actFileObj.Namespace
-		actFile.BeforeAll = actFileObj.BeforeAll
-		actFile.EnvFilePath = actFileObj.EnvFilePath
-		actFile.Log = actFileObj.Log
-		actFile.Shell = actFileObj.Shell
-
-		if actFile.BeforeAll != nil {
-			actFile.BeforeAll.Name = "before"
-		}
-
-		var acts []*Act
-
-		for i := 0; i < len(actFileObj.Acts.Content); i += 2 {
-			var actName string
-			var act Act
-
-			actFileObj.Acts.Content[i].Decode(&actName)
-			actFileObj.Acts.Content[i+1].Decode(&act)
+	if err := value.Decode(&actFileObj); err != nil {
+		return err
+	}
 
-			act.Name = actName
+	actFile.Version = actFileObj.Version
+	actFile.Namespace = actFileObj.Namespace
+	actFile.BeforeAll = actFileObj.BeforeAll
+	actFile.EnvFilePath = actFileObj.EnvFilePath
+	actFile.Log = actFileObj.Log
+	actFile.LogFormat = actFileObj.LogFormat
+	actFile.LogTimestamp = actFileObj.LogTimestamp
+	actFile.Shell = actFileObj.Shell
+	actFile.ShellOpts = actFileObj.ShellOpts
+	actFile.Separators = actFileObj.Separators
+	actFile.NpmScripts = actFileObj.NpmScripts
+	actFile.NotifyAfter = actFileObj.NotifyAfter
+	actFile.Secrets = actFileObj.Secrets
+	actFile.Aws = actFileObj.Aws
+
+	if actFile.BeforeAll != nil {
+		actFile.BeforeAll.Name = "before"
+	}
 
-			acts = append(acts, &act)
-		}
+	acts, err := DecodeActs(actFileObj.Acts)
 
-		actFile.Acts = acts
+	if err != nil {
+		return err
 	}
 
+	actFile.Acts = acts
+
 	return nil
 }
 
@@ -148,35 +283,52 @@ func (actFile *ActFile) UnmarshalYAML(value *yaml.Node) error {
 
 /**
  * This function going to read/parse and actfile.yml from a
- * specific directory.
+ * specific directory. Actfiles are parsed once per run (unless they
+ * change) so each call gets a copy of the parsed actfile sharing its
+ * acts.
  */
-func ReadActFile(filepath string) *ActFile {
-	/**
-	 * We start by creating an empty Actfile struct so we can
-	 * fulfill it.
-	 */
-	spec := ActFile{}
+func ReadActFile(locationPath string) *ActFile {
+	cached, err := getCachedActFile(locationPath)
+
+	if err != nil {
+		if actFileErr, ok := err.(*ActFileError); ok {
+			utils.FatalError(actFileErr.Msg, actFileErr.Err)
+		} else {
+			utils.FatalError("could not read actfile", err)
+		}
 
-	// Try to open actfile.yml
-	file, err := os.Open(filepath)
+		return &ActFile{}
+	}
 
 	/**
-	 * If we can't open the file (it does not exists for example)
-	 * then we give up.
+	 * Before all commands run once per actfile we read so the copy
+	 * starts not initialized.
 	 */
+	spec := *cached
+	spec.InitWg = nil
+
+	return &spec
+}
+
+/**
+ * This function going to read/parse an actfile returning an error
+ * instead of failing so callers serving other users (like the serve
+ * api) can report broken actfiles. Included actfiles are read later
+ * on (like when walking acts) so we make sure they parse as well.
+ */
+func LoadActFile(locationPath string) (*ActFile, error) {
+	cached, err := getCachedActFile(locationPath)
+
 	if err != nil {
-		utils.FatalError("could not read actfile", err)
+		return nil, err
 	}
 
-	// Parse yaml file
-	yaml.NewDecoder(file).Decode(&spec)
+	if err := checkIncludedActFiles(cached, map[string]bool{locationPath: true}); err != nil {
+		return nil, err
+	}
 
-	// Set location path
-	spec.LocationPath = filepath
+	spec := *cached
+	spec.InitWg = nil
 
-	/**
-	 * @TODO : shouldn't we handle yaml parse errors here??
-	 */
-
-	return &spec
+	return &spec, nil
 }
@@ -0,0 +1,43 @@
+package actfile
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+ * Decoding an actfile reports what failed and where instead of
+ * dropping what could not be decoded.
+ */
+func TestActFileDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"wrong field type", "version: 1\nshellopts: -e\n", "line 2: cannot unmarshal !!str `-e` into []string"},
+		{"bad duration", "notify_after: later\n", "line 1: cannot unmarshal !!str `later` into time.Duration"},
+		{"wrong act field type", "acts:\n  foo:\n    quiet: maybe\n", "line 3: cannot unmarshal !!str `maybe` into bool"},
+		{"bad act duration", "acts:\n  foo:\n    debounce: 5 minutes\n", "line 3: cannot unmarshal !!str `5 minutes` into time.Duration"},
+		{"bad check interval", "acts:\n  foo:\n    check:\n      interval: soon\n", "line 4: invalid duration \"soon\""},
+		{"bad stop signal", "acts:\n  foo:\n    stop_timeline:\n      - signal: TREM\n", "line 4: invalid stop timeline signal \"TREM\""},
+		{"wrong cmd field type", "acts:\n  foo:\n    cmds:\n      - cmd: echo\n        detach: maybe\n", "line 5: cannot unmarshal !!str `maybe` into bool"},
+		{"bad stage cmd", "acts:\n  foo:\n    start:\n      cmds:\n        - cmd: [echo]\n", "line 5: cannot unmarshal !!seq into string"},
+		{"chain cmd with act", "acts:\n  foo:\n    cmds:\n      - cmd: echo\n        and:\n          - act: bar\n", "line 6: and commands can't use act, loop or detach"},
+		{"bad nested act", "acts:\n  foo:\n    acts:\n      bar:\n        log_max_files: many\n", "line 5: cannot unmarshal !!str `many` into int"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actFile ActFile
+
+			err := yaml.Unmarshal([]byte(test.text), &actFile)
+
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+/**
+ * This file implements the cache of parsed actfiles. Actfiles are
+ * parsed once per run (unless they change) and actfiles included by
+ * the root actfile (with `include` or `redirect`) can be preloaded in
+ * parallel so resolving acts of big monorepos doesn't read actfiles
+ * one by one.
+ */
+
+package actfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"gopkg.in/yaml.v3"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max number of actfiles we read at the same time when preloading
+ * included actfiles.
+ */
+const maxPreloadWorkers = 8
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a parsed actfile along with the file
+ * modification time when we parsed it.
+ */
+type actFileCacheEntry struct {
+	actFile *ActFile
+	err     error
+	modTime time.Time
+
+	/**
+	 * This channel gets closed once the actfile was parsed so
+	 * concurrent readers of the same actfile wait a single parse.
+	 */
+	ready chan bool
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Parsed actfiles by path.
+ */
+var actFileCache = make(map[string]*actFileCacheEntry)
+
+/**
+ * Mutex to guard the actfile cache.
+ */
+var actFileCacheMutex sync.Mutex
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to read/parse an actfile returning an error
+ * instead of failing so it can run in background.
+ */
+func loadActFile(locationPath string) (*ActFile, error) {
+	/**
+	 * We start by creating an empty Actfile struct so we can
+	 * fulfill it.
+	 */
+	spec := ActFile{}
+
+	// Taskfiles are converted to actfiles.
+	if IsTaskFile(locationPath) {
+		taskActFile, err := ReadTaskFile(locationPath)
+
+		if err != nil {
+			return nil, &ActFileError{Msg: "could not read taskfile", Err: err}
+		}
+
+		if err := compileActNames(taskActFile.Acts, locationPath); err != nil {
+			return nil, err
+		}
+
+		return taskActFile, nil
+	}
+
+	// Try to open actfile.yml
+	file, err := os.Open(locationPath)
+
+	/**
+	 * If we can't open the file (it does not exists for example)
+	 * then we give up.
+	 */
+	if err != nil {
+		return nil, &ActFileError{Msg: "could not read actfile", Err: err}
+	}
+
+	defer file.Close()
+
+	/**
+	 * Parse yaml file. Yaml errors already tell the line where
+	 * parsing failed so we just add the actfile path (empty actfiles
+	 * are fine though).
+	 */
+	if err := yaml.NewDecoder(file).Decode(&spec); err != nil && err != io.EOF {
+		return nil, &ActFileError{Msg: fmt.Sprintf("could not parse actfile %s", locationPath), Err: err}
+	}
+
+	// Set location path
+	spec.LocationPath = locationPath
+
+	if err := compileActNames(spec.Acts, locationPath); err != nil {
+		return nil, err
+	}
+
+	/**
+	 * Npm scripts come after acts defined in the actfile so acts
+	 * defined by user have precedence.
+	 */
+	if spec.NpmScripts {
+		npmAct, err := NewNpmAct(filepath.Join(filepath.Dir(locationPath), NpmPackageFileName))
+
+		if err != nil {
+			return nil, &ActFileError{Msg: "could not read npm scripts", Err: err}
+		}
+
+		spec.Acts = append(spec.Acts, npmAct)
+	}
+
+	return &spec, nil
+}
+
+/**
+ * This function going to get a parsed actfile from the cache parsing
+ * it when it's not cached yet (or when it changed).
+ */
+func getCachedActFile(locationPath string) (*ActFile, error) {
+	stat, err := os.Stat(locationPath)
+
+	if err != nil {
+		return nil, &ActFileError{Msg: "could not read actfile", Err: err}
+	}
+
+	actFileCacheMutex.Lock()
+
+	entry, ok := actFileCache[locationPath]
+
+	if !ok || !entry.modTime.Equal(stat.ModTime()) {
+		entry = &actFileCacheEntry{modTime: stat.ModTime(), ready: make(chan bool)}
+		actFileCache[locationPath] = entry
+
+		actFileCacheMutex.Unlock()
+
+		entry.actFile, entry.err = loadActFile(locationPath)
+		close(entry.ready)
+	} else {
+		actFileCacheMutex.Unlock()
+
+		<-entry.ready
+	}
+
+	return entry.actFile, entry.err
+}
+
+/**
+ * This function going to make sure actfiles included by an actfile
+ * (and the ones they include) can be parsed returning the first
+ * error we find.
+ */
+func checkIncludedActFiles(actFile *ActFile, visited map[string]bool) error {
+	for _, path := range getIncludedActFilePaths(actFile.Acts, filepath.Dir(actFile.LocationPath)) {
+		if visited[path] || !utils.DoFileExists(path) {
+			continue
+		}
+
+		visited[path] = true
+
+		included, err := getCachedActFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		if err := checkIncludedActFiles(included, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to get paths of actfiles included by acts (and
+ * subacts) of an actfile. Paths depending on vars are only known when
+ * the act runs so we skip them.
+ */
+func getIncludedActFilePaths(acts []*Act, baseDir string) []string {
+	var paths []string
+
+	for _, act := range acts {
+		for _, path := range []string{act.Include, act.Redirect} {
+			if path != "" && !strings.Contains(path, "{{") {
+				paths = append(paths, utils.ResolvePath(baseDir, path))
+			}
+		}
+
+		paths = append(paths, getIncludedActFilePaths(act.Acts, baseDir)...)
+	}
+
+	return paths
+}
+
+//############################################################
+// ActFileError Struct Functions
+//############################################################
+
+/**
+ * This function going to get the error message.
+ */
+func (err *ActFileError) Error() string {
+	return fmt.Sprintf("%s: %v", err.Msg, err.Err)
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to parse actfiles included by an actfile (and
+ * the ones they include) in parallel with a bounded number of workers
+ * so they are cached by the time we resolve the act to run. Errors
+ * are ignored here since they going to be reported if the actfile is
+ * actually used.
+ */
+func PreloadActFiles(actFile *ActFile) {
+	paths := make(chan string)
+	visited := map[string]bool{actFile.LocationPath: true}
+
+	var mutex sync.Mutex
+	var pending sync.WaitGroup
+
+	/**
+	 * Queue actfiles not visited yet. We queue from a goroutine so
+	 * workers never block each other.
+	 */
+	queue := func(newPaths []string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		for _, path := range newPaths {
+			if visited[path] {
+				continue
+			}
+
+			visited[path] = true
+			pending.Add(1)
+
+			go func(path string) {
+				paths <- path
+			}(path)
+		}
+	}
+
+	for i := 0; i < maxPreloadWorkers; i++ {
+		go func() {
+			for path := range paths {
+				if loaded, err := getCachedActFile(path); err == nil {
+					queue(getIncludedActFilePaths(loaded.Acts, filepath.Dir(path)))
+				} else {
+					utils.LogDebug("PreloadActFiles : could not read actfile", path, err)
+				}
+
+				pending.Done()
+			}
+		}()
+	}
+
+	queue(getIncludedActFilePaths(actFile.Acts, filepath.Dir(actFile.LocationPath)))
+
+	pending.Wait()
+	close(paths)
+}
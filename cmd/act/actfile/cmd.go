@@ -7,8 +7,10 @@
 package actfile
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -34,6 +36,160 @@ type CmdLoop struct {
 	Glob string
 }
 
+/**
+ * This structure specify assertions about the outcome of a
+ * command. If any assertion doesn't hold the command fails.
+ */
+type CmdExpect struct {
+	/**
+	 * Expected exit code of the command. When set a command exiting
+	 * with this code is considered successful (even if the code is
+	 * not zero).
+	 */
+	ExitCode *int `yaml:"exit_code"`
+
+	/**
+	 * Text the command stdout must contain.
+	 */
+	StdoutContains string `yaml:"stdout_contains"`
+
+	/**
+	 * Regex the command stdout must match.
+	 */
+	StdoutRegex string `yaml:"stdout_regex"`
+}
+
+/**
+ * This structure specify a condition a command waits for (like a
+ * service accepting connections) instead of running a shell command.
+ * Exactly one condition type should be specified.
+ */
+type CmdWaitFor struct {
+	/**
+	 * Address (like `localhost:5432`) which must accept tcp
+	 * connections.
+	 */
+	Tcp string
+
+	/**
+	 * Url which must answer to a GET request with a success status
+	 * (or with the status specified in Status field).
+	 */
+	Http string
+
+	/**
+	 * Expected http status code.
+	 */
+	Status int
+
+	/**
+	 * Path of a file which must exist.
+	 */
+	File string
+
+	/**
+	 * Process which must be running. It can be a pid, the path of a
+	 * pid file or a process name.
+	 */
+	Process string
+
+	/**
+	 * Max time to wait for the condition (like `30s`).
+	 */
+	Timeout time.Duration
+
+	/**
+	 * Interval between attempts (like `500ms`).
+	 */
+	Interval time.Duration
+}
+
+/**
+ * This structure specify an http request a command sends instead of
+ * running a shell command.
+ */
+type CmdHttp struct {
+	/**
+	 * Request method (defaults to GET).
+	 */
+	Method string
+
+	/**
+	 * Request url.
+	 */
+	Url string
+
+	/**
+	 * Request headers.
+	 */
+	Headers map[string]string
+
+	/**
+	 * Request body.
+	 */
+	Body string
+
+	/**
+	 * Expected response status code. When not set any status lower
+	 * than 400 is a success.
+	 */
+	ExpectStatus int `yaml:"expect_status"`
+
+	/**
+	 * Max time the request can take (like `10s`).
+	 */
+	Timeout time.Duration
+
+	/**
+	 * Name of a var to store the response body in.
+	 */
+	Register string
+
+	/**
+	 * Vars to store values of a json response in (var name to a dot
+	 * path like `data.items.0.id`).
+	 */
+	Capture map[string]string
+}
+
+/**
+ * This structure specify a docker container where commands run
+ * instead of running on the host.
+ */
+type CmdContainer struct {
+	/**
+	 * Image to run commands in (with `docker run`).
+	 */
+	Image string
+
+	/**
+	 * Name of an already running container to run commands in (with
+	 * `docker exec`) instead of starting a new one from image.
+	 */
+	Exec string
+
+	/**
+	 * Extra volumes (like `./cache:/cache` or `cache:/root/.cache`).
+	 * Relative host paths are resolved against the actfile dir.
+	 */
+	Volumes []string
+
+	/**
+	 * Working dir inside the container (defaults to the actfile dir
+	 * which is mounted at the same path).
+	 */
+	Workdir string
+
+	/**
+	 * User to run commands as inside the container.
+	 */
+	User string
+
+	/**
+	 * Network to connect the container to.
+	 */
+	Network string
+}
 
 /**
  * The command struct going to contain everything required for
@@ -88,6 +244,12 @@ type Cmd struct {
 	 */
 	Shell string
 
+	/**
+	 * Shell options (like `errexit`, `nounset` and `pipefail`) to
+	 * run the command with.
+	 */
+	ShellOpts []string
+
 	/**
 	 * A command can reference another act to run like this:
 	 *
@@ -152,6 +314,205 @@ type Cmd struct {
 	 * Enable or disable log.
 	 */
 	Log bool
+
+	/**
+	 * List of commands to run in sequence right after this command
+	 * but only if this command succeeded. The chain fails as soon
+	 * as one of these commands fails. So we can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   foo:
+	 *     cmds:
+	 *       - cmd: test -f go.mod
+	 *         and:
+	 *           - go build ./...
+	 * ```
+	 *
+	 * which is the same as `test -f go.mod && go build ./...` but
+	 * without relying on the shell syntax.
+	 */
+	And []*Cmd
+
+	/**
+	 * List of fallback commands to try in order when this command
+	 * (or its `and` chain) fails. The first fallback to succeed
+	 * makes the whole command succeed. So we can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   foo:
+	 *     cmds:
+	 *       - cmd: brew install jq
+	 *         or:
+	 *           - apt-get install -y jq
+	 * ```
+	 */
+	Or []*Cmd
+
+	/**
+	 * Assertions about the command outcome. So we can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   smoke:
+	 *     cmds:
+	 *       - cmd: curl -s localhost:8080/health
+	 *         expect:
+	 *           exit_code: 0
+	 *           stdout_contains: ok
+	 * ```
+	 */
+	Expect *CmdExpect
+
+	/**
+	 * Run the command in a pseudo-terminal so tools keep their colors
+	 * and progress bars.
+	 */
+	Tty bool
+
+	/**
+	 * Compile the whole script file content as a template (so it can
+	 * use act vars like `{{.FlagName}}`) instead of only the script
+	 * path and args.
+	 */
+	Compile bool
+
+	/**
+	 * Docker container to run the command in. So we can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   test:
+	 *     cmds:
+	 *       - cmd: go test ./...
+	 *         container:
+	 *           image: golang:1.16
+	 * ```
+	 */
+	Container *CmdContainer
+
+	/**
+	 * Hosts where the command runs over ssh. When there are many
+	 * hosts the command runs on all of them in parallel. So we can
+	 * have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   uptime:
+	 *     cmds:
+	 *       - cmd: uptime
+	 *         host: [deploy@web1, deploy@web2]
+	 * ```
+	 */
+	Hosts []string
+
+	/**
+	 * User (name or uid) the command runs as (act must have the
+	 * privileges to switch user).
+	 */
+	User string
+
+	/**
+	 * Umask (in octal like `027`) of the command process.
+	 */
+	Umask string
+
+	/**
+	 * Nice level of the command processes (from -20 to 19 where
+	 * higher means lower priority).
+	 */
+	Nice *int
+
+	/**
+	 * Condition to wait for instead of running a shell command. So we
+	 * can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   migrate:
+	 *     cmds:
+	 *       - wait_for:
+	 *           tcp: localhost:5432
+	 *           timeout: 30s
+	 *       - ./migrate.sh
+	 * ```
+	 */
+	WaitFor *CmdWaitFor
+
+	/**
+	 * Http request to send instead of running a shell command. So we
+	 * can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   deploy:
+	 *     cmds:
+	 *       - http:
+	 *           method: POST
+	 *           url: https://api.example.com/deploys
+	 *           body: '{"ref": "{{.Ref}}"}'
+	 *           expect_status: 201
+	 *           capture:
+	 *             DeployId: id
+	 *       - echo "deploy {{.DeployId}} created"
+	 * ```
+	 */
+	Http *CmdHttp
+
+	/**
+	 * Commands connected in a pipeline (stdout of each command going
+	 * to stdin of the next one) managed by act. Each command can set
+	 * its own shell, dir and env vars. So we can have:
+	 *
+	 * ```yaml
+	 * acts:
+	 *   errors:
+	 *     cmds:
+	 *       - pipe:
+	 *           - cat app.log
+	 *           - grep ERROR
+	 *           - cmd: sort | uniq -c
+	 *             shell: sh
+	 * ```
+	 */
+	Pipe []*Cmd
+
+	/**
+	 * Dir (relative to the actfile dir) to run the command in.
+	 */
+	Dir string
+
+	/**
+	 * Env vars of the command (on top of act vars).
+	 */
+	Env map[string]string
+
+	/**
+	 * Line and column where the command was declared in the actfile.
+	 * We use this to trace commands back to their yaml origin.
+	 */
+	Line   int
+	Column int
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check commands chained with and/or. They
+ * run as shell commands so keys about running acts or many commands
+ * (act, loop and detach) are not allowed there.
+ */
+func checkChainCmds(key string, cmds []*Cmd) error {
+	for _, chainCmd := range cmds {
+		if chainCmd.Act != "" || chainCmd.Loop != nil || chainCmd.Detach {
+			return fmt.Errorf("line %d: %s commands can't use act, loop or detach", chainCmd.Line, key)
+		}
+	}
+
+	return nil
 }
 
 //############################################################
@@ -180,6 +541,10 @@ func (cmd *Cmd) UnmarshalYAML(value *yaml.Node) error {
 	 */
 	var cmdLine string
 
+	// Keep track of where the command was declared.
+	cmd.Line = value.Line
+	cmd.Column = value.Column
+
 	if err := value.Decode(&cmdLine); err == nil {
 		/**
 		 * We were able to correctly parse the command as a string
@@ -200,6 +565,7 @@ func (cmd *Cmd) UnmarshalYAML(value *yaml.Node) error {
 		Cmd    		string
 		Script 		string
 		Shell     string
+		ShellOpts []string `yaml:"shellopts"`
 		Act    		string
 		From   		string
 		Detach 		bool
@@ -208,46 +574,85 @@ func (cmd *Cmd) UnmarshalYAML(value *yaml.Node) error {
 		Log  			bool
 		Loop   		*CmdLoop
 		Mismatch 	string
+		And       []*Cmd
+		Or        []*Cmd
+		Expect    *CmdExpect
+		Tty       bool
+		Compile   bool
+		Container *CmdContainer
+		Host      yaml.Node
+		User      string
+		Umask     string
+		Nice      *int
+		WaitFor   *CmdWaitFor `yaml:"wait_for"`
+		Http      *CmdHttp
+		Pipe      []*Cmd
+		Dir       string
+		Env       map[string]string
 	}
 
-	if err := value.Decode(&cmdObj); err == nil {
-		cmd.Cmd = cmdObj.Cmd
-		cmd.Script = cmdObj.Script
-		cmd.Shell = cmdObj.Shell
-		cmd.Act = cmdObj.Act
-		cmd.From = cmdObj.From
-		cmd.Detach = cmdObj.Detach
-		cmd.Args = cmdObj.Args
-		cmd.Quiet = cmdObj.Quiet
-		cmd.Log = cmdObj.Log
-		cmd.Loop = cmdObj.Loop
-		cmd.Mismatch = cmdObj.Mismatch
-
-		// We let user pass command args together with act name.
-		if cmdObj.Act != "" {
-			args := strings.Split(cmdObj.Act, " ")
-			actCallId := args[0]
-			actArgs := args[1:]
-
-			cmd.Act = actCallId
-			cmd.Args = append(cmd.Args, actArgs...)
-		}
+	if err := value.Decode(&cmdObj); err != nil {
+		return err
+	}
 
-		// We let user pass command args together with script.
-		if cmdObj.Script != "" {
-			// Trim whitespaces from template strings
-			var re = regexp.MustCompile(`{{ *([^ ]+) *}}`)
-			scriptLine := re.ReplaceAllString(cmdObj.Script, "{{$1}}")
+	cmd.Cmd = cmdObj.Cmd
+	cmd.Script = cmdObj.Script
+	cmd.Shell = cmdObj.Shell
+	cmd.ShellOpts = cmdObj.ShellOpts
+	cmd.Act = cmdObj.Act
+	cmd.From = cmdObj.From
+	cmd.Detach = cmdObj.Detach
+	cmd.Args = cmdObj.Args
+	cmd.Quiet = cmdObj.Quiet
+	cmd.Log = cmdObj.Log
+	cmd.Loop = cmdObj.Loop
+	cmd.Mismatch = cmdObj.Mismatch
+	if err := checkChainCmds("and", cmdObj.And); err != nil {
+		return err
+	}
 
-			args := strings.Split(scriptLine, " ")
-			scriptPath := args[0]
-			scriptArgs := args[1:]
+	if err := checkChainCmds("or", cmdObj.Or); err != nil {
+		return err
+	}
 
-			cmd.Script = scriptPath
-			cmd.Args = append(cmd.Args, scriptArgs...)
-		}
+	cmd.And = cmdObj.And
+	cmd.Or = cmdObj.Or
+	cmd.Expect = cmdObj.Expect
+	cmd.Tty = cmdObj.Tty
+	cmd.Compile = cmdObj.Compile
+	cmd.Container = cmdObj.Container
+	cmd.Hosts = DecodeHosts(cmdObj.Host)
+	cmd.User = cmdObj.User
+	cmd.Umask = cmdObj.Umask
+	cmd.Nice = cmdObj.Nice
+	cmd.WaitFor = cmdObj.WaitFor
+	cmd.Http = cmdObj.Http
+	cmd.Pipe = cmdObj.Pipe
+	cmd.Dir = cmdObj.Dir
+	cmd.Env = cmdObj.Env
 
-		return nil
+	// We let user pass command args together with act name.
+	if cmdObj.Act != "" {
+		args := strings.Split(cmdObj.Act, " ")
+		actCallId := args[0]
+		actArgs := args[1:]
+
+		cmd.Act = actCallId
+		cmd.Args = append(cmd.Args, actArgs...)
+	}
+
+	// We let user pass command args together with script.
+	if cmdObj.Script != "" {
+		// Trim whitespaces from template strings
+		var re = regexp.MustCompile(`{{ *([^ ]+) *}}`)
+		scriptLine := re.ReplaceAllString(cmdObj.Script, "{{$1}}")
+
+		args := strings.Split(scriptLine, " ")
+		scriptPath := args[0]
+		scriptArgs := args[1:]
+
+		cmd.Script = scriptPath
+		cmd.Args = append(cmd.Args, scriptArgs...)
 	}
 
 	return nil
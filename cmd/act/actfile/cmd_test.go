@@ -0,0 +1,44 @@
+package actfile
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+/**
+ * Chained commands run as shell commands so keys they would silently
+ * ignore must be rejected.
+ */
+func TestChainCmdsRejectActLoopDetach(t *testing.T) {
+	for _, entry := range []string{"act: build", "detach: true\n    cmd: ./server", "cmd: echo {{.LoopItem}}\n    loop:\n      items: [a, b]"} {
+		for _, key := range []string{"and", "or"} {
+			content := "cmd: test -f go.mod\n" + key + ":\n  - " + entry + "\n"
+
+			var cmd Cmd
+			err := yaml.Unmarshal([]byte(content), &cmd)
+
+			if err == nil || !strings.Contains(err.Error(), key+" commands can't use") {
+				t.Errorf("got error %v for %q, want %s chain error", err, content, key)
+			}
+		}
+	}
+}
+
+/**
+ * Plain shell commands are fine in chains.
+ */
+func TestChainCmdsAllowShellCmds(t *testing.T) {
+	content := "cmd: test -f go.mod\nand:\n  - go build ./...\nor:\n  - cmd: echo fallback\n    quiet: true\n"
+
+	var cmd Cmd
+
+	if err := yaml.Unmarshal([]byte(content), &cmd); err != nil {
+		t.Fatalf("could not parse chained commands: %v", err)
+	}
+
+	if len(cmd.And) != 1 || len(cmd.Or) != 1 {
+		t.Errorf("got %d and / %d or commands, want 1 / 1", len(cmd.And), len(cmd.Or))
+	}
+}
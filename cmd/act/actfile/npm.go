@@ -0,0 +1,158 @@
+/**
+ * This file going to expose npm scripts of a package.json file as
+ * acts so JS repos can use act without duplicating script
+ * definitions (like running `act run npm.test` for `npm run test`).
+ */
+
+package actfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold an npm script defined in package.json
+ * file.
+ */
+type NpmScript struct {
+	/**
+	 * Name of the script in package.json (like `test`).
+	 */
+	Name string
+
+	/**
+	 * Command line the script runs (like `jest --coverage`).
+	 */
+	Cmd string
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the name of the act holding npm scripts as subacts.
+ */
+const NpmActName = "npm"
+
+/**
+ * This is the name of the package file where we read npm scripts.
+ */
+const NpmPackageFileName = "package.json"
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to read npm scripts of a package.json file
+ * keeping the order they were defined in.
+ */
+func ReadNpmScripts(packagePath string) ([]*NpmScript, error) {
+	file, err := os.Open(packagePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var pkg struct {
+		Scripts json.RawMessage
+	}
+
+	if err := json.NewDecoder(file).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", packagePath, err)
+	}
+
+	if len(pkg.Scripts) == 0 {
+		return nil, nil
+	}
+
+	/**
+	 * Go maps don't keep keys order so we read script names as json
+	 * tokens instead.
+	 */
+	decoder := json.NewDecoder(strings.NewReader(string(pkg.Scripts)))
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("invalid scripts in %s: %v", packagePath, err)
+	}
+
+	var scripts []*NpmScript
+
+	for decoder.More() {
+		token, err := decoder.Token()
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid scripts in %s: %v", packagePath, err)
+		}
+
+		name, _ := token.(string)
+
+		var cmd string
+
+		if err := decoder.Decode(&cmd); err != nil {
+			return nil, fmt.Errorf("invalid script %s in %s: %v", name, packagePath, err)
+		}
+
+		scripts = append(scripts, &NpmScript{Name: name, Cmd: cmd})
+	}
+
+	return scripts, nil
+}
+
+/**
+ * This function going to get the act name of an npm script. Dots
+ * separate subacts in act call ids so we replace them with colons
+ * (like `test.unit` becoming `test:unit`).
+ */
+func GetNpmScriptActName(script *NpmScript) string {
+	return strings.ReplaceAll(script.Name, ".", ":")
+}
+
+/**
+ * This function going to get the command line running an npm script
+ * passing over act args to it.
+ */
+func GetNpmScriptCmd(script *NpmScript) string {
+	return fmt.Sprintf("npm run '%s' -- {{.CliArgs}}", strings.ReplaceAll(script.Name, "'", `'\''`))
+}
+
+/**
+ * This function going to create an act holding all npm scripts of
+ * a package.json file as subacts.
+ */
+func NewNpmAct(packagePath string) (*Act, error) {
+	scripts, err := ReadNpmScripts(packagePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Act{
+		Name: NpmActName,
+		Desc: fmt.Sprintf("Scripts from %s", NpmPackageFileName),
+	}
+
+	for _, script := range scripts {
+		act.Acts = append(act.Acts, &Act{
+			Name: regexp.QuoteMeta(GetNpmScriptActName(script)),
+			Desc: script.Cmd,
+			Start: &ActExecStage{
+				Name: "start",
+				Cmds: []*Cmd{{Cmd: GetNpmScriptCmd(script)}},
+			},
+		})
+	}
+
+	return act, nil
+}
@@ -0,0 +1,261 @@
+/**
+ * This file going to read go-task Taskfiles (https://taskfile.dev)
+ * mapping tasks onto acts so teams evaluating a switch to act can
+ * run their existing definitions unmodified during migration. We
+ * support the most common fields: tasks with desc, cmds (including
+ * task calls), deps, vars, env, dir and silent plus global vars, env
+ * and dotenv. Dynamic vars (like `sh:` vars) are not supported.
+ */
+
+package actfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the Taskfile fields we support.
+ */
+type taskFile struct {
+	Vars   yaml.Node
+	Env    yaml.Node
+	Dotenv []string
+	Silent bool
+	Tasks  yaml.Node
+}
+
+/**
+ * This struct going to hold the task fields we support.
+ */
+type task struct {
+	Desc    string
+	Summary string
+	Cmds    []yaml.Node
+	Deps    []yaml.Node
+	Vars    yaml.Node
+	Env     yaml.Node
+	Dir     string
+	Silent  bool
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * File names go-task looks up for Taskfiles.
+ */
+var taskFileNames = map[string]bool{
+	"taskfile.yml":       true,
+	"taskfile.yaml":      true,
+	"taskfile.dist.yml":  true,
+	"taskfile.dist.yaml": true,
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to decode Taskfile vars (or env) keeping only
+ * static values.
+ */
+func decodeTaskVars(node yaml.Node, vars map[string]string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		value := node.Content[i+1]
+
+		if value.Kind != yaml.ScalarNode {
+			continue
+		}
+
+		vars[name] = value.Value
+	}
+}
+
+/**
+ * This function going to decode the name of the task referenced by
+ * a dep or a cmd (which can be a string or an object with a task
+ * field).
+ */
+func decodeTaskRef(node yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+
+	var ref struct {
+		Task string
+	}
+
+	node.Decode(&ref)
+
+	return ref.Task
+}
+
+/**
+ * This function going to convert a Taskfile cmd to an act command.
+ */
+func decodeTaskCmd(node yaml.Node, dir string) *Cmd {
+	var cmdLine string
+
+	if node.Kind == yaml.ScalarNode {
+		cmdLine = node.Value
+	} else {
+		var taskCmd struct {
+			Cmd  string
+			Task string
+		}
+
+		node.Decode(&taskCmd)
+
+		if taskCmd.Task != "" {
+			return &Cmd{Act: taskCmd.Task, Line: node.Line, Column: node.Column}
+		}
+
+		cmdLine = taskCmd.Cmd
+	}
+
+	if cmdLine == "" {
+		return nil
+	}
+
+	if dir != "" {
+		cmdLine = fmt.Sprintf("cd '%s' && %s", strings.ReplaceAll(dir, "'", `'\''`), cmdLine)
+	}
+
+	return &Cmd{Cmd: cmdLine, Line: node.Line, Column: node.Column}
+}
+
+/**
+ * This function going to convert a task to an act. Tasks can be
+ * written as objects, a list of commands or a single command.
+ */
+func decodeTask(name string, node *yaml.Node, silent bool) *Act {
+	var t task
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		t.Cmds = []yaml.Node{*node}
+	case yaml.SequenceNode:
+		for _, cmdNode := range node.Content {
+			t.Cmds = append(t.Cmds, *cmdNode)
+		}
+	default:
+		node.Decode(&t)
+	}
+
+	/**
+	 * Act run default act (`_`) when user don't provide an act name
+	 * while go-task run the default task.
+	 */
+	actName := regexp.QuoteMeta(name)
+
+	if name == "default" {
+		actName = "(default|_)"
+	}
+
+	act := &Act{
+		Name:   actName,
+		Desc:   t.Desc,
+		Quiet:  silent || t.Silent,
+		Vars:   make(map[string]string),
+		Line:   node.Line,
+		Column: node.Column,
+	}
+
+	if act.Desc == "" {
+		act.Desc = t.Summary
+	}
+
+	decodeTaskVars(t.Env, act.Vars)
+	decodeTaskVars(t.Vars, act.Vars)
+
+	for _, dep := range t.Deps {
+		if depName := decodeTaskRef(dep); depName != "" {
+			act.Needs = append(act.Needs, &ActNeed{Act: depName})
+		}
+	}
+
+	var cmds []*Cmd
+
+	for _, cmdNode := range t.Cmds {
+		if cmd := decodeTaskCmd(cmdNode, t.Dir); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	/**
+	 * Tasks with only deps are still runnable (they just run their
+	 * deps) so we give them an empty start stage.
+	 */
+	act.Start = &ActExecStage{
+		Name:   "start",
+		Cmds:   cmds,
+		Line:   node.Line,
+		Column: node.Column,
+	}
+
+	return act
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to check if a file is a go-task Taskfile
+ * based on its name.
+ */
+func IsTaskFile(filePath string) bool {
+	return taskFileNames[strings.ToLower(filepath.Base(filePath))]
+}
+
+/**
+ * This function going to read a Taskfile converting it to an
+ * actfile.
+ */
+func ReadTaskFile(locationPath string) (*ActFile, error) {
+	file, err := os.Open(locationPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var tf taskFile
+
+	if err := yaml.NewDecoder(file).Decode(&tf); err != nil {
+		return nil, fmt.Errorf("invalid taskfile %s: %v", locationPath, err)
+	}
+
+	actFile := &ActFile{
+		Version:      "1",
+		LocationPath: locationPath,
+		Vars:         make(map[string]string),
+	}
+
+	decodeTaskVars(tf.Env, actFile.Vars)
+	decodeTaskVars(tf.Vars, actFile.Vars)
+
+	// We only support a single dotenv file.
+	if len(tf.Dotenv) > 0 {
+		actFile.EnvFilePath = tf.Dotenv[0]
+	}
+
+	for i := 0; i+1 < len(tf.Tasks.Content); i += 2 {
+		actFile.Acts = append(actFile.Acts, decodeTask(tf.Tasks.Content[i].Value, tf.Tasks.Content[i+1], tf.Silent))
+	}
+
+	return actFile, nil
+}
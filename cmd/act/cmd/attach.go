@@ -0,0 +1,193 @@
+/**
+ * This file implements the attach subcommand which is responsible
+ * for streaming the output of an act running in the background as
+ * daemon and (when the act is interactive) forwarding user input to
+ * it. Users detach with Ctrl+P Ctrl+Q (like docker).
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hpcloud/tail"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Key codes of the detach sequence (Ctrl+P Ctrl+Q).
+ */
+const (
+	keyCtrlP = 0x10
+	keyCtrlQ = 0x11
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Channel we close to detach.
+ */
+var attachDone = make(chan bool)
+
+/**
+ * Tail following the act log file.
+ */
+var attachTail *tail.Tail
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to forward user input to the act stdin fifo
+ * until user types the detach sequence.
+ */
+func forwardAttachInput(fifo *os.File) {
+	defer utils.RestoreTermOnPanic()
+
+	buf := make([]byte, 1024)
+	prevCtrlP := false
+
+	for {
+		n, err := os.Stdin.Read(buf)
+
+		if err != nil {
+			return
+		}
+
+		var out []byte
+
+		for _, b := range buf[:n] {
+			if prevCtrlP && b == keyCtrlQ {
+				AttachStop()
+				return
+			}
+
+			if prevCtrlP {
+				out = append(out, keyCtrlP)
+			}
+
+			prevCtrlP = b == keyCtrlP
+
+			if !prevCtrlP {
+				out = append(out, b)
+			}
+		}
+
+		if fifo != nil && len(out) > 0 {
+			fifo.Write(out)
+		}
+	}
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `attach` command.
+ */
+func AttachCmdExec(args []string) {
+	info := getTargetInfo("attach", args)
+
+	if info == nil {
+		return
+	}
+
+	/**
+	 * Stream act output from now on.
+	 */
+	t, err := tail.TailFile(info.GetLogFilePath(), tail.Config{
+		Follow: true,
+		Location: &tail.SeekInfo{
+			Offset: 0,
+			Whence: 2,
+		},
+		ReOpen: true,
+		Logger: tail.DiscardingLogger,
+	})
+
+	if err != nil {
+		utils.FatalError("could not open log file", err)
+		return
+	}
+
+	attachTail = t
+
+	go func() {
+		defer utils.RestoreTermOnPanic()
+
+		for line := range t.Lines {
+			fmt.Println(line.Text)
+		}
+	}()
+
+	/**
+	 * Input is forwarded only to interactive acts but we read it
+	 * anyway to detect the detach sequence.
+	 */
+	var fifo *os.File
+
+	if info.Interactive {
+		fifo, err = os.OpenFile(info.GetStdinFilePath(), os.O_WRONLY, 0)
+
+		if err != nil {
+			utils.FatalError("could not open act stdin", err)
+			return
+		}
+
+		defer fifo.Close()
+	}
+
+	/**
+	 * Terminal settings going to be restored on exit by the terminal
+	 * state manager.
+	 */
+	if utils.IsTerminal(int(os.Stdin.Fd())) {
+		utils.MakeInputRaw(int(os.Stdin.Fd()))
+	}
+
+	fmt.Println(utils.Color.Gray(12, fmt.Sprintf("attached to %s (detach with Ctrl+P Ctrl+Q)", info.GetNameIdOrId())))
+
+	go forwardAttachInput(fifo)
+
+	/**
+	 * Wait until user detaches or the act exits.
+	 */
+	for info.IsRunning() {
+		select {
+		case <-attachDone:
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+/**
+ * This function going to detach from the act.
+ */
+func AttachStop() {
+	select {
+	case <-attachDone:
+	default:
+		close(attachDone)
+	}
+}
+
+/**
+ * This function going to cleanup everything for this command on exit.
+ */
+func AttachFinish() {
+	if attachTail != nil {
+		attachTail.Cleanup()
+		attachTail.Stop()
+	}
+}
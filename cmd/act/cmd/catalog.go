@@ -0,0 +1,161 @@
+/**
+ * This file implements the act catalog (`act ls` or `act list acts`)
+ * which shows all runnable acts of an actfile (including nested and
+ * included ones) as a tree of call ids.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold info about a runnable act.
+ */
+type catalogEntry struct {
+	CallId      string `json:"call_id"`
+	Desc        string `json:"desc,omitempty"`
+	ActFilePath string `json:"actfile"`
+	Depth       int    `json:"-"`
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to walk acts (and their subacts) collecting
+ * catalog entries. We keep track of actfiles in the current branch
+ * so include cycles don't loop forever.
+ */
+func walkCatalog(acts []*actfile.Act, actFile *actfile.ActFile, prefix string, depth int, visited map[string]bool) []*catalogEntry {
+	var entries []*catalogEntry
+
+	visited[actFile.LocationPath] = true
+	defer delete(visited, actFile.LocationPath)
+
+	for _, act := range acts {
+		callId := act.Name
+
+		if prefix != "" {
+			callId = fmt.Sprintf("%s%s%s", prefix, run.ActCallIdSeparator, act.Name)
+		}
+
+		// Redirected acts are defined in another actfile.
+		declActFile := actFile
+
+		if act.Redirect != "" {
+			redirectPath := utils.ResolvePath(filepath.Dir(actFile.LocationPath), act.Redirect)
+
+			if !visited[redirectPath] {
+				if redirectAct, redirectActFile := findHelpAct(act.Name, []*actfile.Act{act}, actFile); redirectAct != nil {
+					act, declActFile = redirectAct, redirectActFile
+				}
+			}
+		}
+
+		entries = append(entries, &catalogEntry{
+			CallId:      callId,
+			Desc:        act.Desc,
+			ActFilePath: declActFile.LocationPath,
+			Depth:       depth,
+		})
+
+		subActs, subActFile := getSubActs(act, declActFile)
+
+		if subActFile != declActFile && visited[subActFile.LocationPath] {
+			continue
+		}
+
+		entries = append(entries, walkCatalog(subActs, subActFile, callId, depth+1, visited)...)
+	}
+
+	return entries
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `ls` command.
+ */
+func CatalogCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("ls", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This flag indicates we want json output (for tooling).
+	 */
+	jsonPtr := cmdFlags.Bool("json", false, "Output acts as json")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	wd := utils.GetWd()
+	actFilePath := utils.ResolvePath(wd, *actFilePathPtr)
+
+	if !utils.DoFileExists(actFilePath) {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	actFile := actfile.ReadActFile(actFilePath)
+	entries := walkCatalog(actFile.Acts, actFile, "", 0, make(map[string]bool))
+
+	if *jsonPtr {
+		if entries == nil {
+			entries = []*catalogEntry{}
+		}
+
+		content, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(content))
+		return
+	}
+
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s%s", strings.Repeat("  ", entry.Depth), utils.Color.Green(entry.CallId).Bold())
+
+		if entry.Desc != "" {
+			line += fmt.Sprintf(" - %s", entry.Desc)
+		}
+
+		// Mark acts declared in other actfiles.
+		if entry.ActFilePath != actFilePath {
+			relPath, err := filepath.Rel(wd, entry.ActFilePath)
+
+			if err != nil {
+				relPath = entry.ActFilePath
+			}
+
+			line += fmt.Sprintf(" %s", utils.Color.Gray(12, fmt.Sprintf("(%s)", relPath)))
+		}
+
+		fmt.Println(line)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"syscall"
 
 	"github.com/nosebit/act/cmd/act/run"
 )
@@ -58,9 +59,61 @@ func Exec(args []string) {
 	case "log":
 		LogCmdExec(args[1:])
 	case "list":
-		ListCmdExec()
+		ListCmdExec(args[1:])
+	case "ls":
+		CatalogCmdExec(args[1:])
+	case "prune":
+		PruneCmdExec()
+	case "exec":
+		ExecCmdExec(args[1:])
+	case "attach":
+		AttachCmdExec(args[1:])
+	case "wait":
+		WaitCmdExec(args[1:])
+	case "debug":
+		DebugCmdExec(args[1:])
+	case "top":
+		TopCmdExec(args[1:])
+	case "status":
+		StatusCmdExec(args[1:])
 	case "stop":
 		StopCmdExec(args[1:])
+	case "kill":
+		KillCmdExec(args[1:])
+	case "signal":
+		SignalCmdExec(args[1:])
+	case "restart":
+		RestartCmdExec(args[1:])
+	case "pause":
+		PauseCmdExec(args[1:])
+	case "resume":
+		ResumeCmdExec(args[1:])
+	case "serve":
+		ServeCmdExec(args[1:])
+	case "help":
+		HelpCmdExec(args[1:])
+	case "fmt":
+		FmtCmdExec(args[1:])
+	case "graph":
+		GraphCmdExec(args[1:])
+	case "which":
+		WhichCmdExec(args[1:])
+	case "sh":
+		ShCmdExec(args[1:])
+	case "import":
+		ImportCmdExec(args[1:])
+	case "export":
+		ExportCmdExec(args[1:])
+	case "docs":
+		DocsCmdExec(args[1:])
+	case "vars":
+		VarsCmdExec(args[1:])
+	case "stats":
+		StatsCmdExec(args[1:])
+	case "events":
+		EventsCmdExec(args[1:])
+	case "ui":
+		UiCmdExec(args[1:])
 	default:
 		flag.PrintDefaults()
 		os.Exit(1)
@@ -74,6 +127,42 @@ func Stop() {
 	switch cmdName {
 	case "run":
 		run.Stop()
+	case "top":
+		TopStop()
+	case "attach":
+		AttachStop()
+	case "serve":
+		ServeStop()
+	case "sh":
+		ShStop()
+	case "events":
+		EventsStop()
+	case "ui":
+		UiStop()
+	default:
+	}
+}
+
+/**
+ * This function going to forward a signal received by act to the
+ * current execution.
+ */
+func Signal(sig syscall.Signal) {
+	switch cmdName {
+	case "run":
+		run.Signal(sig)
+	default:
+	}
+}
+
+/**
+ * This function going to dump the state of the current execution
+ * (used to diagnose hangs).
+ */
+func Dump() {
+	switch cmdName {
+	case "run":
+		run.Dump()
 	default:
 	}
 }
@@ -87,6 +176,8 @@ func Finish() {
 		run.Finish()
 	case "log":
 		LogFinish()
+	case "attach":
+		AttachFinish()
 	default:
 	}
 }
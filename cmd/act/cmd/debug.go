@@ -0,0 +1,49 @@
+/**
+ * This file implements the debug subcommand which is responsible
+ * for inspecting the live state of running acts (through their
+ * control socket) to diagnose hangs without killing them.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `debug` command.
+ */
+func DebugCmdExec(args []string) {
+	if len(args) < 1 {
+		utils.FatalError("you need to specify what to debug (state or dump)")
+		return
+	}
+
+	subCmdName := args[0]
+
+	switch subCmdName {
+	case "state", "dump":
+		info := getTargetInfo(fmt.Sprintf("debug %s", subCmdName), args[1:])
+
+		if info == nil {
+			return
+		}
+
+		response, err := info.ControlRequest(subCmdName)
+
+		if err != nil {
+			utils.FatalError("could not connect to act process", err)
+			return
+		}
+
+		fmt.Println(response)
+	default:
+		utils.FatalError(fmt.Sprintf("unknown debug command %s", subCmdName))
+	}
+}
@@ -0,0 +1,338 @@
+/**
+ * This file implements the docs subcommand which is responsible for
+ * generating a reference of all acts of an actfile (including nested
+ * and included ones) as Markdown or HTML so it can be pasted into
+ * contributor guides instead of being written by hand.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the documentation of an act.
+ */
+type docEntry struct {
+	CallId  string
+	Desc    string
+	Flags   []*exportFlag
+	Needs   []*actfile.ActNeed
+	EnvFile string
+	Vars    []string
+	Tags    []string
+	Source  string
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to walk acts (and their subacts) collecting
+ * doc entries. We keep track of actfiles in the current branch so
+ * include cycles don't loop forever.
+ */
+func walkDocs(acts []*actfile.Act, actFile *actfile.ActFile, prefix string, wd string, visited map[string]bool) []*docEntry {
+	var entries []*docEntry
+
+	visited[actFile.LocationPath] = true
+	defer delete(visited, actFile.LocationPath)
+
+	for _, act := range acts {
+		callId := act.Name
+
+		if prefix != "" {
+			callId = fmt.Sprintf("%s%s%s", prefix, run.ActCallIdSeparator, act.Name)
+		}
+
+		// Redirected acts are defined in another actfile.
+		declActFile := actFile
+
+		if act.Redirect != "" {
+			redirectPath := utils.ResolvePath(filepath.Dir(actFile.LocationPath), act.Redirect)
+
+			if !visited[redirectPath] {
+				if redirectAct, redirectActFile := findHelpAct(act.Name, []*actfile.Act{act}, actFile); redirectAct != nil {
+					act, declActFile = redirectAct, redirectActFile
+				}
+			}
+		}
+
+		source := getRelPath(wd, declActFile.LocationPath)
+
+		if act.Line > 0 {
+			source = fmt.Sprintf("%s:%d", source, act.Line)
+		}
+
+		entry := &docEntry{
+			CallId:  callId,
+			Desc:    act.Desc,
+			Flags:   getExportFlags(act),
+			Needs:   act.Needs,
+			EnvFile: act.EnvFilePath,
+			Tags:    act.Tags,
+			Source:  source,
+		}
+
+		for name := range act.Vars {
+			entry.Vars = append(entry.Vars, name)
+		}
+
+		sort.Strings(entry.Vars)
+
+		entries = append(entries, entry)
+
+		subActs, subActFile := getSubActs(act, declActFile)
+
+		if subActFile != declActFile && visited[subActFile.LocationPath] {
+			continue
+		}
+
+		entries = append(entries, walkDocs(subActs, subActFile, callId, wd, visited)...)
+	}
+
+	return entries
+}
+
+/**
+ * This function going to get the usage line of an act.
+ */
+func getDocUsage(entry *docEntry) string {
+	usage := fmt.Sprintf("act run %s", entry.CallId)
+
+	if len(entry.Flags) > 0 {
+		usage += " [flags]"
+	}
+
+	return usage + " [args...]"
+}
+
+/**
+ * This function going to get the textual description of an act need.
+ */
+func getDocNeed(need *actfile.ActNeed) string {
+	if need.Condition == "" {
+		return need.Act
+	}
+
+	return fmt.Sprintf("%s (%s)", need.Act, need.Condition)
+}
+
+/**
+ * This function going to render doc entries as Markdown.
+ */
+func renderDocsMarkdown(title string, entries []*docEntry) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n", title)
+
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "\n## `%s`\n\n", entry.CallId)
+
+		if entry.Desc != "" {
+			fmt.Fprintf(&b, "%s\n\n", entry.Desc)
+		}
+
+		fmt.Fprintf(&b, "```sh\n%s\n```\n\n", getDocUsage(entry))
+
+		if len(entry.Flags) > 0 {
+			b.WriteString("| Flag | Type | Default |\n|------|------|---------|\n")
+
+			for _, actFlag := range entry.Flags {
+				flagType := "string"
+
+				if actFlag.IsBool {
+					flagType = "bool"
+				}
+
+				fmt.Fprintf(&b, "| `-%s` | %s | `%s` |\n", actFlag.Name, flagType, actFlag.Default)
+			}
+
+			b.WriteString("\n")
+		}
+
+		if len(entry.Needs) > 0 {
+			var needs []string
+
+			for _, need := range entry.Needs {
+				needs = append(needs, fmt.Sprintf("`%s`", getDocNeed(need)))
+			}
+
+			fmt.Fprintf(&b, "- **Needs:** %s\n", strings.Join(needs, ", "))
+		}
+
+		if entry.EnvFile != "" {
+			fmt.Fprintf(&b, "- **Env file:** `%s`\n", entry.EnvFile)
+		}
+
+		if len(entry.Vars) > 0 {
+			fmt.Fprintf(&b, "- **Vars:** `%s`\n", strings.Join(entry.Vars, "`, `"))
+		}
+
+		if len(entry.Tags) > 0 {
+			fmt.Fprintf(&b, "- **Tags:** `%s`\n", strings.Join(entry.Tags, "`, `"))
+		}
+
+		fmt.Fprintf(&b, "- **Source:** `%s`\n", entry.Source)
+	}
+
+	return b.String()
+}
+
+/**
+ * This function going to render doc entries as an HTML fragment.
+ */
+func renderDocsHtml(title string, entries []*docEntry) string {
+	var b strings.Builder
+	esc := html.EscapeString
+
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", esc(title))
+
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "<h2 id=\"%s\"><code>%s</code></h2>\n", esc(getJobId(entry.CallId)), esc(entry.CallId))
+
+		if entry.Desc != "" {
+			fmt.Fprintf(&b, "<p>%s</p>\n", esc(entry.Desc))
+		}
+
+		fmt.Fprintf(&b, "<pre><code>%s</code></pre>\n", esc(getDocUsage(entry)))
+
+		if len(entry.Flags) > 0 {
+			b.WriteString("<table>\n<tr><th>Flag</th><th>Type</th><th>Default</th></tr>\n")
+
+			for _, actFlag := range entry.Flags {
+				flagType := "string"
+
+				if actFlag.IsBool {
+					flagType = "bool"
+				}
+
+				fmt.Fprintf(&b, "<tr><td><code>-%s</code></td><td>%s</td><td><code>%s</code></td></tr>\n", esc(actFlag.Name), flagType, esc(actFlag.Default))
+			}
+
+			b.WriteString("</table>\n")
+		}
+
+		b.WriteString("<ul>\n")
+
+		if len(entry.Needs) > 0 {
+			var needs []string
+
+			for _, need := range entry.Needs {
+				needs = append(needs, fmt.Sprintf("<code>%s</code>", esc(getDocNeed(need))))
+			}
+
+			fmt.Fprintf(&b, "<li><strong>Needs:</strong> %s</li>\n", strings.Join(needs, ", "))
+		}
+
+		if entry.EnvFile != "" {
+			fmt.Fprintf(&b, "<li><strong>Env file:</strong> <code>%s</code></li>\n", esc(entry.EnvFile))
+		}
+
+		if len(entry.Vars) > 0 {
+			fmt.Fprintf(&b, "<li><strong>Vars:</strong> <code>%s</code></li>\n", esc(strings.Join(entry.Vars, ", ")))
+		}
+
+		if len(entry.Tags) > 0 {
+			fmt.Fprintf(&b, "<li><strong>Tags:</strong> <code>%s</code></li>\n", esc(strings.Join(entry.Tags, ", ")))
+		}
+
+		fmt.Fprintf(&b, "<li><strong>Source:</strong> <code>%s</code></li>\n</ul>\n", esc(entry.Source))
+	}
+
+	return b.String()
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `docs` command.
+ */
+func DocsCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("docs", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This is the output format (markdown or html).
+	 */
+	formatPtr := cmdFlags.String("format", "markdown", "Output format (markdown or html)")
+
+	/**
+	 * This is the file we write the docs to (stdout by default).
+	 */
+	outPtr := cmdFlags.String("o", "", "Path to write the docs to")
+
+	/**
+	 * This is the title of the generated docs.
+	 */
+	titlePtr := cmdFlags.String("title", "Acts", "Title of the generated docs")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	if *formatPtr != "markdown" && *formatPtr != "html" {
+		utils.FatalError(fmt.Sprintf("invalid docs format %s (use markdown or html)", *formatPtr))
+		return
+	}
+
+	wd := utils.GetWd()
+	actFilePath := utils.ResolvePath(wd, *actFilePathPtr)
+
+	if !utils.DoFileExists(actFilePath) {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	actFile := actfile.ReadActFile(actFilePath)
+	entries := walkDocs(actFile.Acts, actFile, "", wd, make(map[string]bool))
+
+	var content string
+
+	if *formatPtr == "html" {
+		content = renderDocsHtml(*titlePtr, entries)
+	} else {
+		content = renderDocsMarkdown(*titlePtr, entries)
+	}
+
+	if *outPtr == "" {
+		fmt.Print(content)
+		return
+	}
+
+	if err := ioutil.WriteFile(utils.ResolvePath(wd, *outPtr), []byte(content), 0644); err != nil {
+		utils.FatalError("could not write docs file", err)
+		return
+	}
+
+	fmt.Printf("documented %d acts in %s\n", len(entries), *outPtr)
+}
@@ -0,0 +1,193 @@
+/**
+ * This file implements the events subcommand which prints lifecycle
+ * events (run started, stage started, command finished, health
+ * changed and run exited) of all runs in the project as NDJSON. With
+ * -f flag we keep following new events as they get emitted.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/run"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold an events file we are reading from.
+ */
+type eventsFile struct {
+	/**
+	 * The opened file. We keep it open so we can read events of
+	 * foreground runs even after their data dir gets removed.
+	 */
+	file *os.File
+
+	/**
+	 * Incomplete last line read so far.
+	 */
+	partial string
+}
+
+/**
+ * This struct going to hold a raw event line with its time so we
+ * can merge events from different runs.
+ */
+type eventLine struct {
+	time time.Time
+	text string
+}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * How often we check for new events when following.
+ */
+const eventsPollInterval = 250 * time.Millisecond
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Channel we close to stop following events.
+ */
+var eventsDone = make(chan bool)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to open events files of runs we are not
+ * reading from yet.
+ */
+func openEventsFiles(files map[string]*eventsFile) {
+	for _, info := range run.GetAllInfo() {
+		path := info.GetEventsFilePath()
+
+		if _, ok := files[path]; ok {
+			continue
+		}
+
+		file, err := os.Open(path)
+
+		if err != nil {
+			continue
+		}
+
+		files[path] = &eventsFile{file: file}
+	}
+}
+
+/**
+ * This function going to read new complete event lines from all
+ * events files.
+ */
+func readEventLines(files map[string]*eventsFile) []*eventLine {
+	var lines []*eventLine
+
+	for _, evFile := range files {
+		content, err := io.ReadAll(evFile.file)
+
+		if err != nil || len(content) == 0 {
+			continue
+		}
+
+		text := evFile.partial + string(content)
+		parts := strings.Split(text, "\n")
+
+		// Last part is either empty or an incomplete line.
+		evFile.partial = parts[len(parts)-1]
+
+		for _, part := range parts[:len(parts)-1] {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+
+			event := run.Event{}
+			json.Unmarshal([]byte(part), &event)
+			eventTime, _ := time.Parse(time.RFC3339Nano, event.Time)
+
+			lines = append(lines, &eventLine{time: eventTime, text: part})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].time.Before(lines[j].time)
+	})
+
+	return lines
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `events` command.
+ */
+func EventsCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("events", flag.ExitOnError)
+
+	/**
+	 * This flag indicates we want to keep following new events.
+	 */
+	followPtr := cmdFlags.Bool("f", false, "Follow new events")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	files := make(map[string]*eventsFile)
+
+	defer func() {
+		for _, evFile := range files {
+			evFile.file.Close()
+		}
+	}()
+
+	for {
+		openEventsFiles(files)
+
+		for _, line := range readEventLines(files) {
+			fmt.Println(line.text)
+		}
+
+		if !*followPtr {
+			return
+		}
+
+		select {
+		case <-eventsDone:
+			return
+		case <-time.After(eventsPollInterval):
+		}
+	}
+}
+
+/**
+ * This function going to stop following events.
+ */
+func EventsStop() {
+	close(eventsDone)
+}
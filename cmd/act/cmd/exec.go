@@ -0,0 +1,80 @@
+/**
+ * This file implements the exec subcommand which is responsible for
+ * running an arbitrary command inside the context of a running act
+ * (same merged env vars, runtime env file and working directory).
+ * This is useful to debug why an act sees a different environment
+ * than our shell.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `exec` command.
+ */
+func ExecCmdExec(args []string) {
+	if len(args) < 1 {
+		utils.FatalError("you need to specify the name of the act")
+		return
+	}
+
+	info := run.GetInfo(args[0])
+
+	if info == nil {
+		utils.FatalError("act not found")
+		return
+	}
+
+	cmdArgs := args[1:]
+
+	if len(cmdArgs) > 0 && cmdArgs[0] == "--" {
+		cmdArgs = cmdArgs[1:]
+	}
+
+	if len(cmdArgs) < 1 {
+		utils.FatalError("you need to specify the command to execute (like `act exec foo -- env`)")
+		return
+	}
+
+	response, err := info.ControlRequest("env")
+
+	if err != nil {
+		utils.FatalError("could not connect to act process", err)
+		return
+	}
+
+	var execEnv run.ExecEnv
+
+	if err := json.Unmarshal([]byte(response), &execEnv); err != nil {
+		utils.FatalError("could not get act environment", err)
+		return
+	}
+
+	shCmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+	shCmd.Env = execEnv.Env
+	shCmd.Dir = execEnv.Dir
+	shCmd.Stdin = os.Stdin
+	shCmd.Stdout = os.Stdout
+	shCmd.Stderr = os.Stderr
+
+	if err := shCmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			utils.ExitCode = exitErr.ExitCode()
+			return
+		}
+
+		utils.FatalError("could not execute command", err)
+	}
+}
@@ -0,0 +1,365 @@
+/**
+ * This file implements the export subcommand which is responsible
+ * for exporting acts as CI jobs (GitHub Actions workflows or GitLab
+ * CI pipelines) so local act definitions and CI stay in sync. Act
+ * flags become workflow inputs (pipeline variables in GitLab).
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+	"gopkg.in/yaml.v3"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a flag of an exported act.
+ */
+type exportFlag struct {
+	Name    string
+	Default string
+	IsBool  bool
+}
+
+/**
+ * This struct going to hold an act we going to export as a job.
+ */
+type exportAct struct {
+	CallId string
+	Desc   string
+	Flags  []*exportFlag
+}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Command to install act in CI jobs.
+ */
+const exportInstallCmd = "go install github.com/nosebit/act/cmd/act@latest"
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Chars not allowed in CI job ids.
+ */
+var jobIdInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to create a sequence node.
+ */
+func newSeqNode(items ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: items}
+}
+
+/**
+ * This function going to get the CI job id of an act.
+ */
+func getJobId(callId string) string {
+	return jobIdInvalidChars.ReplaceAllString(callId, "-")
+}
+
+/**
+ * This function going to parse act flags (like `env:dev`).
+ */
+func getExportFlags(act *actfile.Act) []*exportFlag {
+	var flags []*exportFlag
+
+	for _, flagDef := range act.Flags {
+		parts := strings.SplitN(flagDef, ":", 2)
+		actFlag := &exportFlag{Name: parts[0]}
+
+		if len(parts) > 1 {
+			actFlag.Default = parts[1]
+		}
+
+		actFlag.IsBool = actFlag.Default == "true" || actFlag.Default == "false"
+		flags = append(flags, actFlag)
+	}
+
+	return flags
+}
+
+/**
+ * This function going to get the GitLab variable name of a flag.
+ */
+func getGitlabVarName(actFlag *exportFlag) string {
+	return fmt.Sprintf("FLAG_%s", strings.ToUpper(jobIdInvalidChars.ReplaceAllString(actFlag.Name, "_")))
+}
+
+/**
+ * This function going to build the act run command of a job using
+ * a function to get the value expression of each flag.
+ */
+func getJobRunCmd(act *exportAct, actFilePath string, flagValue func(actFlag *exportFlag) string) string {
+	cmdLine := "act run"
+
+	if actFilePath != "" {
+		cmdLine += fmt.Sprintf(" -f %s", actFilePath)
+	}
+
+	cmdLine += fmt.Sprintf(" %s", act.CallId)
+
+	for _, actFlag := range act.Flags {
+		cmdLine += fmt.Sprintf(" -%s=%s", actFlag.Name, flagValue(actFlag))
+	}
+
+	return cmdLine
+}
+
+/**
+ * This function going to build a GitHub Actions workflow running
+ * the acts. Workflow can be triggered manually (with flags as
+ * inputs) or on pushes (with flags default values).
+ */
+func getGithubWorkflow(name string, acts []*exportAct, actFilePath string) *yaml.Node {
+	inputs := newMappingNode()
+	jobs := newMappingNode()
+
+	for _, act := range acts {
+		for _, actFlag := range act.Flags {
+			input := newMappingNode(
+				newStrNode("description"), newStrNode(fmt.Sprintf("Flag %s of act %s", actFlag.Name, act.CallId)),
+				newStrNode("required"), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"},
+				newStrNode("default"), newStrNode(actFlag.Default),
+			)
+
+			if actFlag.IsBool {
+				input.Content = append(input.Content, newStrNode("type"), newStrNode("boolean"))
+			}
+
+			setMappingValue(inputs, actFlag.Name, input)
+		}
+
+		runCmd := getJobRunCmd(act, actFilePath, func(actFlag *exportFlag) string {
+			return fmt.Sprintf("\"${{ github.event.inputs.%s || '%s' }}\"", actFlag.Name, actFlag.Default)
+		})
+
+		runStep := newMappingNode(newStrNode("run"), newStrNode(runCmd))
+
+		if act.Desc != "" {
+			runStep = newMappingNode(newStrNode("name"), newStrNode(act.Desc), newStrNode("run"), newStrNode(runCmd))
+		}
+
+		setMappingValue(jobs, getJobId(act.CallId), newMappingNode(
+			newStrNode("runs-on"), newStrNode("ubuntu-latest"),
+			newStrNode("steps"), newSeqNode(
+				newMappingNode(newStrNode("uses"), newStrNode("actions/checkout@v2")),
+				newMappingNode(
+					newStrNode("uses"), newStrNode("actions/setup-go@v2"),
+					newStrNode("with"), newMappingNode(newStrNode("go-version"), newStrNode("1.16")),
+				),
+				newMappingNode(newStrNode("run"), newStrNode(exportInstallCmd)),
+				runStep,
+			),
+		))
+	}
+
+	dispatch := newMappingNode()
+
+	if len(inputs.Content) > 0 {
+		dispatch = newMappingNode(newStrNode("inputs"), inputs)
+	}
+
+	return newMappingNode(
+		newStrNode("name"), newStrNode(name),
+		newStrNode("on"), newMappingNode(
+			newStrNode("push"), newMappingNode(),
+			newStrNode("workflow_dispatch"), dispatch,
+		),
+		newStrNode("jobs"), jobs,
+	)
+}
+
+/**
+ * This function going to build a GitLab CI pipeline running the
+ * acts. Flags become pipeline variables (prefilled when running the
+ * pipeline manually).
+ */
+func getGitlabPipeline(acts []*exportAct, actFilePath string) *yaml.Node {
+	variables := newMappingNode()
+	pipeline := newMappingNode()
+
+	for _, act := range acts {
+		for _, actFlag := range act.Flags {
+			setMappingValue(variables, getGitlabVarName(actFlag), newMappingNode(
+				newStrNode("value"), newStrNode(actFlag.Default),
+				newStrNode("description"), newStrNode(fmt.Sprintf("Flag %s of act %s", actFlag.Name, act.CallId)),
+			))
+		}
+
+		runCmd := getJobRunCmd(act, actFilePath, func(actFlag *exportFlag) string {
+			return fmt.Sprintf("\"$%s\"", getGitlabVarName(actFlag))
+		})
+
+		setMappingValue(pipeline, getJobId(act.CallId), newMappingNode(
+			newStrNode("image"), newStrNode("golang:1.16"),
+			newStrNode("before_script"), newSeqNode(newStrNode(exportInstallCmd)),
+			newStrNode("script"), newSeqNode(newStrNode(runCmd)),
+		))
+	}
+
+	if len(variables.Content) > 0 {
+		pipeline.Content = append([]*yaml.Node{newStrNode("variables"), variables}, pipeline.Content...)
+	}
+
+	return pipeline
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `export` command.
+ */
+func ExportCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("export", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This is the file we write the exported yaml to (stdout by
+	 * default).
+	 */
+	outPtr := cmdFlags.String("o", "", "Path to write the exported file to")
+
+	/**
+	 * This is the name of the exported GitHub workflow.
+	 */
+	namePtr := cmdFlags.String("name", "act", "Name of the exported workflow")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting the
+	 * flags (target followed by the call ids of acts to export).
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) < 2 {
+		utils.FatalError("you need to specify the target and the acts to export (like `act export github build test`)")
+		return
+	}
+
+	target := cmdArgs[0]
+
+	if target != "github" && target != "gitlab" {
+		utils.FatalError(fmt.Sprintf("invalid export target %s (use github or gitlab)", target))
+		return
+	}
+
+	wd := utils.GetWd()
+	actFilePath := utils.ResolvePath(wd, *actFilePathPtr)
+
+	if !utils.DoFileExists(actFilePath) {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	actFile := actfile.ReadActFile(actFilePath)
+
+	/**
+	 * We resolve acts exactly the same way we do when running them
+	 * so exported flags are the ones of the act going to run.
+	 */
+	runCtx := &run.RunCtx{
+		ActFile: actFile,
+		Info:    &run.Info{},
+		Vars:    make(map[string]string),
+		ActVars: make(map[string]string),
+	}
+
+	var acts []*exportAct
+
+	for _, callId := range cmdArgs[1:] {
+		actCtx, err := run.FindActCtx(strings.Split(callId, run.ActCallIdSeparator), actFile, nil, runCtx)
+
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("act %s not found", callId), err)
+			return
+		}
+
+		acts = append(acts, &exportAct{
+			CallId: callId,
+			Desc:   actCtx.Act.Desc,
+			Flags:  getExportFlags(actCtx.Act),
+		})
+	}
+
+	/**
+	 * Jobs run from the repo root so we only pass the actfile path
+	 * when it's not the default one.
+	 */
+	var jobActFilePath string
+
+	if *actFilePathPtr != config.GetActFileName() {
+		jobActFilePath = getRelPath(wd, actFilePath)
+	}
+
+	var doc *yaml.Node
+
+	if target == "github" {
+		doc = getGithubWorkflow(*namePtr, acts, jobActFilePath)
+	} else {
+		doc = getGitlabPipeline(acts, jobActFilePath)
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(actFileIndent)
+
+	if err := encoder.Encode(doc); err != nil {
+		utils.FatalError("could not export acts", err)
+		return
+	}
+
+	encoder.Close()
+
+	content := buf.Bytes()
+
+	if *outPtr == "" {
+		fmt.Print(string(content))
+		return
+	}
+
+	if err := ioutil.WriteFile(utils.ResolvePath(wd, *outPtr), content, 0644); err != nil {
+		utils.FatalError("could not write exported file", err)
+		return
+	}
+
+	fmt.Printf("exported %d acts to %s\n", len(acts), *outPtr)
+}
@@ -0,0 +1,387 @@
+/**
+ * This file implements the fmt subcommand which rewrites an actfile
+ * in a canonical style (consistent key order, commands in the same
+ * form and stable indentation) so diffs stay clean across a team. We
+ * work on the yaml node tree so comments are preserved.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/utils"
+	"gopkg.in/yaml.v3"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Command forms we can normalize commands to. In short form commands
+ * with only a command line are written as plain strings while in
+ * long form all commands are written as objects.
+ */
+const (
+	cmdFormShort = "short"
+	cmdFormLong  = "long"
+)
+
+/**
+ * Indentation we use for actfiles.
+ */
+const actFileIndent = 2
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Canonical key order of actfile root object.
+ */
+var actFileKeyOrder = []string{
+	"version", "namespace", "envfile", "shell", "shellopts", "log",
+	"log_format", "log_timestamp", "separators", "npm_scripts", "notify_after", "secrets",
+	"aws", "before-all", "acts",
+}
+
+/**
+ * Canonical key order of acts. Stages come last (in execution order)
+ * followed by subacts.
+ */
+var actKeyOrder = []string{
+	"desc", "flags", "envfile", "secrets", "container", "host", "user",
+	"umask", "nice", "limits", "include",
+	"redirect", "shell", "shellopts", "script", "parallel", "quiet", "log",
+	"separators",
+	"tty", "stderr_log", "log_max_size", "log_max_age", "log_max_files",
+	"needs", "sources",
+	"check", "notify", "debounce", "min_interval", "dedupe", "lock", "queue",
+	"interactive", "restart", "max_restarts", "restart_backoff",
+	"stop_grace_period", "stop_timeline", "final_timeout", "before", "cmds",
+	"start", "after", "on-ready", "on_success", "on_failure", "final",
+	"teardown", "acts",
+}
+
+/**
+ * Canonical key order of exec stages.
+ */
+var stageKeyOrder = []string{
+	"name", "parallel", "exec_mode", "shell", "quiet", "script", "cmds",
+}
+
+/**
+ * Canonical key order of commands.
+ */
+var cmdKeyOrder = []string{
+	"cmd", "script", "act", "from", "wait_for", "http", "pipe", "args",
+	"dir", "env", "shell", "shellopts", "detach", "loop", "mismatch", "quiet",
+	"log", "tty", "container", "host", "user", "umask", "nice", "expect",
+	"and", "or",
+}
+
+/**
+ * Act keys holding exec stages.
+ */
+var stageKeys = map[string]bool{
+	"before": true, "cmds": true, "start": true, "after": true,
+	"on-ready": true, "on_success": true, "on_failure": true,
+	"final": true, "teardown": true,
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the value node of a key in a mapping
+ * node.
+ */
+func getMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to sort keys of a mapping node following a
+ * canonical order. Unknown keys keep their relative order after the
+ * known ones.
+ */
+func sortMappingKeys(node *yaml.Node, order []string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	var sorted []*yaml.Node
+	used := make(map[int]bool)
+
+	for _, key := range order {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !used[i] && node.Content[i].Value == key {
+				sorted = append(sorted, node.Content[i], node.Content[i+1])
+				used[i] = true
+			}
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !used[i] {
+			sorted = append(sorted, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	node.Content = sorted
+}
+
+/**
+ * This function going to normalize a command node to the chosen
+ * form returning the new node.
+ */
+func fmtCmd(node *yaml.Node, cmdForm string) *yaml.Node {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if cmdForm != cmdFormLong {
+			return node
+		}
+
+		// Comments of the command go to the new object.
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "cmd", HeadComment: node.HeadComment}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: node.Tag, Value: node.Value, Style: node.Style, LineComment: node.LineComment}
+
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, value}, FootComment: node.FootComment}
+	case yaml.MappingNode:
+		sortMappingKeys(node, cmdKeyOrder)
+
+		for _, key := range []string{"pipe", "and", "or"} {
+			fmtCmds(getMappingValue(node, key), cmdForm)
+		}
+
+		isCmdOnly := len(node.Content) == 2 && node.Content[0].Value == "cmd" && node.Content[1].Kind == yaml.ScalarNode
+
+		if cmdForm == cmdFormShort && isCmdOnly {
+			key, value := node.Content[0], node.Content[1]
+
+			value.HeadComment = joinComments(node.HeadComment, key.HeadComment)
+			value.LineComment = joinComments(key.LineComment, value.LineComment)
+			value.FootComment = joinComments(value.FootComment, node.FootComment)
+
+			return value
+		}
+	}
+
+	return node
+}
+
+/**
+ * This function going to join comments skipping empty ones.
+ */
+func joinComments(comments ...string) string {
+	var joined string
+
+	for _, comment := range comments {
+		if comment == "" {
+			continue
+		}
+
+		if joined != "" {
+			joined += "\n"
+		}
+
+		joined += comment
+	}
+
+	return joined
+}
+
+/**
+ * This function going to normalize all commands of a commands list.
+ */
+func fmtCmds(node *yaml.Node, cmdForm string) {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return
+	}
+
+	for i, cmdNode := range node.Content {
+		node.Content[i] = fmtCmd(cmdNode, cmdForm)
+	}
+}
+
+/**
+ * This function going to format an exec stage returning the new
+ * node (a stage can be a command line, a list of commands or an
+ * object).
+ */
+func fmtStage(node *yaml.Node, cmdForm string) *yaml.Node {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if cmdForm != cmdFormLong {
+			return node
+		}
+
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{fmtCmd(node, cmdForm)}}
+	case yaml.SequenceNode:
+		fmtCmds(node, cmdForm)
+	case yaml.MappingNode:
+		sortMappingKeys(node, stageKeyOrder)
+		fmtCmds(getMappingValue(node, "cmds"), cmdForm)
+	}
+
+	return node
+}
+
+/**
+ * This function going to format all acts of an acts mapping. We keep
+ * acts order since it matters for act name matching.
+ */
+func fmtActs(node *yaml.Node, cmdForm string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 1; i < len(node.Content); i += 2 {
+		act := node.Content[i]
+
+		if act.Kind != yaml.MappingNode {
+			continue
+		}
+
+		sortMappingKeys(act, actKeyOrder)
+
+		for j := 0; j+1 < len(act.Content); j += 2 {
+			if stageKeys[act.Content[j].Value] {
+				act.Content[j+1] = fmtStage(act.Content[j+1], cmdForm)
+			}
+		}
+
+		fmtActs(getMappingValue(act, "acts"), cmdForm)
+	}
+}
+
+/**
+ * This function going to format actfile content.
+ */
+func fmtActFile(content []byte, cmdForm string) ([]byte, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root := doc.Content[0]
+
+		sortMappingKeys(root, actFileKeyOrder)
+
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "before-all" {
+				root.Content[i+1] = fmtStage(root.Content[i+1], cmdForm)
+			}
+		}
+
+		fmtActs(getMappingValue(root, "acts"), cmdForm)
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(actFileIndent)
+
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	encoder.Close()
+
+	return buf.Bytes(), nil
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `fmt` command.
+ */
+func FmtCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("fmt", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be formatted.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This flag set the form we normalize commands to.
+	 */
+	cmdFormPtr := cmdFlags.String("cmds", cmdFormShort, "Form of commands (short or long)")
+
+	/**
+	 * This flag indicates we only want to check if the actfile is
+	 * formatted (useful in ci).
+	 */
+	checkPtr := cmdFlags.Bool("check", false, "Fail if actfile is not formatted instead of rewriting it")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	if *cmdFormPtr != cmdFormShort && *cmdFormPtr != cmdFormLong {
+		utils.FatalError(fmt.Sprintf("invalid cmds form %s (use short or long)", *cmdFormPtr))
+		return
+	}
+
+	actFilePath := utils.ResolvePath(utils.GetWd(), *actFilePathPtr)
+	content, err := ioutil.ReadFile(actFilePath)
+
+	if err != nil {
+		utils.FatalError("could not read actfile", err)
+		return
+	}
+
+	formatted, err := fmtActFile(content, *cmdFormPtr)
+
+	if err != nil {
+		utils.FatalError("could not parse actfile", err)
+		return
+	}
+
+	if bytes.Equal(content, formatted) {
+		return
+	}
+
+	if *checkPtr {
+		utils.FatalError(fmt.Sprintf("%s is not formatted (run act fmt)", actFilePath))
+		return
+	}
+
+	stat, err := os.Stat(actFilePath)
+
+	if err != nil {
+		utils.FatalError("could not read actfile", err)
+		return
+	}
+
+	if err := utils.WriteFileAtomic(actFilePath, formatted, stat.Mode()); err != nil {
+		utils.FatalError("could not write actfile", err)
+		return
+	}
+
+	fmt.Printf("formatted %s\n", actFilePath)
+}
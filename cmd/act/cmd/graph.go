@@ -0,0 +1,520 @@
+/**
+ * This file implements the graph subcommand which renders how acts
+ * relate to each other (needs, act commands, subacts, includes and
+ * redirects) across actfiles as a DOT or Mermaid graph.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a node of the act graph. A node is an
+ * act declaration identified by its actfile and its path inside the
+ * actfile (like foo.bar for a nested subact).
+ */
+type graphNode struct {
+	id       string
+	declPath string
+	act      *actfile.Act
+	actFile  *actfile.ActFile
+}
+
+/**
+ * This struct going to hold an edge of the act graph.
+ */
+type graphEdge struct {
+	from *graphNode
+	to   *graphNode
+	kind string
+}
+
+/**
+ * This struct going to hold the act graph while we build it.
+ */
+type actGraph struct {
+	wd       string
+	rootPath string
+	actFiles map[string]*actfile.ActFile
+	nodes    map[string]*graphNode
+	order    []*graphNode
+	edges    []*graphEdge
+}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Kinds of edges of the act graph.
+ */
+const (
+	graphEdgeNeeds    = "needs"
+	graphEdgeAct      = "act"
+	graphEdgeDetach   = "detach"
+	graphEdgeSubact   = "subact"
+	graphEdgeInclude  = "include"
+	graphEdgeRedirect = "redirect"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to find an act matching a name (act names
+ * can be regexes).
+ */
+func matchGraphAct(name string, acts []*actfile.Act) *actfile.Act {
+	for _, act := range acts {
+		if act.MatchName(name) {
+			return act
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to get all commands of an act (including the
+ * ones chained with and/or and the ones in checks).
+ */
+func getActCmds(act *actfile.Act) []*actfile.Cmd {
+	var cmds []*actfile.Cmd
+
+	stages := []*actfile.ActExecStage{
+		act.Before,
+		act.Start,
+		act.After,
+		act.OnSuccess,
+		act.OnFailure,
+		act.Final,
+		act.Teardown,
+	}
+
+	for _, stage := range stages {
+		if stage != nil {
+			cmds = append(cmds, stage.Cmds...)
+		}
+	}
+
+	if act.Check != nil {
+		cmds = append(cmds, act.Check.Cmds...)
+	}
+
+	for i := 0; i < len(cmds); i++ {
+		cmds = append(cmds, cmds[i].And...)
+		cmds = append(cmds, cmds[i].Or...)
+	}
+
+	return cmds
+}
+
+/**
+ * This function going to get the act references of a command. Loops
+ * over items are expanded and references we can only know at
+ * runtime (templates) are skipped.
+ */
+func getCmdActRefs(cmd *actfile.Cmd) []string {
+	var refs []string
+
+	if cmd.Loop != nil && len(cmd.Loop.Items) > 0 {
+		for _, item := range cmd.Loop.Items {
+			refs = append(refs, utils.CompileTemplate(cmd.Act, map[string]string{"LoopItem": item}))
+		}
+	} else {
+		refs = []string{cmd.Act}
+	}
+
+	var validRefs []string
+
+	for _, ref := range refs {
+		// Act commands can have args together with the act name.
+		ref = strings.Split(ref, " ")[0]
+
+		if ref != "" && !strings.Contains(ref, "{{") {
+			validRefs = append(validRefs, ref)
+		}
+	}
+
+	return validRefs
+}
+
+//############################################################
+// actGraph Struct Functions
+//############################################################
+
+/**
+ * This function going to read an actfile (only once).
+ */
+func (g *actGraph) readActFile(filePath string) *actfile.ActFile {
+	if actFile, ok := g.actFiles[filePath]; ok {
+		return actFile
+	}
+
+	if !utils.DoFileExists(filePath) {
+		return nil
+	}
+
+	actFile := actfile.ReadActFile(filePath)
+	g.actFiles[filePath] = actFile
+
+	return actFile
+}
+
+/**
+ * This function going to get the subacts of an act node together
+ * with the actfile they are declared in and the declaration path
+ * prefix of them.
+ */
+func (g *actGraph) getSubActs(node *graphNode) ([]*actfile.Act, *actfile.ActFile, string) {
+	if node.act.Include == "" {
+		return node.act.Acts, node.actFile, node.declPath
+	}
+
+	include := utils.CompileTemplate(node.act.Include, map[string]string{})
+	includedActFile := g.readActFile(utils.ResolvePath(filepath.Dir(node.actFile.LocationPath), include))
+
+	if includedActFile == nil {
+		return nil, nil, ""
+	}
+
+	return includedActFile.Acts, includedActFile, ""
+}
+
+/**
+ * This function going to add an act (and everything it relates to)
+ * to the graph.
+ */
+func (g *actGraph) addAct(act *actfile.Act, actFile *actfile.ActFile, declPath string) *graphNode {
+	id := fmt.Sprintf("%s#%s", actFile.LocationPath, declPath)
+
+	if node, ok := g.nodes[id]; ok {
+		return node
+	}
+
+	node := &graphNode{id: id, declPath: declPath, act: act, actFile: actFile}
+
+	g.nodes[id] = node
+	g.order = append(g.order, node)
+
+	// Redirected acts are defined in another actfile.
+	if act.Redirect != "" {
+		redirectActFile := g.readActFile(utils.ResolvePath(filepath.Dir(actFile.LocationPath), act.Redirect))
+
+		if redirectActFile != nil {
+			if target := matchGraphAct(act.Name, redirectActFile.Acts); target != nil {
+				g.addEdge(node, g.addAct(target, redirectActFile, target.Name), graphEdgeRedirect)
+			}
+		}
+
+		return node
+	}
+
+	// Subacts (nested or included).
+	subActs, subActFile, prefix := g.getSubActs(node)
+
+	for _, subAct := range subActs {
+		kind := graphEdgeSubact
+
+		if subActFile != actFile {
+			kind = graphEdgeInclude
+		}
+
+		g.addEdge(node, g.addAct(subAct, subActFile, joinDeclPath(prefix, subAct.Name)), kind)
+	}
+
+	// Acts called by commands.
+	for _, cmd := range getActCmds(act) {
+		if cmd.Act == "" {
+			continue
+		}
+
+		kind := graphEdgeAct
+
+		if cmd.Detach {
+			kind = graphEdgeDetach
+		}
+
+		for _, ref := range getCmdActRefs(cmd) {
+			if target := g.resolveActRef(node, ref, cmd.From); target != nil {
+				g.addEdge(node, target, kind)
+			}
+		}
+	}
+
+	// Needed acts.
+	for _, need := range act.Needs {
+		if target := g.resolveActRef(node, need.Act, ""); target != nil {
+			g.addEdge(node, target, graphEdgeNeeds)
+		}
+	}
+
+	return node
+}
+
+/**
+ * This function going to resolve an act reference (like the act of
+ * an act command) from an act node the same way we do when running
+ * it: acts with subacts look up references in their subacts and
+ * other acts in their actfile.
+ */
+func (g *actGraph) resolveActRef(node *graphNode, ref string, from string) *graphNode {
+	acts, actFile, prefix := g.getSubActs(node)
+
+	if len(acts) == 0 || from != "" {
+		actFile = node.actFile
+		prefix = ""
+
+		if from != "" {
+			actFile = g.readActFile(utils.ResolvePath(g.wd, from))
+		}
+
+		if actFile == nil {
+			return nil
+		}
+
+		acts = actFile.Acts
+	}
+
+	var target *graphNode
+
+	for _, name := range strings.Split(ref, run.ActCallIdSeparator) {
+		act := matchGraphAct(name, acts)
+
+		if act == nil {
+			return nil
+		}
+
+		target = g.addAct(act, actFile, joinDeclPath(prefix, act.Name))
+		acts, actFile, prefix = g.getSubActs(target)
+	}
+
+	return target
+}
+
+/**
+ * This function going to add an edge to the graph.
+ */
+func (g *actGraph) addEdge(from *graphNode, to *graphNode, kind string) {
+	for _, edge := range g.edges {
+		if edge.from == from && edge.to == to && edge.kind == kind {
+			return
+		}
+	}
+
+	g.edges = append(g.edges, &graphEdge{from: from, to: to, kind: kind})
+}
+
+/**
+ * This function going to get the label of a node (declaration path
+ * followed by the actfile when not the root one).
+ */
+func (g *actGraph) getLabel(node *graphNode) string {
+	if node.actFile.LocationPath == g.rootPath {
+		return node.declPath
+	}
+
+	relPath, err := filepath.Rel(g.wd, node.actFile.LocationPath)
+
+	if err != nil {
+		relPath = node.actFile.LocationPath
+	}
+
+	return fmt.Sprintf("%s (%s)", node.declPath, relPath)
+}
+
+/**
+ * This function going to get nodes reachable from a node.
+ */
+func (g *actGraph) getReachable(start *graphNode) map[*graphNode]bool {
+	reachable := map[*graphNode]bool{start: true}
+	queue := []*graphNode{start}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, edge := range g.edges {
+			if edge.from == node && !reachable[edge.to] {
+				reachable[edge.to] = true
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+
+	return reachable
+}
+
+/**
+ * This function going to render the graph in DOT format.
+ */
+func (g *actGraph) renderDot(nodes []*graphNode, ids map[*graphNode]string, edges []*graphEdge) {
+	fmt.Println("digraph act {")
+	fmt.Println("  rankdir=LR;")
+
+	for _, node := range nodes {
+		fmt.Printf("  %s [label=%q];\n", ids[node], g.getLabel(node))
+	}
+
+	for _, edge := range edges {
+		style := ""
+
+		if edge.kind == graphEdgeInclude || edge.kind == graphEdgeRedirect || edge.kind == graphEdgeSubact {
+			style = ", style=dashed"
+		}
+
+		fmt.Printf("  %s -> %s [label=%q%s];\n", ids[edge.from], ids[edge.to], edge.kind, style)
+	}
+
+	fmt.Println("}")
+}
+
+/**
+ * This function going to render the graph in Mermaid format.
+ */
+func (g *actGraph) renderMermaid(nodes []*graphNode, ids map[*graphNode]string, edges []*graphEdge) {
+	fmt.Println("graph LR")
+
+	for _, node := range nodes {
+		fmt.Printf("  %s[\"%s\"]\n", ids[node], strings.ReplaceAll(g.getLabel(node), "\"", "#quot;"))
+	}
+
+	for _, edge := range edges {
+		arrow := "-->"
+
+		if edge.kind == graphEdgeInclude || edge.kind == graphEdgeRedirect || edge.kind == graphEdgeSubact {
+			arrow = "-.->"
+		}
+
+		fmt.Printf("  %s %s|%s| %s\n", ids[edge.from], arrow, edge.kind, ids[edge.to])
+	}
+}
+
+/**
+ * This function going to join declaration paths.
+ */
+func joinDeclPath(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return fmt.Sprintf("%s%s%s", prefix, run.ActCallIdSeparator, name)
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `graph` command.
+ */
+func GraphCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("graph", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This flag set the output format.
+	 */
+	formatPtr := cmdFlags.String("format", "dot", "Output format (dot or mermaid)")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	cmdArgs := cmdFlags.Args()
+
+	if *formatPtr != "dot" && *formatPtr != "mermaid" {
+		utils.FatalError(fmt.Sprintf("invalid format %s (use dot or mermaid)", *formatPtr))
+		return
+	}
+
+	wd := utils.GetWd()
+	actFilePath := utils.ResolvePath(wd, *actFilePathPtr)
+
+	g := &actGraph{
+		wd:       wd,
+		rootPath: actFilePath,
+		actFiles: make(map[string]*actfile.ActFile),
+		nodes:    make(map[string]*graphNode),
+	}
+
+	rootActFile := g.readActFile(actFilePath)
+
+	if rootActFile == nil {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	for _, act := range rootActFile.Acts {
+		g.addAct(act, rootActFile, act.Name)
+	}
+
+	nodes := g.order
+	edges := g.edges
+
+	// Keep only what an act relates to.
+	if len(cmdArgs) > 0 {
+		ref := strings.Join(cmdArgs, run.ActCallIdSeparator)
+		root := &graphNode{act: &actfile.Act{}, actFile: rootActFile}
+		start := g.resolveActRef(root, ref, "")
+
+		if start == nil {
+			utils.FatalError(fmt.Sprintf("act %s not found", ref))
+			return
+		}
+
+		reachable := g.getReachable(start)
+
+		nodes = nil
+		edges = nil
+
+		for _, node := range g.order {
+			if reachable[node] {
+				nodes = append(nodes, node)
+			}
+		}
+
+		for _, edge := range g.edges {
+			if reachable[edge.from] && reachable[edge.to] {
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	ids := make(map[*graphNode]string)
+
+	for i, node := range nodes {
+		ids[node] = fmt.Sprintf("n%d", i)
+	}
+
+	if *formatPtr == "mermaid" {
+		g.renderMermaid(nodes, ids, edges)
+	} else {
+		g.renderDot(nodes, ids, edges)
+	}
+}
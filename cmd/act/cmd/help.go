@@ -0,0 +1,279 @@
+/**
+ * This file implements the help subcommand which is responsible for
+ * showing what acts are available in an actfile and how to run them
+ * (description, flags, stages and subacts).
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the subacts of an act together with
+ * the actfile they were declared in. Subacts can be nested in the
+ * act itself or pulled in from another actfile (with include).
+ */
+func getSubActs(act *actfile.Act, actFile *actfile.ActFile) ([]*actfile.Act, *actfile.ActFile) {
+	if act.Include == "" {
+		return act.Acts, actFile
+	}
+
+	includePath := utils.ResolvePath(filepath.Dir(actFile.LocationPath), utils.CompileTemplate(act.Include, map[string]string{}))
+
+	if !utils.DoFileExists(includePath) {
+		return nil, actFile
+	}
+
+	includedActFile := actfile.ReadActFile(includePath)
+
+	return includedActFile.Acts, includedActFile
+}
+
+/**
+ * This function going to find an act by its name (act names can be
+ * regexes) following redirects to other actfiles.
+ */
+func findHelpAct(name string, acts []*actfile.Act, actFile *actfile.ActFile) (*actfile.Act, *actfile.ActFile) {
+	for _, act := range acts {
+		if !act.MatchName(name) {
+			continue
+		}
+
+		if act.Redirect != "" {
+			redirectPath := utils.ResolvePath(filepath.Dir(actFile.LocationPath), act.Redirect)
+
+			if utils.DoFileExists(redirectPath) {
+				redirectActFile := actfile.ReadActFile(redirectPath)
+
+				return findHelpAct(name, redirectActFile.Acts, redirectActFile)
+			}
+		}
+
+		return act, actFile
+	}
+
+	return nil, nil
+}
+
+/**
+ * This function going to find an act by its call id (like foo.bar)
+ * along with the actfile it was declared in.
+ */
+func findActByCallId(callId string, actFile *actfile.ActFile) (*actfile.Act, *actfile.ActFile) {
+	acts := actFile.Acts
+	currActFile := actFile
+
+	var act *actfile.Act
+	var actActFile *actfile.ActFile
+
+	for _, name := range strings.Split(callId, run.ActCallIdSeparator) {
+		act, actActFile = findHelpAct(name, acts, currActFile)
+
+		if act == nil {
+			return nil, nil
+		}
+
+		acts, currActFile = getSubActs(act, actActFile)
+	}
+
+	return act, actActFile
+}
+
+/**
+ * This function going to print a list of acts with their
+ * descriptions.
+ */
+func printActList(acts []*actfile.Act) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	for _, act := range acts {
+		fmt.Fprintf(w, "  %s\t%s\n", utils.Color.Green(act.Name).Bold(), act.Desc)
+	}
+
+	w.Flush()
+}
+
+/**
+ * This function going to print help for a specific act.
+ */
+func printActHelp(callId string, act *actfile.Act, actFile *actfile.ActFile) {
+	fmt.Printf("%s", utils.Color.Green(callId).Bold())
+
+	if act.Desc != "" {
+		fmt.Printf(" - %s", act.Desc)
+	}
+
+	fmt.Printf("\n\n")
+
+	subActs, subActFile := getSubActs(act, actFile)
+
+	// Usage
+	usage := fmt.Sprintf("act run %s", callId)
+
+	if len(act.Flags) > 0 {
+		usage += " [flags]"
+	}
+
+	if len(subActs) > 0 {
+		usage += fmt.Sprintf(" | act run %s%s<subact>", callId, run.ActCallIdSeparator)
+	}
+
+	fmt.Println(utils.Color.Bold("Usage:"))
+	fmt.Printf("  %s [args...]\n", usage)
+
+	// Flags
+	if len(act.Flags) > 0 {
+		fmt.Println()
+		fmt.Println(utils.Color.Bold("Flags:"))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+		for _, flagDef := range act.Flags {
+			parts := strings.SplitN(flagDef, ":", 2)
+			defaultVal := ""
+
+			if len(parts) > 1 {
+				defaultVal = parts[1]
+			}
+
+			flagType := "string"
+
+			if defaultVal == "true" || defaultVal == "false" {
+				flagType = "bool"
+			}
+
+			fmt.Fprintf(w, "  -%s\t%s\tdefault: %q\n", parts[0], flagType, defaultVal)
+		}
+
+		w.Flush()
+	}
+
+	// Stages
+	stages := []*actfile.ActExecStage{
+		act.Before,
+		act.Start,
+		act.After,
+		act.OnSuccess,
+		act.OnFailure,
+		act.Final,
+		act.Teardown,
+	}
+
+	var stageLines []string
+
+	for _, stage := range stages {
+		if stage == nil || (len(stage.Cmds) == 0 && stage.Script == "") {
+			continue
+		}
+
+		line := fmt.Sprintf("  %s", stage.Name)
+
+		if stage.Script != "" {
+			line += fmt.Sprintf(" (script %s)", stage.Script)
+		} else if len(stage.Cmds) == 1 {
+			line += " (1 cmd)"
+		} else {
+			line += fmt.Sprintf(" (%d cmds)", len(stage.Cmds))
+		}
+
+		if stage.Parallel {
+			line += " [parallel]"
+		}
+
+		stageLines = append(stageLines, line)
+	}
+
+	if len(stageLines) > 0 {
+		fmt.Println()
+		fmt.Println(utils.Color.Bold("Stages:"))
+		fmt.Println(strings.Join(stageLines, "\n"))
+	}
+
+	// Subacts
+	if len(subActs) > 0 {
+		fmt.Println()
+
+		if subActFile != actFile {
+			fmt.Println(utils.Color.Bold(fmt.Sprintf("Subacts (from %s):", act.Include)))
+		} else {
+			fmt.Println(utils.Color.Bold("Subacts:"))
+		}
+
+		printActList(subActs)
+	}
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `help` command.
+ */
+func HelpCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("help", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	cmdArgs := cmdFlags.Args()
+
+	actFilePath := utils.ResolvePath(utils.GetWd(), *actFilePathPtr)
+
+	if !utils.DoFileExists(actFilePath) {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	actFile := actfile.ReadActFile(actFilePath)
+
+	// Without an act name we list all top level acts.
+	if len(cmdArgs) == 0 {
+		fmt.Println(utils.Color.Bold("Acts:"))
+		printActList(actFile.Acts)
+		fmt.Printf("\nRun `act help <act>` to see details of an act.\n")
+		return
+	}
+
+	/**
+	 * Act name can be a call id (like foo.bar) or a list of names
+	 * (like foo bar) the same way we run acts.
+	 */
+	callId := strings.Join(cmdArgs, run.ActCallIdSeparator)
+	act, actActFile := findActByCallId(callId, actFile)
+
+	if act == nil {
+		utils.FatalError(fmt.Sprintf("act %s not found", callId))
+		return
+	}
+
+	printActHelp(callId, act, actActFile)
+}
@@ -0,0 +1,209 @@
+/**
+ * This file implements the import subcommand which is responsible
+ * for importing scripts defined by other tools (like npm scripts in
+ * package.json) as acts in an actfile so repos can migrate to act
+ * incrementally. We work on the yaml node tree so comments of the
+ * actfile are preserved.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/utils"
+	"gopkg.in/yaml.v3"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to create a string scalar node.
+ */
+func newStrNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+/**
+ * This function going to create a mapping node from key value pairs.
+ */
+func newMappingNode(pairs ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: pairs}
+}
+
+/**
+ * This function going to set the value of a key in a mapping node
+ * (replacing the current value if key already exists).
+ */
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+
+	node.Content = append(node.Content, newStrNode(key), value)
+}
+
+/**
+ * This function going to build the act node holding npm scripts of
+ * a package.json file as subacts.
+ */
+func getNpmActNode(packagePath string) (*yaml.Node, int, error) {
+	scripts, err := actfile.ReadNpmScripts(packagePath)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	acts := newMappingNode()
+
+	for _, script := range scripts {
+		setMappingValue(acts, actfile.GetNpmScriptActName(script), newMappingNode(
+			newStrNode("desc"), newStrNode(script.Cmd),
+			newStrNode("start"), newStrNode(actfile.GetNpmScriptCmd(script)),
+		))
+	}
+
+	actNode := newMappingNode(
+		newStrNode("desc"), newStrNode(fmt.Sprintf("Scripts from %s", actfile.NpmPackageFileName)),
+		newStrNode("acts"), acts,
+	)
+
+	return actNode, len(scripts), nil
+}
+
+/**
+ * This function going to add an act to actfile content returning
+ * the new content. If actfile is empty we create a new one.
+ */
+func addActToActFile(content []byte, name string, actNode *yaml.Node) ([]byte, error) {
+	var doc yaml.Node
+
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{newMappingNode(newStrNode("version"), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: "1"})},
+		}
+	}
+
+	root := doc.Content[0]
+
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("actfile root is not an object")
+	}
+
+	acts := getMappingValue(root, "acts")
+
+	if acts == nil || acts.Kind != yaml.MappingNode {
+		acts = newMappingNode()
+		setMappingValue(root, "acts", acts)
+	}
+
+	setMappingValue(acts, name, actNode)
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(actFileIndent)
+
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	encoder.Close()
+
+	return buf.Bytes(), nil
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `import` command.
+ */
+func ImportCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("import", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile where we going to import acts.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This flag set the name of the act holding imported acts.
+	 */
+	namePtr := cmdFlags.String("name", actfile.NpmActName, "Name of the act holding imported scripts")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting
+	 * the flags (the file to import).
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) < 1 {
+		utils.FatalError(fmt.Sprintf("you need to specify the file to import (like `act import %s`)", actfile.NpmPackageFileName))
+		return
+	}
+
+	importPath := utils.ResolvePath(utils.GetWd(), cmdArgs[0])
+
+	if filepath.Base(importPath) != actfile.NpmPackageFileName {
+		utils.FatalError(fmt.Sprintf("can't import %s (only %s files are supported)", cmdArgs[0], actfile.NpmPackageFileName))
+		return
+	}
+
+	actNode, count, err := getNpmActNode(importPath)
+
+	if err != nil {
+		utils.FatalError("could not read npm scripts", err)
+		return
+	}
+
+	actFilePath := utils.ResolvePath(utils.GetWd(), *actFilePathPtr)
+	content, err := ioutil.ReadFile(actFilePath)
+
+	if err != nil && !os.IsNotExist(err) {
+		utils.FatalError("could not read actfile", err)
+		return
+	}
+
+	newContent, err := addActToActFile(content, *namePtr, actNode)
+
+	if err != nil {
+		utils.FatalError("could not parse actfile", err)
+		return
+	}
+
+	if err := utils.WriteFileAtomic(actFilePath, newContent, 0644); err != nil {
+		utils.FatalError("could not write actfile", err)
+		return
+	}
+
+	fmt.Printf("imported %d scripts from %s into %s act\n", count, cmdArgs[0], *namePtr)
+}
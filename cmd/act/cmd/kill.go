@@ -0,0 +1,74 @@
+/**
+ * This file implements the kill subcommand which is responsible for
+ * killing acts right away (sending KILL to all their commands) when
+ * they don't stop gracefully with `act stop`.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `kill` command.
+ */
+func KillCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("kill", flag.ExitOnError)
+
+	/**
+	 * This flag allows user to kill all running acts.
+	 */
+	allPtr := cmdFlags.Bool("all", false, "Kill all running acts")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting
+	 * the flags (names or glob patterns of acts to kill).
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) < 1 && !*allPtr {
+		utils.FatalError("you need to specify the name of the act to kill")
+		return
+	}
+
+	// Flag info left behind by crashed act processes.
+	run.MarkStaleInfos()
+
+	infos, notFound := resolveStopInfos(cmdArgs, *allPtr)
+	failed := len(notFound) > 0
+
+	for _, pattern := range notFound {
+		utils.LogError(fmt.Sprintf("act %s not found", pattern))
+	}
+
+	for _, info := range infos {
+		if err := info.Kill(); err != nil {
+			utils.LogError(fmt.Sprintf("could not kill act %s", info.GetNameIdOrId()), err)
+			failed = true
+		}
+	}
+
+	// We fail if any of the acts could not be killed.
+	if failed {
+		utils.ExitCode = 1
+	}
+}
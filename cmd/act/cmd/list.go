@@ -6,35 +6,272 @@
 package cmd
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
-	"github.com/logrusorgru/aurora/v3"
 	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
 	"github.com/olekukonko/tablewriter"
 )
 
 //############################################################
-// Exposed Functions
+// Types
 //############################################################
 
 /**
- * This is the main execution point for the `list` command.
+ * This struct going to hold the status of a run we output as json
+ * (in list and status commands and in the serve api). Fields are
+ * part of a stable schema so only add new ones.
  */
-func ListCmdExec() {
-	infos := run.GetAllInfo()
+type runStatus struct {
+	Id            string     `json:"id"`
+	Name          string     `json:"name"`
+	Desc          string     `json:"desc,omitempty"`
+	Act           string     `json:"act"`
+	ActFile       string     `json:"actfile"`
+	State         string     `json:"state"`
+	Health        string     `json:"health,omitempty"`
+	Pid           int        `json:"pid"`
+	Pgid          int        `json:"pgid"`
+	IsDaemon      bool       `json:"daemon"`
+	UptimeSeconds float64    `json:"uptime_seconds"`
+	Restarts      int        `json:"restarts"`
+	LastExitCode  int        `json:"last_exit_code"`
+	ExitCode      *int       `json:"exit_code,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	LogFile       string     `json:"log_file,omitempty"`
+	CpuPercent    *float64   `json:"cpu_percent,omitempty"`
+	RssBytes      *uint64    `json:"rss_bytes,omitempty"`
+	CmdPgids      []int      `json:"cmd_pgids,omitempty"`
+	ChildActIds   []string   `json:"child_act_ids,omitempty"`
+}
 
-	if len(infos) == 0 {
-		fmt.Println(aurora.Yellow("no act running").Bold())
-		return
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Interval we sample processes to compute cpu usage.
+ */
+const usageSampleInterval = 250 * time.Millisecond
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to convert run info to the status we output
+ * as json.
+ */
+func toRunStatus(info *run.Info) *runStatus {
+	status := &runStatus{
+		Id:            info.Id,
+		Name:          info.NameId,
+		Desc:          info.Desc,
+		Act:           info.ActCallId,
+		ActFile:       info.ActFilePath,
+		State:         info.GetState(),
+		Health:        info.GetHealth(),
+		Pid:           info.Pid,
+		Pgid:          info.Pgid,
+		IsDaemon:      info.IsDaemon,
+		UptimeSeconds: info.GetUptime().Seconds(),
+		Restarts:      info.RestartCount,
+		LastExitCode:  info.LastExitCode,
+		StartedAt:     info.StartedAt,
+		CmdPgids:      info.CmdPgids,
+		ChildActIds:   info.ChildActIds,
 	}
 
+	if info.Exited {
+		exitCode := info.ExitCode
+		endedAt := info.EndedAt
+
+		status.ExitCode = &exitCode
+		status.EndedAt = &endedAt
+	}
+
+	if info.IsDaemon {
+		status.LogFile = info.GetLogFilePath()
+	}
+
+	return status
+}
+
+/**
+ * This function going to print a value as indented json.
+ */
+func printJson(value interface{}) {
+	content, _ := json.MarshalIndent(value, "", "  ")
+	fmt.Println(string(content))
+}
+
+/**
+ * This function going to render a table with running acts together
+ * with their resource usage (resource columns are left out when we
+ * got no usages).
+ */
+func renderInfoTable(infos []*run.Info, usages map[string]*run.Usage) {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Id", "Name"})
+
+	if usages != nil {
+		table.SetHeader([]string{"Id", "Name", "State", "Health", "Cpu", "Mem", "Uptime", "Desc", "Actfile"})
+	} else {
+		table.SetHeader([]string{"Id", "Name", "State", "Health", "Uptime", "Desc", "Actfile"})
+	}
+
+	wd := utils.GetWd()
 
 	for _, info := range infos {
-		table.Append([]string{info.Id, info.NameId})
+		actFilePath := info.ActFilePath
+
+		// Show actfile path relative to working dir for brevity.
+		if relPath, err := filepath.Rel(wd, actFilePath); err == nil && actFilePath != "" {
+			actFilePath = relPath
+		}
+
+		health := info.GetHealth()
+
+		if health == "" {
+			health = "-"
+		}
+
+		cpu := "-"
+		mem := "-"
+
+		if usage, ok := usages[info.Id]; ok {
+			cpu = fmt.Sprintf("%.1f%%", usage.Cpu)
+			mem = run.FormatBytes(usage.Rss)
+		}
+
+		state := info.GetState()
+
+		if info.Exited {
+			state = fmt.Sprintf("%s (%d)", state, info.ExitCode)
+		}
+
+		if usages != nil {
+			table.Append([]string{info.Id, info.NameId, state, health, cpu, mem, info.GetUptime().String(), info.Desc, actFilePath})
+		} else {
+			table.Append([]string{info.Id, info.NameId, state, health, info.GetUptime().String(), info.Desc, actFilePath})
+		}
 	}
 
 	table.Render()
 }
+
+/**
+ * This function going to filter out exited acts unless user wants
+ * to see all of them.
+ */
+func filterInfos(infos []*run.Info, all bool) []*run.Info {
+	if all {
+		return infos
+	}
+
+	var filtered []*run.Info
+
+	for _, info := range infos {
+		if !info.Exited && !info.Dead {
+			filtered = append(filtered, info)
+		}
+	}
+
+	return filtered
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `list` command.
+ */
+func ListCmdExec(args []string) {
+	// List available acts instead of running ones.
+	if len(args) > 0 && args[0] == "acts" {
+		CatalogCmdExec(args[1:])
+		return
+	}
+
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("list", flag.ExitOnError)
+
+	/**
+	 * This flag indicates we want to list exited acts as well.
+	 */
+	allPtr := cmdFlags.Bool("all", false, "Show exited acts as well")
+
+	/**
+	 * This flag indicates we want to list acts of all projects and
+	 * not only the ones run from current working dir.
+	 */
+	allProjectsPtr := cmdFlags.Bool("all-projects", false, "Show acts of all projects")
+
+	/**
+	 * This flag indicates we want json output (for tooling).
+	 */
+	jsonPtr := cmdFlags.Bool("json", false, "Output acts as json")
+
+	/**
+	 * This flag indicates we want resource usage of acts (which
+	 * takes a moment to sample).
+	 */
+	usagePtr := cmdFlags.Bool("usage", false, "Show cpu and memory usage of acts")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	// Flag info left behind by crashed act processes.
+	run.MarkStaleInfos()
+
+	infos := run.GetAllInfo()
+
+	if *allProjectsPtr {
+		infos = run.GetAllProjectsInfo()
+	}
+
+	infos = filterInfos(infos, *allPtr)
+
+	var usages map[string]*run.Usage
+
+	if *usagePtr && len(infos) > 0 {
+		usages = run.GetUsages(infos, usageSampleInterval)
+	}
+
+	if *jsonPtr {
+		statuses := []*runStatus{}
+
+		for _, info := range infos {
+			status := toRunStatus(info)
+
+			if usage, ok := usages[info.Id]; ok {
+				status.CpuPercent = &usage.Cpu
+				status.RssBytes = &usage.Rss
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		printJson(statuses)
+		return
+	}
+
+	if len(infos) == 0 {
+		fmt.Println(utils.Color.Yellow("no act running").Bold())
+		return
+	}
+
+	renderInfoTable(infos, usages)
+}
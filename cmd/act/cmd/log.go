@@ -6,19 +6,366 @@
 package cmd
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/hpcloud/tail"
 	"github.com/nosebit/act/cmd/act/run"
 	"github.com/nosebit/act/cmd/act/utils"
 )
 
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a log line of one of the acts we are
+ * logging.
+ */
+type actLogLine struct {
+	name string
+	text string
+	time time.Time
+}
+
+/**
+ * This struct going to hold filters of log lines to show.
+ */
+type logFilter struct {
+	lines  int
+	since  time.Time
+	until  time.Time
+	grep   *regexp.Regexp
+	stream string
+}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Default number of lines (from the end of log files) we show.
+ */
+const defaultLogLines = 10
+
 //############################################################
 // Global Variables
 //############################################################
-var ta *tail.Tail
+
+/**
+ * Tails of all log files we are following.
+ */
+var tails []*tail.Tail
+
+/**
+ * Regex matching ANSI color codes.
+ */
+var ansiColorRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to find the run info and the log file we
+ * should show for a name. The name can be a run name (to show all
+ * output of the run) or a run name followed by a subact path (like
+ * `web.db`) to show just the output of that act.
+ */
+func getLogTarget(name string) (*run.Info, string) {
+	if info := run.GetInfo(name); info != nil {
+		return info, info.GetLogFilePath()
+	}
+
+	parts := strings.Split(name, run.ActCallIdSeparator)
+
+	for i := len(parts) - 1; i > 0; i-- {
+		info := run.GetInfo(strings.Join(parts[:i], run.ActCallIdSeparator))
+
+		if info != nil {
+			callId := strings.Join(append([]string{info.ActCallId}, parts[i:]...), run.ActCallIdSeparator)
+
+			return info, info.GetActLogFilePath(callId)
+		}
+	}
+
+	return nil, ""
+}
+
+/**
+ * This function going to extract the time of a log line. We support
+ * json log lines and prefixed log lines with default or iso8601
+ * timestamps.
+ */
+func getLogLineTime(text string) (time.Time, bool) {
+	text = ansiColorRegex.ReplaceAllString(text, "")
+
+	if strings.HasPrefix(text, "{") {
+		var logLine run.LogLine
+
+		if err := json.Unmarshal([]byte(text), &logLine); err == nil {
+			if t, err := time.Parse(time.RFC3339Nano, logLine.Time); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	idx := strings.Index(text, " | ")
+
+	if idx < 0 {
+		return time.Time{}, false
+	}
+
+	text = text[idx+3:]
+
+	for _, layout := range []string{run.DefaultLogTimestampLayout, run.Iso8601LogTimestampLayout} {
+		if len(text) < len(layout) {
+			continue
+		}
+
+		if t, err := time.ParseInLocation(layout, text[:len(layout)], time.Local); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+/**
+ * This function going to parse a time filter which can be a duration
+ * (like 10m meaning 10 minutes ago) or a timestamp.
+ */
+func parseLogFilterTime(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	layouts := []string{
+		time.RFC3339,
+		run.DefaultLogTimestampLayout,
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %s", value)
+}
+
+/**
+ * This function going to get the stream (stdout or stderr) a log line
+ * came from. In prefixed mode stderr lines have a red prefix.
+ */
+func getLogLineStream(text string) string {
+	if strings.HasPrefix(text, "{") {
+		var logLine run.LogLine
+
+		if err := json.Unmarshal([]byte(text), &logLine); err == nil {
+			return logLine.Stream
+		}
+	}
+
+	redPrefix := utils.Color.Red("").Bold().String()
+
+	end := strings.Index(redPrefix, "m")
+
+	// Without colors stderr lines can't be told apart.
+	if end < 0 {
+		return run.StreamStdout
+	}
+
+	if strings.HasPrefix(text, redPrefix[:end+1]) {
+		return run.StreamStderr
+	}
+
+	return run.StreamStdout
+}
+
+/**
+ * This function going to check if a log line passes filters. Lines
+ * with unknown time never pass time filters.
+ */
+func isLogLineShown(line *actLogLine, filter *logFilter) bool {
+	if !filter.since.IsZero() && (line.time.IsZero() || line.time.Before(filter.since)) {
+		return false
+	}
+
+	if !filter.until.IsZero() && (line.time.IsZero() || line.time.After(filter.until)) {
+		return false
+	}
+
+	if filter.stream != "" && getLogLineStream(line.text) != filter.stream {
+		return false
+	}
+
+	if filter.grep != nil && !filter.grep.MatchString(ansiColorRegex.ReplaceAllString(line.text, "")) {
+		return false
+	}
+
+	return true
+}
+
+/**
+ * This function going to check if we need to read whole log files
+ * to apply filters.
+ */
+func hasLogLineFilter(filter *logFilter) bool {
+	return !filter.since.IsZero() || !filter.until.IsZero() || filter.grep != nil || filter.stream != ""
+}
+
+/**
+ * This function going to read the lines of a log file we should
+ * show. Lines without a time (like raw output) get the time of the
+ * line before them so they stay together when merging and filtering.
+ */
+func readLogLines(name string, logFilePath string, filter *logFilter) []*actLogLine {
+	var texts []string
+	var err error
+
+	if hasLogLineFilter(filter) {
+		var content []byte
+
+		if content, err = ioutil.ReadFile(logFilePath); err == nil && len(content) > 0 {
+			texts = strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		}
+	} else {
+		texts, err = utils.ReadLastLines(logFilePath, filter.lines)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	var lines []*actLogLine
+	var lastTime time.Time
+
+	for _, text := range texts {
+		if t, ok := getLogLineTime(text); ok {
+			lastTime = t
+		}
+
+		line := &actLogLine{name: name, text: text, time: lastTime}
+
+		if isLogLineShown(line, filter) {
+			lines = append(lines, line)
+		}
+	}
+
+	if filter.lines > 0 && len(lines) > filter.lines {
+		lines = lines[len(lines)-filter.lines:]
+	}
+
+	return lines
+}
+
+/**
+ * This function going to print a log line. When logging multiple
+ * acts together we prefix lines with the act name (colored).
+ */
+func printActLogLine(line *actLogLine, withPrefix bool) {
+	if withPrefix {
+		fmt.Printf("%s | %s\n", utils.PaletteColor(line.name, line.name).Bold(), line.text)
+	} else {
+		fmt.Println(line.text)
+	}
+}
+
+/**
+ * This function going to show logs of acts. We first show the last
+ * lines of all acts merged chronologically and then (when following)
+ * show new lines as they arrive.
+ */
+func logActs(names []string, filter *logFilter, follow bool) {
+	var lines []*actLogLine
+	logFilePaths := make(map[string]string)
+	withPrefix := len(names) > 1
+
+	for _, name := range names {
+		info, logFilePath := getLogTarget(name)
+
+		if info == nil {
+			utils.FatalError(fmt.Sprintf("act %s not found", name))
+			return
+		}
+
+		if _, err := os.Stat(logFilePath); err != nil {
+			utils.FatalError(fmt.Sprintf("nothing to log for act %s", name))
+			return
+		}
+
+		logFilePaths[name] = logFilePath
+		lines = append(lines, readLogLines(name, logFilePath, filter)...)
+	}
+
+	if withPrefix {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].time.Before(lines[j].time)
+		})
+	}
+
+	/**
+	 * Lines filter applies to each act so we keep only the last lines
+	 * of the merged output as well.
+	 */
+	if filter.lines > 0 && len(lines) > filter.lines {
+		lines = lines[len(lines)-filter.lines:]
+	}
+
+	for _, line := range lines {
+		printActLogLine(line, withPrefix)
+	}
+
+	if !follow {
+		return
+	}
+
+	newLines := make(chan *actLogLine)
+
+	for _, name := range names {
+		t, err := tail.TailFile(logFilePaths[name], tail.Config{
+			Follow:   true,
+			Location: &tail.SeekInfo{Offset: 0, Whence: 2},
+			ReOpen:   true,
+			Logger:   tail.DiscardingLogger,
+		})
+
+		if err != nil {
+			utils.FatalError("could not open log file", err)
+			return
+		}
+
+		tails = append(tails, t)
+
+		go func(name string, t *tail.Tail) {
+			for line := range t.Lines {
+				newLines <- &actLogLine{name: name, text: line.Text, time: line.Time}
+			}
+		}(name, t)
+	}
+
+	for line := range newLines {
+		// Lines without time are as recent as the time we read them.
+		if t, ok := getLogLineTime(line.text); ok {
+			line.time = t
+		}
+
+		if isLogLineShown(line, filter) {
+			printActLogLine(line, withPrefix)
+		}
+	}
+}
 
 //############################################################
 // Exposed Functions
@@ -40,6 +387,33 @@ func LogCmdExec(args []string) {
 	 */
 	followPtr := cmdFlags.Bool("f", false, "Follow file while it gets updated")
 
+	/**
+	 * This flag set the number of lines (from the end) to show.
+	 */
+	linesPtr := cmdFlags.Int("n", defaultLogLines, "Number of lines to show")
+
+	/**
+	 * These flags filter lines by time. They can be a duration (like
+	 * 10m) or a timestamp.
+	 */
+	sincePtr := cmdFlags.String("since", "", "Show lines since a time (like 10m or 2021-01-02 15:04:05)")
+	untilPtr := cmdFlags.String("until", "", "Show lines until a time (like 5m or 2021-01-02 15:04:05)")
+
+	/**
+	 * This flag filter lines matching a regular expression.
+	 */
+	grepPtr := cmdFlags.String("grep", "", "Show lines matching a regular expression")
+
+	/**
+	 * This flag filter lines from a stream (stdout or stderr).
+	 */
+	streamPtr := cmdFlags.String("stream", "", "Show lines from a stream (stdout or stderr)")
+
+	/**
+	 * This flag filter lines of an act (like web.db) of the run.
+	 */
+	actPtr := cmdFlags.String("act", "", "Show lines of an act (by call id like web.db) of the run")
+
 	/**
 	 * Parse the incoming args extracting defined flags if user
 	 * provided any.
@@ -53,87 +427,105 @@ func LogCmdExec(args []string) {
 	cmdArgs := cmdFlags.Args()
 
 	/**
-	 * For the log command we need user to provide one act name
-	 * for the act we want to retrieve logs.
+	 * For the log command we need user to provide at least one act
+	 * name for the act we want to retrieve logs.
 	 */
 	if len(cmdArgs) < 1 {
 		utils.FatalError("you need to specify the name of the act to log")
+		return
 	}
 
-	/**
-	 * The first argument is the act name id.
-	 *
-	 * @TODO : Allow user to specify a list of act name ids so we
-	 * can log everything together chronologically and tail
-	 * all of them together. This can be usefule to see on act
-	 * calling another act for example and tracing logs.
-	 */
-	actNameId := cmdArgs[0]
+	filter := &logFilter{lines: *linesPtr}
 
-	/**
-	 * Get act run info
-	 */
-	info := run.GetInfo(actNameId)
+	if *sincePtr != "" {
+		since, err := parseLogFilterTime(*sincePtr)
+
+		if err != nil {
+			utils.FatalError("invalid since", err)
+			return
+		}
 
-	if info == nil {
-		utils.FatalError("act not found")
+		filter.since = since
 	}
 
-	logFilePath := info.GetLogFilePath()
+	if *untilPtr != "" {
+		until, err := parseLogFilterTime(*untilPtr)
+
+		if err != nil {
+			utils.FatalError("invalid until", err)
+			return
+		}
+
+		filter.until = until
+	}
+
+	if *grepPtr != "" {
+		grep, err := regexp.Compile(*grepPtr)
+
+		if err != nil {
+			utils.FatalError("invalid grep", err)
+			return
+		}
+
+		filter.grep = grep
+	}
 
-	if _, err := os.Stat(logFilePath); err != nil {
-		utils.FatalError("nothing to log")
+	if *streamPtr != "" && *streamPtr != run.StreamStdout && *streamPtr != run.StreamStderr {
+		utils.FatalError(fmt.Sprintf("invalid stream %s (use stdout or stderr)", *streamPtr))
+		return
 	}
 
+	filter.stream = *streamPtr
+
 	/**
-	 * @TODO : For some reason logs are not being shown until we get
-	 * enought logs to fulfill the offset. When we have few logs the
-	 * tail package shows nothing.
+	 * Each act of a run has its own log file (see getLogTarget) so to
+	 * filter lines of an act we log that file instead.
 	 */
+	if *actPtr != "" {
+		if len(cmdArgs) > 1 {
+			utils.FatalError("act filter can only be used when logging a single run")
+			return
+		}
 
-	t, err := tail.TailFile(logFilePath, tail.Config{
-		Follow: *followPtr,
-		Location: &tail.SeekInfo{
-			Offset: -500,
-			Whence: 2, // 0 - Begining of file; 1 - Current Position; 2 - End of file
-		},
-		ReOpen: *followPtr,
-		Logger: tail.DiscardingLogger,
-	})
+		subActPath := *actPtr
 
-	// Store tail globally so we can cleanup
-	ta = t
+		// Act call ids start with the call id of the run root act.
+		if info := run.GetInfo(cmdArgs[0]); info != nil {
+			subActPath = strings.TrimPrefix(subActPath, fmt.Sprintf("%s%s", info.ActCallId, run.ActCallIdSeparator))
+		}
 
-	if err != nil {
-		utils.FatalError("could not open log file", err)
+		cmdArgs = []string{fmt.Sprintf("%s%s%s", cmdArgs[0], run.ActCallIdSeparator, subActPath)}
 	}
 
 	/**
-	 * We prevent logging the first line because it could be
-	 * broken since SeekInfo used before specifies number of
-	 * bytes as offset.
-	 *
-	 * @TODO - It would be amazing if there was a way to let
-	 * user specify the number of lines (from the end of file)
-	 * to log before starting following the log file.
+	 * When filtering lines we show all matching lines unless user
+	 * explicitly set the number of lines.
 	 */
-	isFirstLine := true
+	if hasLogLineFilter(filter) {
+		isLinesSet := false
 
-	for line := range t.Lines {
-		if !isFirstLine {
-			fmt.Println(line.Text)
-		}
+		cmdFlags.Visit(func(f *flag.Flag) {
+			isLinesSet = isLinesSet || f.Name == "n"
+		})
 
-		isFirstLine = false
+		if !isLinesSet {
+			filter.lines = 0
+		}
 	}
+
+	/**
+	 * When user specify a list of act name ids we log everything
+	 * together chronologically.
+	 */
+	logActs(cmdArgs, filter, *followPtr)
 }
 
 /**
  * This function going to cleanup everything for this command on exit.
  */
 func LogFinish() {
-	if ta != nil {
-		ta.Cleanup()
-		ta.Stop()
+	for _, t := range tails {
+		t.Cleanup()
+		t.Stop()
 	}
 }
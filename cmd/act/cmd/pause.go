@@ -0,0 +1,100 @@
+/**
+ * This file implements the pause and resume subcommands which are
+ * responsible for temporarily stopping acts running in the background
+ * as daemon (without losing their state) and resuming them later.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to parse command line args and get the info
+ * of the target act.
+ */
+func getTargetInfo(cmdName string, args []string) *run.Info {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet(cmdName, flag.ExitOnError)
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting
+	 * the flags.
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) < 1 {
+		utils.FatalError(fmt.Sprintf("you need to specify the name of the act to %s", cmdName))
+		return nil
+	}
+
+	info := run.GetInfo(cmdArgs[0])
+
+	if info == nil {
+		utils.FatalError("act not found")
+	}
+
+	return info
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `pause` command.
+ */
+func PauseCmdExec(args []string) {
+	info := getTargetInfo("pause", args)
+
+	if info == nil {
+		return
+	}
+
+	if info.IsPaused() {
+		utils.FatalError("act is already paused")
+		return
+	}
+
+	info.Pause()
+
+	fmt.Println(fmt.Sprintf("act %s paused", utils.Color.Green(info.GetNameIdOrId()).Bold()))
+}
+
+/**
+ * This is the main execution point for the `resume` command.
+ */
+func ResumeCmdExec(args []string) {
+	info := getTargetInfo("resume", args)
+
+	if info == nil {
+		return
+	}
+
+	if !info.IsPaused() {
+		utils.FatalError("act is not paused")
+		return
+	}
+
+	info.Resume()
+
+	fmt.Println(fmt.Sprintf("act %s resumed", utils.Color.Green(info.GetNameIdOrId()).Bold()))
+}
@@ -0,0 +1,35 @@
+/**
+ * This file implements the prune subcommand which is responsible
+ * for removing records (info and logs) of exited and dead acts.
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `prune` command.
+ */
+func PruneCmdExec() {
+	count := 0
+
+	run.MarkStaleInfos()
+
+	for _, info := range run.GetAllInfo() {
+		if info.Exited || info.Dead {
+			info.RmDataDir()
+			count++
+		}
+	}
+
+	fmt.Println(utils.Color.Green(fmt.Sprintf("%d exited or dead acts pruned", count)).Bold())
+}
@@ -0,0 +1,116 @@
+/**
+ * This file implements the restart subcommand which is responsible
+ * for stopping an act running in the background as daemon and then
+ * starting it again with the same actfile, flags and args.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max time we wait the stopped act process to exit before starting
+ * it again.
+ */
+const restartExitTimeout = 60 * time.Second
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `restart` command.
+ */
+func RestartCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("restart", flag.ExitOnError)
+
+	/**
+	 * This flag allows user to override how long we wait act
+	 * commands to exit before killing them.
+	 */
+	timeoutPtr := cmdFlags.Duration("timeout", -1, "Time to wait commands to exit before killing them")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting
+	 * the flags.
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) < 1 {
+		utils.FatalError("you need to specify the name of the act to restart")
+		return
+	}
+
+	info := run.GetInfo(cmdArgs[0])
+
+	if info == nil {
+		utils.FatalError("act not found")
+		return
+	}
+
+	if !info.IsDaemon || len(info.RunArgs) == 0 {
+		utils.FatalError("only acts running as daemon can be restarted")
+		return
+	}
+
+	// User provided timeout overrides act stop timeline.
+	if *timeoutPtr >= 0 {
+		info.StopGracePeriod = timeoutPtr
+		info.StopTimeline = nil
+	}
+
+	// Stop it gracefully (we check below it actually exited).
+	if err := info.Stop(); err != nil {
+		utils.LogWarn(fmt.Sprintf("could not stop act %s", info.GetNameIdOrId()), err)
+	}
+
+	/**
+	 * Wait the act process to finish (running its final stage) so
+	 * the new process don't compete with the old one for resources.
+	 */
+	deadline := time.Now().Add(restartExitTimeout)
+
+	for info.IsRunning() && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Never run two daemons of the same act at once.
+	if info.IsRunning() {
+		utils.FatalError(fmt.Sprintf("act %s did not exit within %s so it was not started again", info.GetNameIdOrId(), restartExitTimeout))
+		return
+	}
+
+	// Keep track of how many times the act was restarted.
+	os.Setenv("ACT_RESTART_COUNT", strconv.Itoa(info.RestartCount+1))
+
+	// Start it again from the same working dir.
+	if err := os.Chdir(info.Wd); err != nil {
+		utils.FatalError("could not change to act working dir", err)
+		return
+	}
+
+	run.Exec(append([]string{"-d"}, info.RunArgs...))
+}
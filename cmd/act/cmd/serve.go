@@ -0,0 +1,691 @@
+/**
+ * This file implements the serve subcommand which exposes an http
+ * api so tools (like dashboards, internal web UIs or chatops) can
+ * consume act data and control runs without shelling out to act
+ * commands. For now we expose the following endpoints:
+ *
+ * GET /acts - Acts of an actfile (use actfile=<path> to set which
+ * one of the served project).
+ *
+ * GET /runs - Running acts (use all=1 to include exited ones).
+ *
+ * POST /runs - Run an act as daemon. The json body holds the act
+ * call id plus optional actfile, name, flags and args.
+ *
+ * GET /runs/<id> - Status of a run.
+ *
+ * POST /runs/<id>/stop - Stop a run.
+ *
+ * POST /runs/<id>/restart - Restart a run.
+ *
+ * GET /runs/<id>/logs - Log lines of a run as NDJSON (or SSE when
+ * format=sse or requested with Accept: text/event-stream). Use
+ * follow=1 to keep streaming new lines and n=<lines> to set how
+ * many lines (from the end) to send first.
+ *
+ * GET /metrics - Prometheus metrics of all runs.
+ *
+ * When a token is set (with the token flag or ACT_SERVE_TOKEN env
+ * var) every request must send it as a bearer token. Without a token
+ * we only listen on loopback addresses, only accept requests for
+ * loopback hosts (so pages of other sites can't reach us through dns
+ * rebinding) and refuse requests reading actfiles or changing runs
+ * (POST ones). Requests changing runs must have a json content type
+ * so browsers can't send them from other pages.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hpcloud/tail"
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/teris-io/shortid"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the body of requests to run an act.
+ */
+type serveRunReq struct {
+	Act     string            `json:"act"`
+	ActFile string            `json:"actfile"`
+	Name    string            `json:"name"`
+	Flags   map[string]string `json:"flags"`
+	Args    []string          `json:"args"`
+}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Default address the http server listens on.
+ */
+const defaultServeAddr = "127.0.0.1:7070"
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * The http server we are running.
+ */
+var server *http.Server
+
+/**
+ * Token clients must send (as a bearer token) when set.
+ */
+var serveToken string
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to write a json response.
+ */
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(body)
+}
+
+/**
+ * This function going to write a json error response.
+ */
+func writeJsonError(w http.ResponseWriter, status int, message string) {
+	writeJson(w, status, map[string]string{"error": message})
+}
+
+/**
+ * This function going to run an act command (like `act run -d`)
+ * returning its output when it fails.
+ */
+func execActCmd(env []string, args ...string) error {
+	var output bytes.Buffer
+
+	cmd := exec.Command(utils.GetActBin(), args...)
+	cmd.Dir = utils.GetWd()
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(ansiColorRegex.ReplaceAllString(output.String(), "")))
+	}
+
+	return nil
+}
+
+/**
+ * This function going to check if a request has a json body.
+ */
+func isJsonRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && mediaType == "application/json"
+}
+
+/**
+ * This function going to check if an address only listens on the
+ * loopback interface.
+ */
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+
+	if err != nil {
+		host = strings.Trim(addr, "[]")
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+/**
+ * This function going to read the actfile a request refers to (the
+ * default one when empty) writing an error response when it fails.
+ * Only actfiles of the served project (the dir we are serving from)
+ * are accepted and parse errors never stop the server.
+ */
+func readServeActFile(w http.ResponseWriter, actFilePath string) *actfile.ActFile {
+	if actFilePath == "" {
+		actFilePath = config.GetActFileName()
+	}
+
+	projectDir := utils.GetWd()
+	actFilePath = utils.ResolvePath(projectDir, actFilePath)
+
+	if !utils.IsPathInDir(actFilePath, projectDir) {
+		writeJsonError(w, http.StatusForbidden, fmt.Sprintf("actfile %s is not in the served project", actFilePath))
+		return nil
+	}
+
+	if !utils.DoFileExists(actFilePath) {
+		writeJsonError(w, http.StatusNotFound, fmt.Sprintf("actfile %s not found", actFilePath))
+		return nil
+	}
+
+	actFile, err := actfile.LoadActFile(actFilePath)
+
+	if err != nil {
+		writeJsonError(w, http.StatusUnprocessableEntity, err.Error())
+		return nil
+	}
+
+	return actFile
+}
+
+/**
+ * This function going to check if a request sends the serve token as
+ * a bearer token.
+ */
+func hasServeToken(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+
+	token := strings.TrimPrefix(auth, "Bearer ")
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(serveToken)) == 1
+}
+
+/**
+ * This function going to guard handlers with the serve token (if
+ * any). With a token every request must send it. Without a token we
+ * only accept requests for loopback hosts and requests reading
+ * actfiles or changing runs are refused. Requests changing runs need
+ * a json content type as well (which browsers can't send cross
+ * origin without asking first).
+ */
+func withServeAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isChange := r.Method != http.MethodGet && r.Method != http.MethodHead
+
+		if serveToken == "" {
+			if !isLoopbackAddr(r.Host) {
+				writeJsonError(w, http.StatusForbidden, fmt.Sprintf("host %s is not allowed", r.Host))
+				return
+			}
+
+			if isChange || r.URL.Path == "/acts" {
+				writeJsonError(w, http.StatusForbidden, "a token is required to read acts and change runs (use token flag or ACT_SERVE_TOKEN env var)")
+				return
+			}
+		}
+
+		if isChange {
+			if !isJsonRequest(r) {
+				writeJsonError(w, http.StatusUnsupportedMediaType, "content type must be application/json")
+				return
+			}
+		}
+
+		if serveToken != "" && !hasServeToken(r) {
+			writeJsonError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+/**
+ * This function going to convert a line of a log file to the log
+ * line we send to clients.
+ */
+func toLogLine(info *run.Info, text string) *run.LogLine {
+	if strings.HasPrefix(text, "{") {
+		var logLine run.LogLine
+
+		if err := json.Unmarshal([]byte(text), &logLine); err == nil {
+			return &logLine
+		}
+	}
+
+	logLine := &run.LogLine{
+		Act:    info.GetNameIdOrId(),
+		Stream: getLogLineStream(text),
+		Line:   ansiColorRegex.ReplaceAllString(text, ""),
+	}
+
+	if t, ok := getLogLineTime(text); ok {
+		logLine.Time = t.Format(time.RFC3339Nano)
+	}
+
+	return logLine
+}
+
+/**
+ * This function going to handle requests to the logs endpoint of a
+ * run.
+ */
+func handleRunLogs(w http.ResponseWriter, r *http.Request, info *run.Info) {
+	flusher, ok := w.(http.Flusher)
+
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	logFilePath := info.GetLogFilePath()
+
+	if !utils.DoFileExists(logFilePath) {
+		http.Error(w, "nothing to log", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	follow := query.Get("follow") == "1" || query.Get("follow") == "true"
+	isSse := query.Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+
+	lines := defaultLogLines
+
+	if query.Get("n") != "" {
+		n, err := strconv.Atoi(query.Get("n"))
+
+		if err != nil || n < 0 {
+			http.Error(w, "invalid n", http.StatusBadRequest)
+			return
+		}
+
+		lines = n
+	}
+
+	if isSse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+
+	send := func(text string) {
+		content, _ := json.Marshal(toLogLine(info, text))
+
+		if isSse {
+			fmt.Fprintf(w, "data: %s\n\n", content)
+		} else {
+			fmt.Fprintf(w, "%s\n", content)
+		}
+
+		flusher.Flush()
+	}
+
+	texts, _ := utils.ReadLastLines(logFilePath, lines)
+
+	for _, text := range texts {
+		send(text)
+	}
+
+	if !follow {
+		return
+	}
+
+	t, err := tail.TailFile(logFilePath, tail.Config{
+		Follow:   true,
+		Location: &tail.SeekInfo{Offset: 0, Whence: 2},
+		ReOpen:   true,
+		Logger:   tail.DiscardingLogger,
+	})
+
+	if err != nil {
+		utils.LogError("could not open log file", err)
+		return
+	}
+
+	defer func() {
+		t.Stop()
+		t.Cleanup()
+	}()
+
+	for {
+		select {
+		case line, ok := <-t.Lines:
+			if !ok {
+				return
+			}
+
+			send(line.Text)
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+/**
+ * This function going to handle requests to the acts endpoint.
+ */
+func handleActs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	actFile := readServeActFile(w, r.URL.Query().Get("actfile"))
+
+	if actFile == nil {
+		return
+	}
+
+	entries := walkCatalog(actFile.Acts, actFile, "", 0, make(map[string]bool))
+
+	if entries == nil {
+		entries = []*catalogEntry{}
+	}
+
+	writeJson(w, http.StatusOK, entries)
+}
+
+/**
+ * This function going to run an act as daemon. We choose the run id
+ * beforehand so we can tell it to the client.
+ */
+func handleRunStart(w http.ResponseWriter, r *http.Request) {
+	var req serveRunReq
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJsonError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+		return
+	}
+
+	if req.Act == "" || strings.HasPrefix(req.Act, "-") {
+		writeJsonError(w, http.StatusBadRequest, "act is required")
+		return
+	}
+
+	actFile := readServeActFile(w, req.ActFile)
+
+	if actFile == nil {
+		return
+	}
+
+	actFilePath := actFile.LocationPath
+	act, _ := findActByCallId(req.Act, actFile)
+
+	if act == nil {
+		writeJsonError(w, http.StatusNotFound, fmt.Sprintf("act %s not found", req.Act))
+		return
+	}
+
+	// Only flags declared by the act are accepted.
+	declaredFlags := make(map[string]bool)
+
+	for _, flagName := range act.Flags {
+		declaredFlags[strings.Split(flagName, ":")[0]] = true
+	}
+
+	for name := range req.Flags {
+		if !declaredFlags[name] {
+			writeJsonError(w, http.StatusBadRequest, fmt.Sprintf("act %s has no flag %s", req.Act, name))
+			return
+		}
+	}
+
+	runArgs := []string{"run", "-d", "-no-color", fmt.Sprintf("-f=%s", actFilePath)}
+
+	if req.Name != "" {
+		runArgs = append(runArgs, fmt.Sprintf("-name=%s", req.Name))
+	}
+
+	runArgs = append(runArgs, req.Act)
+
+	// Keep flags order stable.
+	var flagNames []string
+
+	for name := range req.Flags {
+		flagNames = append(flagNames, name)
+	}
+
+	sort.Strings(flagNames)
+
+	for _, name := range flagNames {
+		runArgs = append(runArgs, fmt.Sprintf("-%s=%s", name, req.Flags[name]))
+	}
+
+	/**
+	 * Args must not be taken as flags by acts parsing flags (they
+	 * stop parsing flags at `--`).
+	 */
+	if len(act.Flags) > 0 {
+		runArgs = append(runArgs, "--")
+	}
+
+	runArgs = append(runArgs, req.Args...)
+
+	runId, _ := shortid.Generate()
+
+	if err := execActCmd([]string{fmt.Sprintf("ACT_RUN_ID=%s", runId)}, runArgs...); err != nil {
+		writeJsonError(w, http.StatusUnprocessableEntity, fmt.Sprintf("could not run act %s: %v", req.Act, err))
+		return
+	}
+
+	writeJson(w, http.StatusCreated, map[string]string{"id": runId})
+}
+
+/**
+ * This function going to route requests to runs endpoints.
+ */
+func handleRuns(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/runs"), "/")
+
+	if path == "" {
+		switch r.Method {
+		case http.MethodGet:
+			all := r.URL.Query().Get("all") == "1" || r.URL.Query().Get("all") == "true"
+			statuses := []*runStatus{}
+
+			for _, info := range filterInfos(run.GetAllInfo(), all) {
+				statuses = append(statuses, toRunStatus(info))
+			}
+
+			writeJson(w, http.StatusOK, statuses)
+		case http.MethodPost:
+			handleRunStart(w, r)
+		default:
+			writeJsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+		return
+	}
+
+	parts := strings.Split(path, "/")
+
+	if len(parts) > 2 {
+		writeJsonError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	info := run.GetInfo(parts[0])
+
+	if info == nil {
+		writeJsonError(w, http.StatusNotFound, "act not found")
+		return
+	}
+
+	action := ""
+
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	expectedMethod := http.MethodPost
+
+	if action == "" || action == "logs" || action == "state" || action == "dump" {
+		expectedMethod = http.MethodGet
+	}
+
+	if r.Method != expectedMethod {
+		writeJsonError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch action {
+	case "":
+		writeJson(w, http.StatusOK, toRunStatus(info))
+	case "logs":
+		handleRunLogs(w, r, info)
+	case "state", "dump":
+		handleRunControl(w, info, action)
+	case "stop":
+		if !info.IsRunning() {
+			writeJsonError(w, http.StatusConflict, "act is not running")
+			return
+		}
+
+		if err := info.Stop(); err != nil {
+			writeJsonError(w, http.StatusInternalServerError, fmt.Sprintf("could not stop act: %v", err))
+			return
+		}
+
+		writeJson(w, http.StatusAccepted, toRunStatus(info))
+	case "restart":
+		if !info.IsDaemon || len(info.RunArgs) == 0 {
+			writeJsonError(w, http.StatusConflict, "only acts running as daemon can be restarted")
+			return
+		}
+
+		if err := execActCmd(nil, "restart", info.Id); err != nil {
+			writeJsonError(w, http.StatusInternalServerError, fmt.Sprintf("could not restart act: %v", err))
+			return
+		}
+
+		writeJson(w, http.StatusOK, map[string]string{"id": info.Id})
+	default:
+		writeJsonError(w, http.StatusNotFound, "not found")
+	}
+}
+
+/**
+ * This function going to answer with the live state (or the dump) we
+ * get from the control socket of a running act.
+ */
+func handleRunControl(w http.ResponseWriter, info *run.Info, command string) {
+	if !info.IsRunning() {
+		writeJsonError(w, http.StatusConflict, "act is not running")
+		return
+	}
+
+	response, err := info.ControlRequest(command)
+
+	if err != nil {
+		writeJsonError(w, http.StatusBadGateway, fmt.Sprintf("could not reach act: %v", err))
+		return
+	}
+
+	if command == "state" {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(response))
+}
+
+/**
+ * This function going to handle requests to the metrics endpoint.
+ */
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", run.MetricsContentType)
+	run.WriteMetrics(w, run.GetAllInfo())
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `serve` command.
+ */
+func ServeCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	/**
+	 * This flag allows user to set the address we listen on.
+	 */
+	addrPtr := cmdFlags.String("addr", defaultServeAddr, "Address to listen on")
+
+	/**
+	 * This flag allows user to set the token clients must send (the
+	 * token can be set with ACT_SERVE_TOKEN env var as well so it
+	 * don't show up in the process list).
+	 */
+	tokenPtr := cmdFlags.String("token", os.Getenv("ACT_SERVE_TOKEN"), "Bearer token clients must send")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	serveToken = *tokenPtr
+
+	if serveToken == "" {
+		if !isLoopbackAddr(*addrPtr) {
+			utils.FatalError(fmt.Sprintf("a token is required to serve act api on %s (use token flag or ACT_SERVE_TOKEN env var)", *addrPtr))
+			return
+		}
+
+		utils.LogInfo("no token set so acts can't be read and runs can't be started, stopped or restarted")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acts", handleActs)
+	mux.HandleFunc("/runs", handleRuns)
+	mux.HandleFunc("/runs/", handleRuns)
+	mux.HandleFunc("/metrics", handleMetrics)
+
+	server = &http.Server{Addr: *addrPtr, Handler: withServeAuth(mux)}
+
+	utils.LogInfo(fmt.Sprintf("serving act api on %s", *addrPtr))
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		utils.FatalError("could not start server", err)
+	}
+}
+
+/**
+ * This function going to stop the http server.
+ */
+func ServeStop() {
+	if server != nil {
+		server.Close()
+	}
+}
@@ -0,0 +1,184 @@
+/**
+ * This file implements the sh subcommand which runs commands with
+ * the posix shell interpreter embedded in act. This is what act
+ * spawns for commands using `shell: builtin` so acts can run in
+ * machines without bash (like minimal containers and windows).
+ */
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This type going to hold shell options passed with repeated `-o`
+ * flags (like `-o errexit -o pipefail`).
+ */
+type shOptsFlag []string
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * This function cancels the running shell program.
+ */
+var shCancel context.CancelFunc
+
+//############################################################
+// shOptsFlag Struct Functions
+//############################################################
+
+/**
+ * This function going to get the flag value as a string.
+ */
+func (opts *shOptsFlag) String() string {
+	return strings.Join(*opts, ",")
+}
+
+/**
+ * This function going to add a shell option.
+ */
+func (opts *shOptsFlag) Set(value string) error {
+	*opts = append(*opts, value)
+	return nil
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `sh` command. Like posix
+ * shells it runs a command line (`act sh -c "echo hello"`), a script
+ * file (`act sh script.sh arg1 arg2`) or a program read from stdin.
+ */
+func ShCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("sh", flag.ExitOnError)
+
+	/**
+	 * This flag allows user to run a command line instead of a
+	 * script file.
+	 */
+	cmdLinePtr := cmdFlags.String("c", "", "Command line to run")
+
+	/**
+	 * This flag allows user to set shell options (like errexit).
+	 */
+	var opts shOptsFlag
+	cmdFlags.Var(&opts, "o", "Shell option to set (can be repeated)")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting the flags
+	 * (script path followed by script args).
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	var reader io.Reader = os.Stdin
+	var name string
+
+	if *cmdLinePtr != "" {
+		reader = strings.NewReader(*cmdLinePtr)
+	} else if len(cmdArgs) > 0 {
+		file, err := os.Open(cmdArgs[0])
+
+		if err != nil {
+			utils.FatalError("could not open script", err)
+			return
+		}
+
+		defer file.Close()
+
+		reader = file
+		name = cmdArgs[0]
+		cmdArgs = cmdArgs[1:]
+	}
+
+	program, err := syntax.NewParser().Parse(reader, name)
+
+	if err != nil {
+		utils.LogError(err.Error())
+		utils.ExitCode = 2
+		return
+	}
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		utils.FatalError("could not get working directory", err)
+		return
+	}
+
+	/**
+	 * Shell options are set like the `set` builtin would do.
+	 */
+	var params []string
+
+	for _, opt := range opts {
+		params = append(params, "-o", opt)
+	}
+
+	/**
+	 * The interpreter going to run with our env vars, working dir and
+	 * stdio which are the ones act set up for the command.
+	 */
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron(os.Environ()...)),
+		interp.Dir(wd),
+		interp.StdIO(os.Stdin, os.Stdout, os.Stderr),
+		interp.Params(append(append(params, "--"), cmdArgs...)...),
+	)
+
+	if err != nil {
+		utils.FatalError("could not create shell interpreter", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	shCancel = cancel
+
+	defer cancel()
+
+	err = runner.Run(ctx, program)
+
+	if status, ok := interp.IsExitStatus(err); ok {
+		utils.ExitCode = int(status)
+	} else if err != nil {
+		utils.LogError(err.Error())
+		utils.ExitCode = 1
+	}
+}
+
+/**
+ * This function going to stop the running shell program (killing
+ * the processes it spawned).
+ */
+func ShStop() {
+	if shCancel != nil {
+		shCancel()
+	}
+}
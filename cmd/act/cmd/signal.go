@@ -0,0 +1,71 @@
+/**
+ * This file implements the signal subcommand which is responsible
+ * for sending signals to acts running in the background as daemon.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `signal` command.
+ */
+func SignalCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("signal", flag.ExitOnError)
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting
+	 * the flags.
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	/**
+	 * For the signal command we need user to provide an act name
+	 * id and the signal to send.
+	 */
+	if len(cmdArgs) < 2 {
+		utils.FatalError("you need to specify the name of the act and the signal to send")
+		return
+	}
+
+	actNameId := cmdArgs[0]
+
+	sig, err := utils.ParseSignal(cmdArgs[1])
+
+	if err != nil {
+		utils.FatalError(err)
+		return
+	}
+
+	// Get act info
+	info := run.GetInfo(actNameId)
+
+	if info == nil {
+		utils.FatalError("act not found")
+		return
+	}
+
+	info.Signal(sig)
+
+	fmt.Println(fmt.Sprintf("signal %s sent to act %s", sig, utils.Color.Green(info.GetNameIdOrId()).Bold()))
+}
@@ -0,0 +1,67 @@
+/**
+ * This file implements the stats subcommand which shows the timing
+ * summary of a run (how long each command took and how it ended).
+ * This is useful for daemons which don't print the summary when
+ * they finish.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `stats` command.
+ */
+func StatsCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("stats", flag.ExitOnError)
+
+	/**
+	 * This flag indicates we want json output (for tooling).
+	 */
+	jsonPtr := cmdFlags.Bool("json", false, "Output timings as json")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	info := getTargetInfo("stats", cmdFlags.Args())
+
+	if info == nil {
+		return
+	}
+
+	if *jsonPtr {
+		timings := info.Timings
+
+		if timings == nil {
+			timings = []*run.CmdTiming{}
+		}
+
+		printJson(timings)
+		return
+	}
+
+	if len(info.Timings) == 0 {
+		fmt.Println(utils.Color.Yellow("no command run yet").Bold())
+		return
+	}
+
+	run.PrintTimingSummary(os.Stdout, info.Timings)
+}
@@ -0,0 +1,117 @@
+/**
+ * This file implements the status subcommand which is responsible
+ * for showing detailed state of a running act (process tree, uptime,
+ * restarts, etc).
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to print the process tree of an act which
+ * includes the act process, its commands and child detached acts.
+ */
+func printProcessTree(info *run.Info, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	fmt.Printf("%sact %s [pid=%d] [pgid=%d] %s\n", indent, utils.Color.Green(info.GetNameIdOrId()).Bold(), info.Pid, info.Pgid, info.GetState())
+
+	for _, pgid := range info.CmdPgids {
+		fmt.Printf("%s  cmd [pgid=%d]\n", indent, pgid)
+	}
+
+	for _, childId := range info.ChildActIds {
+		if childInfo := run.GetInfo(childId); childInfo != nil {
+			printProcessTree(childInfo, depth+1)
+		}
+	}
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `status` command.
+ */
+func StatusCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("status", flag.ExitOnError)
+
+	/**
+	 * This flag indicates we want json output (for tooling).
+	 */
+	jsonPtr := cmdFlags.Bool("json", false, "Output status as json")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	info := getTargetInfo("status", cmdFlags.Args())
+
+	if info == nil {
+		return
+	}
+
+	if *jsonPtr {
+		printJson(toRunStatus(info))
+		return
+	}
+
+	lastExitCode := "-"
+
+	if info.LastExitCode > 0 {
+		lastExitCode = fmt.Sprintf("%d", info.LastExitCode)
+	}
+
+	logFilePath := "-"
+
+	if info.IsDaemon {
+		logFilePath = info.GetLogFilePath()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Id:\t%s\n", info.Id)
+	fmt.Fprintf(w, "Name:\t%s\n", info.NameId)
+	fmt.Fprintf(w, "State:\t%s\n", info.GetState())
+	fmt.Fprintf(w, "Health:\t%s\n", info.GetHealth())
+	fmt.Fprintf(w, "Pid:\t%d\n", info.Pid)
+	fmt.Fprintf(w, "Pgid:\t%d\n", info.Pgid)
+	fmt.Fprintf(w, "Uptime:\t%s\n", info.GetUptime())
+	fmt.Fprintf(w, "Restarts:\t%d\n", info.RestartCount)
+	fmt.Fprintf(w, "Last exit code:\t%s\n", lastExitCode)
+
+	if info.Exited {
+		fmt.Fprintf(w, "Exit code:\t%d\n", info.ExitCode)
+		fmt.Fprintf(w, "Ended at:\t%s\n", info.EndedAt.Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(w, "Log file:\t%s\n", logFilePath)
+	fmt.Fprintf(w, "Child acts:\t%s\n", strings.Join(info.ChildActIds, ", "))
+
+	w.Flush()
+
+	fmt.Println()
+	printProcessTree(info, 0)
+}
@@ -7,17 +7,77 @@ package cmd
 
 import (
 	"flag"
+	"fmt"
+	"path/filepath"
 
 	"github.com/nosebit/act/cmd/act/run"
 	"github.com/nosebit/act/cmd/act/utils"
 )
 
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to resolve the acts to stop from names, name
+ * glob patterns or all running acts (including dead acts which left
+ * orphan commands running). It returns the list of patterns which
+ * didn't match any running act as well.
+ */
+func resolveStopInfos(patterns []string, all bool) ([]*run.Info, []string) {
+	var infos []*run.Info
+	var notFound []string
+	var running []*run.Info
+
+	for _, info := range run.GetAllInfo() {
+		if (!info.Exited && !info.Dead) || info.HasOrphanCmds() {
+			running = append(running, info)
+		}
+	}
+	selected := make(map[string]bool)
+
+	if all {
+		for _, info := range running {
+			selected[info.Id] = true
+		}
+	}
+
+	for _, pattern := range patterns {
+		found := false
+
+		for _, info := range running {
+			nameMatched, _ := filepath.Match(pattern, info.NameId)
+
+			if nameMatched || info.Id == pattern {
+				selected[info.Id] = true
+				found = true
+			}
+		}
+
+		if !found {
+			notFound = append(notFound, pattern)
+		}
+	}
+
+	/**
+	 * Child detached acts get stopped by their parents so we skip
+	 * them when their parents are going to be stopped too.
+	 */
+	for _, info := range running {
+		if selected[info.Id] && !selected[info.ParentActId] {
+			infos = append(infos, info)
+		}
+	}
+
+	return infos, notFound
+}
+
 //############################################################
 // Exposed Functions
 //############################################################
 
 /**
- * This is the main execution point for the `log` command.
+ * This is the main execution point for the `stop` command.
  */
 func StopCmdExec(args []string) {
 	/**
@@ -26,6 +86,17 @@ func StopCmdExec(args []string) {
 	 */
 	cmdFlags := flag.NewFlagSet("stop", flag.ExitOnError)
 
+	/**
+	 * This flag allows user to override how long we wait act
+	 * commands to exit before killing them.
+	 */
+	timeoutPtr := cmdFlags.Duration("timeout", -1, "Time to wait commands to exit before killing them")
+
+	/**
+	 * This flag allows user to stop all running acts.
+	 */
+	allPtr := cmdFlags.Bool("all", false, "Stop all running acts")
+
 	/**
 	 * Parse the incoming args extracting defined flags if user
 	 * provided any.
@@ -34,34 +105,47 @@ func StopCmdExec(args []string) {
 
 	/**
 	 * This are the command line arguments after extracting
-	 * the flags.
+	 * the flags (names or glob patterns of acts to stop).
 	 */
 	cmdArgs := cmdFlags.Args()
 
 	/**
-	 * For the stop command we need user to provide an act name
-	 * id for the act which going to be stopped.
+	 * For the stop command we need user to provide act name ids
+	 * for the acts which going to be stopped.
 	 */
-	if len(cmdArgs) < 1 {
+	if len(cmdArgs) < 1 && !*allPtr {
 		utils.FatalError("you need to specify the name of the act to stop")
+		return
 	}
 
-	/**
-	 * The first argument is the act name id we want to stop.
-	 *
-	 * @TODO : Allow users to provide a list of act name ids to
-	 * stop everything together and maybe provide a stop all
-	 * by running something like `act stop *`.
-	 */
-	actNameId := cmdArgs[0]
+	// Flag info left behind by crashed act processes.
+	run.MarkStaleInfos()
 
-	// Get act info
-	info := run.GetInfo(actNameId)
+	infos, notFound := resolveStopInfos(cmdArgs, *allPtr)
+	failed := len(notFound) > 0
 
-	if info == nil {
-		utils.FatalError("act not found")
+	for _, pattern := range notFound {
+		utils.LogError(fmt.Sprintf("act %s not found", pattern))
 	}
 
-	// Kill it
-	info.Kill()
+	for _, info := range infos {
+		/**
+		 * User provided timeout overrides act stop timeline.
+		 */
+		if *timeoutPtr >= 0 {
+			info.StopGracePeriod = timeoutPtr
+			info.StopTimeline = nil
+		}
+
+		// Stop it gracefully
+		if err := info.Stop(); err != nil {
+			utils.LogError(fmt.Sprintf("could not stop act %s", info.GetNameIdOrId()), err)
+			failed = true
+		}
+	}
+
+	// We fail if any of the acts could not be stopped.
+	if failed {
+		utils.ExitCode = 1
+	}
 }
@@ -0,0 +1,91 @@
+/**
+ * This file implements the top subcommand which is responsible for
+ * showing running acts together with their resource usage in a live
+ * refreshing table (similar to top shell command).
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Channel we close to stop refreshing the table.
+ */
+var topDone = make(chan bool)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `top` command.
+ */
+func TopCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("top", flag.ExitOnError)
+
+	/**
+	 * This flag allows user to set how often we refresh the table.
+	 */
+	intervalPtr := cmdFlags.Duration("n", 2*time.Second, "Refresh interval")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * Use the alternate screen so user terminal history is kept. It
+	 * going to be restored on exit by the terminal state manager.
+	 */
+	utils.EnterAltScreen()
+	utils.HideCursor()
+
+	for {
+		infos := filterInfos(run.GetAllInfo(), false)
+
+		/**
+		 * We use the refresh interval as sampling interval so cpu
+		 * usage reflects the whole period between refreshes.
+		 */
+		usages := run.GetUsages(infos, *intervalPtr)
+
+		// Clear screen and move cursor to top.
+		fmt.Print("\033[H\033[2J")
+		fmt.Println(utils.Color.Cyan(fmt.Sprintf("act top - %s", time.Now().Format("15:04:05"))).Bold())
+
+		if len(infos) == 0 {
+			fmt.Println(utils.Color.Yellow("no act running").Bold())
+		} else {
+			renderInfoTable(infos, usages)
+		}
+
+		select {
+		case <-topDone:
+			return
+		default:
+		}
+	}
+}
+
+/**
+ * This function going to stop refreshing the table.
+ */
+func TopStop() {
+	close(topDone)
+}
@@ -0,0 +1,416 @@
+/**
+ * This file implements the ui subcommand which is a terminal
+ * dashboard listing running acts with their live state and resource
+ * usage together with a pane streaming logs of the selected act.
+ * Selected act can be stopped or restarted right from the dashboard.
+ * The dashboard is a bubbletea program which takes care of raw input,
+ * alternate screen, rendering and restoring the terminal on exit.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This is the dashboard state. We track the selected act by id
+ * since the list of running acts can change.
+ */
+type uiModel struct {
+	Interval   time.Duration
+	Infos      []*run.Info
+	SelectedId string
+	Usages     map[string]*run.Usage
+	LogLines   []string
+	Message    string
+	Rows       int
+	Cols       int
+	done       chan bool
+}
+
+/**
+ * Message we get when it's time to refresh the list of acts.
+ */
+type uiTickMsg time.Time
+
+/**
+ * Message we get with fresh resource usages of acts.
+ */
+type uiUsagesMsg map[string]*run.Usage
+
+/**
+ * Message we get with a new status message to show.
+ */
+type uiMessageMsg string
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Default terminal size when we cannot get the real one.
+ */
+const (
+	uiDefaultRows = 24
+	uiDefaultCols = 80
+)
+
+/**
+ * How long we sample resource usage of acts.
+ */
+const uiUsageInterval = 2 * time.Second
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Channel we close to quit the dashboard.
+ */
+var uiDone = make(chan bool)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to create the dashboard state.
+ */
+func newUiModel(interval time.Duration, done chan bool) *uiModel {
+	model := &uiModel{
+		Interval: interval,
+		Rows:     uiDefaultRows,
+		Cols:     uiDefaultCols,
+		done:     done,
+	}
+
+	model.refresh()
+
+	return model
+}
+
+/**
+ * This function going to fit a line (without colors) in the terminal
+ * width.
+ */
+func fitUiLine(text string, cols int) string {
+	runes := []rune(ansiColorRegex.ReplaceAllString(text, ""))
+
+	if len(runes) > cols {
+		runes = runes[:cols]
+	}
+
+	return string(runes)
+}
+
+/**
+ * This function going to run the dashboard until user quits (or we
+ * get stopped).
+ */
+func runUi(model *uiModel, opts ...tea.ProgramOption) error {
+	return tea.NewProgram(model, opts...).Start()
+}
+
+//############################################################
+// uiModel Struct Functions
+//############################################################
+
+/**
+ * This function going to reload running acts and the last log lines
+ * of the selected act.
+ */
+func (model *uiModel) refresh() {
+	model.Infos = filterInfos(run.GetAllInfo(), false)
+	model.LogLines = nil
+
+	if selected := model.getSelected(); selected >= 0 {
+		model.SelectedId = model.Infos[selected].Id
+		rows, _ := model.getSize()
+		model.LogLines, _ = utils.ReadLastLines(model.Infos[selected].GetLogFilePath(), rows)
+	}
+}
+
+/**
+ * This function get the terminal size (rows and columns) falling
+ * back to a default size when terminal doesn't report one.
+ */
+func (model *uiModel) getSize() (int, int) {
+	if model.Rows <= 0 || model.Cols <= 0 {
+		return uiDefaultRows, uiDefaultCols
+	}
+
+	return model.Rows, model.Cols
+}
+
+/**
+ * This function get the index of the selected act (the first one
+ * when the selected act is gone) or -1 when no act is running.
+ */
+func (model *uiModel) getSelected() int {
+	if len(model.Infos) == 0 {
+		return -1
+	}
+
+	for idx, info := range model.Infos {
+		if info.Id == model.SelectedId {
+			return idx
+		}
+	}
+
+	return 0
+}
+
+/**
+ * This function going to move the selection up or down.
+ */
+func (model *uiModel) moveSelection(delta int) {
+	selected := model.getSelected() + delta
+
+	if selected >= 0 && selected < len(model.Infos) {
+		model.SelectedId = model.Infos[selected].Id
+		model.refresh()
+	}
+}
+
+/**
+ * This function going to run an act command (like stop or restart)
+ * against the selected act in background.
+ */
+func (model *uiModel) runAction(action string) tea.Cmd {
+	selected := model.getSelected()
+
+	if selected < 0 {
+		return nil
+	}
+
+	info := model.Infos[selected]
+	name := info.GetNameIdOrId()
+	model.Message = fmt.Sprintf("%s %s...", action, name)
+
+	return func() tea.Msg {
+		defer utils.RestoreTermOnPanic()
+
+		if err := execActCmd(nil, action, info.Id); err != nil {
+			return uiMessageMsg(fmt.Sprintf("could not %s %s: %v", action, name, err))
+		}
+
+		return uiMessageMsg(fmt.Sprintf("%s %s done", action, name))
+	}
+}
+
+/**
+ * This function going to schedule the next refresh.
+ */
+func (model *uiModel) tick() tea.Cmd {
+	return tea.Tick(model.Interval, func(now time.Time) tea.Msg {
+		return uiTickMsg(now)
+	})
+}
+
+/**
+ * This function going to sample resource usage of running acts
+ * (sampling blocks for the whole interval so it runs in background).
+ */
+func (model *uiModel) sampleUsages() tea.Cmd {
+	infos := model.Infos
+
+	return func() tea.Msg {
+		defer utils.RestoreTermOnPanic()
+
+		return uiUsagesMsg(run.GetUsages(infos, uiUsageInterval))
+	}
+}
+
+/**
+ * This function going to quit the dashboard when we get stopped from
+ * outside (like when receiving a kill signal).
+ */
+func (model *uiModel) waitDone() tea.Cmd {
+	return func() tea.Msg {
+		<-model.done
+		return tea.Quit()
+	}
+}
+
+/**
+ * This function going to start refreshing the dashboard.
+ */
+func (model *uiModel) Init() tea.Cmd {
+	return tea.Batch(model.tick(), model.sampleUsages(), model.waitDone())
+}
+
+/**
+ * This function going to handle keys pressed by user and background
+ * results (like usage samples).
+ */
+func (model *uiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			model.moveSelection(-1)
+		case "down", "j":
+			model.moveSelection(1)
+		case "s":
+			return model, model.runAction("stop")
+		case "r":
+			return model, model.runAction("restart")
+		case "q", "ctrl+c":
+			return model, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		model.Rows, model.Cols = msg.Height, msg.Width
+		model.refresh()
+	case uiTickMsg:
+		model.refresh()
+		return model, model.tick()
+	case uiUsagesMsg:
+		model.Usages = msg
+		return model, model.sampleUsages()
+	case uiMessageMsg:
+		model.Message = string(msg)
+	}
+
+	return model, nil
+}
+
+/**
+ * This function going to render the whole dashboard.
+ */
+func (model *uiModel) View() string {
+	var lines []string
+
+	rows, cols := model.getSize()
+	selected := model.getSelected()
+
+	lines = append(lines, utils.Color.Cyan(fitUiLine(fmt.Sprintf("act ui - %s (up/down select, s stop, r restart, q quit)", time.Now().Format("15:04:05")), cols)).Bold().String())
+	lines = append(lines, utils.Color.Gray(12, fitUiLine(fmt.Sprintf("  %-10s %-20s %-10s %-10s %-7s %-9s %s", "ID", "NAME", "STATE", "HEALTH", "CPU", "MEM", "UPTIME"), cols)).String())
+
+	if len(model.Infos) == 0 {
+		lines = append(lines, utils.Color.Yellow("  no act running").Bold().String())
+	}
+
+	for idx, info := range model.Infos {
+		health := info.GetHealth()
+
+		if health == "" {
+			health = "-"
+		}
+
+		cpu := "-"
+		mem := "-"
+
+		if usage, ok := model.Usages[info.Id]; ok {
+			cpu = fmt.Sprintf("%.1f%%", usage.Cpu)
+			mem = run.FormatBytes(usage.Rss)
+		}
+
+		marker := " "
+
+		if idx == selected {
+			marker = ">"
+		}
+
+		line := fitUiLine(fmt.Sprintf("%s %-10s %-20s %-10s %-10s %-7s %-9s %s", marker, info.Id, info.NameId, info.GetState(), health, cpu, mem, info.GetUptime().Round(time.Second)), cols)
+
+		if idx == selected {
+			line = utils.Color.Reverse(line).String()
+		}
+
+		lines = append(lines, line)
+	}
+
+	/**
+	 * Logs pane takes all the remaining space except the line
+	 * reserved for the status message.
+	 */
+	if selected >= 0 {
+		lines = append(lines, "")
+		lines = append(lines, utils.Color.Cyan(fitUiLine(fmt.Sprintf("logs of %s", model.Infos[selected].GetNameIdOrId()), cols)).Bold().String())
+
+		logLines := model.LogLines
+
+		if count := rows - len(lines) - 1; count < len(logLines) {
+			if count < 0 {
+				count = 0
+			}
+
+			logLines = logLines[len(logLines)-count:]
+		}
+
+		for _, line := range logLines {
+			lines = append(lines, fitUiLine(line, cols))
+		}
+	}
+
+	for len(lines) < rows-1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, utils.Color.Yellow(fitUiLine(model.Message, cols)).String())
+
+	return strings.Join(lines, "\n")
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `ui` command.
+ */
+func UiCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("ui", flag.ExitOnError)
+
+	/**
+	 * This flag allows user to set how often we refresh the
+	 * dashboard.
+	 */
+	intervalPtr := cmdFlags.Duration("n", time.Second, "Refresh interval")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	if !utils.IsTerminal(int(os.Stdin.Fd())) {
+		utils.FatalError("act ui requires a terminal")
+		return
+	}
+
+	if err := runUi(newUiModel(*intervalPtr, uiDone), tea.WithAltScreen()); err != nil {
+		utils.FatalError("could not run act ui", err)
+	}
+
+	// Release the goroutine waiting for us to be stopped.
+	UiStop()
+}
+
+/**
+ * This function going to quit the dashboard.
+ */
+func UiStop() {
+	select {
+	case <-uiDone:
+	default:
+		close(uiDone)
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+/**
+ * This function going to use a temp state dir (so tests don't touch
+ * the user registry) with running acts saved in it.
+ */
+func setupTestRunningActs(t *testing.T, ids ...string) {
+	prevStateHome, hadStateHome := os.LookupEnv("XDG_STATE_HOME")
+
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	t.Cleanup(func() {
+		if hadStateHome {
+			os.Setenv("XDG_STATE_HOME", prevStateHome)
+		} else {
+			os.Unsetenv("XDG_STATE_HOME")
+		}
+	})
+
+	for _, id := range ids {
+		info := &run.Info{Id: id, NameId: id, Pid: os.Getpid(), StartedAt: time.Now()}
+		info.Save()
+	}
+}
+
+/**
+ * This function going to send a key to the dashboard.
+ */
+func sendTestUiKey(model *uiModel, key string) tea.Cmd {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+
+	switch key {
+	case "up":
+		msg = tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		msg = tea.KeyMsg{Type: tea.KeyDown}
+	}
+
+	_, cmd := model.Update(msg)
+
+	return cmd
+}
+
+/**
+ * Arrow and vim keys move the selection (which stops at both ends)
+ * and q quits.
+ */
+func TestUiKeys(t *testing.T) {
+	setupTestRunningActs(t, "a1", "b2", "c3")
+
+	model := newUiModel(time.Second, make(chan bool))
+
+	for _, step := range []struct {
+		key  string
+		want string
+	}{
+		{"", "a1"},
+		{"down", "b2"},
+		{"j", "c3"},
+		{"down", "c3"},
+		{"up", "b2"},
+		{"k", "a1"},
+		{"k", "a1"},
+		{"x", "a1"},
+	} {
+		if step.key != "" {
+			sendTestUiKey(model, step.key)
+		}
+
+		if model.SelectedId != step.want {
+			t.Errorf("after %q got selected %q, want %q", step.key, model.SelectedId, step.want)
+		}
+	}
+
+	if cmd := sendTestUiKey(model, "q"); cmd == nil || !reflect.DeepEqual(cmd(), tea.Quit()) {
+		t.Error("q did not quit")
+	}
+}
+
+/**
+ * Quitting the dashboard (by key or when stopped) gives the terminal
+ * back as it was.
+ */
+func TestUiRestoresTerminal(t *testing.T) {
+	setupTestRunningActs(t, "a1")
+
+	ptm, pts, err := utils.OpenPty()
+
+	if err != nil {
+		t.Skip("pseudo-terminals not available", err)
+	}
+
+	defer ptm.Close()
+	defer pts.Close()
+
+	// Drain what the dashboard renders so it never blocks writing.
+	go io.Copy(ioutil.Discard, ptm)
+
+	getTermState := func() *utils.TermState {
+		state, err := utils.MakeInputRaw(int(pts.Fd()))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		utils.RestoreTerm(int(pts.Fd()), state)
+
+		return state
+	}
+
+	initialState := getTermState()
+
+	for _, quit := range []string{"key", "stop"} {
+		done := make(chan bool)
+		exited := make(chan error, 1)
+
+		go func() {
+			exited <- runUi(newUiModel(time.Second, done), tea.WithInput(pts), tea.WithOutput(pts), tea.WithAltScreen())
+		}()
+
+		deadline := time.After(5 * time.Second)
+
+		if quit == "stop" {
+			close(done)
+		}
+
+		// Keep pressing q until the dashboard reads input.
+	waitQuit:
+		for {
+			if quit == "key" {
+				ptm.Write([]byte("q"))
+			}
+
+			select {
+			case err := <-exited:
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				break waitQuit
+			case <-deadline:
+				t.Fatalf("dashboard did not quit by %s", quit)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+
+		if quit == "key" {
+			close(done)
+		}
+
+		if state := getTermState(); !reflect.DeepEqual(state, initialState) {
+			t.Errorf("quit by %s: got terminal state %v, want %v", quit, state, initialState)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/**
+ * This file implements the vars subcommand which shows the variables
+ * an act going to see when running (actfile vars, env files, flags
+ * defaults and act runtime vars) without running it.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `vars` command.
+ */
+func VarsCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("vars", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This flag indicates we want environment vars as well.
+	 */
+	allPtr := cmdFlags.Bool("all", false, "Show environment vars as well")
+
+	/**
+	 * This flag indicates we want json output (for tooling).
+	 */
+	jsonPtr := cmdFlags.Bool("json", false, "Output vars as json")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) == 0 {
+		utils.FatalError("missing act name (like act vars foo.bar)")
+		return
+	}
+
+	actFilePath := utils.ResolvePath(utils.GetWd(), *actFilePathPtr)
+
+	if !utils.DoFileExists(actFilePath) {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	actFile := actfile.ReadActFile(actFilePath)
+
+	/**
+	 * We resolve the act exactly the same way we do when running it
+	 * so vars are the ones the act going to see.
+	 */
+	runCtx := &run.RunCtx{
+		ActFile: actFile,
+		Info:    &run.Info{},
+		Vars:    make(map[string]string),
+		ActVars: make(map[string]string),
+	}
+
+	callId := cmdArgs[0]
+	actCtx, err := run.FindActCtx(strings.Split(callId, run.ActCallIdSeparator), actFile, nil, runCtx)
+
+	if err != nil {
+		utils.FatalError(fmt.Sprintf("act %s not found", callId), err)
+		return
+	}
+
+	// Flags going to have their default values.
+	actCtx.FlagVals = make(map[string]string)
+
+	for _, actFlag := range getExportFlags(actCtx.Act) {
+		actCtx.FlagVals[strcase.ToCamel(fmt.Sprintf("flag_%s", actFlag.Name))] = actFlag.Default
+	}
+
+	var vars map[string]string
+
+	if *allPtr {
+		vars = actCtx.MergeVars()
+	} else {
+		vars = actCtx.GetLocalVars()
+
+		for _, varsMap := range []map[string]string{actCtx.ActVars, actCtx.FlagVals} {
+			for key, val := range varsMap {
+				vars[key] = val
+			}
+		}
+	}
+
+	if *jsonPtr {
+		printJson(vars)
+		return
+	}
+
+	var names []string
+
+	for name := range vars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%s=%s\n", utils.Color.Green(name), vars[name])
+	}
+}
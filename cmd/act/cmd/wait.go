@@ -0,0 +1,125 @@
+/**
+ * This file implements the wait subcommand which is responsible for
+ * blocking until an act running in the background as daemon exits
+ * or becomes healthy. This way scripts don't need to poll act list.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Interval we poll the act while waiting.
+ */
+const waitPollInterval = 250 * time.Millisecond
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `wait` command.
+ */
+func WaitCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("wait", flag.ExitOnError)
+
+	/**
+	 * Max time to wait (zero means no limit).
+	 */
+	timeoutPtr := cmdFlags.Duration("timeout", 0, "Max time to wait")
+
+	/**
+	 * What we are waiting for (exit or healthy).
+	 */
+	forPtr := cmdFlags.String("for", "exit", "Wait act to exit or to become healthy (exit|healthy)")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	/**
+	 * This are the command line arguments after extracting
+	 * the flags.
+	 */
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) < 1 {
+		utils.FatalError("you need to specify the name of the act to wait")
+		return
+	}
+
+	if *forPtr != "exit" && *forPtr != run.HealthHealthy {
+		utils.FatalError(fmt.Sprintf("invalid wait condition %s", *forPtr))
+		return
+	}
+
+	nameId := cmdArgs[0]
+	info := run.GetInfo(nameId)
+
+	if info == nil {
+		utils.FatalError("act not found")
+		return
+	}
+
+	var deadline time.Time
+
+	if *timeoutPtr > 0 {
+		deadline = time.Now().Add(*timeoutPtr)
+	}
+
+	for {
+		/**
+		 * Daemons keep a record of their exit but other acts remove
+		 * their info when they finish so we keep the last info we got
+		 * to report the exit code.
+		 */
+		if currInfo := run.GetInfo(info.Id); currInfo != nil {
+			info = currInfo
+		}
+
+		isRunning := info.IsRunning()
+
+		if *forPtr == run.HealthHealthy {
+			if isRunning && info.GetHealth() == run.HealthHealthy {
+				return
+			}
+
+			if !isRunning {
+				utils.FatalError(fmt.Sprintf("act %s exited before getting healthy", nameId))
+				return
+			}
+		} else if !isRunning {
+			if info.Exited {
+				utils.ExitCode = info.ExitCode
+			} else {
+				utils.ExitCode = info.LastExitCode
+			}
+
+			return
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			utils.FatalError(fmt.Sprintf("timeout waiting act %s", nameId))
+			return
+		}
+
+		time.Sleep(waitPollInterval)
+	}
+}
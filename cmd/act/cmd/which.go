@@ -0,0 +1,323 @@
+/**
+ * This file implements the which subcommand which shows how an act
+ * call id gets resolved (which act patterns matched, in which
+ * actfiles and after which redirect/include hops) and what is going
+ * to run for it.
+ */
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
+	"github.com/nosebit/act/cmd/act/run"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a hop of an act call id resolution.
+ */
+type whichHop struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern"`
+	ActFile  string `json:"actfile"`
+	Redirect string `json:"redirect,omitempty"`
+	Include  string `json:"include,omitempty"`
+}
+
+/**
+ * This struct going to hold an act stage as output in json.
+ */
+type whichStage struct {
+	Name     string   `json:"name"`
+	Parallel bool     `json:"parallel"`
+	Script   string   `json:"script,omitempty"`
+	Cmds     []string `json:"cmds"`
+}
+
+/**
+ * This struct going to hold how an act call id gets resolved as
+ * output in json.
+ */
+type whichResult struct {
+	CallId     string        `json:"call_id"`
+	ActFile    string        `json:"actfile"`
+	Resolution []*whichHop   `json:"resolution"`
+	Needs      []string      `json:"needs"`
+	Check      []string      `json:"check"`
+	Stages     []*whichStage `json:"stages"`
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get a path relative to working directory
+ * (or the path itself when that is not possible).
+ */
+func getRelPath(wd string, targetPath string) string {
+	relPath, err := filepath.Rel(wd, targetPath)
+
+	if err != nil {
+		return targetPath
+	}
+
+	return relPath
+}
+
+/**
+ * This function going to describe a command in a single line.
+ */
+func describeCmd(cmd *actfile.Cmd) string {
+	var desc string
+
+	switch {
+	case cmd.Act != "":
+		desc = fmt.Sprintf("act: %s", cmd.Act)
+
+		if cmd.From != "" {
+			desc += fmt.Sprintf(" (from %s)", cmd.From)
+		}
+	case cmd.Script != "":
+		desc = fmt.Sprintf("script: %s", cmd.Script)
+	default:
+		desc = strings.TrimSpace(cmd.Cmd)
+
+		// Multiline commands get shown by their first line only.
+		if lines := strings.Split(desc, "\n"); len(lines) > 1 {
+			desc = fmt.Sprintf("%s ...", lines[0])
+		}
+	}
+
+	if cmd.Detach {
+		desc += " [detach]"
+	}
+
+	if cmd.Loop != nil {
+		desc += " [loop]"
+	}
+
+	return desc
+}
+
+/**
+ * This function going to print commands of an act stage.
+ */
+func printStageCmds(stage *actfile.ActExecStage) {
+	if stage == nil || (len(stage.Cmds) == 0 && stage.Script == "") {
+		return
+	}
+
+	name := stage.Name
+
+	if stage.Parallel {
+		name += " [parallel]"
+	}
+
+	fmt.Printf("  %s:\n", name)
+
+	if stage.Script != "" {
+		fmt.Printf("    - script: %s\n", stage.Script)
+		return
+	}
+
+	for _, cmd := range stage.Cmds {
+		fmt.Printf("    - %s\n", describeCmd(cmd))
+	}
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This is the main execution point for the `which` command.
+ */
+func WhichCmdExec(args []string) {
+	/**
+	 * We create a new flag set to allow this act subcommand to
+	 * accepts flags by their own.
+	 */
+	cmdFlags := flag.NewFlagSet("which", flag.ExitOnError)
+
+	/**
+	 * This is the path to actfile to be used.
+	 */
+	actFilePathPtr := cmdFlags.String("f", config.GetActFileName(), "Path to an actfile yaml file")
+
+	/**
+	 * This flag indicates we want json output (for tooling).
+	 */
+	jsonPtr := cmdFlags.Bool("json", false, "Output resolution as json")
+
+	/**
+	 * Parse the incoming args extracting defined flags if user
+	 * provided any.
+	 */
+	cmdFlags.Parse(args)
+
+	cmdArgs := cmdFlags.Args()
+
+	if len(cmdArgs) == 0 {
+		utils.FatalError("missing act name (like act which foo.bar)")
+		return
+	}
+
+	wd := utils.GetWd()
+	actFilePath := utils.ResolvePath(wd, *actFilePathPtr)
+
+	if !utils.DoFileExists(actFilePath) {
+		utils.FatalError(fmt.Sprintf("actfile %s not found", actFilePath))
+		return
+	}
+
+	actFile := actfile.ReadActFile(actFilePath)
+
+	/**
+	 * We resolve the act exactly the same way we do when running it
+	 * so what we show is what is going to run.
+	 */
+	runCtx := &run.RunCtx{
+		ActFile: actFile,
+		Info:    &run.Info{},
+		Vars:    make(map[string]string),
+		ActVars: make(map[string]string),
+	}
+
+	callId := strings.Join(cmdArgs, run.ActCallIdSeparator)
+	actCtx, err := run.FindActCtx(strings.Split(callId, run.ActCallIdSeparator), actFile, nil, runCtx)
+
+	if err != nil {
+		utils.FatalError(fmt.Sprintf("act %s not found", callId), err)
+		return
+	}
+
+	var chain []*run.ActRunCtx
+
+	for ctx := actCtx; ctx != nil; ctx = ctx.PrevCtx {
+		chain = append([]*run.ActRunCtx{ctx}, chain...)
+	}
+
+	act := actCtx.Act
+
+	stages := []*actfile.ActExecStage{
+		act.Before,
+		act.Start,
+		act.After,
+		act.OnSuccess,
+		act.OnFailure,
+		act.Final,
+		act.Teardown,
+	}
+
+	if *jsonPtr {
+		result := &whichResult{
+			CallId:     callId,
+			ActFile:    actCtx.ActFile.LocationPath,
+			Resolution: []*whichHop{},
+			Needs:      []string{},
+			Check:      []string{},
+			Stages:     []*whichStage{},
+		}
+
+		for _, ctx := range chain {
+			result.Resolution = append(result.Resolution, &whichHop{
+				Name:     ctx.ActVars["ActName"],
+				Pattern:  ctx.Act.Name,
+				ActFile:  ctx.ActFile.LocationPath,
+				Redirect: ctx.Act.Redirect,
+				Include:  ctx.Act.Include,
+			})
+		}
+
+		for _, need := range act.Needs {
+			result.Needs = append(result.Needs, need.Act)
+		}
+
+		if act.Check != nil {
+			for _, cmd := range act.Check.Cmds {
+				result.Check = append(result.Check, describeCmd(cmd))
+			}
+		}
+
+		for _, stage := range stages {
+			if stage == nil || (len(stage.Cmds) == 0 && stage.Script == "") {
+				continue
+			}
+
+			jsonStage := &whichStage{
+				Name:     stage.Name,
+				Parallel: stage.Parallel,
+				Script:   stage.Script,
+				Cmds:     []string{},
+			}
+
+			for _, cmd := range stage.Cmds {
+				jsonStage.Cmds = append(jsonStage.Cmds, describeCmd(cmd))
+			}
+
+			result.Stages = append(result.Stages, jsonStage)
+		}
+
+		printJson(result)
+		return
+	}
+
+	fmt.Printf("%s\n\n", utils.Color.Green(callId).Bold())
+	fmt.Println(utils.Color.Bold("Resolution:"))
+
+	for i, ctx := range chain {
+		line := fmt.Sprintf(
+			"  %d. %s matched %q in %s",
+			i+1,
+			ctx.ActVars["ActName"],
+			ctx.Act.Name,
+			getRelPath(wd, ctx.ActFile.LocationPath),
+		)
+
+		if i < len(chain)-1 {
+			if ctx.Act.Redirect != "" {
+				line += fmt.Sprintf(" %s", utils.Color.Yellow(fmt.Sprintf("-> redirect %s", ctx.Act.Redirect)))
+			} else if ctx.Act.Include != "" {
+				line += fmt.Sprintf(" %s", utils.Color.Yellow(fmt.Sprintf("-> include %s", ctx.Act.Include)))
+			}
+		}
+
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Println(utils.Color.Bold(fmt.Sprintf("Runs (%s):", getRelPath(wd, actCtx.ActFile.LocationPath))))
+
+	if len(act.Needs) > 0 {
+		var needs []string
+
+		for _, need := range act.Needs {
+			needs = append(needs, need.Act)
+		}
+
+		fmt.Printf("  needs: %s\n", strings.Join(needs, ", "))
+	}
+
+	if act.Check != nil && len(act.Check.Cmds) > 0 {
+		fmt.Println("  check:")
+
+		for _, cmd := range act.Check.Cmds {
+			fmt.Printf("    - %s\n", describeCmd(cmd))
+		}
+	}
+
+	for _, stage := range stages {
+		printStageCmds(stage)
+	}
+}
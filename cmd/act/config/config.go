@@ -0,0 +1,240 @@
+/**
+ * This package going to hold user config loaded from a global config
+ * file (`~/.config/act/config.yml` by default). User config set
+ * defaults for all projects of a user. Precedence is always cli flags
+ * first, then project actfile and then user config.
+ *
+ * ```yaml
+ * # ~/.config/act/config.yml
+ * shell: zsh
+ * log: prefixed
+ * actfile: acts.yml
+ * theme:
+ *   colors: true
+ *   palette: [cyan, magenta, blue]
+ * plugins:
+ *   - ~/.act/bin
+ * env:
+ *   - PATH
+ *   - HOME
+ *   - AWS_*
+ * ```
+ */
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"gopkg.in/yaml.v3"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold color theme options.
+ */
+type Theme struct {
+	/**
+	 * This allow user to disable colors (like NO_COLOR env var).
+	 */
+	Colors *bool
+
+	/**
+	 * List of color names used to colorize act prefixes (like
+	 * cyan, bright-blue, etc).
+	 */
+	Palette []string
+}
+
+/**
+ * This struct going to hold user config.
+ */
+type Config struct {
+	/**
+	 * Default shell to run commands with.
+	 */
+	Shell string
+
+	/**
+	 * Default log mode (raw, prefixed or json).
+	 */
+	Log string
+
+	/**
+	 * Default actfile name to look for in working directory.
+	 */
+	ActFile string `yaml:"actfile"`
+
+	/**
+	 * Color theme.
+	 */
+	Theme *Theme
+
+	/**
+	 * List of directories containing executables (plugins) we make
+	 * available to commands by prepending them to PATH.
+	 */
+	Plugins []string
+
+	/**
+	 * List of environment variables (names or patterns like AWS_*)
+	 * commands can see. When empty commands see all of them.
+	 */
+	Env []string
+
+	/**
+	 * This is the path of the file config was loaded from.
+	 */
+	LocationPath string `yaml:"-"`
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Default name of actfiles when user config don't set one.
+ */
+const DefaultActFileName = "actfile.yml"
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * This is the loaded config.
+ */
+var current *Config
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to expand `~` in paths to user home dir.
+ */
+func expandHome(aPath string) string {
+	if aPath != "~" && !strings.HasPrefix(aPath, "~/") {
+		return aPath
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return aPath
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(aPath, "~"))
+}
+
+/**
+ * This function going to load config from a file. Missing config file
+ * is not an error (user simply has no config).
+ */
+func load(filePath string) *Config {
+	config := &Config{}
+
+	if filePath == "" || !utils.DoFileExists(filePath) {
+		return config
+	}
+
+	content, err := ioutil.ReadFile(filePath)
+
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("could not read config file %s", filePath), err)
+		return config
+	}
+
+	if err := yaml.Unmarshal(content, config); err != nil {
+		utils.LogWarn(fmt.Sprintf("could not parse config file %s", filePath), err)
+		return &Config{}
+	}
+
+	config.LocationPath = filePath
+
+	// Plugin paths are relative to config file dir.
+	for i, pluginPath := range config.Plugins {
+		config.Plugins[i] = utils.ResolvePath(filepath.Dir(filePath), expandHome(pluginPath))
+	}
+
+	return config
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to get the path of user config file. User can
+ * point to another file with ACT_CONFIG env var.
+ */
+func GetFilePath() string {
+	if configPath := os.Getenv("ACT_CONFIG"); configPath != "" {
+		return expandHome(configPath)
+	}
+
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+
+	// We use ~/.config in all platforms (including mac).
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+
+		if err != nil {
+			return ""
+		}
+
+		configDir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configDir, "act", "config.yml")
+}
+
+/**
+ * This function going to get user config (loading it the first time).
+ */
+func Get() *Config {
+	if current == nil {
+		current = load(GetFilePath())
+	}
+
+	return current
+}
+
+/**
+ * This function going to get the default actfile name.
+ */
+func GetActFileName() string {
+	if actFileName := Get().ActFile; actFileName != "" {
+		return actFileName
+	}
+
+	return DefaultActFileName
+}
+
+/**
+ * This function going to check if commands can see an environment
+ * variable. Act own variables are always allowed.
+ */
+func IsEnvAllowed(name string) bool {
+	env := Get().Env
+
+	if len(env) == 0 || strings.HasPrefix(name, "ACT_") || name == "NO_COLOR" {
+		return true
+	}
+
+	for _, pattern := range env {
+		if match, _ := filepath.Match(pattern, name); match {
+			return true
+		}
+	}
+
+	return false
+}
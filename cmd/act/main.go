@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	/**
@@ -17,6 +18,7 @@ import (
 	 */
 
 	"github.com/nosebit/act/cmd/act/cmd"
+	"github.com/nosebit/act/cmd/act/config"
 	"github.com/nosebit/act/cmd/act/utils"
 )
 
@@ -41,13 +43,18 @@ func scheduleStopOnKill() {
 	 */
 	sigs := make(chan os.Signal, 1)
 
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	// Signals we send to ourselves on platforms that can't do it.
+	utils.NotifySelf(sigs)
 
 	/**
 	 * When we receive a kill process we going to stop the current
 	 * execution.
 	 */
 	go func() {
+		defer utils.RestoreTermOnPanic()
+
 		/**
 		 * This going to block the execution until sigs channel
 		 * receive a quit signal.
@@ -83,6 +90,44 @@ func scheduleStopOnKill() {
 	}()
 }
 
+/**
+ * This function going to change the working directory before we do
+ * anything else (like make -C) so actfile discovery and relative
+ * paths work from there. Nothing is running yet so we can exit right
+ * away on errors.
+ */
+func changeDir(dir string) {
+	if dir == "" {
+		utils.LogError("flag -C requires a directory")
+		os.Exit(1)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		utils.LogError(fmt.Sprintf("could not change directory to %s", dir), err)
+		os.Exit(1)
+	}
+}
+
+/**
+ * This function going to apply color theme from user config. Cli
+ * flags (like no-color) and NO_COLOR env var have precedence.
+ */
+func applyUserTheme() {
+	theme := config.Get().Theme
+
+	if theme == nil {
+		return
+	}
+
+	if theme.Colors != nil && !*theme.Colors {
+		utils.DisableColor()
+	}
+
+	if err := utils.SetPalette(theme.Palette); err != nil {
+		utils.LogWarn("invalid palette in user config", err)
+	}
+}
+
 //############################################################
 // Main Entrypoint
 //############################################################
@@ -93,6 +138,13 @@ func scheduleStopOnKill() {
  * check what command we are invoking.
  */
 func main() {
+	/**
+	 * Save terminal state so we can restore it on any exit path (even
+	 * when panicking) and never leave user terminal broken.
+	 */
+	utils.SaveTermState()
+
+	defer utils.RestoreTermOnPanic()
 
 	/**
 	 * We start by scheduling a stop job that going to be run
@@ -101,11 +153,42 @@ func main() {
 	 */
 	scheduleStopOnKill()
 
+	/**
+	 * Signals like SIGHUP going to be forwarded to running commands
+	 * instead of stopping the execution.
+	 */
+	scheduleSignalForwarding()
+
 	//--------------------------------------------------
 	// Parse command line args
 	//--------------------------------------------------
 	args := os.Args[1:]
 
+	// User config going to set defaults.
+	applyUserTheme()
+
+	// Global flags going before the subcommand.
+	for len(args) > 0 {
+		if args[0] == "-no-color" || args[0] == "--no-color" {
+			utils.DisableColor()
+			args = args[1:]
+		} else if args[0] == "-C" || args[0] == "--C" {
+			var dir string
+
+			if len(args) > 1 {
+				dir = args[1]
+			}
+
+			changeDir(dir)
+			args = args[2:]
+		} else if strings.HasPrefix(args[0], "-C=") || strings.HasPrefix(args[0], "--C=") {
+			changeDir(strings.SplitN(args[0], "=", 2)[1])
+			args = args[1:]
+		} else {
+			break
+		}
+	}
+
 	// Verify that a subcommand has been provided
 	// os.Arg[0] is the main act command name
 	// os.Arg[1] is act subcommand
@@ -113,6 +196,14 @@ func main() {
 		utils.FatalError("subcommand is required")
 	}
 
+	/**
+	 * SIGQUIT going to dump the execution state of runs only (other
+	 * subcommands keep the default behavior of quitting).
+	 */
+	if len(args) > 0 && args[0] == "run" {
+		scheduleDumpOnQuit()
+	}
+
 	// Now we execute subcommand (synchronously)
 	/**
 	 * Execute the subcomand. The execution of all commands
@@ -133,6 +224,9 @@ func main() {
 	 */
 	cmd.Finish()
 
+	// Restore terminal state changed by commands (like attach).
+	utils.RestoreTermState()
+
 	// Now exit with correct exit code.
 	os.Exit(utils.ExitCode)
 }
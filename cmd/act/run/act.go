@@ -5,17 +5,30 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path"
-	"regexp"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/iancoleman/strcase"
 	"github.com/joho/godotenv"
 	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
 	"github.com/nosebit/act/cmd/act/utils"
 )
 
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the name of the folder (next to the actfile) where we look
+ * for scripts of acts without commands.
+ */
+const ConventionScriptsDirName = "acts"
+
 //############################################################
 // Types
 //############################################################
@@ -64,6 +77,14 @@ type ActRunCtx struct {
 	 */
 	Args []string
 
+	/**
+	 * Log mode inherited from the act which called this act (acts
+	 * called by other acts log the same way their caller does). Acts
+	 * are shared by all calls so we keep it here instead of changing
+	 * the act.
+	 */
+	InheritedLog *string
+
 	/**
 	 * Set of variables passed from parent acts.
 	 */
@@ -78,6 +99,124 @@ type ActRunCtx struct {
 	 * Set of variables scoped to act execution.
 	 */
 	Vars map[string]string
+
+	/**
+	 * Flag indicating a command of this act (or of a subact called
+	 * by this act) failed.
+	 */
+	Failed bool
+
+	/**
+	 * Exit code of the first failed command.
+	 */
+	ExitCode int
+
+	/**
+	 * Command line of the first failed command.
+	 */
+	FailedCmd string
+
+	/**
+	 * Flag indicating we are running the final stages of this act
+	 * (i.e., on_success/on_failure and final stages).
+	 */
+	InFinalStage bool
+
+	/**
+	 * Flag indicating final stages of this act took longer than
+	 * allowed and therefore got aborted.
+	 */
+	FinalTimedOut bool
+
+	/**
+	 * Time when the act started running.
+	 */
+	StartedAt time.Time
+
+	/**
+	 * Number of commands of this act which are pending (i.e., the
+	 * stage is still waiting them to finish).
+	 */
+	pendingCmds int32
+
+	/**
+	 * Shell shared by commands of the current stage when it runs in
+	 * persistent exec mode.
+	 */
+	persistentShell *persistentShell
+
+	/**
+	 * This ensures final stages run exactly once.
+	 */
+	finalOnce sync.Once
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to quote args so a posix shell takes each one
+ * as a single word.
+ */
+func quoteShellArgs(args []string) string {
+	quotedArgs := make([]string, len(args))
+
+	for idx, arg := range args {
+		quotedArgs[idx] = utils.ShellQuote(arg)
+	}
+
+	return strings.Join(quotedArgs, " ")
+}
+
+/**
+ * This function going to filter env vars keeping only the ones not
+ * inherited untouched from the host environment (like PATH and HOME).
+ * We use this when running commands on other machines (containers or
+ * remote hosts) where host vars make no sense.
+ */
+func getOwnEnvVars(envars []string) []string {
+	hostEnv := make(map[string]string)
+
+	for _, kv := range os.Environ() {
+		if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+			hostEnv[parts[0]] = parts[1]
+		}
+	}
+
+	var ownEnvars []string
+
+	for _, kv := range envars {
+		parts := strings.SplitN(kv, "=", 2)
+
+		if len(parts) != 2 {
+			continue
+		}
+
+		if val, ok := hostEnv[parts[0]]; ok && val == parts[1] {
+			continue
+		}
+
+		ownEnvars = append(ownEnvars, kv)
+	}
+
+	return ownEnvars
+}
+
+/**
+ * This function going to get sorted names of vars. Values can hold
+ * secrets (like tokens) so debug logs only show names.
+ */
+func getVarNames(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+
+	for name := range vars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
 }
 
 //############################################################
@@ -108,28 +247,44 @@ func (ctx *ActRunCtx) GetLocalVars() map[string]string {
 	actEnvFileVars := make(map[string]string)
 
 	if ctx.ActFile.EnvFilePath != "" {
-		envFilePath := utils.ResolvePath(path.Dir(ctx.ActFile.LocationPath), ctx.ActFile.EnvFilePath)
-		envars, _ := godotenv.Read(envFilePath)
+		envFilePath := utils.ResolvePath(filepath.Dir(ctx.ActFile.LocationPath), ctx.ActFile.EnvFilePath)
+		envars, err := ctx.RunCtx.readEnvFile(envFilePath)
+
+		if err != nil && !os.IsNotExist(err) {
+			utils.LogWarn(fmt.Sprintf("could not read env file %s", envFilePath), err)
+		}
+
 		envFileVars = envars
 	}
 
 	if ctx.Act.EnvFilePath != "" {
-		envFilePath := utils.ResolvePath(path.Dir(ctx.ActFile.LocationPath), ctx.Act.EnvFilePath)
-		envars, _ := godotenv.Read(envFilePath)
+		envFilePath := utils.ResolvePath(filepath.Dir(ctx.ActFile.LocationPath), ctx.Act.EnvFilePath)
+		envars, err := ctx.RunCtx.readEnvFile(envFilePath)
+
+		if err != nil && !os.IsNotExist(err) {
+			utils.LogWarn(fmt.Sprintf("could not read env file %s", envFilePath), err)
+		}
+
 		actEnvFileVars = envars
 	}
 
-	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : parent vars", ctx.Act.Name), ctx.ParentVars)
-	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : global env file vars", ctx.Act.Name), envFileVars)
-	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : act env file vars", ctx.Act.Name), actEnvFileVars)
+	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : parent vars", ctx.Act.Name), getVarNames(ctx.ParentVars))
+	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : global env file vars", ctx.Act.Name), getVarNames(envFileVars))
+	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : act env file vars", ctx.Act.Name), getVarNames(actEnvFileVars))
 
 	varsMapList := []map[string]string{
 		// Variables passed from parent acts.
 		ctx.ParentVars,
 
+		// Static actfile vars.
+		ctx.ActFile.Vars,
+
 		// Load vars from files first.
 		envFileVars,
 
+		// Static act vars.
+		ctx.Act.Vars,
+
 		// Load vars from act level env file.
 		actEnvFileVars,
 
@@ -143,7 +298,7 @@ func (ctx *ActRunCtx) GetLocalVars() map[string]string {
 		}
 	}
 
-	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : final vars", ctx.Act.Name), vars)
+	utils.LogDebug(fmt.Sprintf("GetLocalVars [act=%s] : final vars", ctx.Act.Name), getVarNames(vars))
 
 	return vars
 }
@@ -162,13 +317,18 @@ func (ctx *ActRunCtx) MergeVars() map[string]string {
 
 	// Iterate over environ vars
 	for _, kv := range os.Environ() {
-		parts := strings.Split(kv, "=")
+		parts := strings.SplitN(kv, "=", 2)
 
-		if len(parts) == 2 {
+		if len(parts) == 2 && config.IsEnvAllowed(parts[0]) {
 			environVars[parts[0]] = parts[1]
 		}
 	}
 
+	// Plugins from user config are available to commands.
+	if plugins := config.Get().Plugins; len(plugins) > 0 {
+		environVars["PATH"] = strings.Join(append(plugins, environVars["PATH"]), string(os.PathListSeparator))
+	}
+
 	varsMapList := []map[string]string{
 		// Variables from the enviornment going to be overriden.
 		environVars,
@@ -198,12 +358,33 @@ func (ctx *ActRunCtx) MergeVars() map[string]string {
 		}
 	}
 
-	// Add the set of all command line arguments as a single var
+	/**
+	 * Add the set of all command line arguments as a single var (see
+	 * getShellLineVars for how we use it in shell command lines).
+	 */
 	vars["CliArgs"] = strings.Join(ctx.Args, " ")
 
 	return vars
 }
 
+/**
+ * This function going to get vars to compile a shell command line
+ * (or script) with. Args in CliArgs get quoted there so args with
+ * spaces or quotes reach commands as is and can't inject shell
+ * syntax.
+ */
+func (ctx *ActRunCtx) getShellLineVars(vars map[string]string) map[string]string {
+	shellVars := make(map[string]string, len(vars))
+
+	for key, val := range vars {
+		shellVars[key] = val
+	}
+
+	shellVars["CliArgs"] = quoteShellArgs(ctx.Args)
+
+	return shellVars
+}
+
 /**
  * This function convert vars to env vars.
  */
@@ -236,6 +417,45 @@ func (ctx *ActRunCtx) VarsToEnvVars(vars map[string]string) []string {
 	return envars
 }
 
+/**
+ * This function going to build the environment of processes we spawn
+ * (commands and detached acts) from act variables. We use it for all
+ * execution paths so they see the same environment.
+ */
+func (ctx *ActRunCtx) GetEnvVars(vars map[string]string) []string {
+	envVars := make(map[string]string)
+
+	for key, val := range vars {
+		envVars[key] = val
+	}
+
+	/**
+	 * Set a special ACT_ENV_FILE variable pointing to the full
+	 * path to env file set on actfile.
+	 */
+	if ctx.ActFile.EnvFilePath != "" {
+		envVars["ACT_ENV_FILE"] = utils.ResolvePath(filepath.Dir(ctx.ActFile.LocationPath), ctx.ActFile.EnvFilePath)
+	}
+
+	envars := ctx.VarsToEnvVars(envVars)
+
+	/**
+	 * Secrets are injected only in the environment (they are not
+	 * template vars) so they never end up in files we write.
+	 */
+	secretVars, err := ctx.GetSecretVars()
+
+	if err != nil {
+		utils.LogWarn(err)
+	}
+
+	for key, val := range secretVars {
+		envars = append(envars, fmt.Sprintf("%s=%s", key, val))
+	}
+
+	return envars
+}
+
 /**
  * This function going to get the whole act run context stack
  * starting from this act run context. Act contexts are linked
@@ -316,15 +536,229 @@ func (ctx *ActRunCtx) ExecBeforeAll() {
 	}
 }
 
+/**
+ * This function going to set the stage this act is executing. It's
+ * read by other goroutines (like run state requests) so we set it
+ * holding the run lock.
+ */
+func (ctx *ActRunCtx) setCurrentStage(stage *actfile.ActExecStage) {
+	ctx.RunCtx.mutex.Lock()
+	defer ctx.RunCtx.mutex.Unlock()
+
+	ctx.CurrentStage = stage
+}
+
+/**
+ * This function going to mark this act context and all previous
+ * act contexts in the chain as failed. Only the first failure is
+ * recorded.
+ */
+func (ctx *ActRunCtx) SetFailed(exitCode int, cmdLine string) {
+	// Failure state is read by other goroutines (like run state requests).
+	ctx.RunCtx.mutex.Lock()
+
+	for currCtx := ctx; currCtx != nil; currCtx = currCtx.PrevCtx {
+		if currCtx.Failed {
+			continue
+		}
+
+		currCtx.Failed = true
+		currCtx.ExitCode = exitCode
+		currCtx.FailedCmd = cmdLine
+	}
+
+	ctx.RunCtx.mutex.Unlock()
+
+	ctx.RunCtx.Info.SetLastExitCode(exitCode)
+}
+
+/**
+ * This function going to create a new act context sharing everything
+ * with this one except the current stage. This way we can run stages
+ * of the same act concurrently.
+ */
+func (ctx *ActRunCtx) Fork() *ActRunCtx {
+	return &ActRunCtx{
+		RunCtx:       ctx.RunCtx,
+		ActFile:      ctx.ActFile,
+		Act:          ctx.Act,
+		PrevCtx:      ctx.PrevCtx,
+		CallId:       ctx.CallId,
+		FlagVals:     ctx.FlagVals,
+		Args:         ctx.Args,
+		InheritedLog: ctx.InheritedLog,
+		ParentVars:   ctx.ParentVars,
+		ActVars:      ctx.ActVars,
+		Vars:         ctx.Vars,
+		StartedAt:    ctx.StartedAt,
+	}
+}
+
+/**
+ * This function going to get the log mode of the act (which can be
+ * inherited from the caller act).
+ */
+func (ctx *ActRunCtx) GetActLog() string {
+	if ctx.InheritedLog != nil {
+		return *ctx.InheritedLog
+	}
+
+	return ctx.Act.Log
+}
+
+/**
+ * This function going to check if this act context or any previous
+ * act context in the chain is running its final stages. This way
+ * acts called from final commands are considered finalizing too.
+ */
+func (ctx *ActRunCtx) IsFinalizing() bool {
+	for currCtx := ctx; currCtx != nil; currCtx = currCtx.PrevCtx {
+		if currCtx.FinalTimedOut {
+			return false
+		}
+
+		if currCtx.InFinalStage {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to check if commands of this act context are
+ * allowed to run. Commands run while the execution is running but
+ * final stages must run even if the execution was stopped (as long
+ * as they don't timeout).
+ */
+func (ctx *ActRunCtx) CanRun() bool {
+	return !ctx.RunCtx.IsStopped() || ctx.IsFinalizing()
+}
+
+/**
+ * This function going to get a channel closed when commands of this
+ * act context are not allowed to run anymore (final stages are never
+ * canceled so we get a nil channel for them).
+ */
+func (ctx *ActRunCtx) Done() <-chan struct{} {
+	if ctx.IsFinalizing() {
+		return nil
+	}
+
+	return ctx.RunCtx.Ctx.Done()
+}
+
+/**
+ * This function going to wait for a duration or until the execution
+ * is stopped returning if commands are still allowed to run.
+ */
+func (ctx *ActRunCtx) Sleep(duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	return ctx.CanRun()
+}
+
+/**
+ * This function going to wait while the act is paused (so we don't
+ * start commands while paused) returning if commands are still
+ * allowed to run.
+ */
+func (ctx *ActRunCtx) WaitResumed() bool {
+	for ctx.RunCtx.Info.IsPaused() {
+		if !ctx.Sleep(pausedPollInterval) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/**
+ * This function going to emit a structured trace event enriched with
+ * run, act and stage info.
+ */
+func (ctx *ActRunCtx) Trace(event string, fields utils.TraceFields) {
+	if !utils.IsTraceEnabled() {
+		return
+	}
+
+	entry := utils.TraceFields{
+		"run_id": ctx.RunCtx.Info.Id,
+		"act":    ctx.CallId,
+	}
+
+	if ctx.CurrentStage != nil {
+		entry["stage"] = ctx.CurrentStage.Name
+	}
+
+	for key, val := range fields {
+		entry[key] = val
+	}
+
+	utils.Trace(event, entry)
+}
+
+/**
+ * This function get how long final stages of this act can take
+ * to run when the execution was stopped.
+ */
+func (ctx *ActRunCtx) GetFinalTimeout() time.Duration {
+	if ctx.Act.FinalTimeout > 0 {
+		return ctx.Act.FinalTimeout
+	}
+
+	return DefaultFinalTimeout
+}
+
 /**
  * This function going to run teardown commands of currently
- * running act upon exit.
- *
- * @TODO: We need to run teardown cmds of all running acts.
+ * running act upon exit. No matter how many times this function
+ * is called final stages going to run exactly once.
  */
 func (ctx *ActRunCtx) FinalStageExec() {
+	ctx.finalOnce.Do(ctx.finalStageExec)
+}
+
+/**
+ * This function going to actually run final stages.
+ */
+func (ctx *ActRunCtx) finalStageExec() {
 	utils.LogDebug("FinalStageExec : starting", ctx.Act.Name)
 
+	ctx.RunCtx.mutex.Lock()
+	ctx.InFinalStage = true
+	ctx.RunCtx.mutex.Unlock()
+
+	if ctx.ActVars == nil {
+		ctx.ActVars = make(map[string]string)
+	}
+
+	/**
+	 * Expose the act outcome to on_failure and final commands.
+	 */
+	ctx.ActVars["ActExitCode"] = strconv.Itoa(ctx.ExitCode)
+	ctx.ActVars["ActFailedCmd"] = ctx.FailedCmd
+
+	/**
+	 * Outcome stages. When execution was interrupted by the user
+	 * (and no command failed) we don't run any of them.
+	 */
+	if ctx.Failed {
+		if ctx.Act.OnFailure != nil {
+			StageCmdsExec(ctx.Act.OnFailure, ctx)
+		}
+	} else if !ctx.RunCtx.IsFinishing {
+		if ctx.Act.OnSuccess != nil {
+			StageCmdsExec(ctx.Act.OnSuccess, ctx)
+		}
+	}
+
 	if ctx.Act.Final != nil {
 		utils.LogDebug("FinalStageExec : final commands found", ctx.Act.Name)
 
@@ -336,15 +770,54 @@ func (ctx *ActRunCtx) FinalStageExec() {
 		StageCmdsExec(ctx.Act.Teardown, ctx)
 	}
 
+	/**
+	 * Notify about the act outcome (interrupted acts are neither
+	 * failed nor succeeded).
+	 */
+	if ctx.Failed {
+		ctx.Notify(NotifyEventFailure)
+	} else if !ctx.RunCtx.IsFinishing {
+		ctx.Notify(NotifyEventSuccess)
+	}
+
 	utils.LogDebug("FinalStageExec : end", ctx.Act.Name)
 }
 
+/**
+ * This function going to find the script to run for an act without
+ * commands following the `acts/<name>.sh` (or `acts/<name>/main.sh`)
+ * convention. Subacts are looked up in subfolders (like
+ * `acts/foo/bar.sh` for `foo.bar`). The returned path is relative to
+ * the actfile folder.
+ */
+func (ctx *ActRunCtx) getConventionScript() string {
+	baseDir := filepath.Dir(ctx.ActFile.LocationPath)
+	namePath := filepath.Join(strings.Split(ctx.CallId, ActCallIdSeparator)...)
+
+	candidates := []string{
+		filepath.Join(ConventionScriptsDirName, fmt.Sprintf("%s.sh", namePath)),
+		filepath.Join(ConventionScriptsDirName, namePath, "main.sh"),
+	}
+
+	for _, candidate := range candidates {
+		if utils.DoFileExists(filepath.Join(baseDir, candidate)) {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
 /**
  * This function going to execute an act.
  */
 func (ctx *ActRunCtx) Exec() {
 	// Add this to call stack.
-	ctx.RunCtx.ActCtxCallStack = append(ctx.RunCtx.ActCtxCallStack, ctx)
+	ctx.RunCtx.PushActCtx(ctx)
+
+	startedAt := time.Now()
+	ctx.StartedAt = startedAt
+	ctx.Trace("act_start", utils.TraceFields{"args": ctx.Args, "line": ctx.Act.Line})
 
 	// First thing we execute all before acts not executed yet.
 	ctx.ExecBeforeAll()
@@ -444,42 +917,82 @@ func (ctx *ActRunCtx) Exec() {
 		utils.LogDebug(fmt.Sprintf("Act Exec [act=%s] : flags", ctx.Act.Name), ctx.FlagVals)
 	}
 
+	/**
+	 * If Act does not have an act stage we fallback to the script
+	 * convention (passing over act args to the script).
+	 */
+	if ctx.Act.Start == nil {
+		if script := ctx.getConventionScript(); script != "" {
+			utils.LogDebug(fmt.Sprintf("Act Exec [act=%s] : convention script", ctx.Act.Name), script)
+
+			/**
+			 * Parsed acts are shared by all calls (actfiles are cached)
+			 * so we set the stage on a copy of the act.
+			 */
+			act := *ctx.Act
+
+			act.Start = &actfile.ActExecStage{
+				Name: "start",
+				Cmds: []*actfile.Cmd{{
+					Script:  script,
+					Args:    ctx.Args,
+					Compile: ctx.Act.Compile,
+				}},
+			}
+
+			ctx.Act = &act
+		}
+	}
+
 	// If Act does not have an act stage lets return (do nothing)
 	if ctx.Act.Start == nil {
+		ctx.RunCtx.PopActCtx(ctx)
 		return
 	}
-	
+
+	// Make sure acts this act needs are in the required condition.
+	if len(ctx.Act.Needs) > 0 {
+		ctx.NeedsExec()
+	}
+
 	// First we execute before stage if present
 	if ctx.Act.Before != nil {
 		StageCmdsExec(ctx.Act.Before, ctx)
 	}
 
 	/**
-	 * Execute start commands now.
+	 * Execute start commands now (restarting them according to the
+	 * act restart policy).
 	 */
-	StageCmdsExec(ctx.Act.Start, ctx)
+	ctx.StartStageExec()
 
 	/**
-	 * Run final commands.
+	 * If execution was stopped (by the user or by a failure) we keep
+	 * this act in the call stack so its final stages going to be run
+	 * by the cleanup process.
 	 */
-	if ctx.RunCtx.State != ExecStateStopped {
-		utils.LogDebug("Act.Exec : final stage call")
-
-		/**
-		 * If we are finishing the last active act context, then we are going
-		 * to release all detached child acts we are still running.
-		 */
-		if len(ctx.RunCtx.ActCtxCallStack) == 1 {
-			ctx.RunCtx.Info.KillChildActs()
-		}
+	if !ctx.CanRun() {
+		ctx.Trace("act_end", utils.TraceFields{"stopped": true, "duration_ms": time.Since(startedAt).Milliseconds()})
+		return
+	}
 
-		// Now we run final stage.
-		ctx.FinalStageExec()
+	utils.LogDebug("Act.Exec : final stage call")
 
-		// Remove this from call stack
-		lastIdx := len(ctx.RunCtx.ActCtxCallStack) - 1
-		ctx.RunCtx.ActCtxCallStack = ctx.RunCtx.ActCtxCallStack[:lastIdx]
+	/**
+	 * If we are finishing the last active act context, then we are going
+	 * to release all detached child acts we are still running.
+	 */
+	if ctx.RunCtx.GetCallStackSize() == 1 {
+		ctx.RunCtx.Info.KillChildActs()
 	}
+
+	// Now we run final stage.
+	ctx.FinalStageExec()
+
+	ctx.Trace("act_end", utils.TraceFields{"failed": ctx.Failed, "duration_ms": time.Since(startedAt).Milliseconds()})
+
+	// Remove this from call stack
+	ctx.RunCtx.PopActCtx(ctx)
 }
 
 //############################################################
@@ -507,7 +1020,7 @@ func FindActCtx(
 	/**
 	 * Working directory is always relative to actfile location.
 	 */
-	wd := path.Dir(actFile.LocationPath)
+	wd := filepath.Dir(actFile.LocationPath)
 
 	/**
 	 * If we have a previous matched act context
@@ -522,6 +1035,8 @@ func FindActCtx(
 	 */
 	if prevCtx != nil {
 		parentVars = prevCtx.GetLocalVars()
+	} else if runCtx.ParentVars != nil {
+		parentVars = runCtx.ParentVars
 	}
 
 	if prevCtx != nil && prevCtx.Act != nil && len(prevCtx.Act.Acts) > 0 {
@@ -549,13 +1064,11 @@ func FindActCtx(
 		 * which going to match when running `act run foo-bar` for
 		 * example.
 		 */
-		match, _ := regexp.MatchString(fmt.Sprintf("^%s$", act.Name), targetActName)
-
 		/**
 		 * If actName does not match simply continue to next
 		 * defined act name in the actfile.
 		 */
-		if !match {
+		if !act.MatchName(targetActName) {
 			continue
 		}
 
@@ -576,7 +1089,7 @@ func FindActCtx(
 		// Act vars has precedence
 		ctx.ActVars["ActName"] = targetActName
 		ctx.ActVars["ActFilePath"] = ctx.ActFile.LocationPath
-		ctx.ActVars["ActFileDir"] = path.Dir(ctx.ActFile.LocationPath)
+		ctx.ActVars["ActFileDir"] = filepath.Dir(ctx.ActFile.LocationPath)
 
 		vars := ctx.MergeVars()
 
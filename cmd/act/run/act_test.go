@@ -0,0 +1,100 @@
+package run
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+/**
+ * Args with spaces, quotes and shell syntax must reach a posix shell
+ * as they are when spliced into a command line.
+ */
+func TestQuoteShellArgs(t *testing.T) {
+	args := []string{
+		"plain",
+		"with space",
+		"it's",
+		`double "quotes"`,
+		"$(echo injected)",
+		"`echo injected`",
+		"a; echo injected",
+		"back\\slash",
+		"",
+		"-n",
+		"multi\nline",
+	}
+
+	cmdLine := "printf '%s\\0' " + quoteShellArgs(args)
+	output, err := exec.Command("sh", "-c", cmdLine).Output()
+
+	if err != nil {
+		t.Fatalf("could not run %q: %v", cmdLine, err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("got args %q, want %q", got, args)
+	}
+}
+
+/**
+ * Without args we must not add any word to the command line.
+ */
+func TestQuoteShellArgsEmpty(t *testing.T) {
+	if quoted := quoteShellArgs(nil); quoted != "" {
+		t.Errorf("got %q, want empty string", quoted)
+	}
+}
+
+/**
+ * Only shell command lines get quoted args (other templates like http
+ * urls or act calls get args as they are).
+ */
+func TestGetShellLineVars(t *testing.T) {
+	ctx := &ActRunCtx{Args: []string{"a b", "c"}}
+	vars := map[string]string{"CliArgs": "a b c", "Other": "x"}
+
+	shellVars := ctx.getShellLineVars(vars)
+
+	if shellVars["CliArgs"] != "'a b' 'c'" {
+		t.Errorf("got shell CliArgs %q, want %q", shellVars["CliArgs"], "'a b' 'c'")
+	}
+
+	if shellVars["Other"] != "x" {
+		t.Errorf("got Other %q, want %q", shellVars["Other"], "x")
+	}
+
+	if vars["CliArgs"] != "a b c" {
+		t.Errorf("raw CliArgs changed to %q", vars["CliArgs"])
+	}
+}
+
+/**
+ * Environment values containing '=' (like connection strings or
+ * base64 values) must reach commands as they are.
+ */
+func TestMergeVarsEnvValueWithEquals(t *testing.T) {
+	const name = "ACT_TEST_DSN"
+	const value = "postgres://db?sslmode=disable&token=YWJj=="
+
+	os.Setenv(name, value)
+	defer os.Unsetenv(name)
+
+	ctx := &ActRunCtx{
+		Act:     &actfile.Act{Name: "test"},
+		ActFile: &actfile.ActFile{LocationPath: filepath.Join(t.TempDir(), "actfile.yml")},
+	}
+
+	ctx.RunCtx = &RunCtx{Info: &Info{Id: "test"}, ActCtx: ctx}
+
+	if got := ctx.MergeVars()[name]; got != value {
+		t.Errorf("got %s=%q, want %q", name, got, value)
+	}
+}
@@ -0,0 +1,199 @@
+/**
+ * This file implements AWS secret providers for SSM Parameter Store
+ * (`aws-ssm:/app/db/password`) and Secrets Manager
+ * (`aws-sm:prod/app#password`) on top of the AWS SDK. Credentials,
+ * region and endpoints are resolved by the SDK like any other AWS
+ * tool does (env vars, shared config/credentials profiles including
+ * assume role, SSO and credential_process, web identity, ECS
+ * container credentials and EC2 instance roles). Region, profile and
+ * a custom endpoint can be set per actfile with the `aws` block.
+ */
+
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct implements SecretProvider interface for AWS SSM
+ * Parameter Store. References are parameter names (decrypted when
+ * they are secure strings).
+ */
+type AwsSsmSecretProvider struct{}
+
+/**
+ * This struct implements SecretProvider interface for AWS Secrets
+ * Manager. References are secret ids optionally followed by `#key`
+ * to pick a key of json secrets.
+ */
+type AwsSmSecretProvider struct{}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max time we wait to load aws config and fetch a secret.
+ */
+const awsTimeout = 10 * time.Second
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to load aws config (region and credentials)
+ * taking into account the actfile `aws` block.
+ */
+func getAwsConfig(ctx context.Context, actFile *actfile.ActFile) (aws.Config, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+
+	if actFile.Aws != nil && actFile.Aws.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(actFile.Aws.Region))
+	}
+
+	if actFile.Aws != nil && actFile.Aws.Profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(actFile.Aws.Profile))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+
+	if err != nil {
+		return cfg, fmt.Errorf("could not load aws config: %v", err)
+	}
+
+	if cfg.Region == "" {
+		return cfg, fmt.Errorf("aws region not set (use aws region in actfile or AWS_REGION env var)")
+	}
+
+	return cfg, nil
+}
+
+/**
+ * This function going to get the actfile endpoint (if any) for aws
+ * clients. We set it as a client option which the SDK applies after
+ * resolving env and profile endpoints so the actfile one wins.
+ */
+func getAwsEndpoint(actFile *actfile.ActFile) *string {
+	if actFile.Aws == nil || actFile.Aws.Endpoint == "" {
+		return nil
+	}
+
+	return aws.String(actFile.Aws.Endpoint)
+}
+
+//############################################################
+// AwsSsmSecretProvider Struct Functions
+//############################################################
+
+/**
+ * This function going to fetch a parameter from SSM Parameter Store.
+ */
+func (p *AwsSsmSecretProvider) GetSecret(ref string, actFile *actfile.ActFile) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), awsTimeout)
+	defer cancel()
+
+	cfg, err := getAwsConfig(ctx, actFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	client := ssm.NewFromConfig(cfg, func(o *ssm.Options) {
+		if endpoint := getAwsEndpoint(actFile); endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+
+	output, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(ref),
+		WithDecryption: aws.Bool(true),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(output.Parameter.Value), nil
+}
+
+//############################################################
+// AwsSmSecretProvider Struct Functions
+//############################################################
+
+/**
+ * This function going to fetch a secret from Secrets Manager. When
+ * a key is given the secret must be a json object.
+ */
+func (p *AwsSmSecretProvider) GetSecret(ref string, actFile *actfile.ActFile) (string, error) {
+	parts := strings.SplitN(ref, "#", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), awsTimeout)
+	defer cancel()
+
+	cfg, err := getAwsConfig(ctx, actFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	client := secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+		if endpoint := getAwsEndpoint(actFile); endpoint != nil {
+			o.BaseEndpoint = endpoint
+		}
+	})
+
+	output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(parts[0]),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	// Binary secrets have no string value.
+	secret := aws.ToString(output.SecretString)
+
+	if output.SecretString == nil {
+		secret = string(output.SecretBinary)
+	}
+
+	if len(parts) == 1 {
+		return secret, nil
+	}
+
+	var values map[string]interface{}
+
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a json object", parts[0])
+	}
+
+	val, ok := values[parts[1]]
+
+	if !ok {
+		return "", fmt.Errorf("key %s not found in %s", parts[1], parts[0])
+	}
+
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+
+	content, _ := json.Marshal(val)
+
+	return string(content), nil
+}
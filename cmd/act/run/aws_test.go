@@ -0,0 +1,312 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+/**
+ * This function going to set env vars for the duration of a test.
+ */
+func setTestEnv(t *testing.T, env map[string]string) {
+	for name, value := range env {
+		prevValue, hadValue := os.LookupEnv(name)
+
+		os.Setenv(name, value)
+
+		t.Cleanup(func(name string) func() {
+			return func() {
+				if hadValue {
+					os.Setenv(name, prevValue)
+				} else {
+					os.Unsetenv(name)
+				}
+			}
+		}(name))
+	}
+}
+
+/**
+ * This function going to isolate aws settings of a test (so user
+ * credentials and config files are never used) and write the given
+ * shared config file.
+ */
+func setupTestAws(t *testing.T, config string, env map[string]string) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+
+	ioutil.WriteFile(configPath, []byte(config), 0644)
+
+	setTestEnv(t, map[string]string{
+		"AWS_CONFIG_FILE":             configPath,
+		"AWS_SHARED_CREDENTIALS_FILE": filepath.Join(dir, "credentials"),
+		"AWS_ACCESS_KEY_ID":           "",
+		"AWS_SECRET_ACCESS_KEY":       "",
+		"AWS_SESSION_TOKEN":           "",
+		"AWS_PROFILE":                 "",
+		"AWS_REGION":                  "",
+		"AWS_DEFAULT_REGION":          "",
+		"AWS_ENDPOINT_URL":            "",
+		"AWS_EC2_METADATA_DISABLED":   "true",
+	})
+
+	setTestEnv(t, env)
+}
+
+/**
+ * This function going to start a fake aws api. Handler gets the
+ * action (X-Amz-Target header of json apis) with the request body and
+ * returns the response (strings are sent as is) or nil for a not
+ * found error. Requests are sent to the requests channel.
+ */
+func startTestAwsServer(t *testing.T, handler func(target string, body []byte) interface{}, requests chan *http.Request) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		requests <- req
+
+		resp := handler(req.Header.Get("X-Amz-Target"), body)
+
+		if resp == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+			return
+		}
+
+		if str, ok := resp.(string); ok {
+			w.Write([]byte(str))
+			return
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+/**
+ * This function going to answer SSM GetParameter with the given
+ * parameters.
+ */
+func getTestAwsParameter(params map[string]string) func(string, []byte) interface{} {
+	return func(target string, body []byte) interface{} {
+		var input struct {
+			Name string
+		}
+
+		json.Unmarshal(body, &input)
+
+		value, ok := params[input.Name]
+
+		if target != "AmazonSSM.GetParameter" || !ok {
+			return nil
+		}
+
+		return map[string]interface{}{
+			"Parameter": map[string]interface{}{"Name": input.Name, "Value": value},
+		}
+	}
+}
+
+/**
+ * SSM parameters are fetched decrypted with signed requests sent to
+ * the actfile endpoint.
+ */
+func TestAwsSsmSecretProvider(t *testing.T) {
+	setupTestAws(t, "", map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDTEST",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	})
+
+	requests := make(chan *http.Request, 10)
+	server := startTestAwsServer(t, getTestAwsParameter(map[string]string{"/app/db/password": "s3cr3t"}), requests)
+
+	actFile := &actfile.ActFile{Aws: &actfile.ActFileAws{Region: "eu-west-1", Endpoint: server.URL}}
+
+	provider := &AwsSsmSecretProvider{}
+	value, err := provider.GetSecret("/app/db/password", actFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value != "s3cr3t" {
+		t.Errorf("got value %q, want %q", value, "s3cr3t")
+	}
+
+	req := <-requests
+
+	var input struct {
+		Name           string
+		WithDecryption bool
+	}
+
+	json.NewDecoder(req.Body).Decode(&input)
+
+	if input.Name != "/app/db/password" || !input.WithDecryption {
+		t.Errorf("got input %+v, want decrypted /app/db/password", input)
+	}
+
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "Credential=AKIDTEST/") || !strings.Contains(auth, "/eu-west-1/ssm/") {
+		t.Errorf("got authorization %q, want it signed with test credentials for eu-west-1", auth)
+	}
+
+	if _, err := provider.GetSecret("/app/missing", actFile); err == nil {
+		t.Error("expected error for missing parameter")
+	}
+}
+
+/**
+ * Secrets Manager secrets are returned whole or by json key.
+ */
+func TestAwsSmSecretProvider(t *testing.T) {
+	setupTestAws(t, "", map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDTEST",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+		"AWS_REGION":            "us-east-1",
+	})
+
+	requests := make(chan *http.Request, 10)
+	server := startTestAwsServer(t, func(target string, body []byte) interface{} {
+		var input struct {
+			SecretId string
+		}
+
+		json.Unmarshal(body, &input)
+
+		secrets := map[string]string{
+			"prod/app":   `{"password":"s3cr3t","port":5432}`,
+			"prod/plain": "s3cr3t",
+		}
+
+		if target != "secretsmanager.GetSecretValue" || secrets[input.SecretId] == "" {
+			return nil
+		}
+
+		return map[string]interface{}{"Name": input.SecretId, "SecretString": secrets[input.SecretId]}
+	}, requests)
+
+	actFile := &actfile.ActFile{Aws: &actfile.ActFileAws{Endpoint: server.URL}}
+	provider := &AwsSmSecretProvider{}
+
+	cases := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{"prod/app", `{"password":"s3cr3t","port":5432}`, ""},
+		{"prod/app#password", "s3cr3t", ""},
+		{"prod/app#port", "5432", ""},
+		{"prod/app#user", "", "key user not found in prod/app"},
+		{"prod/plain#password", "", "secret prod/plain is not a json object"},
+		{"prod/missing", "", "ResourceNotFoundException"},
+	}
+
+	for _, c := range cases {
+		value, err := provider.GetSecret(c.ref, actFile)
+
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: got error %v, want %q", c.ref, err, c.wantErr)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", c.ref, err)
+		} else if value != c.want {
+			t.Errorf("%s: got value %q, want %q", c.ref, value, c.want)
+		}
+	}
+}
+
+/**
+ * Profiles assuming a role get temporary credentials from STS. Env
+ * endpoints are used since the actfile one only applies to SSM and
+ * Secrets Manager clients.
+ */
+func TestAwsAssumeRoleProfile(t *testing.T) {
+	requests := make(chan *http.Request, 10)
+	server := startTestAwsServer(t, func(target string, body []byte) interface{} {
+		if target != "" {
+			return getTestAwsParameter(map[string]string{"/app/db/password": "s3cr3t"})(target, body)
+		}
+
+		return `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>rolesecret</SecretAccessKey>
+      <SessionToken>roletoken</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/deploy/act</Arn>
+      <AssumedRoleId>AROATEST:act</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+	}, requests)
+
+	setupTestAws(t, "[profile base]\naws_access_key_id = AKIDBASE\naws_secret_access_key = basesecret\n\n[profile deploy]\nregion = us-west-2\nrole_arn = arn:aws:iam::123456789012:role/deploy\nsource_profile = base\n", map[string]string{
+		"AWS_ENDPOINT_URL_STS": server.URL,
+		"AWS_ENDPOINT_URL_SSM": server.URL,
+	})
+
+	actFile := &actfile.ActFile{Aws: &actfile.ActFileAws{Profile: "deploy"}}
+
+	if _, err := (&AwsSsmSecretProvider{}).GetSecret("/app/db/password", actFile); err != nil {
+		t.Fatal(err)
+	}
+
+	close(requests)
+
+	var ssmReq *http.Request
+
+	for req := range requests {
+		if req.Header.Get("X-Amz-Target") != "" {
+			ssmReq = req
+		}
+	}
+
+	if ssmReq == nil {
+		t.Fatal("no ssm request")
+	}
+
+	if auth := ssmReq.Header.Get("Authorization"); !strings.Contains(auth, "Credential=ASIAROLE/") {
+		t.Errorf("got authorization %q, want it signed with role credentials", auth)
+	}
+
+	if token := ssmReq.Header.Get("X-Amz-Security-Token"); token != "roletoken" {
+		t.Errorf("got security token %q, want %q", token, "roletoken")
+	}
+}
+
+/**
+ * We fail clearly when no region is set anywhere.
+ */
+func TestAwsRegionNotSet(t *testing.T) {
+	setupTestAws(t, "", map[string]string{
+		"AWS_ACCESS_KEY_ID":     "AKIDTEST",
+		"AWS_SECRET_ACCESS_KEY": "secret",
+	})
+
+	_, err := (&AwsSsmSecretProvider{}).GetSecret("/app/db/password", &actfile.ActFile{})
+
+	if err == nil || !strings.Contains(err.Error(), "aws region not set") {
+		t.Errorf("got error %v, want region not set error", err)
+	}
+}
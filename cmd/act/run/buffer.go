@@ -0,0 +1,92 @@
+/**
+ * This file implements buffering of command output so we can print
+ * it only when the command fails (quiet success mode). Writes to
+ * stdout and stderr are buffered together so we can replay them in
+ * the same order they happened.
+ */
+
+package run
+
+import (
+	"io"
+	"sync"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a chunk of output and where it should
+ * be written.
+ */
+type outputChunk struct {
+	target io.Writer
+	data   []byte
+}
+
+/**
+ * This struct going to hold buffered output of a command.
+ */
+type outputBuffer struct {
+	chunks []*outputChunk
+	mutex  sync.Mutex
+}
+
+/**
+ * This struct going to buffer writes to a specific target.
+ */
+type bufferedWriter struct {
+	buf    *outputBuffer
+	target io.Writer
+}
+
+//############################################################
+// bufferedWriter Struct Functions
+//############################################################
+
+/**
+ * This function implements io.Writer interface.
+ */
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	w.buf.mutex.Lock()
+	defer w.buf.mutex.Unlock()
+
+	// Writers can reuse p so we need to copy it.
+	data := make([]byte, len(p))
+	copy(data, p)
+
+	w.buf.chunks = append(w.buf.chunks, &outputChunk{target: w.target, data: data})
+
+	return len(p), nil
+}
+
+//############################################################
+// outputBuffer Struct Functions
+//############################################################
+
+/**
+ * This function going to create a writer which buffers writes to
+ * a target.
+ */
+func (b *outputBuffer) Writer(target io.Writer) io.Writer {
+	if target == nil {
+		return nil
+	}
+
+	return &bufferedWriter{buf: b, target: target}
+}
+
+/**
+ * This function going to write all buffered output to its targets.
+ */
+func (b *outputBuffer) Replay() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, chunk := range b.chunks {
+		chunk.target.Write(chunk.data)
+	}
+
+	b.chunks = nil
+}
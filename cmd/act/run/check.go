@@ -0,0 +1,516 @@
+/**
+ * This file implements act checks which are used to verify if an
+ * act is in success state (useful for long running acts). A check
+ * is composed by shell commands and declarative probes (http, tcp,
+ * file, process and log) combined by a readiness expression.
+ */
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+//############################################################
+// Constants
+//############################################################
+
+/**
+ * This is the default max time a probe can take to run.
+ */
+const DefaultProbeTimeout = 5 * time.Second
+
+/**
+ * This is the default interval between act checks.
+ */
+const DefaultCheckInterval = 2 * time.Second
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check if a shell command succeeds.
+ */
+func cmdProbeExec(cmdLine string, timeout time.Duration, ctx *ActRunCtx) error {
+	vars := ctx.MergeVars()
+
+	shell := getShell(nil, ctx)
+	shBin, shBinArgs := getShellExecArgs(shell, getShellArgs(shell, utils.CompileTemplate(cmdLine, ctx.getShellLineVars(vars)), nil, nil))
+	shCmd := exec.Command(shBin, shBinArgs...)
+	shCmd.Dir = filepath.Dir(ctx.ActFile.LocationPath)
+	shCmd.Env = ctx.VarsToEnvVars(vars)
+	shCmd.SysProcAttr = procgroup.NewSysProcAttr()
+
+	if err := shCmd.Start(); err != nil {
+		return err
+	}
+
+	procgroup.Track(shCmd)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- shCmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		/**
+		 * Like we do with commands we kill the whole process group so
+		 * processes spawned by the shell don't keep running.
+		 */
+		if err := procgroup.Signal(shCmd.Process.Pid, syscall.SIGKILL); err != nil {
+			utils.LogDebug(fmt.Sprintf("could not kill probe with process pgid=%d", shCmd.Process.Pid), err)
+		}
+
+		<-done
+
+		return fmt.Errorf("probe timed out after %s", timeout)
+	}
+}
+
+/**
+ * This function going to check if an http endpoint answers with
+ * the expected status.
+ */
+func httpProbeExec(url string, status int, timeout time.Duration) error {
+	client := http.Client{Timeout: timeout}
+
+	resp, err := client.Get(url)
+
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if status > 0 && resp.StatusCode != status {
+		return fmt.Errorf("expected status %d but got %d", status, resp.StatusCode)
+	}
+
+	if status == 0 && resp.StatusCode >= 400 {
+		return fmt.Errorf("got status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+/**
+ * This function going to check if an address accepts tcp
+ * connections.
+ */
+func tcpProbeExec(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+/**
+ * This function going to check if a process with a given binary name
+ * is alive.
+ */
+func processNameProbeExec(name string) error {
+	for pid := range procgroup.List() {
+		if procgroup.GetBinName(pid) == name {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no process %s is running", name)
+}
+
+/**
+ * This function going to check if a process is alive. The process
+ * can be specified by its pid, by the path of a pid file or by its
+ * name.
+ */
+func processProbeExec(process string, baseDir string) error {
+	pidStr := process
+
+	if _, err := strconv.Atoi(process); err != nil {
+		pidFilePath := utils.ResolvePath(baseDir, process)
+
+		// Anything which doesn't look like a pid file path is a name.
+		if !strings.ContainsAny(process, `/\`) && !utils.DoFileExists(pidFilePath) {
+			return processNameProbeExec(process)
+		}
+
+		content, err := ioutil.ReadFile(pidFilePath)
+
+		if err != nil {
+			return err
+		}
+
+		pidStr = strings.TrimSpace(string(content))
+	}
+
+	pid, err := strconv.Atoi(pidStr)
+
+	if err != nil {
+		return fmt.Errorf("invalid pid %s", pidStr)
+	}
+
+	if !isProcessRunning(pid) {
+		return fmt.Errorf("process %d is not running", pid)
+	}
+
+	return nil
+}
+
+/**
+ * This function going to check if a log file matches a regex.
+ */
+func logProbeExec(pattern string, logFilePath string) error {
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(logFilePath)
+
+	if err != nil {
+		return err
+	}
+
+	if !re.Match(content) {
+		return fmt.Errorf("log does not match '%s'", pattern)
+	}
+
+	return nil
+}
+
+//############################################################
+// Readiness Expression
+//############################################################
+
+/**
+ * This struct going to evaluate a readiness expression like
+ * `api && (db || !cache)` against probe results.
+ */
+type readyExprParser struct {
+	tokens  []string
+	pos     int
+	results map[string]bool
+}
+
+/**
+ * This function going to split the expression into tokens.
+ */
+func tokenizeReadyExpr(expr string) []string {
+	re := regexp.MustCompile(`&&|\|\||!|\(|\)|[^\s&|!()]+`)
+
+	return re.FindAllString(expr, -1)
+}
+
+/**
+ * This function get the current token without consuming it.
+ */
+func (p *readyExprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+
+	return ""
+}
+
+/**
+ * This function consume the current token.
+ */
+func (p *readyExprParser) next() string {
+	token := p.peek()
+	p.pos++
+
+	return token
+}
+
+/**
+ * This function going to parse `a || b` expressions.
+ */
+func (p *readyExprParser) parseOr() (bool, error) {
+	val, err := p.parseAnd()
+
+	for err == nil && p.peek() == "||" {
+		p.next()
+
+		var right bool
+		right, err = p.parseAnd()
+		val = val || right
+	}
+
+	return val, err
+}
+
+/**
+ * This function going to parse `a && b` expressions.
+ */
+func (p *readyExprParser) parseAnd() (bool, error) {
+	val, err := p.parseNot()
+
+	for err == nil && p.peek() == "&&" {
+		p.next()
+
+		var right bool
+		right, err = p.parseNot()
+		val = val && right
+	}
+
+	return val, err
+}
+
+/**
+ * This function going to parse negations, parenthesis and probe
+ * names.
+ */
+func (p *readyExprParser) parseNot() (bool, error) {
+	token := p.next()
+
+	switch token {
+	case "!":
+		val, err := p.parseNot()
+		return !val, err
+	case "(":
+		val, err := p.parseOr()
+
+		if err == nil && p.next() != ")" {
+			err = errors.New("missing closing parenthesis")
+		}
+
+		return val, err
+	case "", ")", "&&", "||":
+		return false, fmt.Errorf("unexpected token '%s'", token)
+	}
+
+	val, present := p.results[token]
+
+	if !present {
+		return false, fmt.Errorf("unknown probe %s", token)
+	}
+
+	return val, nil
+}
+
+/**
+ * This function going to evaluate a readiness expression.
+ */
+func evalReadyExpr(expr string, results map[string]bool) (bool, error) {
+	parser := &readyExprParser{
+		tokens:  tokenizeReadyExpr(expr),
+		results: results,
+	}
+
+	val, err := parser.parseOr()
+
+	if err == nil && parser.pos < len(parser.tokens) {
+		err = fmt.Errorf("unexpected token '%s'", parser.peek())
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("invalid ready expression '%s' : %v", expr, err)
+	}
+
+	return val, nil
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to run a single probe returning an error if
+ * the probe didn't pass.
+ */
+func ProbeExec(probe *actfile.ActCheckProbe, ctx *ActRunCtx) error {
+	timeout := probe.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultProbeTimeout
+	}
+
+	baseDir := filepath.Dir(ctx.ActFile.LocationPath)
+
+	switch {
+	case probe.Cmd != "":
+		return cmdProbeExec(probe.Cmd, timeout, ctx)
+	case probe.Http != "":
+		return httpProbeExec(probe.Http, probe.Status, timeout)
+	case probe.Tcp != "":
+		return tcpProbeExec(probe.Tcp, timeout)
+	case probe.File != "":
+		if !utils.DoFileExists(utils.ResolvePath(baseDir, probe.File)) {
+			return fmt.Errorf("file %s does not exist", probe.File)
+		}
+
+		return nil
+	case probe.Process != "":
+		return processProbeExec(probe.Process, baseDir)
+	case probe.Log != "":
+		logFilePath := ctx.RunCtx.Info.GetLogFilePath()
+
+		if probe.LogFile != "" {
+			logFilePath = utils.ResolvePath(baseDir, probe.LogFile)
+		}
+
+		return logProbeExec(probe.Log, logFilePath)
+	}
+
+	return errors.New("probe has no type")
+}
+
+/**
+ * This function going to run act checks. It returns true if the
+ * act is in success state (i.e., ready).
+ */
+func (ctx *ActRunCtx) CheckExec() (bool, error) {
+	check := ctx.Act.Check
+
+	if check == nil {
+		return true, nil
+	}
+
+	/**
+	 * All check commands must succeed.
+	 */
+	for _, cmd := range check.Cmds {
+		if err := cmdProbeExec(cmd.Cmd, DefaultProbeTimeout, ctx); err != nil {
+			utils.LogDebug(fmt.Sprintf("CheckExec [act=%s] : command failed", ctx.Act.Name), cmd.Cmd, err)
+			return false, nil
+		}
+	}
+
+	/**
+	 * Run probes. Unnamed probes must always pass while named ones
+	 * are combined by the ready expression (if provided).
+	 */
+	results := make(map[string]bool)
+	allPassed := true
+
+	for _, probe := range check.Probes {
+		err := ProbeExec(probe, ctx)
+		passed := err == nil
+
+		utils.LogDebug(fmt.Sprintf("CheckExec [act=%s] : probe %s passed=%t", ctx.Act.Name, probe.Name, passed), err)
+
+		if probe.Name != "" {
+			results[probe.Name] = passed
+		}
+
+		if !passed && (probe.Name == "" || check.Ready == "") {
+			allPassed = false
+		}
+	}
+
+	if !allPassed {
+		return false, nil
+	}
+
+	if check.Ready != "" {
+		return evalReadyExpr(check.Ready, results)
+	}
+
+	return true, nil
+}
+
+/**
+ * This function going to run act checks periodically while the start
+ * stage is running (i.e., health checks). Act health transitions
+ * (healthy/unhealthy) are recorded in run info. When checks first
+ * pass we flag the act as ready and run the after stage exactly once.
+ * Watching ends when the done channel gets closed (i.e., when start
+ * stage finishes).
+ */
+func (ctx *ActRunCtx) WatchHealth(done chan bool) {
+	interval := ctx.Act.Check.Interval
+
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	info := ctx.RunCtx.Info
+
+	// Flag act as starting until checks pass.
+	info.AddStartingAct(ctx.CallId)
+	defer info.RmStartingAct(ctx.CallId)
+
+	isReady := false
+
+	for ctx.CanRun() {
+		healthy, err := ctx.CheckExec()
+
+		if err != nil {
+			utils.LogError(fmt.Sprintf("act %s check failed", ctx.CallId), err)
+			return
+		}
+
+		/**
+		 * Before first passing the act is just starting so we only
+		 * track health transitions after that.
+		 */
+		if healthy && !isReady {
+			utils.LogDebug(fmt.Sprintf("WatchHealth [act=%s] : ready", ctx.Act.Name))
+
+			isReady = true
+
+			info.RmStartingAct(ctx.CallId)
+			info.AddReadyAct(ctx.CallId)
+			info.SetActHealth(ctx.CallId, HealthHealthy)
+			ctx.EmitEvent(EventHealthChanged, map[string]interface{}{"health": HealthHealthy})
+
+			/**
+			 * After stage runs concurrently with the start stage so we
+			 * run it in its own act context.
+			 */
+			if ctx.Act.After != nil {
+				StageCmdsExec(ctx.Act.After, ctx.Fork())
+			}
+		} else if isReady {
+			health := HealthUnhealthy
+
+			if healthy {
+				health = HealthHealthy
+			}
+
+			if info.SetActHealth(ctx.CallId, health) {
+				ctx.EmitEvent(EventHealthChanged, map[string]interface{}{"health": health})
+
+				if healthy {
+					utils.LogInfo(fmt.Sprintf("act %s is healthy again", ctx.CallId))
+					ctx.Notify(NotifyEventHealthy)
+				} else {
+					utils.LogWarn(fmt.Sprintf("act %s is unhealthy", ctx.CallId))
+					ctx.Notify(NotifyEventUnhealthy)
+				}
+			}
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package run
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+/**
+ * Probes timing out get their whole process group killed (not only
+ * the shell) and return right away.
+ */
+func TestCmdProbeExecTimeout(t *testing.T) {
+	actCtx := newTestRunningCtx(t)
+	pidFilePath := filepath.Join(t.TempDir(), "pgid")
+
+	startedAt := time.Now()
+	err := cmdProbeExec("echo $$ > "+pidFilePath+"; sleep 10 & wait", 200*time.Millisecond, actCtx)
+
+	if err == nil {
+		t.Fatal("probe should time out")
+	}
+
+	if elapsed := time.Since(startedAt); elapsed > 5*time.Second {
+		t.Errorf("probe took %s to time out", elapsed)
+	}
+
+	content, err := ioutil.ReadFile(pidFilePath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgid, _ := strconv.Atoi(strings.TrimSpace(string(content)))
+
+	// Give the system a moment to reap killed processes.
+	for i := 0; i < 100 && procgroup.IsGroupRunning(pgid); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if procgroup.IsGroupRunning(pgid) {
+		t.Errorf("process group %d is still running", pgid)
+	}
+}
@@ -1,21 +1,39 @@
 package run
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
 	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
 	"github.com/teris-io/shortid"
 )
 
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the shell name users set to run commands with the posix
+ * shell interpreter embedded in act (so bash is not required).
+ */
+const BuiltinShell = "builtin"
+
 //############################################################
 // Internal Functions
 //############################################################
@@ -32,12 +50,16 @@ func getLogMode(_ *actfile.Cmd, ctx *ActRunCtx) string {
 	 */
 	logMode := "raw"
 
+	if config.Get().Log != "" {
+		logMode = config.Get().Log
+	}
+
 	if ctx.ActFile.Log != "" {
 		logMode = ctx.ActFile.Log
 	}
 
-	if ctx.Act.Log != "" {
-		logMode = ctx.Act.Log
+	if actLog := ctx.GetActLog(); actLog != "" {
+		logMode = actLog
 	}
 
 	if ctx.RunCtx.Log != "" {
@@ -47,21 +69,462 @@ func getLogMode(_ *actfile.Cmd, ctx *ActRunCtx) string {
 	return logMode
 }
 
+/**
+ * This function going to check if command output is going to be
+ * logged.
+ */
+func isCmdQuiet(cmd *actfile.Cmd, ctx *ActRunCtx) bool {
+	return ctx.RunCtx.Quiet || ctx.Act.Quiet || ctx.CurrentStage.Quiet || cmd.Quiet
+}
+
+/**
+ * This function going to check if command going to run in a
+ * pseudo-terminal. When running in raw log mode with act output not
+ * attached to a terminal we don't allocate one so the command behaves
+ * as it would without act (like when piping output to a file).
+ */
+func isCmdTty(cmd *actfile.Cmd, ctx *ActRunCtx) bool {
+	if !cmd.Tty && !ctx.Act.Tty {
+		return false
+	}
+
+	if !ctx.RunCtx.IsDaemon && getLogMode(cmd, ctx) == "raw" {
+		return utils.IsTerminal(int(os.Stdout.Fd()))
+	}
+
+	return true
+}
+
+/**
+ * This function going to get writers for output of a command which
+ * doesn't run through cmdProcExec (stdout and stderr go straight to
+ * the terminal in raw log mode and to log writers otherwise).
+ */
+func getCmdOutputs(cmd *actfile.Cmd, ctx *ActRunCtx) (io.Writer, io.Writer) {
+	if isCmdQuiet(cmd, ctx) {
+		return nil, nil
+	}
+
+	if !ctx.RunCtx.IsDaemon && getLogMode(cmd, ctx) == "raw" {
+		return ctx.RunCtx.MaskWriter(os.Stdout), ctx.RunCtx.MaskWriter(os.Stderr)
+	}
+
+	l := NewLogWriter(ctx)
+	l.LogToConsole = true
+
+	errL := NewStderrLogWriter(ctx)
+	errL.LogToConsole = true
+
+	return l, errL
+}
+
+/**
+ * This function going to print a separator before the output of a
+ * command in raw log mode so users can distinguish which command
+ * produced which lines. We print separators only when the stage has
+ * more than one command and separators were not disabled.
+ */
+func printCmdSeparator(cmd *actfile.Cmd, ctx *ActRunCtx, idx int, total int) {
+	if total < 2 || cmd.Act != "" || cmd.Loop != nil {
+		return
+	}
+
+	if ctx.RunCtx.IsDaemon || ctx.RunCtx.QuietSuccess || isCmdQuiet(cmd, ctx) || getLogMode(cmd, ctx) != "raw" {
+		return
+	}
+
+	enabled := !ctx.RunCtx.NoSeparators
+
+	if enabled && ctx.Act.Separators != nil {
+		enabled = *ctx.Act.Separators
+	} else if enabled && ctx.ActFile.Separators != nil {
+		enabled = *ctx.ActFile.Separators
+	}
+
+	if !enabled {
+		return
+	}
+
+	fmt.Println(utils.Color.Gray(12, fmt.Sprintf("── %s [%d/%d] ──", ctx.CallId, idx+1, total)))
+}
+
+/**
+ * This function going to get the exit code from the error returned
+ * by a finished command.
+ */
+func getExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if exiterr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
+		}
+	}
+
+	if statusErr, ok := err.(*ShellStatusError); ok {
+		return statusErr.ExitCode
+	}
+
+	return -1
+}
+
+/**
+ * This function going to check command assertions against the
+ * outcome of a finished command. It returns the error the command
+ * should fail with (if any).
+ */
+func checkCmdExpect(expect *actfile.CmdExpect, err error, stdout string, vars map[string]string) error {
+	/**
+	 * When user expects a specific exit code then a command exiting
+	 * with that code is a success even if the code is not zero.
+	 */
+	if expect.ExitCode != nil {
+		exitCode := getExitCode(err)
+
+		if exitCode != *expect.ExitCode {
+			return fmt.Errorf("expected exit code %d but got %d", *expect.ExitCode, exitCode)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if expect.StdoutContains != "" {
+		text := utils.CompileTemplate(expect.StdoutContains, vars)
+
+		if !strings.Contains(stdout, text) {
+			return fmt.Errorf("expected stdout to contain '%s'", text)
+		}
+	}
+
+	if expect.StdoutRegex != "" {
+		re, reErr := regexp.Compile(utils.CompileTemplate(expect.StdoutRegex, vars))
+
+		if reErr != nil {
+			return fmt.Errorf("invalid stdout regex: %v", reErr)
+		}
+
+		if !re.MatchString(stdout) {
+			return fmt.Errorf("expected stdout to match '%s'", re.String())
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to get the provenance of a command in the
+ * format `actfile.yml:line` (with actfile path relative to working
+ * dir) so users can trace a command back to its yaml origin.
+ */
+func getCmdSource(cmd *actfile.Cmd, ctx *ActRunCtx) string {
+	filePath := ctx.ActFile.LocationPath
+
+	if relPath, err := filepath.Rel(utils.GetWd(), filePath); err == nil {
+		filePath = relPath
+	}
+
+	if cmd.Line == 0 {
+		return filePath
+	}
+
+	return fmt.Sprintf("%s:%d", filePath, cmd.Line)
+}
+
+/**
+ * This function get the shell to use to run a command in the right
+ * precedence order (command, act, actfile and then user config).
+ */
+func getShell(cmd *actfile.Cmd, ctx *ActRunCtx) string {
+	shell := utils.GetDefaultShell()
+
+	if config.Get().Shell != "" {
+		shell = config.Get().Shell
+	}
+
+	if ctx.ActFile.Shell != "" {
+		shell = ctx.ActFile.Shell
+	}
+
+	if ctx.Act.Shell != "" {
+		shell = ctx.Act.Shell
+	}
+
+	if cmd != nil && cmd.Shell != "" {
+		shell = cmd.Shell
+	}
+
+	return shell
+}
+
+/**
+ * This function going to get the dir to run a command in (commands
+ * run in the actfile dir unless they set a dir relative to it).
+ */
+func getCmdDir(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) string {
+	baseDir := filepath.Dir(ctx.ActFile.LocationPath)
+
+	if cmd.Dir == "" {
+		return baseDir
+	}
+
+	return utils.ResolvePath(baseDir, utils.CompileTemplate(cmd.Dir, vars))
+}
+
+/**
+ * This function going to get vars of a command (act vars plus the
+ * command own env vars).
+ */
+func getCmdVars(cmd *actfile.Cmd, vars map[string]string) map[string]string {
+	if len(cmd.Env) == 0 {
+		return vars
+	}
+
+	cmdVars := make(map[string]string)
+
+	for key, val := range vars {
+		cmdVars[key] = val
+	}
+
+	for key, val := range cmd.Env {
+		cmdVars[key] = utils.CompileTemplate(val, vars)
+	}
+
+	return cmdVars
+}
+
+/**
+ * This function going to get shell options of a command in the right
+ * precedence order (command, act and then actfile).
+ */
+func getShellOpts(cmd *actfile.Cmd, ctx *ActRunCtx) []string {
+	if cmd != nil && len(cmd.ShellOpts) > 0 {
+		return cmd.ShellOpts
+	}
+
+	if len(ctx.Act.ShellOpts) > 0 {
+		return ctx.Act.ShellOpts
+	}
+
+	return ctx.ActFile.ShellOpts
+}
+
+/**
+ * This function going to get user, umask and nice level of command
+ * processes (command values have precedence over act values).
+ */
+func getCmdProcAttrs(cmd *actfile.Cmd, ctx *ActRunCtx) (string, string, *int) {
+	user, umask, nice := ctx.Act.User, ctx.Act.Umask, ctx.Act.Nice
+
+	if cmd.User != "" {
+		user = cmd.User
+	}
+
+	if cmd.Umask != "" {
+		umask = cmd.Umask
+	}
+
+	if cmd.Nice != nil {
+		nice = cmd.Nice
+	}
+
+	return user, umask, nice
+}
+
+/**
+ * This function going to get the kind of a shell (powershell, cmd,
+ * builtin or sh for all posix shells like bash and zsh) which tells
+ * how to pass commands to it.
+ */
+func getShellKind(shell string) string {
+	if shell == BuiltinShell {
+		return BuiltinShell
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(shell), filepath.Ext(shell)))
+
+	switch name {
+	case "powershell", "pwsh":
+		return "powershell"
+	case "cmd":
+		return "cmd"
+	}
+
+	return "sh"
+}
+
+/**
+ * This function going to get the args to pass to posix shells (and
+ * to the builtin shell) so they run with shell options.
+ */
+func getShellOptArgs(opts []string) []string {
+	var args []string
+
+	for _, opt := range opts {
+		args = append(args, "-o", opt)
+	}
+
+	return args
+}
+
+/**
+ * This function going to get powershell statements equivalent to
+ * shell options (options with no equivalent are ignored).
+ */
+func getPowershellOptsPrelude(opts []string) string {
+	var prelude string
+
+	for _, opt := range opts {
+		switch opt {
+		case "errexit":
+			prelude += "$ErrorActionPreference = 'Stop'; "
+		case "nounset":
+			prelude += "Set-StrictMode -Version Latest; "
+		}
+	}
+
+	return prelude
+}
+
+/**
+ * This function going to get the args to pass to a shell so it runs
+ * a command line with shell options. Args are passed as separate
+ * words after `--` so the command line gets them as positional
+ * params (`$@`) without any quoting issue. Powershell and cmd have
+ * no positional params and cmd has no shell options so they are
+ * ignored.
+ */
+func getShellArgs(shell string, cmdLine string, args []string, opts []string) []string {
+	switch getShellKind(shell) {
+	case "powershell":
+		return []string{"-NoProfile", "-Command", getPowershellOptsPrelude(opts) + cmdLine}
+	case "cmd":
+		return []string{"/C", cmdLine}
+	}
+
+	return append(append(getShellOptArgs(opts), "-c", cmdLine, "--"), args...)
+}
+
+/**
+ * This function going to get the args to pass to a shell so it runs
+ * a script file with shell options. Powershell and cmd can't take
+ * options for script files so they are ignored.
+ */
+func getShellScriptArgs(shell string, script string, args []string, opts []string) []string {
+	switch getShellKind(shell) {
+	case "powershell":
+		return append([]string{"-NoProfile", "-File", script}, args...)
+	case "cmd":
+		return append([]string{"/C", script}, args...)
+	}
+
+	return append(append(getShellOptArgs(opts), script), args...)
+}
+
+/**
+ * This function going to get a comment line telling where a command
+ * of a script we generate comes from (like `# from actfile.yml:12`).
+ * Batch scripts on windows use REM for comments.
+ */
+func getSourceComment(source string, ext string) string {
+	source = strings.ReplaceAll(source, "\n", " ")
+
+	if ext := strings.ToLower(ext); ext == ".bat" || ext == ".cmd" {
+		return fmt.Sprintf("REM from %s\r\n", source)
+	}
+
+	return fmt.Sprintf("# from %s\n", source)
+}
+
+/**
+ * This function going to compile the whole content of a script file
+ * as a template and write it to a temporary file (keeping the script
+ * extension so shells like powershell accept it). The compiled script
+ * tells where it comes from in a comment (after the shebang line if
+ * any). It returns the path of the compiled script which caller
+ * should remove.
+ */
+func compileScript(scriptPath string, vars map[string]string, source string) (string, error) {
+	content, err := ioutil.ReadFile(scriptPath)
+
+	if err != nil {
+		return "", err
+	}
+
+	compiled := utils.CompileTemplate(string(content), vars)
+	comment := getSourceComment(source, filepath.Ext(scriptPath))
+
+	if strings.HasPrefix(compiled, "#!") {
+		if idx := strings.Index(compiled, "\n"); idx >= 0 {
+			compiled = compiled[:idx+1] + comment + compiled[idx+1:]
+		} else {
+			compiled = compiled + "\n" + comment
+		}
+	} else {
+		compiled = comment + compiled
+	}
+
+	file, err := ioutil.TempFile("", fmt.Sprintf("act-*%s", filepath.Ext(scriptPath)))
+
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	if _, err := file.WriteString(compiled); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+/**
+ * This function going to get the binary to spawn and the args to
+ * pass to it so a shell runs with the provided shell args. The
+ * builtin shell runs as an act subprocess (`act sh`) so it gets its
+ * own process group like any other shell.
+ */
+func getShellExecArgs(shell string, shArgs []string) (string, []string) {
+	if getShellKind(shell) == BuiltinShell {
+		return utils.GetActBin(), append([]string{"sh"}, shArgs...)
+	}
+
+	return shell, shArgs
+}
+
 /**
  * This function going to run an act in detached mode. In this
  * mode the act going to be run as separate act process which
- * can be managed independently (stopped/logged).
+ * can be managed independently (stopped/logged). It returns the
+ * run id of the detached act.
  */
-func actDetachExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
+func actDetachExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) string {
+	/**
+	 * Detached acts are managed through persisted run info so we
+	 * can't run them in memory-only mode.
+	 */
+	if memoryOnly {
+		utils.FatalError("cannot run detached act in memory-only mode")
+
+		if wg != nil {
+			wg.Done()
+		}
+
+		return ""
+	}
+
 	actFilePath := ctx.ActFile.LocationPath
 
 	if cmd.From != "" {
-		actFilePath = utils.ResolvePath(path.Dir(ctx.ActFile.LocationPath), cmd.From)
+		actFilePath = utils.ResolvePath(filepath.Dir(ctx.ActFile.LocationPath), cmd.From)
 	}
 
 	childId, _ := shortid.Generate()
 
-	utils.LogDebug("actDetachExec", childId)
+	utils.LogDebug(fmt.Sprintf("actDetachExec [source=%s]", getCmdSource(cmd, ctx)), childId)
 
 	// Set environment vars
 	vars := ctx.MergeVars()
@@ -70,8 +533,15 @@ func actDetachExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	vars["ACT_PARENT_RUN_ID"] = ctx.RunCtx.Info.Id
 	vars["ACT_RUN_ID"] = childId
 
+	/**
+	 * Pass our local vars so the detached act gets them as parent
+	 * vars like acts called in process do.
+	 */
+	parentVars, _ := json.Marshal(ctx.GetLocalVars())
+	vars["ACT_PARENT_VARS"] = string(parentVars)
+
 	// Create env vars
-	envars := ctx.VarsToEnvVars(vars)
+	envars := ctx.GetEnvVars(vars)
 
 	logMode := getLogMode(cmd, ctx)
 
@@ -79,14 +549,14 @@ func actDetachExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	cmdLineArgs := []string{"run", fmt.Sprintf("-f=%s", actFilePath), fmt.Sprintf("-l=%s", logMode), actNameId}
 	cmdLineArgs = append(cmdLineArgs, cmd.Args...)
 
-	shCmd := exec.Command("act", cmdLineArgs...)
+	shCmd := exec.Command(utils.GetActBin(), cmdLineArgs...)
 	shCmd.Dir = utils.GetWd()
 	shCmd.Env = envars
 
-	utils.LogDebug("actDetachExec : envars", envars)
+	utils.LogDebug("actDetachExec : vars", getVarNames(vars))
 
-	// Ensure we create a new session for the created process (this mean a new pgid).
-	shCmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	// Ensure we create a new process group for the created process.
+	shCmd.SysProcAttr = procgroup.NewSysProcAttr()
 
 	/**
 	 * Detached acts going to log only to file. If user want to see logs
@@ -106,9 +576,10 @@ func actDetachExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 
 	// Start act execution
 	shCmd.Start()
-	
+	procgroup.Track(shCmd)
+
 	pid := shCmd.Process.Pid
-	pgid, _ := syscall.Getpgid(pid)
+	pgid, _ := procgroup.Getpgid(pid)
 
 	utils.LogDebug("actDetachExec : child act started", pid, pgid)
 
@@ -120,6 +591,8 @@ func actDetachExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	if wg != nil {
 		wg.Done()
 	}
+
+	return childId
 }
 
 //############################################################
@@ -136,17 +609,76 @@ func StageCmdsExec(stage *actfile.ActExecStage, ctx *ActRunCtx) {
 	 * Prevent execution if we are not in the running state. This is
 	 * important so we don't execute stages when we get killed by
 	 * client (which is going to put the execution in the stopped state).
+	 * Final stages are the exception since they must always run.
 	 */
-	if ctx.RunCtx.State != ExecStateRunning {
+	if !ctx.CanRun() {
 		return
 	}
 
-	ctx.CurrentStage = stage
+	ctx.setCurrentStage(stage)
+
+	/**
+	 * In persistent exec mode commands of the stage share a single
+	 * shell process. Parallel commands can't share a shell so they
+	 * keep running isolated.
+	 */
+	switch stage.ExecMode {
+	case "", ExecModeIsolated:
+	case ExecModePersistent:
+		if stage.Parallel {
+			utils.LogWarn(fmt.Sprintf("persistent exec mode is ignored for parallel stage %s (act %s)", stage.Name, ctx.CallId))
+		} else if ps := newPersistentShell(stage, ctx); ps != nil {
+			prevShell := ctx.persistentShell
+			ctx.persistentShell = ps
+
+			defer func() {
+				ps.Close()
+				ctx.persistentShell = prevShell
+			}()
+		}
+	default:
+		utils.FatalError(fmt.Sprintf("invalid exec mode %s for stage %s (act %s)", stage.ExecMode, stage.Name, ctx.CallId))
+		return
+	}
 
 	utils.LogDebug(fmt.Sprintf("StageCmdsExec : start execution [act=%s] [stage=%s] [cmds_count=%d]", ctx.Act.Name, stage.Name, len(stage.Cmds)))
 
+	stageStartedAt := time.Now()
+	ctx.Trace("stage_start", utils.TraceFields{"cmds_count": len(stage.Cmds), "parallel": stage.Parallel, "line": stage.Line})
+	ctx.EmitEvent(EventStageStarted, map[string]interface{}{"cmds_count": len(stage.Cmds), "parallel": stage.Parallel})
+
 	wg := sync.WaitGroup{}
 	wg.Add(len(stage.Cmds))
+	atomic.AddInt32(&ctx.pendingCmds, int32(len(stage.Cmds)))
+
+	/**
+	 * Slots of parallel commands when stage limits how many commands
+	 * can run at the same time.
+	 */
+	var slots chan bool
+
+	if stage.Parallel && stage.MaxParallel > 0 {
+		slots = make(chan bool, stage.MaxParallel)
+	}
+
+	/**
+	 * Execute a single command of the stage tracing its start and end.
+	 */
+	cmdExec := func(idx int, cmd *actfile.Cmd) {
+		cmdStartedAt := time.Now()
+		ctx.Trace("cmd_start", utils.TraceFields{"cmd_index": idx, "line": cmd.Line})
+
+		CmdExec(cmd, ctx, nil)
+
+		ctx.Trace("cmd_end", utils.TraceFields{"cmd_index": idx, "line": cmd.Line, "duration_ms": time.Since(cmdStartedAt).Milliseconds()})
+
+		if slots != nil {
+			<-slots
+		}
+
+		atomic.AddInt32(&ctx.pendingCmds, -1)
+		wg.Done()
+	}
 
 	for idx, cmd := range stage.Cmds {
 		/**
@@ -154,24 +686,54 @@ func StageCmdsExec(stage *actfile.ActExecStage, ctx *ActRunCtx) {
 	   * important so we don't execute more commands when we get killed by
 	   * client (which is going to put the execution in the stopped state).
 		 */
-		if ctx.RunCtx.State != ExecStateRunning {
+		if !ctx.CanRun() {
+			atomic.AddInt32(&ctx.pendingCmds, -1)
+			wg.Done()
+			continue
+		}
+
+		/**
+		 * Failures don't stop supervised executions so we need to skip
+		 * remaining commands ourselves to let the supervisor restart the
+		 * act.
+		 */
+		if !stage.Parallel && ctx.Failed && ctx.IsSupervised() {
+			atomic.AddInt32(&ctx.pendingCmds, -1)
 			wg.Done()
 			continue
 		}
 
 		utils.LogDebug(fmt.Sprintf("StageCmdsExec : cmd execution [act=%s] [stage=%s] [progress=%d/%d]", ctx.Act.Name, stage.Name, idx+1, len(stage.Cmds)))
 
-		if stage.Parallel{
-			go CmdExec(cmd, ctx, &wg)
-		} else {
-			CmdExec(cmd, ctx, &wg)
-		}
+		printCmdSeparator(cmd, ctx, idx, len(stage.Cmds))
+
+		if stage.Parallel{
+			/**
+			 * Wait a free slot unless execution gets stopped in the
+			 * meantime.
+			 */
+			if slots != nil {
+				select {
+				case slots <- true:
+				case <-ctx.Done():
+					atomic.AddInt32(&ctx.pendingCmds, -1)
+					wg.Done()
+					continue
+				}
+			}
+
+			go cmdExec(idx, cmd)
+		} else {
+			cmdExec(idx, cmd)
+		}
 
 		utils.LogDebug(fmt.Sprintf("StageCmdsExec : cmd execution done [act=%s] [stage=%s] [progress=%d/%d]", ctx.Act.Name, stage.Name, idx+1, len(stage.Cmds)))
 	}
 
 	// Wait execution of all commands.
 	wg.Wait()
+
+	ctx.Trace("stage_end", utils.TraceFields{"duration_ms": time.Since(stageStartedAt).Milliseconds()})
 }
 
 /**
@@ -182,8 +744,14 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	 * Prevent execution if we are not in the running state. This is
 	 * important so we don't execute stages when we get killed by
 	 * client (which is going to put the execution in the stopped state).
+	 * Final stages are the exception since they must always run.
 	 */
-	if ctx.RunCtx.State != ExecStateRunning {
+	if !ctx.CanRun() {
+		return
+	}
+
+	// Paused acts don't start new commands until they get resumed.
+	if !ctx.WaitResumed() {
 		return
 	}
 
@@ -199,16 +767,18 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	 * generated commands.
 	 */
 	if cmd.Loop != nil {
+		utils.LogDebug(fmt.Sprintf("CmdExec : loop found [act=%s] [source=%s]", ctx.Act.Name, getCmdSource(cmd, ctx)))
+
 		var items []string
 
 		if cmd.Loop.Glob != "" {
-			baseDir := path.Dir(ctx.ActFile.LocationPath)
+			baseDir := filepath.Dir(ctx.ActFile.LocationPath)
 			glob := utils.CompileTemplate(cmd.Loop.Glob, vars)
 			pattern := utils.ResolvePath(baseDir, glob)
 			paths, err := filepath.Glob(pattern)
 
 			if err != nil {
-				utils.FatalError("glob error", err)
+				utils.FatalError(fmt.Sprintf("glob error (%s)", getCmdSource(cmd, ctx)), err)
 			}
 
 			items = paths
@@ -222,16 +792,16 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 			for _, item := range items {
 				vars["LoopItem"] = item
 
-				genCmd := actfile.Cmd{
-					Cmd:      utils.CompileTemplate(cmd.Cmd, vars),
-					Act:      utils.CompileTemplate(cmd.Act, vars),
-					From:     utils.CompileTemplate(cmd.From, vars),
-					Args:     cmd.Args,
-					Script:   cmd.Script,
-					Detach:   cmd.Detach,
-					Mismatch: cmd.Mismatch,
-					Quiet:    cmd.Quiet,
-				}
+				/**
+				 * Generated commands are copies of the loop command so
+				 * they keep all its settings (like container, hosts or
+				 * user) while running once per item.
+				 */
+				genCmd := *cmd
+				genCmd.Cmd = utils.CompileTemplate(cmd.Cmd, ctx.getShellLineVars(vars))
+				genCmd.Act = utils.CompileTemplate(cmd.Act, vars)
+				genCmd.From = utils.CompileTemplate(cmd.From, vars)
+				genCmd.Loop = nil
 
 				cmds = append(cmds, &genCmd)
 			}
@@ -259,7 +829,7 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	 * If command is invoking another act then lets run it.
 	 */
 	if cmd.Act != "" {
-		utils.LogDebug(fmt.Sprintf("CmdExec : sub act found [act=%s]", ctx.Act.Name))
+		utils.LogDebug(fmt.Sprintf("CmdExec : sub act found [act=%s] [source=%s]", ctx.Act.Name, getCmdSource(cmd, ctx)))
 
 		/**
 		 * If we want to run the act as separate act process
@@ -303,14 +873,20 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 				return
 			}
 
-			utils.FatalError(err)
+			utils.FatalError(fmt.Sprintf("%s (%s)", err, getCmdSource(cmd, ctx)))
 		}
 
 		nextCtx.Args = cmdArgs
-		nextCtx.Act.Log = ctx.Act.Log
 
-		utils.LogDebug(fmt.Sprintf("CmdExec : sub act : start execution [act=%s]", ctx.Act.Name, nextCtx.Args))
-		nextCtx.Exec()
+		/**
+		 * Parsed acts are shared by all calls (actfiles are cached)
+		 * so the caller log mode goes to the act context.
+		 */
+		callerLog := ctx.GetActLog()
+		nextCtx.InheritedLog = &callerLog
+
+		utils.LogDebug(fmt.Sprintf("CmdExec : sub act : start execution [act=%s]", ctx.Act.Name), nextCtx.Args)
+		ctx.RunCtx.ExecActCtx(nextCtx)
 		utils.LogDebug(fmt.Sprintf("CmdExec : sub act : end [act=%s]", ctx.Act.Name))
 
 		/**
@@ -324,6 +900,180 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 		return
 	}
 
+	/**
+	 * Run the command (together with any chained `and`/`or` commands)
+	 * and get any error code thrown.
+	 *
+	 * @note: When we kill the main process we going to run KillChildren
+	 * function to kill all children. In this case the command going
+	 * to rise an error because it got killed.
+	 */
+	cmdStartedAt := time.Now()
+	lastCmd, cmdLine, err := cmdChainExec(cmd, ctx, vars)
+
+	// Command output must be written before we report about it.
+	ctx.RunCtx.FlushLogs()
+
+	ctx.recordCmdTiming(cmdLine, cmdStartedAt, err)
+
+	if err != nil && !ctx.RunCtx.IsFinishing && !ctx.RunCtx.IsStopped() {
+		errMsg := fmt.Sprintf("command '%s' failed (%s)", cmdLine, getCmdSource(lastCmd, ctx))
+
+		/**
+		 * Record the failure so on_failure stage can run. Commands killed
+		 * by a signal (like when user stops the execution) are not
+		 * considered failures.
+		 */
+		exitCode := getExitCode(err)
+
+		if _, ok := err.(*exec.ExitError); !ok && exitCode < 0 {
+			exitCode = 1
+		}
+
+		if exitCode > 0 {
+			ctx.SetFailed(exitCode, cmdLine)
+		}
+
+		notifyOnly := ctx.CurrentStage.Parallel || ctx.IsSupervised()
+
+		if exiterr, ok := err.(*exec.ExitError); ok {
+			/**
+			 * Program exited with exit code other then 0 (which means
+			 * an error happened). This works both on Unix and Windows.
+			 *
+			 * Code got from:
+			 *
+			 * https://stackoverflow.com/questions/10385551/get-exit-code-go
+			 */
+			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+				exitStatus := status.ExitStatus()
+
+				if exitStatus > 0 {
+					/**
+					 * We don't want to exit from main process when we are
+					 * running commands in parallel (or when a supervisor is
+					 * going to restart the act) but we want to get notified
+					 * about command failure.
+					 */
+					if notifyOnly {
+						utils.LogError(errMsg, err)
+					} else {
+						utils.FatalErrorWithCode(status.ExitStatus(), errMsg, err)
+					}
+				}
+			} else {
+				if notifyOnly {
+					utils.LogError(errMsg, err)
+				} else {
+					utils.FatalError(errMsg, err)
+				}
+			}
+		} else if notifyOnly {
+			utils.LogError(errMsg, err)
+		} else if exitCode > 0 {
+			utils.FatalErrorWithCode(exitCode, errMsg, err)
+		} else {
+			utils.FatalError(errMsg, err)
+		}
+	}
+
+	/**
+	 * Now that we finished running the command we need to
+	 * release the wait group (i.e., mark it as done).
+	 */
+	if wg != nil {
+	 wg.Done()
+	}
+}
+
+/**
+ * This function going to execute a shell command together with
+ * its `and` chain and, in case the chain fails, try each one of
+ * the `or` fallback commands in order. It returns the last executed
+ * command, its command line and the error it thrown.
+ */
+func cmdChainExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) (*actfile.Cmd, string, error) {
+	lastCmd := cmd
+	cmdLine, err := cmdShellExec(cmd, ctx, vars)
+
+	for _, andCmd := range cmd.And {
+		if err != nil || !ctx.CanRun() {
+			break
+		}
+
+		lastCmd, cmdLine, err = cmdChainExec(andCmd, ctx, vars)
+	}
+
+	for _, orCmd := range cmd.Or {
+		if err == nil || !ctx.CanRun() {
+			break
+		}
+
+		utils.LogDebug(fmt.Sprintf("cmdChainExec : trying fallback [act=%s]", ctx.Act.Name), err)
+
+		lastCmd, cmdLine, err = cmdChainExec(orCmd, ctx, vars)
+	}
+
+	return lastCmd, cmdLine, err
+}
+
+/**
+ * This function going to run a shell command (script or command line)
+ * locally or on remote hosts (or wait for a condition, send an http
+ * request or run a pipeline) and wait it to finish. The error thrown by the
+ * command is returned so callers can decide what to do about it.
+ */
+func cmdShellExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) (string, error) {
+	if cmd.WaitFor != nil {
+		return cmdWaitForExec(cmd, ctx, vars)
+	}
+
+	if cmd.Http != nil {
+		return cmdHttpExec(cmd, ctx, vars)
+	}
+
+	if len(cmd.Pipe) > 0 {
+		return cmdPipeExec(cmd, ctx, vars)
+	}
+
+	if hosts := getCmdHosts(cmd, ctx); len(hosts) > 0 {
+		return cmdRemoteExec(cmd, ctx, vars, hosts)
+	}
+
+	if ctx.persistentShell != nil && canRunPersistent(cmd, ctx) {
+		return ctx.persistentShell.exec(cmd, vars)
+	}
+
+	return cmdProcExec(cmd, ctx, vars, "")
+}
+
+/**
+ * This function going to spawn a shell process for a command (script
+ * or command line) and wait it to finish. When host is set the shell
+ * process is an ssh client running the command on that host.
+ */
+func cmdProcExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string, host string) (string, error) {
+	vars = getCmdVars(cmd, vars)
+
+	// Set shell to use in the right precedence order.
+	shell := getShell(cmd, ctx)
+
+	/**
+	 * Commands running on a remote host or in a container use a shell
+	 * of that machine.
+	 */
+	var container *actfile.CmdContainer
+	var containerVolumes []string
+	var remoteScriptPath string
+
+	if host == "" {
+		container = getCmdContainer(cmd, ctx)
+	}
+
+	if host != "" || container != nil {
+		shell = getExternalShell(cmd, ctx)
+	}
+
 	/**
 	 * Set the command to run (script or command line).
 	 */
@@ -340,58 +1090,83 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 			cmdArgs = append(cmdArgs, compiledArg)
 		}
 
-		shArgs = append([]string{cmdLine}, cmdArgs...)
+		scriptPath := cmdLine
+
+		if cmd.Compile {
+			compiledPath, err := compileScript(utils.ResolvePath(filepath.Dir(ctx.ActFile.LocationPath), cmdLine), ctx.getShellLineVars(vars), getCmdSource(cmd, ctx))
+
+			if err != nil {
+				return cmdLine, err
+			}
+
+			defer os.Remove(compiledPath)
+
+			scriptPath = compiledPath
+
+			// Compiled script lives outside actfile dir.
+			containerVolumes = append(containerVolumes, fmt.Sprintf("%s:%s:ro", compiledPath, compiledPath))
+		}
+
+		remoteScriptPath = scriptPath
+
+		shArgs = getShellScriptArgs(shell, scriptPath, cmdArgs, getShellOpts(cmd, ctx))
 	} else {
-		cmdLine = utils.CompileTemplate(cmd.Cmd, vars)
+		cmdLine = utils.CompileTemplate(cmd.Cmd, ctx.getShellLineVars(vars))
 
-		shArgs = []string{"-c", cmdLine, "--"}
+		shArgs = getShellArgs(shell, cmdLine, ctx.Args, getShellOpts(cmd, ctx))
 	}
 
-	// Set shell to use in the right precedence order.
-	shell := "bash"
+	utils.LogDebug(fmt.Sprintf("CmdExec : starting execution [act=%s] [source=%s]", ctx.Act.Name, getCmdSource(cmd, ctx)), shArgs)
+	utils.LogVerbose(fmt.Sprintf("running %s [act=%s]", cmdLine, ctx.CallId))
 
-	if ctx.ActFile.Shell != "" {
-		shell = ctx.ActFile.Shell
-	}
+	/**
+	 * Set environment variables using all available variables
+	 * (runtime vars are already merged into them).
+	 */
+	envars := ctx.GetEnvVars(vars)
 
-	if ctx.Act.Shell != "" {
-		shell = ctx.Act.Shell
-	}
+	// Command to spawn.
+	shBin, shBinArgs := getShellExecArgs(shell, shArgs)
+	containerName := ""
 
-	if cmd.Shell != "" {
-		shell = cmd.Shell
+	if container != nil {
+		if container.Exec == "" {
+			containerName = newContainerName(ctx)
+		}
+
+		shBin = "docker"
+		shBinArgs = getContainerArgs(container, containerName, ctx, envars, shell, shArgs, isCmdTty(cmd, ctx), containerVolumes)
 	}
 
-	utils.LogDebug(fmt.Sprintf("CmdExec : starting execution [act=%s]", ctx.Act.Name), shArgs)
+	sshTty := isCmdTty(cmd, ctx) && remoteScriptPath == ""
+	remoteToken := ""
 
-	// Command to spawn.
-	shCmd := exec.Command(shell, shArgs...)
+	if host != "" {
+		sshEnvFilePath := ""
 
-	/**
-	 * We going to run the scrip relative to the folder which contains
-	 * the actfile where we actually matched the act to run.
-	 */
-	shCmd.Dir = path.Dir(ctx.ActFile.LocationPath)
+		// Stdin of tty commands is a terminal so env vars go in a file.
+		if sshTty && len(getSshEnvVars(envars)) > 0 {
+			var err error
 
-	/**
-	 * Load env vars
-	 */
-	godotenv.Load(ctx.RunCtx.Info.GetEnvVarsFilePath())
+			if sshEnvFilePath, err = uploadSshEnv(host, envars); err != nil {
+				return cmdLine, err
+			}
+		}
 
-	/**
-	 * Set a special ACT_ENV_FILE variable pointing to the full
-	 * path to env file set on actfile.
-	 */
-	if ctx.ActFile.EnvFilePath != "" {
-		envFilePath := utils.ResolvePath(path.Dir(ctx.ActFile.LocationPath), ctx.ActFile.EnvFilePath)
+		remoteToken, _ = shortid.Generate()
 
-		vars["ACT_ENV_FILE"] = envFilePath
+		shBin = "ssh"
+		shBinArgs = getSshArgs(host, envars, shell, shArgs, remoteScriptPath, sshTty, sshEnvFilePath, remoteToken)
 	}
 
+	shCmd := exec.Command(shBin, shBinArgs...)
+
 	/**
-	 * Set environment variables using all available variables.
+	 * We going to run the scrip relative to the folder which contains
+	 * the actfile where we actually matched the act to run (unless
+	 * the command sets its own dir).
 	 */
-	envars := ctx.VarsToEnvVars(vars)
+	shCmd.Dir = getCmdDir(cmd, ctx, vars)
 
 	// Set all env vars to shell command.
 	shCmd.Env = envars
@@ -408,20 +1183,13 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	 * Further explanations in:
 	 *
 	 * https://medium.com/@felixge/killing-a-child-process-and-all-of-its-children-in-go-54079af94773
-	 *
-	 * @NOTE : For some reason using SysProcAttr.Setpgid give us some
-	 * weird behaviors at least in MacOS. Using SysProcAttr.Setsid seems
-	 * to have the same end result (creating different pgid for child
-	 * process). Based on the following:
-	 *
-	 * https://stackoverflow.com/questions/43364958/start-command-with-new-process-group-id-golang
 	 */
-	shCmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	shCmd.SysProcAttr = procgroup.NewSysProcAttr()
 
 	/**
 	 * Set output
 	 */
-	if !ctx.RunCtx.Quiet && !ctx.Act.Quiet && !ctx.CurrentStage.Quiet && !cmd.Quiet {
+	if !isCmdQuiet(cmd, ctx) {
 
 		/**
 		 * Set the log mode. By default log mode is `raw` and therefore we going
@@ -431,9 +1199,9 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 		 */
 		logMode := getLogMode(cmd, ctx)
 
-		if !ctx.RunCtx.IsDaemon && logMode == "raw" {
-			shCmd.Stdout = os.Stdout
-			shCmd.Stderr = os.Stderr
+		if !ctx.RunCtx.IsDaemon && logMode == "raw" && host == "" {
+			shCmd.Stdout = ctx.RunCtx.MaskWriter(os.Stdout)
+			shCmd.Stderr = ctx.RunCtx.MaskWriter(os.Stderr)
 			shCmd.Stdin = os.Stdin
 		} else {
 			/**
@@ -443,14 +1211,241 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 			 * to lose colors here (like jest logging).
 			 */
 			l := NewLogWriter(ctx)
+			l.LogToConsole = true
+
+			errL := NewStderrLogWriter(ctx)
+			errL.LogToConsole = true
+
+			// Output of remote commands is always prefixed with the host.
+			l.Host = host
+			errL.Host = host
 
 			shCmd.Stdout = l
-			shCmd.Stderr = l
+			shCmd.Stderr = errL
+		}
+	}
+
+	/**
+	 * In quiet success mode we hold command output and print it only
+	 * if the command fails.
+	 */
+	var outBuf *outputBuffer
+
+	if ctx.RunCtx.QuietSuccess && shCmd.Stdout != nil {
+		outBuf = &outputBuffer{}
+		shCmd.Stdout = outBuf.Writer(shCmd.Stdout)
+		shCmd.Stderr = outBuf.Writer(shCmd.Stderr)
+	}
+
+	/**
+	 * Start commands of interactive daemons read input sent by users
+	 * attached to the act.
+	 */
+	if ctx.RunCtx.Stdin != nil && ctx.CurrentStage == ctx.RunCtx.ActCtx.Act.Start {
+		shCmd.Stdin = ctx.RunCtx.Stdin
+	}
+
+	/**
+	 * Scripts don't exist on remote hosts so we send their content
+	 * to the remote shell through stdin.
+	 */
+	if host != "" && remoteScriptPath != "" {
+		scriptFile, err := openRemoteScript(remoteScriptPath, ctx)
+
+		if err != nil {
+			return cmdLine, err
+		}
+
+		defer scriptFile.Close()
+
+		shCmd.Stdin = scriptFile
+	}
+
+	// Remote shells read act env vars from stdin before the input.
+	if host != "" && !sshTty && len(getSshEnvVars(envars)) > 0 {
+		stdin, err := getSshEnvStdin(envars, shCmd.Stdin)
+
+		if err != nil {
+			return cmdLine, err
+		}
+
+		defer stdin.Close()
+
+		shCmd.Stdin = stdin
+	}
+
+	/**
+	 * If command has output assertions then we need to capture its
+	 * stdout as well.
+	 */
+	var stdoutBuf bytes.Buffer
+
+	if cmd.Expect != nil {
+		if shCmd.Stdout != nil {
+			shCmd.Stdout = io.MultiWriter(shCmd.Stdout, &stdoutBuf)
+		} else {
+			shCmd.Stdout = &stdoutBuf
+		}
+	}
+
+	/**
+	 * When command asks for a tty we give it a pseudo-terminal as
+	 * stdin, stdout and stderr and copy what it writes to the output
+	 * we would give it otherwise. If we can't allocate a pseudo-terminal
+	 * we just fallback to regular pipes.
+	 */
+	var ptm, pts, ptyIn *os.File
+	var ptyOut io.Writer
+
+	/**
+	 * Masking secrets puts a pipe between the command and our terminal
+	 * so we give the command a pseudo-terminal too (otherwise it would
+	 * not see a terminal anymore).
+	 */
+	_, isMasked := shCmd.Stdout.(*secretMaskWriter)
+	isMaskedTty := isMasked && utils.IsTerminal(int(os.Stdout.Fd()))
+
+	// Remote scripts are sent through stdin so they run without tty.
+	isTty := isCmdTty(cmd, ctx) && (host == "" || sshTty)
+
+	if isTty || isMaskedTty {
+		master, slave, err := utils.OpenPty()
+
+		if err != nil {
+			utils.LogDebug("cmdShellExec : could not allocate pty", err)
+		} else {
+			ptm = master
+			pts = slave
+			ptyOut = shCmd.Stdout
+
+			if ptyOut == nil {
+				ptyOut = ioutil.Discard
+			}
+
+			/**
+			 * We forward input only from interactive daemon input or from
+			 * a terminal (otherwise the command would never see stdin end).
+			 */
+			if shCmd.Stdin == ctx.RunCtx.Stdin && ctx.RunCtx.Stdin != nil {
+				ptyIn = ctx.RunCtx.Stdin
+			} else if shCmd.Stdin == os.Stdin && utils.IsTerminal(int(os.Stdin.Fd())) {
+				ptyIn = os.Stdin
+			}
+
+			if utils.IsTerminal(int(os.Stdout.Fd())) {
+				utils.CopyWinsize(int(os.Stdout.Fd()), ptm)
+			}
+
+			shCmd.Stdin = pts
+			shCmd.Stdout = pts
+			shCmd.Stderr = pts
+
+			// The pseudo-terminal (stdin) becomes the controlling terminal.
+			shCmd.SysProcAttr = procgroup.NewTtySysProcAttr()
 		}
 	}
 
+	/**
+	 * User, umask and nice level are about processes of this machine
+	 * so they don't apply to remote hosts and containers.
+	 */
+	isLocal := host == "" && container == nil
+	procUser, procUmask, procNice := getCmdProcAttrs(cmd, ctx)
+
+	var limits *procgroup.Limits
+
+	if isLocal {
+		var err error
+
+		if limits, err = getActLimits(ctx); err != nil {
+			return cmdLine, err
+		}
+	}
+
+	if isLocal && procUser != "" {
+		if err := procgroup.SetUser(shCmd.SysProcAttr, procUser); err != nil {
+			return cmdLine, fmt.Errorf("could not run command as user %s: %v", procUser, err)
+		}
+	}
+
+	// Umask is process wide so the command sets its own.
+	var startErr error
+
+	if isLocal && procUmask != "" {
+		umask, err := strconv.ParseUint(procUmask, 8, 32)
+
+		if err != nil {
+			startErr = fmt.Errorf("invalid umask %s", procUmask)
+		} else {
+			startErr = procgroup.SetUmask(shCmd, int(umask))
+		}
+	}
+
+	var cg *procgroup.Cgroup
+
+	if startErr == nil && limits != nil {
+		cg = setCmdLimits(ctx, limits, shCmd)
+	}
+
 	// Start act execution
-	shCmd.Start()
+	if startErr == nil {
+		startErr = shCmd.Start()
+	}
+
+	if err := startErr; err != nil {
+		if ptm != nil {
+			ptm.Close()
+			pts.Close()
+		}
+
+		return cmdLine, releaseCmdLimits(ctx, cg, err)
+	}
+
+	profileMarkSpawn()
+
+	/**
+	 * Copy pseudo-terminal output until the command (and everyone
+	 * holding the terminal) exits. Reading from master fails with EIO
+	 * at that point.
+	 */
+	var ptyDone, ptyInDone, ptyInStopped chan bool
+
+	if ptm != nil {
+		// Only the command should hold the slave side now.
+		pts.Close()
+
+		ptyDone = make(chan bool)
+
+		go func() {
+			defer utils.RestoreTermOnPanic()
+
+			io.Copy(ptyOut, ptm)
+			close(ptyDone)
+		}()
+
+		/**
+		 * Input is copied only while the command runs. Our terminal
+		 * goes to raw mode meanwhile so keys reach the command as they
+		 * are typed and the pseudo-terminal does the echo.
+		 */
+		if ptyIn != nil {
+			ptyInDone = make(chan bool)
+			ptyInStopped = make(chan bool)
+
+			if ptyIn == os.Stdin {
+				if state, err := utils.MakePtyInputRaw(int(os.Stdin.Fd())); err == nil {
+					defer utils.RestoreTerm(int(os.Stdin.Fd()), state)
+				}
+			}
+
+			go func() {
+				defer utils.RestoreTermOnPanic()
+
+				utils.CopyPtyInput(ptm, ptyIn, ptyInDone)
+				close(ptyInStopped)
+			}()
+		}
+	}
 
 	/**
 	 * Now that act is executing we can collect some runtime info like
@@ -458,74 +1453,105 @@ func CmdExec(cmd *actfile.Cmd, ctx *ActRunCtx, wg *sync.WaitGroup) {
 	 */
 	pid := shCmd.Process.Pid
 
+	procgroup.Track(shCmd)
+
 	/**
 	 * Try to get process group id so we can kill all child processes.
 	 */
-	pgid, err := syscall.Getpgid(pid)
+	pgid, err := procgroup.Getpgid(pid)
 
 	if err != nil {
 		utils.FatalError(fmt.Sprintf("could not get pgid for pid=%d", pid), err)
 	}
 
-	// Save to run context info file
-	ctx.RunCtx.Info.AddCmdPgid(pgid)
+	if isLocal && procNice != nil {
+		if err := procgroup.SetNice(pgid, *procNice); err != nil {
+			utils.LogWarn(fmt.Sprintf("could not set nice level %d", *procNice), err)
+		}
+	}
 
 	/**
-	 * Wait command finalization and get any error code thrown.
-	 *
-	 * @note: When we kill the main process we going to run KillChildren
-	 * function to kill all children. In this case shCmd.Wait going
-	 * to rise an error because the command got killed.
+	 * Save to run context info file. Final commands are tracked apart
+	 * from other commands so they don't get killed when the execution
+	 * is stopped.
 	 */
-	if err := shCmd.Wait(); err != nil && !ctx.RunCtx.IsFinishing {
-		if exiterr, ok := err.(*exec.ExitError); ok {
-			errMsg := fmt.Sprintf("command '%s' failed", cmdLine)
+	isFinal := ctx.IsFinalizing()
 
-			/**
-			 * Program exited with exit code other then 0 (which means
-			 * an error happened). This works both on Unix and Windows.
-			 *
-			 * Code got from:
-			 *
-			 * https://stackoverflow.com/questions/10385551/get-exit-code-go
-			 */
-			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-				exitStatus := status.ExitStatus()
+	procStartedAt := time.Now()
+	ctx.Trace("proc_start", utils.TraceFields{"child_pid": pid, "pgid": pgid, "cmd": cmdLine, "source": getCmdSource(cmd, ctx)})
 
-				if exitStatus > 0 {
-					/**
-					 * We don't want to exit from main process when we are
-					 * running commands in parallel but we want to get
-					 * notified about command failure.
-					 */
-					if ctx.CurrentStage.Parallel {
-						utils.LogError(errMsg, err)
-					} else {
-						utils.FatalErrorWithCode(status.ExitStatus(), errMsg, err)
-					}
-				}
-			} else {
-				if ctx.CurrentStage.Parallel {
-					utils.LogError(errMsg, err)
-				} else {
-					utils.FatalError(errMsg, err)
-				}
-			}
-		}
+	stageName := ""
+
+	if ctx.CurrentStage != nil {
+		stageName = ctx.CurrentStage.Name
+	}
+
+	ctx.RunCtx.AddRunningCmd(&RunningCmd{
+		Act:       ctx.CallId,
+		Stage:     stageName,
+		Cmd:       cmdLine,
+		Source:    getCmdSource(cmd, ctx),
+		Pid:       pid,
+		Pgid:      pgid,
+		StartedAt: procStartedAt,
+	})
+
+	ctx.RunCtx.TrackCmdPgid(pgid, isFinal)
+
+	if containerName != "" && !isFinal {
+		ctx.RunCtx.Info.AddContainer(containerName)
+		defer ctx.RunCtx.Info.RmContainer(containerName)
 	}
 
-	utils.LogDebug(fmt.Sprintf("CmdExec : wait done [act=%s]", ctx.Act.Name), shArgs)
+	if remoteToken != "" && !isFinal {
+		ctx.RunCtx.Info.AddRemoteCmd(&RemoteCmd{Host: host, Token: remoteToken})
+		defer ctx.RunCtx.Info.RmRemoteCmd(remoteToken)
+	}
 
 	/**
-	 * Now that the command finished let's remove its pgid.
+	 * Wait command finalization.
 	 */
-	ctx.RunCtx.Info.RmCmdPgid(pgid)
+	err = shCmd.Wait()
+
+	if ptyInDone != nil {
+		close(ptyInDone)
+		<-ptyInStopped
+	}
+
+	if ptm != nil {
+		<-ptyDone
+		ptm.Close()
+	}
+
+	flushMaskWriters(ptyOut, shCmd.Stdout, shCmd.Stderr)
+
+	err = releaseCmdLimits(ctx, cg, err)
+
+	utils.LogDebug(fmt.Sprintf("cmdShellExec : wait done [act=%s]", ctx.Act.Name), shArgs)
 
 	/**
-	 * Now that we finished running the command we need to
-	 * release the wait group (i.e., mark it as done).
+	 * Check command assertions against the command outcome.
 	 */
-	if wg != nil {
-	 wg.Done()
+	if cmd.Expect != nil {
+		err = checkCmdExpect(cmd.Expect, err, stdoutBuf.String(), vars)
+	}
+
+	if outBuf != nil && err != nil {
+		outBuf.Replay()
 	}
+
+	ctx.Trace("proc_end", utils.TraceFields{"child_pid": pid, "exit_code": getExitCode(err), "duration_ms": time.Since(procStartedAt).Milliseconds()})
+
+	ctx.RunCtx.RmRunningCmd(pgid)
+
+	/**
+	 * Now that the command finished let's remove its pgid.
+	 */
+	if isFinal {
+		ctx.RunCtx.RmFinalPgid(pgid)
+	} else {
+		ctx.RunCtx.Info.RmCmdPgid(pgid)
+	}
+
+	return cmdLine, err
 }
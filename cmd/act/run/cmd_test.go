@@ -0,0 +1,92 @@
+package run
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/**
+ * Act args must reach posix command lines as positional params
+ * without being parsed by the shell.
+ */
+func TestGetShellArgsPositionalParams(t *testing.T) {
+	args := []string{"with space", "it's", "$HOME", "; echo injected", "-c"}
+
+	shArgs := getShellArgs("sh", `printf '%s\0' "$@"`, args, nil)
+	output, err := exec.Command("sh", shArgs...).Output()
+
+	if err != nil {
+		t.Fatalf("could not run sh %q: %v", shArgs, err)
+	}
+
+	got := strings.Split(strings.TrimSuffix(string(output), "\x00"), "\x00")
+
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("got args %q, want %q", got, args)
+	}
+}
+
+/**
+ * Without args the command line gets no positional params.
+ */
+func TestGetShellArgsNoArgs(t *testing.T) {
+	shArgs := getShellArgs("sh", `printf '%d' "$#"`, nil, nil)
+	output, err := exec.Command("sh", shArgs...).Output()
+
+	if err != nil {
+		t.Fatalf("could not run sh %q: %v", shArgs, err)
+	}
+
+	if string(output) != "0" {
+		t.Errorf("got %s positional params, want 0", output)
+	}
+}
+
+/**
+ * Shells without positional params never get args.
+ */
+func TestGetShellArgsCmdShell(t *testing.T) {
+	shArgs := getShellArgs("cmd", "echo hi", []string{"a"}, nil)
+
+	if want := []string{"/C", "echo hi"}; !reflect.DeepEqual(shArgs, want) {
+		t.Errorf("got %q, want %q", shArgs, want)
+	}
+}
+
+/**
+ * Compiled scripts tell where they come from right after the shebang
+ * line (so it keeps working).
+ */
+func TestCompileScriptSourceComment(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, c := range map[string]struct{ content, want string }{
+		"plain.sh":  {"echo {{.Name}}\n", "# from actfile.yml:7\necho bruno\n"},
+		"bang.sh":   {"#!/bin/sh\necho {{.Name}}\n", "#!/bin/sh\n# from actfile.yml:7\necho bruno\n"},
+		"build.bat": {"echo {{.Name}}\r\n", "REM from actfile.yml:7\r\necho bruno\r\n"},
+	} {
+		scriptPath := filepath.Join(dir, name)
+
+		if err := ioutil.WriteFile(scriptPath, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		compiledPath, err := compileScript(scriptPath, map[string]string{"Name": "bruno"}, "actfile.yml:7")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, _ := ioutil.ReadFile(compiledPath)
+		os.Remove(compiledPath)
+
+		if string(content) != c.want {
+			t.Errorf("%s: got %q, want %q", name, content, c.want)
+		}
+	}
+}
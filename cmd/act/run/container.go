@@ -0,0 +1,255 @@
+/**
+ * This file implements running commands inside docker containers
+ * (set with `container` on acts or commands). Commands run through
+ * `docker run` (or `docker exec` into an already running container)
+ * with act variables as env vars and the actfile dir mounted at the
+ * same path, so output flows through our regular log writers. Since
+ * killing the docker client doesn't stop the container we keep track
+ * of container names and stop them when stopping the act.
+ */
+
+package run
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Default shell we use inside containers and on remote hosts since
+ * they may not have bash.
+ */
+const externalDefaultShell = "sh"
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Counter we use to give unique names to containers of a run.
+ */
+var containerCount int64
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the container a command should run in
+ * (command container has precedence over act container).
+ */
+func getCmdContainer(cmd *actfile.Cmd, ctx *ActRunCtx) *actfile.CmdContainer {
+	if cmd.Container != nil {
+		return cmd.Container
+	}
+
+	return ctx.Act.Container
+}
+
+/**
+ * This function going to get the shell to run commands with outside
+ * this machine (inside a container or on a remote host). Global shell
+ * settings are about this machine so only act and command shells are
+ * taken into account.
+ */
+func getExternalShell(cmd *actfile.Cmd, ctx *ActRunCtx) string {
+	if cmd.Shell != "" {
+		return cmd.Shell
+	}
+
+	if ctx.Act.Shell != "" {
+		return ctx.Act.Shell
+	}
+
+	return externalDefaultShell
+}
+
+/**
+ * This function going to resolve the host side of a volume relative
+ * to the actfile dir (named volumes are kept as is).
+ */
+func resolveContainerVolume(volume string, baseDir string) string {
+	parts := strings.SplitN(volume, ":", 2)
+
+	if len(parts) == 2 && (parts[0] == "." || strings.HasPrefix(parts[0], "./") || strings.HasPrefix(parts[0], "../")) {
+		return fmt.Sprintf("%s:%s", utils.ResolvePath(baseDir, parts[0]), parts[1])
+	}
+
+	return volume
+}
+
+/**
+ * This function going to build docker args to run a shell command
+ * inside a container. Env var values are not passed in args (so they
+ * don't show up in process listings) but taken by docker from its
+ * own environment.
+ */
+func getContainerArgs(container *actfile.CmdContainer, name string, ctx *ActRunCtx, envars []string, shell string, shArgs []string, tty bool, extraVolumes []string) []string {
+	baseDir := filepath.Dir(ctx.ActFile.LocationPath)
+	workdir := baseDir
+
+	if container.Workdir != "" {
+		workdir = container.Workdir
+
+		if !filepath.IsAbs(workdir) {
+			workdir = filepath.Join(baseDir, workdir)
+		}
+	}
+
+	var args []string
+
+	if container.Exec != "" {
+		args = []string{"exec", "-i"}
+	} else {
+		args = []string{
+			"run", "--rm", "-i", "--init",
+			"--name", name,
+			"--label", fmt.Sprintf("act.run_id=%s", ctx.RunCtx.Info.Id),
+			"-v", fmt.Sprintf("%s:%s", baseDir, baseDir),
+		}
+
+		for _, volume := range append(container.Volumes, extraVolumes...) {
+			args = append(args, "-v", resolveContainerVolume(volume, baseDir))
+		}
+
+		if container.Network != "" {
+			args = append(args, "--network", container.Network)
+		}
+
+		// Docker enforces act limits on the container.
+		if limits := ctx.Act.Limits; limits != nil {
+			if limits.Cpu > 0 {
+				args = append(args, "--cpus", fmt.Sprintf("%g", limits.Cpu))
+			}
+
+			if memory, err := utils.ParseBytes(limits.Memory); err == nil && memory > 0 {
+				args = append(args, "--memory", fmt.Sprintf("%d", memory))
+			}
+
+			if limits.OpenFiles > 0 {
+				args = append(args, "--ulimit", fmt.Sprintf("nofile=%d:%d", limits.OpenFiles, limits.OpenFiles))
+			}
+
+			if limits.Processes > 0 {
+				args = append(args, "--pids-limit", fmt.Sprintf("%d", limits.Processes))
+			}
+		}
+	}
+
+	if tty {
+		args = append(args, "-t")
+	}
+
+	args = append(args, "-w", workdir)
+
+	if container.User != "" {
+		args = append(args, "-u", container.User)
+	}
+
+	// Vars inherited from host environment (like PATH) are about the host.
+	for _, kv := range getOwnEnvVars(envars) {
+		args = append(args, "-e", strings.SplitN(kv, "=", 2)[0])
+	}
+
+	if container.Exec != "" {
+		args = append(args, container.Exec)
+	} else {
+		args = append(args, container.Image)
+	}
+
+	return append(append(args, shell), shArgs...)
+}
+
+/**
+ * This function going to generate a unique name for a container of
+ * this run.
+ */
+func newContainerName(ctx *ActRunCtx) string {
+	return fmt.Sprintf("act-%s-%d", ctx.RunCtx.Info.Id, atomic.AddInt64(&containerCount, 1))
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function going to add a running container and then save
+ * info back to file system.
+ */
+func (info *Info) AddContainer(name string) {
+	info.mutex.Lock()
+
+	info.Containers = append(info.Containers, name)
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to remove a container that finished and then
+ * save info back to file system.
+ */
+func (info *Info) RmContainer(name string) {
+	info.mutex.Lock()
+
+	var containers []string
+
+	for _, val := range info.Containers {
+		if val != name {
+			containers = append(containers, val)
+		}
+	}
+
+	info.Containers = containers
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to stop running containers of this act in
+ * background (with docker kill when the stop timeline kills right
+ * away or docker stop with the stop grace period otherwise). It
+ * returns a function which waits containers to be stopped.
+ */
+func (info *Info) stopContainers(timeline []*actfile.ActStopStep) func() {
+	var wg sync.WaitGroup
+
+	containers := make([]string, len(info.Containers))
+	copy(containers, info.Containers)
+
+	immediate := isKillTimeline(timeline)
+
+	for _, name := range containers {
+		args := []string{"stop", "--time", fmt.Sprintf("%d", int(info.GetStopGracePeriod().Seconds())), name}
+
+		if immediate {
+			args = []string{"kill", name}
+		}
+
+		wg.Add(1)
+
+		go func(args []string) {
+			defer wg.Done()
+
+			utils.LogDebug("stopContainers", args)
+
+			if output, err := exec.Command("docker", args...).CombinedOutput(); err != nil {
+				utils.LogDebug("stopContainers : could not stop container", err, string(output))
+			}
+		}(args)
+	}
+
+	return wg.Wait
+}
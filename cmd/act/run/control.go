@@ -0,0 +1,399 @@
+/**
+ * This file implements the control socket of act run processes. Each
+ * running act listens on a unix socket in its data dir so other act
+ * commands can inspect its live state (to diagnose hangs for example)
+ * without killing it. The protocol is simple: client sends a command
+ * name in a single line and the server writes back the response and
+ * closes the connection.
+ */
+
+package run
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the name of the control socket file we create in the
+ * act data dir.
+ */
+const ControlSocketFileName = "control.sock"
+
+/**
+ * Max time we wait a running act to answer a control request.
+ */
+const ControlRequestTimeout = 5 * time.Second
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max length of unix socket paths (the smallest limit across
+ * platforms which is the one of macos).
+ */
+const maxControlSocketPathLen = 104
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the live state of an act in the call
+ * stack.
+ */
+type ActState struct {
+	CallId       string
+	Act          string
+	Line         int
+	Stage        string
+	PendingCmds  int
+	Failed       bool
+	InFinalStage bool
+}
+
+/**
+ * This struct going to hold the environment where commands of the
+ * running act are executed.
+ */
+type ExecEnv struct {
+	Env []string
+	Dir string
+}
+
+/**
+ * This struct going to hold the live state of the running process.
+ */
+type RunState struct {
+	Id          string
+	NameId      string
+	State       string
+	IsFinishing bool
+	CallStack   []*ActState
+	CmdPgids    []int
+	FinalPgids  []int
+	RunningCmds []*RunningCmd
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Handlers for control commands by command name.
+ */
+var controlHandlers = map[string]func() string{
+	"state": func() string {
+		content, _ := json.MarshalIndent(GetRunState(), "", " ")
+		return string(content)
+	},
+	"dump": GetDump,
+	"flush": func() string {
+		if runCtx != nil {
+			runCtx.Info.Flush()
+		}
+
+		return "ok"
+	},
+	"env": func() string {
+		content, _ := json.MarshalIndent(GetExecEnv(), "", " ")
+		return string(content)
+	},
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to handle a control connection.
+ */
+func handleControlConn(conn net.Conn) {
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(ControlRequestTimeout))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+
+	if err != nil {
+		utils.LogDebug("handleControlConn : could not read command", err)
+		return
+	}
+
+	command := strings.TrimSpace(line)
+	handler, ok := controlHandlers[command]
+
+	if !ok {
+		fmt.Fprintf(conn, "unknown command %s", command)
+		return
+	}
+
+	conn.Write([]byte(handler()))
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function get the control socket path for this run info.
+ */
+func (info *Info) GetControlSocketPath() string {
+	return info.getControlSocketPaths()[0]
+}
+
+/**
+ * This function get the paths where the control socket can be in
+ * order of preference. Socket paths are limited in length so data
+ * dirs nested deep (like in long project paths) use a short path in
+ * the user temp dir instead.
+ */
+func (info *Info) getControlSocketPaths() []string {
+	shortPath := filepath.Join(getUserTempDirPath(), info.Id+".sock")
+	socketPath := filepath.Join(info.GetDataDirPath(), ControlSocketFileName)
+
+	if len(socketPath) > maxControlSocketPathLen {
+		return []string{shortPath}
+	}
+
+	return []string{socketPath, shortPath}
+}
+
+/**
+ * This function going to remove control socket files of this run.
+ */
+func (info *Info) rmControlSocket() {
+	for _, socketPath := range info.getControlSocketPaths() {
+		os.Remove(socketPath)
+	}
+}
+
+/**
+ * This function going to send a command to the running act process
+ * through its control socket and return the response.
+ */
+func (info *Info) ControlRequest(command string) (string, error) {
+	var conn net.Conn
+	var err error
+
+	for _, socketPath := range info.getControlSocketPaths() {
+		if conn, err = net.DialTimeout("unix", socketPath, ControlRequestTimeout); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(ControlRequestTimeout))
+
+	if _, err := fmt.Fprintf(conn, "%s\n", command); err != nil {
+		return "", err
+	}
+
+	response, err := ioutil.ReadAll(conn)
+
+	return string(response), err
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function get the live state of the current run.
+ */
+func GetRunState() *RunState {
+	if runCtx == nil {
+		return nil
+	}
+
+	// Commands keep running so we snapshot the state under locks.
+	info := runCtx.Info
+	info.mutex.Lock()
+
+	state := &RunState{
+		Id:       info.Id,
+		NameId:   info.NameId,
+		CmdPgids: append([]int{}, info.CmdPgids...),
+	}
+
+	info.mutex.Unlock()
+
+	state.RunningCmds = runCtx.GetRunningCmds()
+
+	runCtx.mutex.Lock()
+	defer runCtx.mutex.Unlock()
+
+	state.State = runCtx.State
+	state.IsFinishing = runCtx.IsFinishing
+	state.FinalPgids = append([]int{}, runCtx.FinalPgids...)
+
+	for _, ctx := range runCtx.ActCtxCallStack {
+		actState := &ActState{
+			CallId:       ctx.CallId,
+			Act:          ctx.Act.Name,
+			Line:         ctx.Act.Line,
+			PendingCmds:  int(atomic.LoadInt32(&ctx.pendingCmds)),
+			Failed:       ctx.Failed,
+			InFinalStage: ctx.InFinalStage,
+		}
+
+		if ctx.CurrentStage != nil {
+			actState.Stage = ctx.CurrentStage.Name
+		}
+
+		state.CallStack = append(state.CallStack, actState)
+	}
+
+	return state
+}
+
+/**
+ * This function get the environment (merged env vars and working
+ * dir) where commands of the act currently running are executed.
+ */
+func GetExecEnv() *ExecEnv {
+	if runCtx == nil || runCtx.ActCtx == nil {
+		return nil
+	}
+
+	// Use the innermost act currently running.
+	ctx := runCtx.ActCtx
+
+	if stack := runCtx.GetCallStack(); len(stack) > 0 {
+		ctx = stack[len(stack)-1]
+	}
+
+	// Commands of the act get this same environment.
+	return &ExecEnv{
+		Env: ctx.GetEnvVars(ctx.MergeVars()),
+		Dir: filepath.Dir(ctx.ActFile.LocationPath),
+	}
+}
+
+/**
+ * This function going to build a human readable dump of the current
+ * run containing the act call stack, currently executing commands
+ * with their runtimes and goroutine stacks.
+ */
+func GetDump() string {
+	var sb strings.Builder
+
+	if state := GetRunState(); state != nil {
+		fmt.Fprintf(&sb, "act %s [id=%s] [state=%s] [finishing=%t]\n\n", state.NameId, state.Id, state.State, state.IsFinishing)
+
+		sb.WriteString("call stack:\n")
+
+		for idx, actState := range state.CallStack {
+			fmt.Fprintf(&sb, "  #%d %s [act=%s] [line=%d] [stage=%s] [pending_cmds=%d] [failed=%t] [final=%t]\n", idx, actState.CallId, actState.Act, actState.Line, actState.Stage, actState.PendingCmds, actState.Failed, actState.InFinalStage)
+		}
+
+		sb.WriteString("\nrunning commands:\n")
+
+		for _, cmd := range state.RunningCmds {
+			fmt.Fprintf(&sb, "  [pid=%d] [pgid=%d] [act=%s] [stage=%s] [runtime=%s] (%s) %s\n", cmd.Pid, cmd.Pgid, cmd.Act, cmd.Stage, time.Since(cmd.StartedAt).Round(time.Millisecond), cmd.Source, cmd.Cmd)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	/**
+	 * Get stacks of all goroutines growing the buffer until it fits
+	 * the whole dump.
+	 */
+	buf := make([]byte, 64*1024)
+
+	for {
+		n := runtime.Stack(buf, true)
+
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+
+		buf = make([]byte, 2*len(buf))
+	}
+
+	sb.WriteString("goroutines:\n\n")
+	sb.Write(buf)
+
+	return sb.String()
+}
+
+/**
+ * This function going to print the dump of the current run to
+ * stderr.
+ */
+func Dump() {
+	fmt.Fprintln(os.Stderr, GetDump())
+}
+
+/**
+ * This function going to start listening for control requests. When
+ * we can't listen in the data dir we fallback to a short socket path
+ * in the user temp dir.
+ */
+func StartControlServer() {
+	var listener net.Listener
+	var err error
+
+	// Remove stale socket files (if any).
+	runCtx.Info.rmControlSocket()
+
+	for _, socketPath := range runCtx.Info.getControlSocketPaths() {
+		dirPath := filepath.Dir(socketPath)
+
+		if dirPath == getUserTempDirPath() {
+			err = utils.MakePrivateDir(dirPath)
+		} else {
+			// Info file (which creates the data dir) can be saved later.
+			err = os.MkdirAll(dirPath, 0755)
+		}
+
+		if err == nil {
+			listener, err = net.Listen("unix", socketPath)
+		}
+
+		if err == nil {
+			break
+		}
+
+		utils.LogWarn(fmt.Sprintf("could not listen for control requests on %s", socketPath), err)
+	}
+
+	if err != nil {
+		return
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go handleControlConn(conn)
+		}
+	}()
+}
@@ -0,0 +1,102 @@
+package run
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+/**
+ * Exec env must match the environment act commands get (including
+ * the special ACT_ENV_FILE var).
+ */
+func TestGetExecEnvActEnvFile(t *testing.T) {
+	actCtx := newTestRunningCtx(t)
+	actCtx.ActFile.EnvFilePath = ".env"
+
+	dir := filepath.Dir(actCtx.ActFile.LocationPath)
+	envFilePath := filepath.Join(dir, ".env")
+
+	if err := ioutil.WriteFile(envFilePath, []byte("FOO=bar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	execEnv := GetExecEnv()
+
+	if execEnv.Dir != dir {
+		t.Errorf("got dir %s, want %s", execEnv.Dir, dir)
+	}
+
+	envVars := make(map[string]bool)
+
+	for _, kv := range execEnv.Env {
+		envVars[kv] = true
+	}
+
+	for _, want := range []string{"FOO=bar", "ACT_ENV_FILE=" + envFilePath} {
+		if !envVars[want] {
+			t.Errorf("exec env %q is missing %s", execEnv.Env, want)
+		}
+	}
+}
+
+/**
+ * Run state can be requested while acts keep changing it (run with
+ * -race to catch unsafe reads).
+ */
+func TestGetRunStateWhileRunning(t *testing.T) {
+	actCtx := newTestRunningCtx(t)
+	runCtx.PushActCtx(actCtx)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			actCtx.setCurrentStage(&actfile.ActExecStage{Name: "start"})
+			runCtx.Info.AddCmdPgid(1000 + i)
+			runCtx.AddFinalPgid(2000 + i)
+			actCtx.SetFailed(1, "false")
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if state := GetRunState(); len(state.CallStack) != 1 {
+			t.Fatalf("got call stack %v, want test act", state.CallStack)
+		}
+	}
+
+	wg.Wait()
+
+	state := GetRunState()
+
+	if act := state.CallStack[0]; !act.Failed || act.Stage != "start" {
+		t.Errorf("got act state %+v, want failed in start stage", act)
+	}
+}
+
+/**
+ * Runs with data dirs too deep for a socket path still answer
+ * control requests through a short socket path.
+ */
+func TestControlServerLongDataDir(t *testing.T) {
+	newTestRunningCtx(t)
+
+	longDirPath := filepath.Join(os.Getenv("XDG_STATE_HOME"), strings.Repeat("d", 120))
+	os.Setenv("XDG_STATE_HOME", longDirPath)
+
+	defer runCtx.Info.rmControlSocket()
+
+	StartControlServer()
+
+	if response, err := runCtx.Info.ControlRequest("flush"); err != nil || response != "ok" {
+		t.Errorf("got response %q (%v), want ok", response, err)
+	}
+}
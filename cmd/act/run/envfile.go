@@ -0,0 +1,187 @@
+/**
+ * This file implements reading of env files set with `envfile` in
+ * actfiles. Env files can be encrypted with sops (dotenv format) or
+ * age in which case we decrypt them in memory (by shelling to the
+ * sops or age binaries) so plaintext is never written to disk.
+ */
+
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Headers of age encrypted files (binary and armored).
+ */
+const (
+	ageFileHeader        = "age-encryption.org/v1"
+	ageArmoredFileHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+)
+
+/**
+ * Key sops adds to encrypted dotenv files.
+ */
+const sopsEnvFileKey = "sops_version="
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold env file vars parsed in a run along with
+ * the file modification time and size when we parsed it.
+ */
+type envFileCacheEntry struct {
+	vars    map[string]string
+	modTime time.Time
+	size    int64
+}
+
+/**
+ * This struct going to hold env files parsed in a run by path so we
+ * don't read (and decrypt) the same env file for every command.
+ */
+type envFileCache struct {
+	entries map[string]*envFileCacheEntry
+
+	mutex sync.Mutex
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check if env file content was encrypted
+ * with sops.
+ */
+func isSopsEnvFile(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), sopsEnvFileKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to check if env file content was encrypted
+ * with age.
+ */
+func isAgeEnvFile(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(ageFileHeader)) || bytes.HasPrefix(content, []byte(ageArmoredFileHeader))
+}
+
+/**
+ * This function going to get the age identity file. We use the same
+ * identity sops uses so both encryption flavors share keys.
+ */
+func getAgeIdentityFilePath() string {
+	if filePath := os.Getenv("SOPS_AGE_KEY_FILE"); filePath != "" {
+		return filePath
+	}
+
+	configDir, _ := os.UserConfigDir()
+
+	return filepath.Join(configDir, "sops", "age", "keys.txt")
+}
+
+/**
+ * This function going to run a decryption command capturing the
+ * plaintext in memory.
+ */
+func decryptEnvFile(name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+/**
+ * This function going to read vars from an env file decrypting it
+ * first when it's encrypted with sops or age.
+ */
+func readEnvFile(filePath string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if isSopsEnvFile(content) {
+		if content, err = decryptEnvFile("sops", "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", filePath); err != nil {
+			return nil, err
+		}
+	} else if isAgeEnvFile(content) {
+		if content, err = decryptEnvFile("age", "--decrypt", "-i", getAgeIdentityFilePath(), filePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return godotenv.Unmarshal(string(content))
+}
+
+//############################################################
+// RunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to read vars from an env file reusing vars we
+ * already parsed in this run while the file doesn't change. Callers
+ * must not change the returned vars.
+ */
+func (ctx *RunCtx) readEnvFile(filePath string) (map[string]string, error) {
+	stat, err := os.Stat(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &ctx.envFiles
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry, ok := cache.entries[filePath]
+
+	if ok && entry.modTime.Equal(stat.ModTime()) && entry.size == stat.Size() {
+		return entry.vars, nil
+	}
+
+	vars, err := readEnvFile(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cache.entries == nil {
+		cache.entries = make(map[string]*envFileCacheEntry)
+	}
+
+	cache.entries[filePath] = &envFileCacheEntry{vars: vars, modTime: stat.ModTime(), size: stat.Size()}
+
+	return vars, nil
+}
@@ -0,0 +1,164 @@
+/**
+ * This file implements lifecycle events of runs (run started, stage
+ * started, command finished, health changed and run exited). Each
+ * run appends its events as NDJSON to an events file in its data
+ * dir so tooling (like `act events`) can follow what is going on
+ * without polling run info.
+ */
+
+package run
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a lifecycle event of a run.
+ */
+type Event struct {
+	/**
+	 * When the event happened (RFC3339 with nanoseconds).
+	 */
+	Time string `json:"time"`
+
+	/**
+	 * Event type (like `run_started`).
+	 */
+	Event string `json:"event"`
+
+	/**
+	 * Id of the run emitting the event.
+	 */
+	RunId string `json:"run_id"`
+
+	/**
+	 * Name of the run emitting the event.
+	 */
+	Name string `json:"name"`
+
+	/**
+	 * Call id of the act emitting the event (if any).
+	 */
+	Act string `json:"act,omitempty"`
+
+	/**
+	 * Stage emitting the event (if any).
+	 */
+	Stage string `json:"stage,omitempty"`
+
+	/**
+	 * Extra data of the event which depends on its type.
+	 */
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the name of the file in the act data dir where we append
+ * lifecycle events of the run.
+ */
+const EventsFileName = "events.jsonl"
+
+/**
+ * Lifecycle event types.
+ */
+const (
+	EventRunStarted    = "run_started"
+	EventStageStarted  = "stage_started"
+	EventCmdFinished   = "cmd_finished"
+	EventHealthChanged = "health_changed"
+	EventRunExited     = "run_exited"
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Mutex to prevent events emitted by parallel commands from being
+ * interleaved.
+ */
+var eventsMutex sync.Mutex
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function get the events file path for this run info.
+ */
+func (info *Info) GetEventsFilePath() string {
+	return filepath.Join(info.GetDataDirPath(), EventsFileName)
+}
+
+/**
+ * This function going to append a lifecycle event to the events
+ * file of this run.
+ */
+func (info *Info) EmitEvent(event string, act string, stage string, data map[string]interface{}) {
+	// Events are not persisted in memory-only mode.
+	if memoryOnly {
+		return
+	}
+
+	content, err := json.Marshal(&Event{
+		Time:  time.Now().Format(time.RFC3339Nano),
+		Event: event,
+		RunId: info.Id,
+		Name:  info.GetNameIdOrId(),
+		Act:   act,
+		Stage: stage,
+		Data:  data,
+	})
+
+	if err != nil {
+		utils.LogDebug("EmitEvent : could not marshal event", event, err)
+		return
+	}
+
+	eventsMutex.Lock()
+	defer eventsMutex.Unlock()
+
+	os.MkdirAll(info.GetDataDirPath(), 0755)
+
+	file, err := os.OpenFile(info.GetEventsFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		utils.LogDebug("EmitEvent : could not open events file", err)
+		return
+	}
+
+	defer file.Close()
+
+	file.Write(append(content, '\n'))
+}
+
+//############################################################
+// ActRunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to emit a lifecycle event of this act.
+ */
+func (ctx *ActRunCtx) EmitEvent(event string, data map[string]interface{}) {
+	stage := ""
+
+	if ctx.CurrentStage != nil {
+		stage = ctx.CurrentStage.Name
+	}
+
+	ctx.RunCtx.Info.EmitEvent(event, ctx.CallId, stage, data)
+}
@@ -0,0 +1,210 @@
+/**
+ * This file implements `http` commands which send an http request
+ * (replacing curl and jq one-liners in deployment and webhook steps).
+ * The response body is printed as command output and can be stored
+ * in runtime vars (whole or picking values of json responses) so
+ * following commands can use it in templates.
+ */
+
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Default max time an http command request can take.
+ */
+const DefaultHttpCmdTimeout = 30 * time.Second
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get a value of decoded json data by a dot
+ * path (like `data.items.0.id`).
+ */
+func lookupJsonPath(data interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if key == "" {
+			continue
+		}
+
+		switch val := data.(type) {
+		case map[string]interface{}:
+			if data = val[key]; data == nil {
+				return nil, false
+			}
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nil, false
+			}
+
+			data = val[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+/**
+ * This function going to get the vars an http command stores from
+ * its response.
+ */
+func getHttpCmdVars(httpCmd *actfile.CmdHttp, body []byte) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	if httpCmd.Register != "" {
+		vars[httpCmd.Register] = string(body)
+	}
+
+	if len(httpCmd.Capture) == 0 {
+		return vars, nil
+	}
+
+	var data interface{}
+
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("could not capture vars from response: %v", err)
+	}
+
+	for name, path := range httpCmd.Capture {
+		val, ok := lookupJsonPath(data, path)
+
+		if !ok {
+			return nil, fmt.Errorf("response has no %s", path)
+		}
+
+		// Strings are stored as is and anything else as json.
+		if str, isStr := val.(string); isStr {
+			vars[name] = str
+		} else {
+			content, _ := json.Marshal(val)
+			vars[name] = string(content)
+		}
+	}
+
+	return vars, nil
+}
+
+/**
+ * This function going to send the http request of a command. It
+ * fails when the request fails or the response status is not the
+ * expected one.
+ */
+func cmdHttpExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) (string, error) {
+	httpCmd := cmd.Http
+
+	method := strings.ToUpper(utils.CompileTemplate(httpCmd.Method, vars))
+
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	url := utils.CompileTemplate(httpCmd.Url, vars)
+	body := utils.CompileTemplate(httpCmd.Body, vars)
+	cmdLine := fmt.Sprintf("%s %s", method, url)
+
+	/**
+	 * Requests of final stages are never canceled while others are
+	 * aborted as soon as the execution gets stopped.
+	 */
+	reqCtx := context.Background()
+
+	if !ctx.IsFinalizing() {
+		reqCtx = ctx.RunCtx.Ctx
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, method, url, strings.NewReader(body))
+
+	if err != nil {
+		return cmdLine, err
+	}
+
+	for name, val := range httpCmd.Headers {
+		req.Header.Set(name, utils.CompileTemplate(val, vars))
+	}
+
+	if body != "" && req.Header.Get("Content-Type") == "" && json.Valid([]byte(body)) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	timeout := httpCmd.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultHttpCmdTimeout
+	}
+
+	client := http.Client{Timeout: timeout}
+
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return cmdLine, err
+	}
+
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+
+	if err != nil {
+		return cmdLine, err
+	}
+
+	if output, _ := getCmdOutputs(cmd, ctx); output != nil {
+		output.Write(respBody)
+
+		if len(respBody) > 0 && respBody[len(respBody)-1] != '\n' {
+			output.Write([]byte("\n"))
+		}
+
+		flushMaskWriters(output)
+	}
+
+	if httpCmd.ExpectStatus > 0 && resp.StatusCode != httpCmd.ExpectStatus {
+		err = fmt.Errorf("expected status %d but got %d", httpCmd.ExpectStatus, resp.StatusCode)
+	} else if httpCmd.ExpectStatus == 0 && resp.StatusCode >= 400 {
+		err = fmt.Errorf("got status %d", resp.StatusCode)
+	}
+
+	if cmd.Expect != nil {
+		err = checkCmdExpect(cmd.Expect, err, string(respBody), vars)
+	}
+
+	if err != nil {
+		return cmdLine, err
+	}
+
+	respVars, err := getHttpCmdVars(httpCmd, respBody)
+
+	if err != nil {
+		return cmdLine, err
+	}
+
+	if len(respVars) > 0 {
+		if err := ctx.RunCtx.Info.SetRuntimeVars(respVars); err != nil {
+			return cmdLine, fmt.Errorf("could not store response vars: %v", err)
+		}
+	}
+
+	return cmdLine, nil
+}
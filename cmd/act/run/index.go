@@ -0,0 +1,330 @@
+/**
+ * This file implements the run info index. Looking up a run by name
+ * used to read and decode the info file of every run in the project
+ * data dir so we keep a small index (id, name, parent, pid, state and
+ * input fingerprint of each run) updated whenever run info is saved
+ * or removed. When the index doesn't match run dirs on disk (like
+ * when an act process crashed before updating it) we fallback to
+ * scanning and rebuild it.
+ */
+
+package run
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the index entry of a run.
+ */
+type IndexEntry struct {
+	Id          string
+	NameId      string `json:",omitempty"`
+	ParentActId string `json:",omitempty"`
+	Pid         int
+	Exited      bool      `json:",omitempty"`
+	Dead        bool      `json:",omitempty"`
+	EndedAt     time.Time `json:",omitempty"`
+	Fingerprint string    `json:",omitempty"`
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Name of the index file we keep in project data dir.
+ */
+const IndexFileName = "index.json"
+
+/**
+ * Name of the lock file we use to guard index updates.
+ */
+const IndexLockFileName = "index.lock"
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to create the index entry of a run.
+ */
+func newIndexEntry(info *Info) IndexEntry {
+	return IndexEntry{
+		Id:          info.Id,
+		NameId:      info.NameId,
+		ParentActId: info.ParentActId,
+		Pid:         info.Pid,
+		Exited:      info.Exited,
+		Dead:        info.Dead,
+		EndedAt:     info.EndedAt,
+		Fingerprint: info.Fingerprint,
+	}
+}
+
+/**
+ * This function going to get names of run dirs in a data dir. Other
+ * files living there (like the index, locks and throttle files) are
+ * skipped and so are dirs without run info.
+ */
+func getRunDirNames(dataDirPath string) ([]string, error) {
+	dir, err := os.Open(dataDirPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer dir.Close()
+
+	files, err := dir.Readdir(-1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var runNames []string
+
+	for _, f := range files {
+		if !f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+
+		if utils.DoFileExists(filepath.Join(dataDirPath, f.Name(), InfoFileName)) {
+			runNames = append(runNames, f.Name())
+		}
+	}
+
+	return runNames, nil
+}
+
+/**
+ * This function going to read the index file of a data dir.
+ */
+func readIndexFile(dataDirPath string) (map[string]IndexEntry, error) {
+	content, err := ioutil.ReadFile(filepath.Join(dataDirPath, IndexFileName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]IndexEntry)
+
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+/**
+ * This function going to write the index file of a data dir.
+ */
+func writeIndexFile(dataDirPath string, entries map[string]IndexEntry) error {
+	content, _ := json.Marshal(entries)
+
+	return utils.WriteFileAtomic(filepath.Join(dataDirPath, IndexFileName), content, 0644)
+}
+
+/**
+ * This function going to read the index of a data dir. It returns
+ * false when there is no index or it's stale (i.e., it doesn't have
+ * exactly one entry per run dir).
+ */
+func readIndex(dataDirPath string) (map[string]IndexEntry, bool) {
+	if memoryOnly {
+		return nil, false
+	}
+
+	entries, err := readIndexFile(dataDirPath)
+
+	if err != nil {
+		return nil, false
+	}
+
+	names, err := getRunDirNames(dataDirPath)
+
+	if err != nil || len(names) != len(entries) {
+		return nil, false
+	}
+
+	for _, name := range names {
+		if _, ok := entries[name]; !ok {
+			return nil, false
+		}
+	}
+
+	return entries, true
+}
+
+/**
+ * This function going to rebuild the index of a data dir from run
+ * infos we got scanning it.
+ */
+func rebuildIndex(dataDirPath string, infos []*Info) {
+	if memoryOnly || !utils.DoFileExists(dataDirPath) {
+		return
+	}
+
+	unlock, err := utils.LockFile(filepath.Join(dataDirPath, IndexLockFileName), true)
+
+	if err != nil {
+		utils.LogDebug("rebuildIndex : could not lock index file", err)
+		return
+	}
+
+	defer unlock()
+
+	entries := make(map[string]IndexEntry)
+
+	for _, info := range infos {
+		entries[info.Id] = newIndexEntry(info)
+	}
+
+	if err := writeIndexFile(dataDirPath, entries); err != nil {
+		utils.LogDebug("rebuildIndex : could not write index file", err)
+	}
+}
+
+/**
+ * This function going to update the index entry of a run (removing
+ * it when entry is nil). We don't rebuild a missing (or corrupted)
+ * index here since lookups going to find it stale and rebuild it.
+ */
+func updateIndex(dataDirPath string, id string, entry *IndexEntry) {
+	unlock, err := utils.LockFile(filepath.Join(dataDirPath, IndexLockFileName), true)
+
+	if err != nil {
+		utils.LogDebug("updateIndex : could not lock index file", err)
+		return
+	}
+
+	defer unlock()
+
+	entries, err := readIndexFile(dataDirPath)
+
+	if err != nil {
+		entries = make(map[string]IndexEntry)
+	}
+
+	if entry == nil {
+		delete(entries, id)
+	} else {
+		entries[id] = *entry
+	}
+
+	if err := writeIndexFile(dataDirPath, entries); err != nil {
+		utils.LogDebug("updateIndex : could not write index file", err)
+	}
+}
+
+/**
+ * This function going to choose the run matching a name (name id or
+ * id) from index entries. Running acts take precedence over exited
+ * ones with the same name.
+ */
+func findIndexEntry(entries map[string]IndexEntry, name string) *IndexEntry {
+	var exitedEntry *IndexEntry
+
+	for id := range entries {
+		entry := entries[id]
+
+		if entry.NameId != name && entry.Id != name {
+			continue
+		}
+
+		if !entry.Exited && !entry.Dead {
+			return &entry
+		}
+
+		if exitedEntry == nil || entry.EndedAt.After(exitedEntry.EndedAt) {
+			exitedEntry = &entry
+		}
+	}
+
+	return exitedEntry
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function going to register this run in the index unless a
+ * running act conflicts with it (like a duplicated run). Checking
+ * and registering happen while holding the index lock so concurrent
+ * runs can't both pass the check. It returns the conflicting entry
+ * if any.
+ */
+func (info *Info) claimIndexEntry(conflicts func(entry IndexEntry) bool) (*IndexEntry, error) {
+	dataDirPath := filepath.Dir(info.GetDataDirPath())
+
+	if err := os.MkdirAll(dataDirPath, 0755); err != nil {
+		return nil, err
+	}
+
+	unlock, err := utils.LockFile(filepath.Join(dataDirPath, IndexLockFileName), true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	entries, err := readIndexFile(dataDirPath)
+
+	if err != nil {
+		entries = make(map[string]IndexEntry)
+	}
+
+	// Runs missing from index (like when it's stale) are loaded from their info files.
+	if names, err := getRunDirNames(dataDirPath); err == nil {
+		for _, name := range names {
+			if _, ok := entries[name]; ok {
+				continue
+			}
+
+			if runInfo := loadInfoFromFile(filepath.Join(dataDirPath, name, InfoFileName)); runInfo != nil {
+				entries[name] = newIndexEntry(runInfo)
+			}
+		}
+	}
+
+	for id := range entries {
+		entry := entries[id]
+
+		if entry.Id != info.Id && !entry.Exited && !entry.Dead && isProcessRunning(entry.Pid) && conflicts(entry) {
+			return &entry, nil
+		}
+	}
+
+	entries[info.Id] = newIndexEntry(info)
+
+	return nil, writeIndexFile(dataDirPath, entries)
+}
+
+/**
+ * This function going to update the index entry of this run when
+ * it changed since the last time we indexed it.
+ */
+func (info *Info) updateIndexEntry() {
+	entry := newIndexEntry(info)
+
+	if info.indexEntry != nil && *info.indexEntry == entry {
+		return
+	}
+
+	updateIndex(filepath.Dir(info.GetDataDirPath()), info.Id, &entry)
+
+	info.indexEntry = &entry
+}
@@ -0,0 +1,265 @@
+package run
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+/**
+ * This function going to point the registry to a temp dir for the
+ * duration of a test.
+ */
+func setupTestStateDir(t testing.TB) {
+	prevStateHome, hadStateHome := os.LookupEnv("XDG_STATE_HOME")
+
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	t.Cleanup(func() {
+		if hadStateHome {
+			os.Setenv("XDG_STATE_HOME", prevStateHome)
+		} else {
+			os.Unsetenv("XDG_STATE_HOME")
+		}
+	})
+}
+
+/**
+ * A run claiming the index sees runs which claimed it before even
+ * when their info was not saved yet.
+ */
+func TestClaimIndexEntryConflict(t *testing.T) {
+	setupTestStateDir(t)
+
+	sameFingerprint := func(fingerprint string) func(entry IndexEntry) bool {
+		return func(entry IndexEntry) bool {
+			return entry.Fingerprint == fingerprint
+		}
+	}
+
+	first := &Info{Id: "first", Pid: os.Getpid(), Fingerprint: "abc"}
+	second := &Info{Id: "second", Pid: os.Getpid(), Fingerprint: "abc"}
+	other := &Info{Id: "other", Pid: os.Getpid(), Fingerprint: "def"}
+
+	if entry, err := first.claimIndexEntry(sameFingerprint(first.Fingerprint)); err != nil || entry != nil {
+		t.Fatalf("first claim got entry %v and error %v", entry, err)
+	}
+
+	entry, err := second.claimIndexEntry(sameFingerprint(second.Fingerprint))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry == nil || entry.Id != first.Id {
+		t.Errorf("got conflicting entry %v, want %s", entry, first.Id)
+	}
+
+	if entry, err := other.claimIndexEntry(sameFingerprint(other.Fingerprint)); err != nil || entry != nil {
+		t.Errorf("other claim got entry %v and error %v", entry, err)
+	}
+}
+
+/**
+ * This function going to read the index failing when it doesn't
+ * match run dirs on disk.
+ */
+func readTestIndex(t *testing.T) map[string]IndexEntry {
+	entries, ok := readIndex(GetActDataDirPath())
+
+	if !ok {
+		t.Fatal("index does not match run dirs")
+	}
+
+	return entries
+}
+
+/**
+ * Saving and removing runs keep the index in sync with run dirs.
+ */
+func TestIndexAddRemove(t *testing.T) {
+	setupTestStateDir(t)
+
+	foo := &Info{Id: "foo-id", NameId: "foo", Pid: os.Getpid()}
+	bar := &Info{Id: "bar-id", NameId: "bar", Pid: os.Getpid()}
+
+	foo.Save()
+	bar.Save()
+
+	if entries := readTestIndex(t); len(entries) != 2 || entries["foo-id"].NameId != "foo" {
+		t.Errorf("got entries %v, want foo and bar", entries)
+	}
+
+	if info := GetInfo("bar"); info == nil || info.Id != "bar-id" {
+		t.Errorf("got info %v for bar, want bar-id", info)
+	}
+
+	foo.RmDataDir()
+
+	if entries := readTestIndex(t); len(entries) != 1 || entries["bar-id"].Id != "bar-id" {
+		t.Errorf("got entries %v, want only bar", entries)
+	}
+
+	if info := GetInfo("foo"); info != nil {
+		t.Errorf("got info %v for removed foo", info.Id)
+	}
+}
+
+/**
+ * Pruning exited runs removes their index entries while running ones
+ * are kept.
+ */
+func TestIndexPrune(t *testing.T) {
+	setupTestStateDir(t)
+
+	running := &Info{Id: "running-id", NameId: "running", Pid: os.Getpid()}
+	exited := &Info{Id: "exited-id", NameId: "exited", Pid: os.Getpid()}
+
+	running.Save()
+	exited.RecordExit(0)
+
+	if entry := readTestIndex(t)["exited-id"]; !entry.Exited {
+		t.Errorf("got entry %v, want exited", entry)
+	}
+
+	for _, info := range GetAllInfo() {
+		if info.Exited || info.Dead {
+			info.RmDataDir()
+		}
+	}
+
+	entries := readTestIndex(t)
+
+	if _, ok := entries["exited-id"]; ok || len(entries) != 1 {
+		t.Errorf("got entries %v, want only running", entries)
+	}
+}
+
+/**
+ * A stale index (like when an act process crashed before updating
+ * it) gets rebuilt from run dirs.
+ */
+func TestIndexRebuild(t *testing.T) {
+	setupTestStateDir(t)
+
+	info := &Info{Id: "foo-id", NameId: "foo", Pid: os.Getpid()}
+	info.Save()
+
+	// Run dir removed without updating the index.
+	os.RemoveAll(info.GetDataDirPath())
+
+	if _, ok := readIndex(GetActDataDirPath()); ok {
+		t.Fatal("index should be stale")
+	}
+
+	if infos := GetAllInfo(); len(infos) != 0 {
+		t.Errorf("got %d infos, want none", len(infos))
+	}
+
+	if entries := readTestIndex(t); len(entries) != 0 {
+		t.Errorf("got entries %v after rebuild, want none", entries)
+	}
+}
+
+/**
+ * Number of runs we seed for index benchmarks.
+ */
+const benchRunCount = 500
+
+/**
+ * This function going to seed runs (most of them exited) for index
+ * benchmarks.
+ */
+func seedBenchRuns(b *testing.B) {
+	setupTestStateDir(b)
+
+	for i := 0; i < benchRunCount; i++ {
+		info := &Info{Id: fmt.Sprintf("run-%d-id", i), NameId: fmt.Sprintf("run-%d", i), Pid: os.Getpid()}
+
+		if i < benchRunCount-10 {
+			info.RecordExit(0)
+		} else {
+			info.Save()
+		}
+	}
+}
+
+/**
+ * This function going to run a benchmark with an up to date index and
+ * with a stale one (removed before each iteration) so lookups
+ * fallback to scanning all runs.
+ */
+func runIndexBench(b *testing.B, fn func()) {
+	seedBenchRuns(b)
+
+	indexPath := filepath.Join(GetActDataDirPath(), IndexFileName)
+
+	b.Run("index", func(b *testing.B) {
+		fn()
+
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			fn()
+		}
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			os.Remove(indexPath)
+			b.StartTimer()
+
+			fn()
+		}
+	})
+}
+
+/**
+ * Looking a run up by name.
+ */
+func BenchmarkGetInfo(b *testing.B) {
+	name := fmt.Sprintf("run-%d", benchRunCount/2)
+
+	runIndexBench(b, func() {
+		if info := GetInfo(name); info == nil {
+			b.Fatalf("run %s not found", name)
+		}
+	})
+}
+
+/**
+ * Listing all runs.
+ */
+func BenchmarkGetAllInfo(b *testing.B) {
+	runIndexBench(b, func() {
+		if infos := GetAllInfo(); len(infos) != benchRunCount {
+			b.Fatalf("got %d infos, want %d", len(infos), benchRunCount)
+		}
+	})
+}
+
+/**
+ * Lock and throttle files living next to run dirs don't make the
+ * index stale.
+ */
+func TestIndexIgnoresOtherFiles(t *testing.T) {
+	setupTestStateDir(t)
+
+	info := &Info{Id: "foo-id", NameId: "foo", Pid: os.Getpid()}
+	info.Save()
+
+	dataDirPath := GetActDataDirPath()
+
+	for _, name := range []string{"abc.lock", "abc.lock.owner", "abc.last", "abc.debounce"} {
+		if err := ioutil.WriteFile(filepath.Join(dataDirPath, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if entries := readTestIndex(t); len(entries) != 1 || entries["foo-id"].NameId != "foo" {
+		t.Errorf("got entries %v, want only foo", entries)
+	}
+}
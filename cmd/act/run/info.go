@@ -2,15 +2,22 @@ package run
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
-	"github.com/logrusorgru/aurora/v3"
+	"github.com/joho/godotenv"
+	"github.com/nosebit/act/cmd/act/actfile"
 	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
 )
 
 //############################################################
@@ -26,23 +33,91 @@ import (
 const ActCallIdSeparator = "."
 
 /**
- * This is the name of the directory where we going to hold
- * all info for running acts.
+ * This is the name of the project local directory where we going
+ * to hold act logs when user wants them close to the project.
  */
 const ActDataDirName = ".actdt"
 
+/**
+ * This is the name of the log file in the act data dir.
+ */
+const LogFileName = "log"
+
+/**
+ * This is the name of the dir (next to the log file) where we keep
+ * a log file for each act of the run.
+ */
+const ActLogsDirName = "logs"
+
 /**
  * This is the file name we going to use when saving the info
  * struct back to file system.
  */
 const InfoFileName = "info.json"
 
+/**
+ * This is the name of the lock file we use to guard access to the
+ * info file from multiple processes.
+ */
+const InfoLockFileName = "info.lock"
+
+/**
+ * This is the name of the registry dir where we move corrupted info
+ * files so they can be inspected later.
+ */
+const QuarantineDirName = "quarantine"
+
 /**
  * This is the name of dotenv var file we use to share variables
  * between act/command execution.
  */
 const EnvFileName = "env"
 
+/**
+ * This is the name of the fifo file we create in the act data dir
+ * for interactive daemons so attached users can send input to them.
+ */
+const StdinFileName = "stdin"
+
+/**
+ * This is the name of the marker file we create in the act data dir
+ * when the act is paused. We use a separate file (instead of a field
+ * in info file) so the act process doesn't override it when saving
+ * its own info.
+ */
+const PausedFileName = "paused"
+
+/**
+ * Act health states.
+ */
+const (
+	HealthHealthy   string = "healthy"
+	HealthUnhealthy        = "unhealthy"
+)
+
+/**
+ * This is the default time we wait commands to exit after asking
+ * them to terminate before killing them.
+ */
+const DefaultStopGracePeriod = 10 * time.Second
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * This is how long we wait before writing info to file system after
+ * a frequent change (like a command spawn) so changes happening in a
+ * burst get coalesced into a single write.
+ */
+const infoSaveDebounce = 100 * time.Millisecond
+
+/**
+ * This is how often we check if a paused act was resumed before
+ * starting a command.
+ */
+const pausedPollInterval = 200 * time.Millisecond
+
 //############################################################
 // Types
 //############################################################
@@ -73,6 +148,48 @@ type Info struct {
 	 */
 	NameId string
 
+	/**
+	 * Description of the running act as specified in the actfile.
+	 */
+	Desc string
+
+	/**
+	 * Resolved path of the actfile where the running act was found.
+	 */
+	ActFilePath string
+
+	/**
+	 * Call id of the act being run.
+	 */
+	ActCallId string
+
+	/**
+	 * Arguments (flags, act name and act args) passed to run command
+	 * which we use to restart the act.
+	 */
+	RunArgs []string
+
+	/**
+	 * Working directory from where the act was run.
+	 */
+	Wd string
+
+	/**
+	 * Flag indicating the act is running as a daemon.
+	 */
+	IsDaemon bool
+
+	/**
+	 * Flag indicating act logs are kept in the project dir instead
+	 * of in the user registry.
+	 */
+	LocalLogs bool
+
+	/**
+	 * Flag indicating the act accepts input from attached users.
+	 */
+	Interactive bool
+
 	/**
 	 * This is the process group id of this act process.
 	 */
@@ -93,6 +210,27 @@ type Info struct {
 	 */
 	CmdPgids []int
 
+	/**
+	 * Start time of the leader process of each command process group
+	 * (recorded when the command starts) so we can tell our process
+	 * groups apart from recycled ones even after act process died.
+	 */
+	CmdPgidStarts map[int]string `json:",omitempty"`
+
+	/**
+	 * Names of docker containers running commands of this act so we
+	 * can stop them when stopping the act (killing the docker client
+	 * doesn't stop the container).
+	 */
+	Containers []string `json:",omitempty"`
+
+	/**
+	 * Commands running on remote hosts so we can stop them when
+	 * stopping the act (killing the ssh client doesn't stop commands
+	 * running without a tty).
+	 */
+	RemoteCmds []*RemoteCmd `json:",omitempty"`
+
 	/**
 	 * This is a list of ids of all act detached processes created
 	 * by this act process.
@@ -104,31 +242,275 @@ type Info struct {
 	 */
 	IsKilling bool
 
+	/**
+	 * How long we wait commands to exit after asking them to
+	 * terminate (SIGTERM) before killing them (SIGKILL).
+	 */
+	StopGracePeriod *time.Duration `json:",omitempty"`
+
+	/**
+	 * Timeline of signals we send to commands when stopping the act.
+	 */
+	StopTimeline []*actfile.ActStopStep `json:",omitempty"`
+
+	/**
+	 * List of call ids of acts which checks already passed.
+	 */
+	ReadyActs []string
+
+	/**
+	 * Health (healthy/unhealthy) of acts with checks by call id.
+	 */
+	Health map[string]string
+
+	/**
+	 * List of call ids of acts which checks are still being watched
+	 * (i.e., acts which are not ready yet).
+	 */
+	StartingActs []string
+
+	/**
+	 * When the act process started.
+	 */
+	StartedAt time.Time
+
+	/**
+	 * How many times this act was restarted.
+	 */
+	RestartCount int
+
+	/**
+	 * Exit code of the last failed command.
+	 */
+	LastExitCode int
+
+	/**
+	 * Flag indicating the act process already finished and this info
+	 * is just a terminal record of the run.
+	 */
+	Exited bool
+
+	/**
+	 * Flag indicating the act process died without recording its
+	 * exit (like when it crashed or was killed with SIGKILL).
+	 */
+	Dead bool
+
+	/**
+	 * Exit code of the act process (set when it exits).
+	 */
+	ExitCode int
+
+	/**
+	 * When the act process finished.
+	 */
+	EndedAt time.Time
+
+	/**
+	 * For how long the act process ran.
+	 */
+	Duration time.Duration
+
+	/**
+	 * Checksum of the run inputs (sources and args) which we use
+	 * to detect duplicated runs.
+	 */
+	Fingerprint string
+
+	/**
+	 * Timing of commands run so far.
+	 */
+	Timings []*CmdTiming `json:",omitempty"`
+
 	/**
 	 * Mutex to pevent race conditions of multiple parallel
 	 * commands changing the same info struct.
 	 */
 	mutex sync.Mutex `json:"-"`
+
+	/**
+	 * Last index entry we saved for this info (so we only update the
+	 * index when the entry changes).
+	 */
+	indexEntry *IndexEntry `json:"-"`
+
+	/**
+	 * Flag indicating info has changes not written to file system
+	 * yet along with the timer going to write them.
+	 */
+	dirty     bool        `json:"-"`
+	saveTimer *time.Timer `json:"-"`
+
+	/**
+	 * Running commands and child acts as of the last time we read or
+	 * wrote the info file (so saves can merge changes other act
+	 * processes made meanwhile).
+	 */
+	synced *infoSyncedSets `json:"-"`
+}
+
+/**
+ * This struct going to hold info fields other act processes (like
+ * child acts or act stop) change concurrently.
+ */
+type infoSyncedSets struct {
+	CmdPgids    []int
+	ChildActIds []string
 }
 
+//############################################################
+// Internal Variables
+//############################################################
+/**
+ * Flag indicating act data dir is not writable and therefore we
+ * are keeping run info in memory only.
+ */
+var memoryOnly bool
+
 //############################################################
 // Internal Functions
 //############################################################
+
+/**
+ * This function going to check if a stop timeline kills right away.
+ */
+func isKillTimeline(timeline []*actfile.ActStopStep) bool {
+	if len(timeline) == 0 {
+		return false
+	}
+
+	sig, err := utils.ParseSignal(timeline[0].Signal)
+
+	return err == nil && sig == syscall.SIGKILL
+}
+
+/**
+ * This function going to combine errors into a single one (nil
+ * errors are skipped).
+ */
+func combineErrors(errs []error) error {
+	var nonNilErrs []error
+	var msgs []string
+
+	for _, err := range errs {
+		if err != nil {
+			nonNilErrs = append(nonNilErrs, err)
+			msgs = append(msgs, err.Error())
+		}
+	}
+
+	switch len(nonNilErrs) {
+	case 0:
+		return nil
+	case 1:
+		return nonNilErrs[0]
+	}
+
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+/**
+ * This function going to check if a process group still has any
+ * process running.
+ */
+func isProcessGroupRunning(pgid int) bool {
+	return procgroup.IsGroupRunning(pgid)
+}
+
 /**
  * This function going to check if process is up and running.
  */
 func isProcessRunning(pid int) bool {
-	process, err := os.FindProcess(int(pid))
+	return procgroup.IsRunning(pid)
+}
 
-	if err == nil {
-		err := process.Signal(syscall.Signal(0))
+/**
+ * This function going to check if we can write files in a data dir
+ * by creating (and removing) a test file.
+ */
+func isDataDirWritable(dataDirPath string) bool {
+	testFile, err := ioutil.TempFile(dataDirPath, ".write-test")
 
-		if err == nil {
-			return true
+	if err != nil {
+		return false
+	}
+
+	testFile.Close()
+	os.Remove(testFile.Name())
+
+	return true
+}
+
+/**
+ * This function going to switch to memory-only mode because a data
+ * dir is not writable.
+ */
+func useMemoryOnly(dataDirPath string) {
+	memoryOnly = true
+
+	utils.LogWarn(fmt.Sprintf("act data dir %s is not writable : running in memory-only mode (daemons and list not available)", dataDirPath))
+}
+
+/**
+ * This function going to merge a list changed by us and by another
+ * process since base. We keep items they have (unless we removed
+ * them) and items we added.
+ */
+func mergeSyncedList(base []string, ours []string, theirs []string) []string {
+	inBase := make(map[string]bool)
+	inOurs := make(map[string]bool)
+	inTheirs := make(map[string]bool)
+
+	for _, val := range base {
+		inBase[val] = true
+	}
+
+	for _, val := range ours {
+		inOurs[val] = true
+	}
+
+	for _, val := range theirs {
+		inTheirs[val] = true
+	}
+
+	var merged []string
+	seen := make(map[string]bool)
+
+	for _, val := range append(append([]string{}, theirs...), ours...) {
+		keep := (inTheirs[val] && (inOurs[val] || !inBase[val])) || (inOurs[val] && !inBase[val])
+
+		if keep && !seen[val] {
+			merged = append(merged, val)
+			seen[val] = true
 		}
 	}
 
-	return false
+	return merged
+}
+
+/**
+ * This function going to merge pgids changed by us and by another
+ * process since base.
+ */
+func mergeSyncedPgids(base []int, ours []int, theirs []int) []int {
+	toList := func(pgids []int) []string {
+		var list []string
+
+		for _, pgid := range pgids {
+			list = append(list, strconv.Itoa(pgid))
+		}
+
+		return list
+	}
+
+	var merged []int
+
+	for _, val := range mergeSyncedList(toList(base), toList(ours), toList(theirs)) {
+		pgid, _ := strconv.Atoi(val)
+		merged = append(merged, pgid)
+	}
+
+	return merged
 }
 
 //############################################################
@@ -152,7 +534,7 @@ func (info *Info) AddChildActId(id string) {
 
 	if idx < 0 {
 		info.ChildActIds = append(info.ChildActIds, id)
-		info.Save()
+		info.save()
 	}
 
 	info.mutex.Unlock()
@@ -179,15 +561,15 @@ func (info *Info) RmChildActId(id string) {
 		copy(childActIds, info.ChildActIds)
 
 		info.ChildActIds = append(childActIds[:idx], childActIds[idx+1:]...)
-		info.Save()
+		info.save()
 	}
 
 	info.mutex.Unlock()
 }
 
 /**
- * This function going to add a new Pgid to info and then save
- * info back to file system.
+ * This function going to add a new Pgid to info and then schedule
+ * a save of info back to file system.
  */
 func (info *Info) AddCmdPgid(pgid int) {
 	info.mutex.Lock()
@@ -203,15 +585,24 @@ func (info *Info) AddCmdPgid(pgid int) {
 
 	if idx < 0 {
 		info.CmdPgids = append(info.CmdPgids, pgid)
-		info.Save()
+
+		if start := procgroup.GetStartTime(pgid); start != "" {
+			if info.CmdPgidStarts == nil {
+				info.CmdPgidStarts = make(map[int]string)
+			}
+
+			info.CmdPgidStarts[pgid] = start
+		}
+
+		info.saveLater()
 	}
 
 	info.mutex.Unlock()
 }
 
 /**
- * This function removes a pgid from info and then save the info
- * back to file system.
+ * This function removes a pgid from info and then schedule a save
+ * of info back to file system.
  */
 func (info *Info) RmCmdPgid(pgid int) {
 	info.mutex.Lock()
@@ -234,112 +625,769 @@ func (info *Info) RmCmdPgid(pgid int) {
 		copy(cmdPgids, info.CmdPgids)
 
 		info.CmdPgids = append(cmdPgids[:idx], cmdPgids[idx+1:]...)
-		info.Save()
+		delete(info.CmdPgidStarts, pgid)
+		info.saveLater()
 	}
 
 	info.mutex.Unlock()
 }
 
 /**
- * This function going to set IsKilling flag.
+ * This function going to flag an act as ready (i.e., its checks
+ * passed) and then save info back to file system.
  */
-func (info *Info) SetIsKilling() {
+func (info *Info) AddReadyAct(callId string) {
 	info.mutex.Lock()
 
-	info.IsKilling = true
-	info.Save()
+	for _, val := range info.ReadyActs {
+		if val == callId {
+			info.mutex.Unlock()
+			return
+		}
+	}
+
+	info.ReadyActs = append(info.ReadyActs, callId)
+	info.save()
 
 	info.mutex.Unlock()
 }
 
 /**
- * This function get name id if present or id otherwise.
+ * This function going to flag an act as starting (i.e., we are
+ * waiting its checks to pass) and then save info back to file system.
  */
-func (info *Info) GetNameIdOrId() string {
-	if info.NameId != "" {
-		return info.NameId
+func (info *Info) AddStartingAct(callId string) {
+	info.mutex.Lock()
+
+	for _, val := range info.StartingActs {
+		if val == callId {
+			info.mutex.Unlock()
+			return
+		}
 	}
 
-	return info.Id
-}
+	info.StartingActs = append(info.StartingActs, callId)
+	info.save()
 
-/**
- * This function get data dir for this run info.
- */
-func (info *Info) GetDataDirPath() string {
-	return path.Join(utils.GetWd(), ActDataDirName, info.Id)
+	info.mutex.Unlock()
 }
 
 /**
- * This function get the log file path for this run info.
+ * This function going to remove the starting flag of an act and
+ * then save info back to file system.
  */
-func (info *Info) GetLogFilePath() string {
-	return path.Join(utils.GetWd(), ActDataDirName, info.Id, "log")
-}
+func (info *Info) RmStartingAct(callId string) {
+	info.mutex.Lock()
 
-/**
- * This function get env vars file path for this run info.
- */
-func (info *Info) GetEnvVarsFilePath() string {
-	return path.Join(info.GetDataDirPath(), EnvFileName)
+	for idx, val := range info.StartingActs {
+		if val == callId {
+			startingActs := make([]string, len(info.StartingActs))
+			copy(startingActs, info.StartingActs)
+
+			info.StartingActs = append(startingActs[:idx], startingActs[idx+1:]...)
+			info.save()
+			break
+		}
+	}
+
+	info.mutex.Unlock()
 }
 
 /**
- * This function going to save info to a file in the data
- * directory.
+ * This function going to set the health of an act and then save info
+ * back to file system. It returns true if health changed.
  */
-func (info *Info) Save() {
-	content, _ := json.MarshalIndent(info, "", " ")
+func (info *Info) SetActHealth(callId string, health string) bool {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
 
-	dirPath := info.GetDataDirPath()
+	if info.Health == nil {
+		info.Health = make(map[string]string)
+	}
 
-	os.MkdirAll(dirPath, 0755)
+	if info.Health[callId] == health {
+		return false
+	}
 
-	infoFilePath := path.Join(dirPath, InfoFileName)
+	info.Health[callId] = health
+	info.save()
 
-	if err := ioutil.WriteFile(infoFilePath, content, 0644); err != nil {
-		utils.FatalError("could not save run info file", err)
-	}
+	return true
 }
 
 /**
- * This function going to remove run info directory.
+ * This function going to check if any act is unhealthy.
  */
-func (info *Info) RmDataDir() {
-	dataDirPath := info.GetDataDirPath()
+func (info *Info) IsUnhealthy() bool {
+	for _, health := range info.Health {
+		if health == HealthUnhealthy {
+			return true
+		}
+	}
 
-	os.RemoveAll(dataDirPath)
+	return false
 }
 
 /**
- * This function going to kill only the running child commands.
+ * This function get the overall health of acts with checks. It
+ * returns an empty string if no act has checks yet.
  */
-func (info *Info) KillChildCmds() {
-	cmdPgids := make([]int, len(info.CmdPgids))
-	copy(cmdPgids, info.CmdPgids)
+func (info *Info) GetHealth() string {
+	if info.IsUnhealthy() {
+		return HealthUnhealthy
+	}
+
+	if len(info.Health) > 0 {
+		return HealthHealthy
+	}
+
+	return ""
+}
+
+/**
+ * This function going to increment the restart count and then save
+ * info back to file system.
+ */
+func (info *Info) IncRestartCount() {
+	info.mutex.Lock()
+
+	info.RestartCount++
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to set the exit code of the last failed
+ * command and then save info back to file system.
+ */
+func (info *Info) SetLastExitCode(exitCode int) {
+	info.mutex.Lock()
+
+	info.LastExitCode = exitCode
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to set IsKilling flag.
+ */
+func (info *Info) SetIsKilling() {
+	info.mutex.Lock()
+
+	info.IsKilling = true
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function get name id if present or id otherwise.
+ */
+func (info *Info) GetNameIdOrId() string {
+	if info.NameId != "" {
+		return info.NameId
+	}
+
+	return info.Id
+}
+
+/**
+ * This function get data dir for this run info.
+ */
+func (info *Info) GetDataDirPath() string {
+	/**
+	 * In memory-only mode we still need a place to hold runtime
+	 * files (like the env file shared between commands) so we use
+	 * a private temp dir which is not visible to other commands.
+	 */
+	if memoryOnly {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("act-%s", info.Id))
+	}
+
+	// Info can be from another project (like when listing all projects).
+	if info.Wd != "" {
+		return filepath.Join(GetProjectDataDirPath(info.Wd), info.Id)
+	}
+
+	return filepath.Join(GetActDataDirPath(), info.Id)
+}
+
+/**
+ * This function get the project local dir where we keep logs for
+ * this run info when user wants logs close to the project.
+ */
+func (info *Info) GetLocalLogDirPath() string {
+	return filepath.Join(info.Wd, ActDataDirName, info.Id)
+}
+
+/**
+ * This function get the log file path for this run info.
+ */
+func (info *Info) GetLogFilePath() string {
+	if info.LocalLogs && info.Wd != "" && !memoryOnly {
+		return filepath.Join(info.GetLocalLogDirPath(), LogFileName)
+	}
+
+	return filepath.Join(info.GetDataDirPath(), LogFileName)
+}
+
+/**
+ * This function get the path of the log file of a specific act (by
+ * its call id) of this run.
+ */
+func (info *Info) GetActLogFilePath(callId string) string {
+	return filepath.Join(filepath.Dir(info.GetLogFilePath()), ActLogsDirName, fmt.Sprintf("%s.log", callId))
+}
+
+/**
+ * This function get the path of the file where we tee stderr output
+ * for this run info.
+ */
+func (info *Info) GetErrLogFilePath() string {
+	return fmt.Sprintf("%s%s", info.GetLogFilePath(), ErrLogFileExt)
+}
+
+/**
+ * This function get the stdin fifo path for this run info.
+ */
+func (info *Info) GetStdinFilePath() string {
+	return filepath.Join(info.GetDataDirPath(), StdinFileName)
+}
+
+/**
+ * This function get env vars file path for this run info.
+ */
+func (info *Info) GetEnvVarsFilePath() string {
+	return filepath.Join(info.GetDataDirPath(), EnvFileName)
+}
+
+/**
+ * This function going to set vars in the runtime env vars file so
+ * following commands get them (as template and env vars).
+ */
+func (info *Info) SetRuntimeVars(vars map[string]string) error {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	filePath := info.GetEnvVarsFilePath()
+	runtimeVars, err := godotenv.Read(filePath)
+
+	if err != nil {
+		runtimeVars = make(map[string]string)
+	}
+
+	for key, val := range vars {
+		runtimeVars[key] = val
+	}
+
+	os.MkdirAll(filepath.Dir(filePath), 0755)
+
+	return godotenv.Write(runtimeVars, filePath)
+}
+
+/**
+ * This function going to save info to a file in the data
+ * directory.
+ */
+func (info *Info) Save() {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	info.save()
+}
+
+/**
+ * This function going to save info to a file in the data
+ * directory. It must be called with info mutex locked so
+ * concurrent saves don't interleave.
+ */
+func (info *Info) save() {
+	info.dirty = false
+
+	if info.saveTimer != nil {
+		info.saveTimer.Stop()
+		info.saveTimer = nil
+	}
+
+	dirPath := info.GetDataDirPath()
+
+	os.MkdirAll(dirPath, 0755)
+
+	// Run info is not persisted in memory-only mode.
+	if memoryOnly {
+		return
+	}
+
+	infoFilePath := filepath.Join(dirPath, InfoFileName)
+
+	// Index gets updated once we release the info file lock.
+	defer info.updateIndexEntry()
+
+	/**
+	 * Other act processes (like parent/child acts or act stop) can
+	 * write the same info file so we hold a lock while merging their
+	 * changes and writing ours.
+	 */
+	unlock, err := utils.LockFile(filepath.Join(dirPath, InfoLockFileName), true)
+
+	if err != nil {
+		utils.LogDebug("Save : could not lock run info file", err)
+	} else {
+		defer unlock()
+
+		info.mergeFileChanges(infoFilePath)
+	}
+
+	content, _ := json.MarshalIndent(info, "", " ")
+
+	info.markSynced()
+
+	if err := utils.WriteFileAtomic(infoFilePath, content, 0644); err != nil {
+		// Data dir can stop being writable while we run.
+		if dataDirPath := filepath.Dir(dirPath); !isDataDirWritable(dataDirPath) {
+			useMemoryOnly(dataDirPath)
+			return
+		}
+
+		utils.FatalError("could not save run info file", err)
+	}
+}
+
+/**
+ * This function going to remember running commands and child acts
+ * as we just read or wrote them to the info file.
+ */
+func (info *Info) markSynced() {
+	info.synced = &infoSyncedSets{
+		CmdPgids:    append([]int{}, info.CmdPgids...),
+		ChildActIds: append([]string{}, info.ChildActIds...),
+	}
+}
+
+/**
+ * This function going to merge changes other act processes made to
+ * the info file since we last read or wrote it (like act stop
+ * removing commands it killed or child acts adding themselves) into
+ * ours. It must be called holding the info file lock so nobody
+ * writes the file between our merge and our write.
+ */
+func (info *Info) mergeFileChanges(infoFilePath string) {
+	if info.synced == nil {
+		return
+	}
+
+	content, err := ioutil.ReadFile(infoFilePath)
+
+	if err != nil {
+		return
+	}
+
+	var fileInfo Info
+
+	if err := json.Unmarshal(content, &fileInfo); err != nil {
+		return
+	}
+
+	info.CmdPgids = mergeSyncedPgids(info.synced.CmdPgids, info.CmdPgids, fileInfo.CmdPgids)
+	info.ChildActIds = mergeSyncedList(info.synced.ChildActIds, info.ChildActIds, fileInfo.ChildActIds)
+	info.IsKilling = info.IsKilling || fileInfo.IsKilling
+
+	// Start times follow the pgids we kept.
+	pgidStarts := make(map[int]string)
+
+	for _, pgid := range info.CmdPgids {
+		if start, ok := info.CmdPgidStarts[pgid]; ok {
+			pgidStarts[pgid] = start
+		} else if start, ok := fileInfo.CmdPgidStarts[pgid]; ok {
+			pgidStarts[pgid] = start
+		}
+	}
+
+	if len(pgidStarts) > 0 || info.CmdPgidStarts != nil {
+		info.CmdPgidStarts = pgidStarts
+	}
+}
+
+/**
+ * This function going to schedule a save of info to file system. It
+ * must be called with info mutex locked. Changes made until the
+ * timer fires are written all at once.
+ */
+func (info *Info) saveLater() {
+	info.dirty = true
+
+	if info.saveTimer != nil {
+		return
+	}
+
+	info.saveTimer = time.AfterFunc(infoSaveDebounce, func() {
+		info.mutex.Lock()
+		defer info.mutex.Unlock()
+
+		if info.dirty {
+			info.save()
+		}
+	})
+}
+
+/**
+ * This function going to schedule a save of info to file system.
+ */
+func (info *Info) SaveLater() {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	info.saveLater()
+}
+
+/**
+ * This function going to write pending info changes to file system
+ * right away.
+ */
+func (info *Info) Flush() {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	if info.dirty {
+		info.save()
+	}
+}
+
+/**
+ * This function going to drop pending info changes so a scheduled
+ * save don't recreate a removed data dir.
+ */
+func (info *Info) discardPending() {
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	info.dirty = false
+
+	if info.saveTimer != nil {
+		info.saveTimer.Stop()
+		info.saveTimer = nil
+	}
+}
+
+/**
+ * This function going to ask the act process owning this info (when
+ * it's another process) to flush pending changes and then reload the
+ * running commands and child acts from file system. We do this before
+ * stopping an act so we don't miss commands it just started.
+ */
+func (info *Info) syncPending() {
+	if info.Pid == os.Getpid() || !info.IsRunning() {
+		return
+	}
+
+	if _, err := info.ControlRequest("flush"); err != nil {
+		utils.LogDebug("syncPending : could not flush run info", err)
+		return
+	}
+
+	fresh := loadInfoFromFile(filepath.Join(info.GetDataDirPath(), InfoFileName))
+
+	if fresh == nil {
+		return
+	}
+
+	info.mutex.Lock()
+	info.CmdPgids = fresh.CmdPgids
+	info.CmdPgidStarts = fresh.CmdPgidStarts
+	info.ChildActIds = fresh.ChildActIds
+	info.markSynced()
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to remove run info directory.
+ */
+func (info *Info) RmDataDir() {
+	info.discardPending()
+
+	dataDirPath := info.GetDataDirPath()
+
+	os.RemoveAll(dataDirPath)
+
+	if !memoryOnly {
+		updateIndex(filepath.Dir(dataDirPath), info.Id, nil)
+	}
+
+	if info.LocalLogs && info.Wd != "" {
+		os.RemoveAll(info.GetLocalLogDirPath())
+	}
+}
+
+/**
+ * This function going to keep a terminal record of the run (exit
+ * code, end time and duration) so users can inspect exit status and
+ * logs of finished daemons until they prune them.
+ */
+func (info *Info) RecordExit(exitCode int) {
+	info.mutex.Lock()
+
+	info.Exited = true
+	info.ExitCode = exitCode
+	info.EndedAt = time.Now()
+	info.CmdPgids = nil
+	info.CmdPgidStarts = nil
+
+	if !info.StartedAt.IsZero() {
+		info.Duration = info.EndedAt.Sub(info.StartedAt).Round(time.Millisecond)
+	}
+
+	info.save()
+
+	info.mutex.Unlock()
+
+	// Remove files which only make sense while the act is running.
+	info.rmControlSocket()
+	os.Remove(info.GetStdinFilePath())
+	os.Remove(filepath.Join(info.GetDataDirPath(), PausedFileName))
+}
+
+/**
+ * This function going to release run info when the act process
+ * finishes. Daemons keep a terminal record while other acts get
+ * their data dir removed.
+ */
+func (info *Info) Close(exitCode int) {
+	if info.IsDaemon && !memoryOnly {
+		info.RecordExit(exitCode)
+		return
+	}
+
+	info.RmDataDir()
+}
+
+/**
+ * This function get how long we wait commands to exit after
+ * asking them to terminate before killing them.
+ */
+func (info *Info) GetStopGracePeriod() time.Duration {
+	if info.StopGracePeriod != nil {
+		return *info.StopGracePeriod
+	}
+
+	return DefaultStopGracePeriod
+}
+
+/**
+ * This function get the timeline of signals we send to commands when
+ * stopping the act. By default we ask commands to terminate and kill
+ * them after the stop grace period. Custom timelines not ending with
+ * a kill get one a grace period after their last step so commands
+ * never outlive the stop.
+ */
+func (info *Info) GetStopTimeline() []*actfile.ActStopStep {
+	if len(info.StopTimeline) > 0 {
+		last := info.StopTimeline[len(info.StopTimeline)-1]
+
+		if sig, err := utils.ParseSignal(last.Signal); err == nil && sig == syscall.SIGKILL {
+			return info.StopTimeline
+		}
+
+		return append(append([]*actfile.ActStopStep{}, info.StopTimeline...), &actfile.ActStopStep{
+			Signal: "KILL",
+			After:  last.After + info.GetStopGracePeriod(),
+		})
+	}
+
+	return []*actfile.ActStopStep{
+		{Signal: "TERM"},
+		{Signal: "KILL", After: info.GetStopGracePeriod()},
+	}
+}
+
+/**
+ * This function going to run a stop hook act. Since we can be stopping
+ * the act from another process (like `act stop`) we run the hook as a
+ * separate act process. Hooks can't hold the stop back so we stop
+ * waiting (and ask the hook to stop) once timeout passed.
+ */
+func (info *Info) runStopHook(hook string, timeout time.Duration) {
+	utils.LogDebug(fmt.Sprintf("runStopHook [id=%s] [hook=%s] [timeout=%s]", info.Id, hook, timeout))
+
+	shCmd := exec.Command(utils.GetActBin(), "run", fmt.Sprintf("-f=%s", info.ActFilePath), hook)
+	shCmd.Dir = info.Wd
+	shCmd.Stdout = os.Stdout
+	shCmd.Stderr = os.Stderr
+
+	if err := shCmd.Start(); err != nil {
+		utils.LogError(fmt.Sprintf("stop hook %s failed", hook), err)
+		return
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- shCmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			utils.LogError(fmt.Sprintf("stop hook %s failed", hook), err)
+		}
+	case <-time.After(timeout):
+		// The hook act stops its own commands.
+		shCmd.Process.Signal(syscall.SIGTERM)
+		utils.LogError(fmt.Sprintf("stop hook %s timed out after %s", hook, timeout))
+	}
+}
+
+/**
+ * This function going to kill only the running child commands. We
+ * follow the act stop timeline sending each signal to the commands
+ * still running at the step time.
+ */
+func (info *Info) KillChildCmds() {
+	info.signalChildCmds(info.GetStopTimeline())
+}
+
+/**
+ * This function going to send signals of a stop timeline to running
+ * child commands. It returns what was actually signaled (like
+ * "TERM to 2 cmds") in the order signals were sent along with an
+ * error when some commands could not be signaled.
+ */
+func (info *Info) signalChildCmds(timeline []*actfile.ActStopStep) ([]string, error) {
+	containersDone := info.stopContainers(timeline)
+	defer containersDone()
+
+	remoteCmdsDone := info.stopRemoteCmds(timeline)
+	defer remoteCmdsDone()
+
+	cmdPgids := make([]int, len(info.CmdPgids))
+	copy(cmdPgids, info.CmdPgids)
 
 	utils.LogDebug(fmt.Sprintf("KillChildCmds [id=%s] [num_cmds=%d]", info.Id, len(cmdPgids)))
 
-	// Kill all running commands.
+	var alivePgids []int
+	var signaled []string
+	var errs []error
+
 	for _, pgid := range cmdPgids {
-		utils.LogDebug(fmt.Sprintf("KillChildCmds [id=%s] : kill command %d", info.Id, pgid))
+		if pgid > 0 {
+			alivePgids = append(alivePgids, pgid)
+		}
+	}
+
+	startedAt := time.Now()
 
-		if pgid < 0 {
+	for stepIdx, step := range timeline {
+		sig, err := utils.ParseSignal(step.Signal)
+
+		if err != nil {
+			utils.LogError("invalid stop timeline signal", err)
 			continue
 		}
 
-		if err := syscall.Kill(-pgid, syscall.SIGKILL); err != nil {
-			utils.LogDebug(fmt.Sprintf("could not kill command with process pgid=%d\n", pgid), err)
-		} else {
-			info.RmCmdPgid(pgid)
+		/**
+		 * Wait until the step time while commands are still running.
+		 */
+		for len(alivePgids) > 0 {
+			var stillAlivePgids []int
+
+			for _, pgid := range alivePgids {
+				if isProcessGroupRunning(pgid) {
+					stillAlivePgids = append(stillAlivePgids, pgid)
+				} else {
+					info.RmCmdPgid(pgid)
+				}
+			}
+
+			alivePgids = stillAlivePgids
+
+			if len(alivePgids) == 0 || !time.Now().Before(startedAt.Add(step.After)) {
+				break
+			}
+
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		if len(alivePgids) == 0 {
+			return signaled, combineErrors(errs)
+		}
+
+		if step.Hook != "" {
+			// Hooks get the time left before the next step (at least a second).
+			deadline := time.Now().Add(info.GetStopGracePeriod())
+
+			if stepIdx+1 < len(timeline) {
+				deadline = startedAt.Add(timeline[stepIdx+1].After)
+			}
+
+			timeout := time.Until(deadline)
+
+			if timeout < time.Second {
+				timeout = time.Second
+			}
+
+			info.runStopHook(step.Hook, timeout)
+		}
+
+		numSignaled := 0
+
+		for _, pgid := range alivePgids {
+			// Never signal process groups recycled by other processes.
+			if !info.verifyPgid(pgid) {
+				continue
+			}
+
+			utils.LogDebug(fmt.Sprintf("KillChildCmds [id=%s] : send %s to command %d", info.Id, sig, pgid))
+
+			if err := procgroup.Signal(pgid, sig); err != nil {
+				utils.LogDebug(fmt.Sprintf("could not signal command with process pgid=%d\n", pgid), err)
+
+				// Commands which exited meanwhile are not a failure.
+				if isProcessGroupRunning(pgid) {
+					errs = append(errs, fmt.Errorf("could not send %s to command %d: %w", step.Signal, pgid, err))
+				}
+
+				continue
+			}
+
+			numSignaled++
+
+			if sig == syscall.SIGKILL {
+				info.RmCmdPgid(pgid)
+			} else {
+				// Paused commands need to be resumed to handle the signal.
+				procgroup.Signal(pgid, utils.SIGCONT)
+			}
+		}
+
+		if numSignaled == 1 {
+			signaled = append(signaled, fmt.Sprintf("%s to 1 cmd", step.Signal))
+		} else if numSignaled > 1 {
+			signaled = append(signaled, fmt.Sprintf("%s to %d cmds", step.Signal, numSignaled))
+		}
+
+		if sig == syscall.SIGKILL {
+			return signaled, combineErrors(errs)
 		}
 	}
+
+	return signaled, combineErrors(errs)
 }
 
 /**
  * This function going to kill only the running child detached acts.
  */
 func (info *Info) KillChildActs() {
+	info.killChildActs(false)
+}
+
+/**
+ * This function going to stop (or kill right away when immediate is
+ * true) the running child detached acts. It returns the number of
+ * child acts we signaled and the errors of child acts we could not
+ * stop.
+ */
+func (info *Info) killChildActs(immediate bool) (int, error) {
 	/**
 	 * To prevent child acts killing this process we going to add a
 	 * fake pgid to running pgids.
@@ -348,20 +1396,27 @@ func (info *Info) KillChildActs() {
 
 	utils.LogDebug(fmt.Sprintf("KillChildActs [id=%s] [num_childs=%d]", info.Id, len(info.ChildActIds)))
 
+	numChildren := 0
+	var errs []error
+
 	/**
 	 * Kill all child acts.
 	 */
-	 if len(info.ChildActIds) > 0 {
-		for _, childId := range info.ChildActIds {
-			childInfo := GetInfo(childId)
+	for _, childId := range info.ChildActIds {
+		childInfo := GetInfo(childId)
 
-			if childInfo != nil {
-				utils.LogDebug(fmt.Sprintf("KillChildActs [id=%s] : kill child %s", info.Id, childId))
+		if childInfo != nil {
+			utils.LogDebug(fmt.Sprintf("KillChildActs [id=%s] : kill child %s", info.Id, childId))
 
-				childInfo.Kill()
+			if err := childInfo.terminate(immediate); err != nil {
+				errs = append(errs, fmt.Errorf("child act %s: %w", childInfo.GetNameIdOrId(), err))
 			}
+
+			numChildren++
 		}
 	}
+
+	return numChildren, combineErrors(errs)
 }
 
 /**
@@ -376,45 +1431,238 @@ func (info *Info) KillChildren() {
 }
 
 /**
- * This function going to quit a running process associated
- * with this specific info.
+ * This function going to send a signal to all running commands
+ * and child detached acts associated with this info.
  */
-func (info *Info) Kill() {
-	utils.LogDebug(fmt.Sprintf("Kill [id=%s]", info.Id))
+func (info *Info) Signal(sig syscall.Signal) {
+	utils.LogDebug(fmt.Sprintf("Signal [id=%s] [signal=%s]", info.Id, sig))
 
-	info.KillChildren()
+	for _, childId := range info.ChildActIds {
+		if childInfo := GetInfo(childId); childInfo != nil {
+			childInfo.Signal(sig)
+		}
+	}
+
+	info.signalCmds(sig)
+}
+
+/**
+ * This function going to send a signal to running commands of this
+ * act only (child detached acts are not signaled).
+ */
+func (info *Info) signalCmds(sig syscall.Signal) {
+	for _, pgid := range info.CmdPgids {
+		if pgid < 0 || !info.verifyPgid(pgid) {
+			continue
+		}
+
+		if err := procgroup.Signal(pgid, sig); err != nil {
+			utils.LogDebug(fmt.Sprintf("could not signal command with process pgid=%d", pgid), err)
+		}
+	}
+}
+
+/**
+ * This function going to check if the act process is still running.
+ */
+func (info *Info) IsRunning() bool {
+	return !info.Exited && !info.Dead && isProcessRunning(info.Pid)
+}
+
+/**
+ * This function going to check if a dead act (which crashed) left
+ * commands running behind.
+ */
+func (info *Info) HasOrphanCmds() bool {
+	return info.Dead && len(info.CmdPgids) > 0
+}
+
+/**
+ * This function going to check if the act is paused.
+ */
+func (info *Info) IsPaused() bool {
+	return utils.DoFileExists(filepath.Join(info.GetDataDirPath(), PausedFileName))
+}
+
+/**
+ * This function going to pause all running commands (and child
+ * detached acts) of this act by sending them SIGSTOP. Each child act
+ * gets paused (and flagged as paused) once by its own Pause call. We
+ * flag the act as paused first so it doesn't start new commands
+ * while we stop running ones.
+ */
+func (info *Info) Pause() {
+	utils.WriteToFile(filepath.Join(info.GetDataDirPath(), PausedFileName), "")
+
+	for _, childId := range info.ChildActIds {
+		if childInfo := GetInfo(childId); childInfo != nil {
+			childInfo.Pause()
+		}
+	}
+
+	info.signalCmds(utils.SIGSTOP)
+}
+
+/**
+ * This function going to resume all paused commands (and child
+ * detached acts) of this act by sending them SIGCONT.
+ */
+func (info *Info) Resume() {
+	for _, childId := range info.ChildActIds {
+		if childInfo := GetInfo(childId); childInfo != nil {
+			childInfo.Resume()
+		}
+	}
+
+	info.signalCmds(utils.SIGCONT)
+
+	os.Remove(filepath.Join(info.GetDataDirPath(), PausedFileName))
+}
+
+/**
+ * This function get a textual representation of the act state.
+ */
+func (info *Info) GetState() string {
+	if info.Dead {
+		return "dead"
+	}
+
+	if !info.IsRunning() {
+		return "exited"
+	}
+
+	if info.IsPaused() {
+		return "paused"
+	}
+
+	if len(info.StartingActs) > 0 {
+		return "starting"
+	}
+
+	if info.IsUnhealthy() {
+		return HealthUnhealthy
+	}
+
+	return "running"
+}
+
+/**
+ * This function get for how long the act is running.
+ */
+func (info *Info) GetUptime() time.Duration {
+	if info.Exited || info.Dead {
+		return info.Duration.Round(time.Second)
+	}
+
+	if info.StartedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(info.StartedAt).Round(time.Second)
+}
+
+/**
+ * This function going to stop a running act gracefully following its
+ * stop timeline (by default we send TERM and then KILL after the stop
+ * grace period). It returns an error when something could not be
+ * stopped.
+ */
+func (info *Info) Stop() error {
+	return info.terminate(false)
+}
+
+/**
+ * This function going to kill a running act right away sending KILL
+ * to all its commands (and child detached acts).
+ */
+func (info *Info) Kill() error {
+	return info.terminate(true)
+}
+
+/**
+ * This function going to stop (or kill right away when immediate is
+ * true) a running act with everything it started and report what was
+ * actually signaled. Errors of commands, child acts and the parent
+ * act we could not stop are combined.
+ */
+func (info *Info) terminate(immediate bool) error {
+	utils.LogDebug(fmt.Sprintf("terminate [id=%s] [immediate=%t]", info.Id, immediate))
 
 	/**
-	 * Remove data dir.
+	 * Nothing to stop when the act already exited. Dead acts (which
+	 * crashed) can still have orphan commands running though.
+	 */
+	if info.Exited || (info.Dead && !info.HasOrphanCmds()) {
+		return nil
+	}
+
+	timeline := info.GetStopTimeline()
+	action := "stopped"
+
+	if immediate {
+		timeline = []*actfile.ActStopStep{{Signal: "KILL"}}
+		action = "killed"
+	}
+
+	info.syncPending()
+
+	numChildren, childrenErr := info.killChildActs(immediate)
+	signaled, cmdsErr := info.signalChildCmds(timeline)
+	errs := []error{cmdsErr, childrenErr}
+
+	/**
+	 * Remove data dir. Running daemons keep their data dir because
+	 * they going to record their exit status when they finish.
 	 *
 	 * @QUESTION : Is it possible to run into a race condition when
 	 * removing data dir? Because this is also being done in cleanup
 	 * function we have in run/run.go.
 	 */
-	info.RmDataDir()
+	if !info.IsDaemon || !info.IsRunning() {
+		info.RmDataDir()
+	}
+
+	// Report what we actually signaled.
+	if numChildren == 1 {
+		signaled = append(signaled, "1 child act")
+	} else if numChildren > 1 {
+		signaled = append(signaled, fmt.Sprintf("%d child acts", numChildren))
+	}
+
+	report := "nothing running"
 
-	// Print
-	fmt.Println(fmt.Sprintf("act %s stopped", aurora.Green(info.GetNameIdOrId()).Bold()))
+	if len(signaled) > 0 {
+		report = strings.Join(signaled, ", ")
+	}
+
+	fmt.Println(fmt.Sprintf("act %s %s (%s)", utils.Color.Green(info.GetNameIdOrId()).Bold(), action, report))
 
-	// Kill parent if needed
+	// Stop parent if needed
 	if info.ParentActId != "" {
-		utils.LogDebug("Kill : has parent", info.Id, info.ParentActId)
+		utils.LogDebug("terminate : has parent", info.Id, info.ParentActId)
 
 		parentInfo := GetInfo(info.ParentActId)
 
 		if parentInfo != nil && !parentInfo.IsKilling {
+			parentInfo.syncPending()
+
 			// Remove from parent
 			parentInfo.RmChildActId(info.Id)
 
 			// If parent is still running something then we finish.
 			if len(parentInfo.CmdPgids) > 0 || len(parentInfo.ChildActIds) > 0 {
-				return
+				return combineErrors(errs)
 			}
 
-			utils.LogDebug("Kill : killing parent", info.Id, info.ParentActId)
-			parentInfo.Kill()
+			utils.LogDebug("terminate : stopping parent", info.Id, info.ParentActId)
+
+			if err := parentInfo.terminate(immediate); err != nil {
+				errs = append(errs, fmt.Errorf("parent act %s: %w", parentInfo.GetNameIdOrId(), err))
+			}
 		}
 	}
+
+	return combineErrors(errs)
 }
 
 //############################################################
@@ -426,40 +1674,122 @@ func (info *Info) Kill() {
  * struct and then we fill the struct with content of the file.
  */
 func loadInfoFromFile(jsonPath string) *Info {
-	if _, err := os.Stat(jsonPath); err == nil {
-		file, err := os.Open(jsonPath)
+	if _, err := os.Stat(jsonPath); err != nil {
+		return nil
+	}
 
-		if err != nil {
-			utils.FatalError("could not read act info file", err)
-		}
+	// Make sure we don't read while another process is writing.
+	unlock, err := utils.LockFile(filepath.Join(filepath.Dir(jsonPath), InfoLockFileName), false)
 
-		defer file.Close()
+	if err != nil {
+		utils.LogDebug("loadInfoFromFile : could not lock run info file", err)
+	} else {
+		defer unlock()
+	}
 
-		fileContent, _ := ioutil.ReadAll(file)
+	fileContent, err := ioutil.ReadFile(jsonPath)
 
-		var info Info
+	if err != nil {
+		utils.LogWarn(fmt.Sprintf("could not read act info file %s", jsonPath), err)
+		return nil
+	}
 
-		json.Unmarshal(fileContent, &info)
+	var info Info
 
-		return &info
+	if err := json.Unmarshal(fileContent, &info); err != nil {
+		quarantineInfoFile(jsonPath, err)
+		return nil
 	}
 
-	return nil
+	info.markSynced()
+
+	return &info
+}
+
+/**
+ * This function going to move a corrupted info file to the registry
+ * quarantine dir (instead of failing) so users can inspect it later.
+ */
+func quarantineInfoFile(jsonPath string, reason error) {
+	quarantineDirPath := filepath.Join(GetStateDirPath(), QuarantineDirName)
+	os.MkdirAll(quarantineDirPath, 0755)
+
+	runId := filepath.Base(filepath.Dir(jsonPath))
+	targetPath := filepath.Join(quarantineDirPath, fmt.Sprintf("%s-%d.json", runId, time.Now().Unix()))
+
+	if err := os.Rename(jsonPath, targetPath); err != nil {
+		utils.LogWarn(fmt.Sprintf("could not quarantine corrupted act info file %s", jsonPath), err)
+		return
+	}
+
+	utils.LogWarn(fmt.Sprintf("act info file %s is corrupted (%s) : moved to %s", jsonPath, reason, targetPath))
 }
 
 //############################################################
 // Exported Functions
 //############################################################
+/**
+ * This function get the act data dir where we keep info for all
+ * acts running from current working dir (i.e., the project data
+ * dir in user registry).
+ */
+func GetActDataDirPath() string {
+	return GetProjectDataDirPath(utils.GetWd())
+}
+
+/**
+ * This function going to make sure act data dir exists and is
+ * writable. If we can't create it or write to it (like in sandboxes
+ * without a writable home or a read-only existing dir) we fallback
+ * to memory-only mode where run info is not persisted and therefore
+ * daemons and list are not available.
+ */
+func CheckDataDir() {
+	dataDirPath := GetActDataDirPath()
+
+	os.MkdirAll(dataDirPath, 0755)
+
+	if !isDataDirWritable(dataDirPath) {
+		useMemoryOnly(dataDirPath)
+	}
+}
+
+/**
+ * This function going to check if we are running in memory-only
+ * mode.
+ */
+func IsMemoryOnly() bool {
+	return memoryOnly
+}
+
 /**
  * This function get call stack from an act id.
  */
 func GetInfoCallStack(id string) []*Info {
-	allInfos := GetAllInfo()
-
-	// Convert to map for simplicity
 	infoMap := make(map[string]*Info)
 
-	for _, info := range allInfos {
+	/**
+	 * With an index we only need to load infos of the stack (parents
+	 * are loaded while walking up).
+	 */
+	if entries, ok := readIndex(GetActDataDirPath()); ok {
+		var stack []*Info
+
+		for entry, hasEntry := entries[id]; hasEntry; entry, hasEntry = entries[entry.ParentActId] {
+			info := loadInfoFromFile(filepath.Join(GetActDataDirPath(), entry.Id, InfoFileName))
+
+			if info == nil {
+				break
+			}
+
+			stack = append([]*Info{info}, stack...)
+		}
+
+		return stack
+	}
+
+	// Convert to map for simplicity
+	for _, info := range GetAllInfo() {
 		infoMap[info.Id] = info
 	}
 
@@ -480,31 +1810,15 @@ func GetInfoCallStack(id string) []*Info {
 }
 
 /**
- * This function going to get all run info.
+ * This function going to get all run info (rebuilding the index
+ * when it's stale since we scanned all runs anyway).
  */
 func GetAllInfo() []*Info {
-	dataDirPath := path.Join(utils.GetWd(), ActDataDirName)
-
-	files, err := ioutil.ReadDir(dataDirPath)
-	var infos []*Info
-
-	if err != nil {
-		utils.FatalError("could not react act dir", err)
-	}
-
-	for _, f := range files {
-		if f.IsDir() {
-			dirPath := path.Join(dataDirPath, f.Name())
-			jsonPath := path.Join(dirPath, InfoFileName)
-			info := loadInfoFromFile(jsonPath)
+	dataDirPath := GetActDataDirPath()
+	infos := getDataDirInfos(dataDirPath)
 
-			if info == nil {
-				// Remove folder
-				os.RemoveAll(dirPath)
-			} else {
-				infos = append(infos, info)
-			}
-		}
+	if _, ok := readIndex(dataDirPath); !ok {
+		rebuildIndex(dataDirPath, infos)
 	}
 
 	return infos
@@ -512,31 +1826,40 @@ func GetAllInfo() []*Info {
 
 /**
  * This function get info for a specific act by its name
- * as associated by the user.
+ * as associated by the user. Running acts take precedence over
+ * exited ones with the same name. We look the name up in the
+ * index and fallback to scanning all runs when it's stale.
  */
 func GetInfo(name string) *Info {
-	dataDirPath := path.Join(utils.GetWd(), ActDataDirName)
+	dataDirPath := GetActDataDirPath()
 
-	files, err := ioutil.ReadDir(dataDirPath)
+	if entries, ok := readIndex(dataDirPath); ok {
+		entry := findIndexEntry(entries, name)
 
-	if err != nil {
-		utils.FatalError("could not react act dir", err)
+		if entry == nil {
+			return nil
+		}
+
+		if info := loadInfoFromFile(filepath.Join(dataDirPath, entry.Id, InfoFileName)); info != nil {
+			return info
+		}
 	}
 
-	for _, f := range files {
-		if f.IsDir() {
-			dirPath := path.Join(dataDirPath, f.Name())
-			jsonPath := path.Join(dirPath, InfoFileName)
-			info := loadInfoFromFile(jsonPath)
+	var exitedInfo *Info
 
-			if info == nil {
-				// Remove folder
-				os.RemoveAll(dirPath)
-			} else if info.NameId == name || info.Id == name {
-				return info
-			}
+	for _, info := range GetAllInfo() {
+		if info.NameId != name && info.Id != name {
+			continue
+		}
+
+		if !info.Exited && !info.Dead {
+			return info
+		}
+
+		if exitedInfo == nil || info.EndedAt.After(exitedInfo.EndedAt) {
+			exitedInfo = info
 		}
 	}
 
-	return nil
+	return exitedInfo
 }
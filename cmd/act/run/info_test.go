@@ -0,0 +1,148 @@
+package run
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/**
+ * Saving info keeps changes another act process (like act stop or a
+ * child act) saved since we last read or wrote the info file.
+ */
+func TestInfoSaveMergesOtherChanges(t *testing.T) {
+	setupTestStateDir(t)
+
+	owner := &Info{Id: "owner-id", Pid: os.Getpid(), CmdPgids: []int{101, 102}}
+	owner.Save()
+
+	other := loadInfoFromFile(filepath.Join(owner.GetDataDirPath(), InfoFileName))
+
+	if other == nil {
+		t.Fatal("could not load info")
+	}
+
+	// Other process kills a command and adds a child act.
+	other.CmdPgids = []int{102}
+	other.ChildActIds = []string{"child-id"}
+	other.IsKilling = true
+	other.Save()
+
+	// Owner starts a command without knowing about it.
+	owner.CmdPgids = append(owner.CmdPgids, 103)
+	owner.Save()
+
+	saved := loadInfoFromFile(filepath.Join(owner.GetDataDirPath(), InfoFileName))
+
+	if want := []int{102, 103}; !reflect.DeepEqual(saved.CmdPgids, want) {
+		t.Errorf("got pgids %v, want %v", saved.CmdPgids, want)
+	}
+
+	if want := []string{"child-id"}; !reflect.DeepEqual(saved.ChildActIds, want) {
+		t.Errorf("got child acts %v, want %v", saved.ChildActIds, want)
+	}
+
+	if !saved.IsKilling {
+		t.Error("got killing flag lost")
+	}
+
+	// Owner removing the child act wins over the stale file.
+	owner.ChildActIds = nil
+	owner.Save()
+
+	if saved := loadInfoFromFile(filepath.Join(owner.GetDataDirPath(), InfoFileName)); len(saved.ChildActIds) != 0 {
+		t.Errorf("got child acts %v, want none", saved.ChildActIds)
+	}
+}
+
+/**
+ * Paused acts wait to be resumed before starting commands.
+ */
+func TestPausedActWaitsForResume(t *testing.T) {
+	ctx := newTestRunningCtx(t)
+	info := ctx.RunCtx.Info
+
+	if err := os.MkdirAll(info.GetDataDirPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	info.Pause()
+
+	resumed := make(chan bool)
+
+	go func() {
+		resumed <- ctx.WaitResumed()
+	}()
+
+	select {
+	case <-resumed:
+		t.Fatal("commands allowed to start while paused")
+	case <-time.After(3 * pausedPollInterval):
+	}
+
+	info.Resume()
+
+	select {
+	case canRun := <-resumed:
+		if !canRun {
+			t.Error("commands not allowed to run after resume")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("act still waiting after resume")
+	}
+}
+
+/**
+ * Stop errors of commands and child acts are combined into one.
+ */
+func TestCombineErrors(t *testing.T) {
+	cmdsErr := errors.New("could not send TERM to command 10")
+
+	if err := combineErrors([]error{nil, nil}); err != nil {
+		t.Errorf("got %v, want no error", err)
+	}
+
+	if err := combineErrors([]error{cmdsErr, nil}); err != cmdsErr {
+		t.Errorf("got %v, want the only error", err)
+	}
+
+	err := combineErrors([]error{cmdsErr, errors.New("child act foo: could not send KILL to command 11")})
+
+	if err == nil || err.Error() != "could not send TERM to command 10; child act foo: could not send KILL to command 11" {
+		t.Errorf("got %v, want both errors", err)
+	}
+}
+
+/**
+ * An existing data dir we can't write to switches to memory-only mode
+ * right away instead of failing when run info is first saved.
+ */
+func TestCheckDataDirReadOnly(t *testing.T) {
+	setupTestStateDir(t)
+
+	dataDirPath := GetActDataDirPath()
+
+	if err := os.MkdirAll(dataDirPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Chmod(dataDirPath, 0555)
+
+	t.Cleanup(func() {
+		os.Chmod(dataDirPath, 0755)
+		memoryOnly = false
+	})
+
+	if isDataDirWritable(dataDirPath) {
+		t.Skip("read-only dirs are writable for this user")
+	}
+
+	CheckDataDir()
+
+	if !IsMemoryOnly() {
+		t.Error("got persistent mode, want memory-only mode")
+	}
+}
@@ -0,0 +1,314 @@
+package run
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/**
+ * Act binary built for integration tests (built once when a test
+ * first needs it).
+ */
+var testActBin struct {
+	once sync.Once
+	dir  string
+	path string
+	err  error
+}
+
+/**
+ * This function going to get the act binary built from this tree.
+ */
+func getTestActBin(t *testing.T) string {
+	testActBin.once.Do(func() {
+		testActBin.dir, testActBin.err = ioutil.TempDir("", "act-test-bin")
+
+		if testActBin.err != nil {
+			return
+		}
+
+		testActBin.path = filepath.Join(testActBin.dir, "act")
+		output, err := exec.Command("go", "build", "-o", testActBin.path, "github.com/nosebit/act/cmd/act").CombinedOutput()
+
+		if err != nil {
+			testActBin.err = err
+			testActBin.path = string(output)
+		}
+	})
+
+	if testActBin.err != nil {
+		t.Fatalf("could not build act: %v %s", testActBin.err, testActBin.path)
+	}
+
+	return testActBin.path
+}
+
+/**
+ * This function going to write an actfile in a temp dir returning
+ * the dir path.
+ */
+func writeTestActFile(t *testing.T, content string) string {
+	dir := t.TempDir()
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "actfile.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+/**
+ * This function going to run the act binary in a dir returning its
+ * output.
+ */
+func runTestActBin(t *testing.T, dir string, env []string, args ...string) string {
+	cmd := exec.Command(getTestActBin(t), args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), append([]string{"NO_COLOR=1"}, env...)...)
+
+	output, err := cmd.CombinedOutput()
+
+	if err != nil {
+		t.Fatalf("act %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return string(output)
+}
+
+/**
+ * This function going to wait until a run (by name or id) exited.
+ */
+func waitTestRunExit(t *testing.T, dir string, name string) {
+	deadline := time.Now().Add(10 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if info := getTestRunInfo(dir, name); info != nil && info.Exited {
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatalf("run %s did not exit", name)
+}
+
+/**
+ * This function going to get info of a run of a project dir.
+ */
+func getTestRunInfo(dir string, name string) *Info {
+	for _, info := range getDataDirInfos(GetProjectDataDirPath(dir)) {
+		if info.Id == name || info.NameId == name {
+			return info
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to remove the act binary once tests finish.
+ */
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	if testActBin.dir != "" {
+		os.RemoveAll(testActBin.dir)
+	}
+
+	os.Exit(code)
+}
+
+/**
+ * Output of a daemon can be read back with `act log` while it runs
+ * and after it exited.
+ */
+func TestDaemonLog(t *testing.T) {
+	setupTestStateDir(t)
+
+	dir := writeTestActFile(t, "acts:\n  greet:\n    start:\n      - echo hello from daemon\n      - sleep 1\n")
+
+	runTestActBin(t, dir, []string{"ACT_RUN_ID=greetrun"}, "run", "-d", "greet")
+
+	deadline := time.Now().Add(10 * time.Second)
+	output := ""
+
+	for time.Now().Before(deadline) && !strings.Contains(output, "hello from daemon") {
+		time.Sleep(100 * time.Millisecond)
+		output = runTestActBin(t, dir, nil, "log", "greetrun")
+	}
+
+	if !strings.Contains(output, "hello from daemon") {
+		t.Fatalf("got daemon log %q, want its output", output)
+	}
+
+	waitTestRunExit(t, dir, "greetrun")
+
+	if output := runTestActBin(t, dir, nil, "log", "greetrun"); !strings.Contains(output, "hello from daemon") {
+		t.Errorf("got exited daemon log %q, want its output", output)
+	}
+}
+
+/**
+ * Actfile used to check env propagation. The show act
+ * writes vars from all sources to a file named by the out flag.
+ */
+const testEnvActFile = `version: 1
+envfile: root.env
+acts:
+  show:
+    envfile: show.env
+    flags:
+      - name
+      - out
+    start:
+      - printf '%s|%s|%s|%s|%s\n' "$ROOT_VAR" "$SHOW_VAR" "$FLAG_NAME" "$CALLER_VAR" "$PARENT_VAR" > "$FLAG_OUT.txt"
+  caller:
+    envfile: caller.env
+    flags:
+      - out
+    start:
+      - act: show
+        args: ["-name=bruno", "-out={{.FlagOut}}"]
+  caller-detached:
+    envfile: caller.env
+    start:
+      - act: show
+        detach: true
+        args: ["-name=bruno", "-out=detached"]
+      - for i in $(seq 100); do [ -f detached.txt ] && break; sleep 0.1; done
+`
+
+/**
+ * This function going to wait for a file written by an act returning
+ * its content.
+ */
+func waitTestFile(t *testing.T, path string) string {
+	deadline := time.Now().Add(10 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if content, err := ioutil.ReadFile(path); err == nil && len(content) > 0 {
+			return string(content)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatalf("file %s was not written", path)
+	return ""
+}
+
+/**
+ * Vars from root and act envfiles, flags, caller envfile and parent
+ * env reach commands in foreground, detached and daemon modes.
+ */
+func TestEnvPropagation(t *testing.T) {
+	setupTestStateDir(t)
+
+	dir := writeTestActFile(t, testEnvActFile)
+
+	for name, content := range map[string]string{
+		"root.env":   "ROOT_VAR=root\n",
+		"show.env":   "SHOW_VAR=show\n",
+		"caller.env": "CALLER_VAR=caller\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := []string{"PARENT_VAR=parent"}
+	want := "root|show|bruno|caller|parent\n"
+
+	runTestActBin(t, dir, env, "run", "caller", "-out=foreground")
+	runTestActBin(t, dir, env, "run", "caller-detached")
+	runTestActBin(t, dir, env, "run", "-d", "caller", "-out=daemon")
+
+	for _, mode := range []string{"foreground", "detached", "daemon"} {
+		if got := waitTestFile(t, filepath.Join(dir, mode+".txt")); got != want {
+			t.Errorf("got %q in %s mode, want %q", got, mode, want)
+		}
+	}
+}
+
+/**
+ * Debug logs don't show var values (which can hold secrets) of acts
+ * running in foreground or detached.
+ */
+func TestDebugLogHidesVarValues(t *testing.T) {
+	setupTestStateDir(t)
+
+	dir := writeTestActFile(t, "envfile: .env\nacts:\n  child:\n    start:\n      - echo child\n  parent:\n    start:\n      - act: child\n        detach: true\n      - act: child\n")
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("FILE_TOKEN=file-secret-value\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := runTestActBin(t, dir, []string{"ACT_DEBUG=1", "ENV_TOKEN=env-secret-value"}, "run", "parent")
+
+	if !strings.Contains(output, "actDetachExec") {
+		t.Fatalf("got output %q, want debug logs", output)
+	}
+
+	for _, secret := range []string{"file-secret-value", "env-secret-value"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("debug output shows %s", secret)
+		}
+	}
+}
+
+/**
+ * Process trace events keep the act pid and report the command pid
+ * apart.
+ */
+func TestTraceProcPids(t *testing.T) {
+	setupTestStateDir(t)
+
+	dir := writeTestActFile(t, "acts:\n  greet:\n    start:\n      - echo hello\n")
+	tracePath := filepath.Join(dir, "trace.jsonl")
+
+	runTestActBin(t, dir, []string{"ACT_TRACE=jsonl", "ACT_TRACE_FILE=" + tracePath}, "run", "greet")
+
+	content, err := ioutil.ReadFile(tracePath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actPid float64
+	numProcEvents := 0
+
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var entry map[string]interface{}
+
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid trace line %q: %v", line, err)
+		}
+
+		if actPid == 0 {
+			actPid, _ = entry["pid"].(float64)
+		}
+
+		if entry["pid"] != actPid {
+			t.Errorf("got pid %v in %s event, want act pid %v", entry["pid"], entry["event"], actPid)
+		}
+
+		if entry["event"] == "proc_start" || entry["event"] == "proc_end" {
+			numProcEvents++
+
+			if childPid, _ := entry["child_pid"].(float64); childPid == 0 || childPid == actPid {
+				t.Errorf("got child pid %v in %s event, want command pid", entry["child_pid"], entry["event"])
+			}
+		}
+	}
+
+	if numProcEvents != 2 {
+		t.Errorf("got %d process events, want 2", numProcEvents)
+	}
+}
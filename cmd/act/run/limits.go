@@ -0,0 +1,136 @@
+/**
+ * This file implements resource limits of acts (set with `limits` in
+ * actfiles). Each command gets its own cgroup (v2) when we can create
+ * one (cpu, memory and processes limits) plus an open files rlimit.
+ * Commands start already limited so
+ * processes they spawn can't escape limits. Commands killed for
+ * running out of memory are reported in run info.
+ */
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+//############################################################
+// Exported Variables
+//############################################################
+
+/**
+ * Error we return when a command was killed for running out of
+ * memory.
+ */
+var ErrOomKilled = errors.New("killed for running out of memory")
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Call ids of acts we already warned about limits we could not
+ * enforce (so watch tasks don't flood logs).
+ */
+var limitsWarned sync.Map
+
+/**
+ * Count of cgroups we created (so each command gets its own).
+ */
+var cgroupCount int64
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get resource limits of an act.
+ */
+func getActLimits(ctx *ActRunCtx) (*procgroup.Limits, error) {
+	if ctx.Act.Limits == nil {
+		return nil, nil
+	}
+
+	limits := &procgroup.Limits{
+		Cpu:       ctx.Act.Limits.Cpu,
+		OpenFiles: ctx.Act.Limits.OpenFiles,
+		Processes: ctx.Act.Limits.Processes,
+	}
+
+	if ctx.Act.Limits.Memory != "" {
+		memory, err := utils.ParseBytes(ctx.Act.Limits.Memory)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory limit %s", ctx.Act.Limits.Memory)
+		}
+
+		limits.Memory = memory
+	}
+
+	return limits, nil
+}
+
+/**
+ * This function going to warn (once per act) about limits we could
+ * not enforce.
+ */
+func warnLimits(ctx *ActRunCtx, err error) {
+	if _, warned := limitsWarned.LoadOrStore(ctx.CallId, true); !warned {
+		utils.LogWarn(fmt.Sprintf("could not enforce all limits of act %s", ctx.CallId), err)
+	}
+}
+
+/**
+ * This function going to make a command start with limits (it must
+ * not be started yet). It returns the cgroup of the command (if any)
+ * which must be released once the command finishes. Limits we can't
+ * enforce (like cpu without cgroups) are warned about and the command
+ * runs anyway.
+ */
+func setCmdLimits(ctx *ActRunCtx, limits *procgroup.Limits, shCmd *exec.Cmd) *procgroup.Cgroup {
+	var cg *procgroup.Cgroup
+	var cgErr error
+
+	if limits.Cpu > 0 || limits.Memory > 0 || limits.Processes > 0 {
+		name := fmt.Sprintf("act-%s-%d", ctx.RunCtx.Info.Id, atomic.AddInt64(&cgroupCount, 1))
+		cg, cgErr = procgroup.NewCgroup(name, limits)
+	}
+
+	if err := procgroup.SetLimits(shCmd, limits, cg); err != nil {
+		// We tell why we could not use cgroups so users can fix it.
+		if errors.Is(err, procgroup.ErrUnenforcedLimits) && cgErr != nil {
+			err = fmt.Errorf("%w: %v", err, cgErr)
+		}
+
+		warnLimits(ctx, err)
+	}
+
+	return cg
+}
+
+/**
+ * This function going to release the cgroup of a finished command
+ * returning ErrOomKilled when it was killed for running out of
+ * memory.
+ */
+func releaseCmdLimits(ctx *ActRunCtx, cg *procgroup.Cgroup, err error) error {
+	if cg == nil {
+		return err
+	}
+
+	if cg.OomKills() > 0 {
+		err = fmt.Errorf("%w (memory limit %s)", ErrOomKilled, ctx.Act.Limits.Memory)
+	}
+
+	if rmErr := cg.Remove(); rmErr != nil {
+		utils.LogDebug("releaseCmdLimits : could not remove cgroup", rmErr)
+	}
+
+	return err
+}
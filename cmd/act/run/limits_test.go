@@ -0,0 +1,72 @@
+package run
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+/**
+ * Commands see their rlimits from the start (they are not set after
+ * the command started).
+ */
+func TestCmdStartsWithLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("limits are enforced on linux only")
+	}
+
+	ctx := newTestRunningCtx(t)
+	shCmd := exec.Command("sh", "-c", "ulimit -n")
+
+	cg := setCmdLimits(ctx, &procgroup.Limits{OpenFiles: 77}, shCmd)
+
+	output, err := shCmd.Output()
+
+	if err := releaseCmdLimits(ctx, cg, err); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.TrimSpace(string(output)); got != "77" {
+		t.Errorf("got open files limit %s, want 77", got)
+	}
+}
+
+/**
+ * Processes a command leaves in background get killed so its cgroup
+ * can be removed.
+ */
+func TestCmdLimitsKillLeftovers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("limits are enforced on linux only")
+	}
+
+	ctx := newTestRunningCtx(t)
+	shCmd := exec.Command("sh", "-c", "sleep 60 >/dev/null 2>&1 & echo $!")
+
+	cg := setCmdLimits(ctx, &procgroup.Limits{Processes: 100}, shCmd)
+
+	if cg == nil {
+		t.Skip("cgroups v2 not available")
+	}
+
+	output, err := shCmd.Output()
+
+	if err := releaseCmdLimits(ctx, cg, err); err != nil {
+		t.Fatal(err)
+	}
+
+	if utils.DoFileExists(cg.Path) {
+		t.Errorf("cgroup %s was not removed", cg.Path)
+	}
+
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(output)))
+
+	if pid <= 0 || isProcessRunning(pid) {
+		t.Errorf("background process %d still running", pid)
+	}
+}
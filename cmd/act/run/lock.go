@@ -0,0 +1,130 @@
+/**
+ * This file implements act locks which make sure only one run of an
+ * act is running at a time in the same project (like to prevent
+ * starting long migrations twice by accident). Locks are advisory
+ * file locks so they get released automatically when the act process
+ * dies.
+ */
+
+package run
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Function to release the act lock held by this process (if any).
+ * We keep a reference to it so the lock file doesn't get closed (and
+ * the lock released) by the garbage collector before we exit.
+ */
+var releaseActLock func()
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the lock file path of an act.
+ */
+func getActLockFilePath(ctx *ActRunCtx) string {
+	return filepath.Join(GetActDataDirPath(), fmt.Sprintf("%s.lock", getThrottleKey(ctx)))
+}
+
+/**
+ * This function going to get the id of the run currently holding
+ * the act lock.
+ */
+func getActLockOwner(ctx *ActRunCtx) string {
+	content, err := ioutil.ReadFile(fmt.Sprintf("%s.owner", getActLockFilePath(ctx)))
+
+	if err != nil {
+		return "unknown"
+	}
+
+	return string(content)
+}
+
+/**
+ * This function going to check if an act is locked.
+ */
+func isActLocked(ctx *ActRunCtx) bool {
+	return ctx.Act.Lock || ctx.RunCtx.Lock
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to check if the act lock is free without
+ * holding it. It returns false (and fails) if another run of the
+ * act is running.
+ */
+func CheckActLock(ctx *ActRunCtx) bool {
+	if memoryOnly || !isActLocked(ctx) {
+		return true
+	}
+
+	os.MkdirAll(GetActDataDirPath(), 0755)
+
+	unlock, err := utils.TryLockFile(getActLockFilePath(ctx))
+
+	if err == syscall.EWOULDBLOCK {
+		utils.FatalError(fmt.Sprintf("act %s already running (id=%s)", ctx.CallId, getActLockOwner(ctx)))
+		return false
+	}
+
+	if err == nil {
+		unlock()
+	}
+
+	return true
+}
+
+/**
+ * This function going to acquire the act lock (when the act is
+ * locked) for the whole run. When the lock is held by another run we
+ * either fail or wait for it to finish (lock wait).
+ */
+func AcquireActLock(ctx *ActRunCtx) bool {
+	if memoryOnly || !isActLocked(ctx) {
+		return true
+	}
+
+	os.MkdirAll(GetActDataDirPath(), 0755)
+
+	lockFilePath := getActLockFilePath(ctx)
+	unlock, err := utils.TryLockFile(lockFilePath)
+
+	if err == syscall.EWOULDBLOCK {
+		if !ctx.RunCtx.LockWait {
+			utils.FatalError(fmt.Sprintf("act %s already running (id=%s)", ctx.CallId, getActLockOwner(ctx)))
+			return false
+		}
+
+		utils.LogInfo(fmt.Sprintf("act %s already running (id=%s) : waiting it to finish", ctx.CallId, getActLockOwner(ctx)))
+
+		unlock, err = utils.LockFile(lockFilePath, true)
+	}
+
+	if err != nil {
+		utils.FatalError("could not acquire act lock", err)
+		return false
+	}
+
+	releaseActLock = unlock
+
+	utils.WriteFileAtomic(fmt.Sprintf("%s.owner", lockFilePath), []byte(ctx.RunCtx.Info.Id), 0644)
+
+	return true
+}
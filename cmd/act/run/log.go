@@ -11,19 +11,77 @@ package run
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/logrusorgru/aurora/v3"
 	"github.com/nosebit/act/cmd/act/utils"
 )
 
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Output streams of commands.
+ */
+const (
+	StreamStdout string = "stdout"
+	StreamStderr        = "stderr"
+)
+
+/**
+ * Log mode where each output line is logged as a json object.
+ */
+const LogModeJson = "json"
+
+/**
+ * Default layout of timestamps in log lines.
+ */
+const DefaultLogTimestampLayout = "2006-01-02 15:04:05.000000"
+
+/**
+ * Layout of iso8601 timestamps in log lines.
+ */
+const Iso8601LogTimestampLayout = "2006-01-02T15:04:05.000Z07:00"
+
+/**
+ * Extension of the file where we tee stderr output of acts with
+ * stderr log enabled.
+ */
+const ErrLogFileExt = ".err"
+
 //############################################################
 // Types
 //############################################################
 
+/**
+ * This struct going to hold a log line in json log mode.
+ */
+type LogLine struct {
+	Time   string `json:"time"`
+	Act    string `json:"act"`
+	Host   string `json:"host,omitempty"`
+	Stream string `json:"stream"`
+	Line   string `json:"line"`
+}
+
+/**
+ * This struct going to hold the fields available to log format
+ * templates.
+ */
+type LogPrefix struct {
+	Act    string
+	RunId  string
+	Time   string
+	Stream string
+	Host   string
+}
+
 /**
  * This is the main struct which implements io.Writer interface
  * to be used as stdout/stderr for commands.
@@ -31,10 +89,15 @@ import (
 type LogWriter struct {
 	Detached 			bool
 	LogToConsole 	bool
+	Stream        string
+	Host          string
 	ctx       		*ActRunCtx
 	buf       		*bytes.Buffer
 	readLines 		string
-	logFile   		*os.File
+	logFile   		*logFile
+	actLogFile    *logFile
+	errFile       *logFile
+	format        *template.Template
 }
 
 /**
@@ -85,18 +148,41 @@ func (l *LogWriter) OutputLines() (err error) {
 		}
 
 		l.readLines += line
+
+		if !l.Detached {
+			line = collapseCarriageReturns(line)
+		}
+
 		l.out(line)
 	}
 
 	return nil
 }
 
+/**
+ * This function going to add the host (of remote commands) to a log
+ * prefix.
+ */
+func (l *LogWriter) withHost(logPrefix string) string {
+	if l.Host == "" {
+		return logPrefix
+	}
+
+	// User part of the host (like deploy@web1) is just noise here.
+	host := l.Host[strings.LastIndex(l.Host, "@")+1:]
+
+	return fmt.Sprintf("%s@%s", logPrefix, host)
+}
+
 /**
  * Output string to screen/file.
  */
 func (l *LogWriter) out(str string) (err error) {
+	// Secrets must never reach console or log files.
+	str = l.ctx.RunCtx.MaskSecrets(str)
+
 	// Get time to log.
-	now := time.Now().Format("2006-01-02 15:04:05.000000")
+	now := formatLogTimestamp(time.Now(), l.ctx)
 
 	/**
 	 * If this act process was invoked by other act then
@@ -108,23 +194,53 @@ func (l *LogWriter) out(str string) (err error) {
 		logPrefix = fmt.Sprintf("%s.%s", l.ctx.ActFile.Namespace, l.ctx.Act.Name)
 	}
 
-	var strToLog string
+	// Formatted line goes to a pooled buffer we queue for writing.
+	buf := getLogBuf()
 
 	/**
 	 * If act process is detached from another parent act process then
-	 * we going to prevent add prefix info.
+	 * we going to prevent add prefix info. Otherwise we tag each line
+	 * with the stream it came from so stdout and stderr can be told
+	 * apart in the single chronological stream.
 	 */
 	if l.Detached {
-		strToLog = str
+		buf.WriteString(str)
+	} else if getLogMode(nil, l.ctx) == LogModeJson {
+		json.NewEncoder(buf).Encode(&LogLine{
+			Time:   time.Now().Format(time.RFC3339Nano),
+			Act:    logPrefix,
+			Host:   l.Host,
+			Stream: l.Stream,
+			Line:   strings.TrimSuffix(str, "\n"),
+		})
+	} else if l.format != nil {
+		l.format.Execute(buf, &LogPrefix{
+			Act:    logPrefix,
+			RunId:  l.ctx.RunCtx.Info.Id,
+			Time:   now,
+			Stream: l.Stream,
+			Host:   l.Host,
+		})
+
+		buf.WriteString(str)
+	} else if now == "" {
+		fmt.Fprintf(buf, "%s | %s", getLogPrefixColor(l.Stream, l.ctx.CallId, l.withHost(logPrefix)), str)
 	} else {
-		strToLog = fmt.Sprintf("%s | %s %s", aurora.Yellow(logPrefix).Bold(), aurora.Cyan(now), str)
+		fmt.Fprintf(buf, "%s | %s %s", getLogPrefixColor(l.Stream, l.ctx.CallId, l.withHost(logPrefix)), utils.Color.Cyan(now), str)
 	}
 
-	/**
-	 * Log both to stdout and to file.
-	 */
-	if l.LogToConsole {
-		fmt.Print(strToLog)
+	record := &logRecord{
+		buf:        buf,
+		console:    l.LogToConsole,
+		actLogFile: l.actLogFile,
+	}
+
+	// Tee stderr output to its own file.
+	if l.errFile != nil && l.Stream == StreamStderr {
+		record.errFile = l.errFile
+		record.errBuf = getLogBuf()
+
+		fmt.Fprintf(record.errBuf, "%s %s", now, str)
 	}
 
 	/**
@@ -134,12 +250,76 @@ func (l *LogWriter) out(str string) (err error) {
 	 * this child act in isolation.
 	 */
 	if l.ctx.RunCtx.Info.ParentActId != "" {
-		l.logFile.Write([]byte(strToLog))
+		record.logFile = l.logFile
 	}
 
+	/**
+	 * Console and files are written by the run log queue so the
+	 * command producing output don't wait those writes.
+	 */
+	l.ctx.RunCtx.queueLog(record)
+
 	return nil
 }
 
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to format the timestamp of a log line based on
+ * actfile log timestamp setting.
+ */
+func formatLogTimestamp(t time.Time, ctx *ActRunCtx) string {
+	switch ctx.ActFile.LogTimestamp {
+	case "":
+		return t.Format(DefaultLogTimestampLayout)
+	case "none":
+		return ""
+	case "iso8601":
+		return t.Format(Iso8601LogTimestampLayout)
+	case "relative":
+		return fmt.Sprintf("+%s", t.Sub(ctx.RunCtx.Info.StartedAt).Round(time.Millisecond))
+	default:
+		return t.Format(ctx.ActFile.LogTimestamp)
+	}
+}
+
+/**
+ * This function going to color the act name of a log prefix. Each act
+ * gets a stable color (based on its call id) so outputs of acts
+ * running in parallel are easy to tell apart while stderr lines are
+ * always red.
+ */
+func getLogPrefixColor(stream string, callId string, logPrefix string) aurora.Value {
+	if stream == StreamStderr {
+		return utils.Color.Red(logPrefix).Bold()
+	}
+
+	return utils.PaletteColor(callId, logPrefix).Bold()
+}
+
+/**
+ * This function going to handle carriage returns in a log line.
+ * Programs (like progress bars) use carriage returns to rewrite the
+ * current line so we keep only the text written last. Terminals
+ * (like pseudo-terminals) also end lines with "\r\n".
+ */
+func collapseCarriageReturns(line string) string {
+	hasNewLine := strings.HasSuffix(line, "\n")
+	line = strings.TrimRight(line, "\r\n")
+
+	if idx := strings.LastIndex(line, "\r"); idx >= 0 {
+		line = line[idx+1:]
+	}
+
+	if hasNewLine {
+		line += "\n"
+	}
+
+	return line
+}
+
 //############################################################
 // Exported Functions
 //############################################################
@@ -148,18 +328,63 @@ func (l *LogWriter) out(str string) (err error) {
  * This function going to create a new log writer.
  */
 func NewLogWriter(ctx *ActRunCtx) *LogWriter {
+	/**
+	 * Log files are shared by all log writers of the run (they are
+	 * closed when the run ends).
+	 */
 	logFilePath := ctx.RunCtx.Info.GetLogFilePath()
-	logFile, err := os.OpenFile(logFilePath, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	logFile, err := ctx.RunCtx.openLogFile(logFilePath)
 
 	if err != nil {
 		utils.FatalError(fmt.Sprintf("cannot open log file at %s", logFilePath), err)
 	}
 
 	l := &LogWriter{
+		Stream:  StreamStdout,
 		buf:     bytes.NewBuffer([]byte("")),
 		ctx:     ctx,
 		logFile: logFile,
 	}
 
+	if ctx.ActFile.LogFormat != "" {
+		format, err := template.New("log_format").Parse(ctx.ActFile.LogFormat)
+
+		if err != nil {
+			utils.FatalError("invalid log_format", err)
+		} else {
+			l.format = format
+		}
+	}
+
+	actLogFilePath := ctx.RunCtx.Info.GetActLogFilePath(ctx.CallId)
+
+	if actLogFile, err := ctx.RunCtx.openLogFile(actLogFilePath); err == nil {
+		l.actLogFile = actLogFile
+	} else {
+		utils.LogDebug("NewLogWriter : could not open act log file", err)
+	}
+
+	return l
+}
+
+/**
+ * This function going to create a new log writer for stderr output
+ * of commands.
+ */
+func NewStderrLogWriter(ctx *ActRunCtx) *LogWriter {
+	l := NewLogWriter(ctx)
+	l.Stream = StreamStderr
+
+	if ctx.Act.StderrLog {
+		errLogFilePath := ctx.RunCtx.Info.GetErrLogFilePath()
+		errFile, err := ctx.RunCtx.openLogFile(errLogFilePath)
+
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("cannot open stderr log file at %s", errLogFilePath), err)
+		}
+
+		l.errFile = errFile
+	}
+
 	return l
 }
@@ -0,0 +1,49 @@
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+/**
+ * Throughput (MB/s) of prefixing command output and writing it to
+ * run log files.
+ */
+func BenchmarkLogWriter(b *testing.B) {
+	setupTestStateDir(b)
+
+	actCtx := &ActRunCtx{
+		CallId:  "bench",
+		Act:     &actfile.Act{Name: "bench"},
+		ActFile: &actfile.ActFile{LocationPath: filepath.Join(b.TempDir(), "actfile.yml")},
+	}
+
+	ctx := &RunCtx{Info: &Info{Id: "bench", NameId: "bench", Pid: os.Getpid(), StartedAt: time.Now()}, ActCtx: actCtx}
+	actCtx.RunCtx = ctx
+
+	os.MkdirAll(ctx.Info.GetDataDirPath(), 0755)
+
+	var chunk bytes.Buffer
+
+	for i := 0; chunk.Len() < 64*1024; i++ {
+		fmt.Fprintf(&chunk, "line %d of some typical command output with a few words\n", i)
+	}
+
+	l := NewLogWriter(actCtx)
+	defer ctx.closeLogFiles()
+
+	b.SetBytes(int64(chunk.Len()))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.Write(chunk.Bytes())
+	}
+
+	ctx.FlushLogs()
+}
@@ -0,0 +1,138 @@
+/**
+ * This file implements the log files written by log writers of a run.
+ * Each log file is opened once per run (instead of once per command)
+ * so long running acts don't leak file descriptors, and it's rotated
+ * (see rotate.go) as it grows.
+ */
+
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold an open log file along with its size
+ * (tracked in memory so we don't stat the file on every line).
+ */
+type logFile struct {
+	path     string
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+/**
+ * This struct going to hold log files opened by a run by path.
+ */
+type logFiles struct {
+	files map[string]*logFile
+	mutex sync.Mutex
+}
+
+//############################################################
+// logFile Struct Functions
+//############################################################
+
+/**
+ * This function going to write to the log file rotating it first when
+ * it's due. Log files are written only by the run log queue so we
+ * don't need to lock here.
+ */
+func (f *logFile) write(p []byte) {
+	if rotator != nil && rotator.isDue(f.size, f.openedAt) {
+		f.rotate()
+	}
+
+	n, _ := f.file.Write(p)
+	f.size += int64(n)
+}
+
+/**
+ * This function going to rotate the log file and open a new one.
+ */
+func (f *logFile) rotate() {
+	if err := shiftLogFiles(f.path, rotator.maxFiles); err != nil {
+		utils.LogDebug("logFile : could not rotate log file", f.path, err)
+		return
+	}
+
+	file, err := os.OpenFile(f.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+
+	if err != nil {
+		utils.LogDebug("logFile : could not create log file", f.path, err)
+		return
+	}
+
+	f.file.Close()
+
+	f.file = file
+	f.size = 0
+	f.openedAt = time.Now()
+}
+
+//############################################################
+// RunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to get the log file of a path opening it on
+ * first use.
+ */
+func (ctx *RunCtx) openLogFile(path string) (*logFile, error) {
+	ctx.logFiles.mutex.Lock()
+	defer ctx.logFiles.mutex.Unlock()
+
+	if f, ok := ctx.logFiles.files[path]; ok {
+		return f, nil
+	}
+
+	// Log file can be kept out of the data dir (like project local logs).
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f := &logFile{path: path, file: file, openedAt: time.Now()}
+
+	if stat, err := file.Stat(); err == nil {
+		f.size = stat.Size()
+	}
+
+	if ctx.logFiles.files == nil {
+		ctx.logFiles.files = make(map[string]*logFile)
+	}
+
+	ctx.logFiles.files[path] = f
+
+	return f, nil
+}
+
+/**
+ * This function going to close all log files of the run once queued
+ * log lines were written.
+ */
+func (ctx *RunCtx) closeLogFiles() {
+	ctx.FlushLogs()
+
+	ctx.logFiles.mutex.Lock()
+	defer ctx.logFiles.mutex.Unlock()
+
+	for _, f := range ctx.logFiles.files {
+		f.file.Close()
+	}
+
+	ctx.logFiles.files = nil
+}
@@ -0,0 +1,47 @@
+package run
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+/**
+ * Run log files (like per act logs) must rotate once they get bigger
+ * than the max size.
+ */
+func TestLogFileRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "act.log")
+
+	rotator = &logRotator{maxSize: 10, maxFiles: 2}
+	defer func() { rotator = nil }()
+
+	ctx := &RunCtx{}
+	f, err := ctx.openLogFile(path)
+
+	if err != nil {
+		t.Fatalf("could not open log file: %v", err)
+	}
+
+	defer ctx.closeLogFiles()
+
+	f.write([]byte("0123456789"))
+	f.write([]byte("abc"))
+
+	rotated, err := ioutil.ReadFile(path + ".1")
+
+	if err != nil || string(rotated) != "0123456789" {
+		t.Errorf("got rotated content %q (%v), want %q", rotated, err, "0123456789")
+	}
+
+	current, _ := ioutil.ReadFile(path)
+
+	if string(current) != "abc" {
+		t.Errorf("got current content %q, want %q", current, "abc")
+	}
+
+	if f.size != 3 {
+		t.Errorf("got tracked size %d, want 3", f.size)
+	}
+}
@@ -0,0 +1,204 @@
+/**
+ * This file implements the queue log writers send formatted log lines
+ * to. A single goroutine per run writes queued lines to console and
+ * log files so commands producing lots of output are not slowed down
+ * by those writes and lines of parallel commands never interleave.
+ */
+
+package run
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max number of log records waiting to be written. Log writers block
+ * when the queue is full so we never drop output.
+ */
+const logQueueSize = 1024
+
+/**
+ * Buffers bigger than this are not returned to the pool so a single
+ * huge line doesn't keep memory allocated for the whole run.
+ */
+const maxPooledLogBufSize = 64 * 1024
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a formatted log line along with where it
+ * going to be written to.
+ */
+type logRecord struct {
+	/**
+	 * Line to write to console and log files.
+	 */
+	buf *bytes.Buffer
+
+	/**
+	 * Line to write to the stderr log file (if any).
+	 */
+	errBuf *bytes.Buffer
+
+	/**
+	 * Flag indicating the line going to be written to console.
+	 */
+	console bool
+
+	/**
+	 * Files to write the line to.
+	 */
+	logFile    *logFile
+	actLogFile *logFile
+	errFile    *logFile
+
+	/**
+	 * When set this record is a flush request and we close this
+	 * channel once all records queued before it were written.
+	 */
+	done chan bool
+}
+
+/**
+ * This struct going to hold the log records queue of a run.
+ */
+type logQueue struct {
+	records chan *logRecord
+
+	once sync.Once
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Pool of buffers we use to format log lines.
+ */
+var logBufPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get an empty buffer from the pool.
+ */
+func getLogBuf() *bytes.Buffer {
+	buf := logBufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	return buf
+}
+
+/**
+ * This function going to return a buffer to the pool.
+ */
+func putLogBuf(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledLogBufSize {
+		return
+	}
+
+	logBufPool.Put(buf)
+}
+
+//############################################################
+// logRecord Struct Functions
+//############################################################
+
+/**
+ * This function going to write the record to console and log files
+ * and release its buffers.
+ */
+func (record *logRecord) write() {
+	if record.errFile != nil && record.errBuf != nil {
+		record.errFile.write(record.errBuf.Bytes())
+	}
+
+	if record.console {
+		writeConsoleLog(record.buf.Bytes())
+	}
+
+	if record.logFile != nil {
+		record.logFile.write(record.buf.Bytes())
+	}
+
+	if record.actLogFile != nil {
+		record.actLogFile.write(record.buf.Bytes())
+	}
+
+	putLogBuf(record.buf)
+	putLogBuf(record.errBuf)
+}
+
+//############################################################
+// logQueue Struct Functions
+//############################################################
+
+/**
+ * This function going to write queued records in order.
+ */
+func (queue *logQueue) run() {
+	defer utils.RestoreTermOnPanic()
+
+	for record := range queue.records {
+		if record.done != nil {
+			close(record.done)
+			continue
+		}
+
+		record.write()
+	}
+}
+
+//############################################################
+// RunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to get the log queue of the run starting the
+ * goroutine writing its records on first use.
+ */
+func (ctx *RunCtx) getLogQueue() *logQueue {
+	queue := &ctx.logs
+
+	queue.once.Do(func() {
+		queue.records = make(chan *logRecord, logQueueSize)
+		go queue.run()
+	})
+
+	return queue
+}
+
+/**
+ * This function going to queue a log record to be written.
+ */
+func (ctx *RunCtx) queueLog(record *logRecord) {
+	ctx.getLogQueue().records <- record
+}
+
+/**
+ * This function going to wait all queued log records to be written.
+ * We do this before printing anything not going through the queue
+ * (like command errors) so output keeps its order.
+ */
+func (ctx *RunCtx) FlushLogs() {
+	done := make(chan bool)
+
+	ctx.queueLog(&logRecord{done: done})
+
+	<-done
+}
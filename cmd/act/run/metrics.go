@@ -0,0 +1,166 @@
+/**
+ * This file implements Prometheus metrics of act runs. Metrics are
+ * written in the Prometheus text exposition format so they can be
+ * scraped from a daemon metrics listener (started with the metrics
+ * flag of run command) or from `act serve` (for all runs).
+ */
+
+package run
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a metric family (all samples of a
+ * metric).
+ */
+type metricFamily struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []string
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Content type of metrics in Prometheus text format.
+ */
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to escape a label value.
+ */
+func escapeMetricLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+/**
+ * This function going to get the labels identifying a run.
+ */
+func getMetricLabels(info *Info, extra ...string) string {
+	labels := []string{
+		fmt.Sprintf(`id="%s"`, escapeMetricLabel(info.Id)),
+		fmt.Sprintf(`name="%s"`, escapeMetricLabel(info.GetNameIdOrId())),
+		fmt.Sprintf(`act="%s"`, escapeMetricLabel(info.ActCallId)),
+		fmt.Sprintf(`actfile="%s"`, escapeMetricLabel(info.ActFilePath)),
+	}
+
+	for i := 0; i+1 < len(extra); i += 2 {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, extra[i], escapeMetricLabel(extra[i+1])))
+	}
+
+	return fmt.Sprintf("{%s}", strings.Join(labels, ","))
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to write metrics of runs in the Prometheus
+ * text format.
+ */
+func WriteMetrics(w io.Writer, infos []*Info) {
+	up := &metricFamily{Name: "act_up", Type: "gauge", Help: "Whether the act process is running (1) or not (0)."}
+	restarts := &metricFamily{Name: "act_restarts_total", Type: "counter", Help: "Number of times the act was restarted."}
+	exitCode := &metricFamily{Name: "act_last_exit_code", Type: "gauge", Help: "Exit code of the last failed command (or of the act process when it exited)."}
+	duration := &metricFamily{Name: "act_run_duration_seconds", Type: "gauge", Help: "For how long the act is running (or ran)."}
+	health := &metricFamily{Name: "act_health_status", Type: "gauge", Help: "Health of acts with checks: healthy (1) or unhealthy (0)."}
+
+	for _, info := range infos {
+		labels := getMetricLabels(info)
+
+		isUp := 0
+
+		if info.IsRunning() {
+			isUp = 1
+		}
+
+		lastExitCode := info.LastExitCode
+
+		if info.Exited {
+			lastExitCode = info.ExitCode
+		}
+
+		up.Samples = append(up.Samples, fmt.Sprintf("%s%s %d", up.Name, labels, isUp))
+		restarts.Samples = append(restarts.Samples, fmt.Sprintf("%s%s %d", restarts.Name, labels, info.RestartCount))
+		exitCode.Samples = append(exitCode.Samples, fmt.Sprintf("%s%s %d", exitCode.Name, labels, lastExitCode))
+		duration.Samples = append(duration.Samples, fmt.Sprintf("%s%s %g", duration.Name, labels, info.GetUptime().Seconds()))
+
+		// Keep samples order stable between scrapes.
+		var callIds []string
+
+		for callId := range info.Health {
+			callIds = append(callIds, callId)
+		}
+
+		sort.Strings(callIds)
+
+		for _, callId := range callIds {
+			isHealthy := 0
+
+			if info.Health[callId] == HealthHealthy {
+				isHealthy = 1
+			}
+
+			health.Samples = append(health.Samples, fmt.Sprintf("%s%s %d", health.Name, getMetricLabels(info, "check", callId), isHealthy))
+		}
+	}
+
+	for _, family := range []*metricFamily{up, restarts, exitCode, duration, health} {
+		fmt.Fprintf(w, "# HELP %s %s\n", family.Name, family.Help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", family.Name, family.Type)
+
+		for _, sample := range family.Samples {
+			fmt.Fprintln(w, sample)
+		}
+	}
+}
+
+/**
+ * This function going to start an http listener exposing metrics of
+ * this run (and of its detached child acts) on /metrics.
+ */
+func StartMetricsServer(addr string) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		infos := []*Info{runCtx.Info}
+
+		for _, childId := range runCtx.Info.ChildActIds {
+			if childInfo := GetInfo(childId); childInfo != nil {
+				infos = append(infos, childInfo)
+			}
+		}
+
+		w.Header().Set("Content-Type", MetricsContentType)
+		WriteMetrics(w, infos)
+	})
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			utils.LogError(fmt.Sprintf("could not serve metrics on %s", addr), err)
+		}
+	}()
+}
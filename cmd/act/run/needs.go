@@ -0,0 +1,176 @@
+/**
+ * This file implements act dependencies (needs). Before running an
+ * act we make sure all acts it needs are in the required condition:
+ * completed (run to completion), service_started (running in the
+ * background) or service_healthy (running in the background with its
+ * checks passing).
+ */
+
+package run
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Need conditions.
+ */
+const (
+	NeedCompleted      string = "completed"
+	NeedServiceStarted        = "service_started"
+	NeedServiceHealthy        = "service_healthy"
+)
+
+/**
+ * Max time we wait a needed service act to start (i.e., to save its
+ * run info).
+ */
+const NeedStartTimeout = 30 * time.Second
+
+/**
+ * Max time we wait a needed service act to get healthy after it
+ * started.
+ */
+const NeedHealthyTimeout = 5 * time.Minute
+
+/**
+ * Interval we poll needed service acts for their health.
+ */
+const NeedPollInterval = 500 * time.Millisecond
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to find a running act by its name id.
+ */
+func findRunningInfo(nameId string) *Info {
+	if !utils.DoFileExists(GetActDataDirPath()) {
+		return nil
+	}
+
+	for _, info := range GetAllInfo() {
+		if info.NameId == nameId && info.IsRunning() {
+			return info
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to check if a needed act declares checks. Acts
+ * we can't find are assumed to have checks so we keep waiting for
+ * them until timeout.
+ */
+func needHasCheck(need *actfile.ActNeed, ctx *ActRunCtx) bool {
+	needCtx, err := FindActCtx(strings.Split(need.Act, ActCallIdSeparator), ctx.ActFile, nil, ctx.RunCtx)
+
+	if err != nil || needCtx == nil || needCtx.Act == nil {
+		return true
+	}
+
+	return needCtx.Act.Check != nil
+}
+
+/**
+ * This function going to wait a needed service act to get healthy.
+ * Needed acts without checks are healthy as soon as they are running.
+ */
+func waitServiceHealthy(need *actfile.ActNeed, runId string, ctx *ActRunCtx) {
+	hasCheck := needHasCheck(need, ctx)
+	startDeadline := time.Now().Add(NeedStartTimeout)
+
+	var healthyDeadline time.Time
+
+	for ctx.CanRun() {
+		info := GetInfo(runId)
+
+		if info != nil {
+			if healthyDeadline.IsZero() {
+				healthyDeadline = time.Now().Add(NeedHealthyTimeout)
+			}
+
+			if !hasCheck || info.GetHealth() == HealthHealthy {
+				return
+			}
+
+			if time.Now().After(healthyDeadline) {
+				utils.FatalError(fmt.Sprintf("needed act %s did not get healthy in %s", need.Act, NeedHealthyTimeout))
+				return
+			}
+		} else if !healthyDeadline.IsZero() {
+			utils.FatalError(fmt.Sprintf("needed act %s exited before getting healthy", need.Act))
+			return
+		} else if time.Now().After(startDeadline) {
+			utils.FatalError(fmt.Sprintf("needed act %s did not start", need.Act))
+			return
+		}
+
+		ctx.Sleep(NeedPollInterval)
+	}
+}
+
+//############################################################
+// ActRunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to make sure all acts needed by this act are
+ * in the required condition. Needed services which are not running
+ * yet are started as detached acts (and therefore get stopped when
+ * this act finishes).
+ */
+func (ctx *ActRunCtx) NeedsExec() {
+	stage := &actfile.ActExecStage{Name: "needs"}
+
+	for _, need := range ctx.Act.Needs {
+		if !ctx.CanRun() {
+			return
+		}
+
+		condition := need.Condition
+
+		if condition == "" {
+			condition = NeedCompleted
+		}
+
+		utils.LogDebug(fmt.Sprintf("NeedsExec [act=%s] : need %s", ctx.Act.Name, need.Act), condition)
+
+		switch condition {
+		case NeedCompleted:
+			stage.Cmds = []*actfile.Cmd{{Act: need.Act}}
+			StageCmdsExec(stage, ctx)
+		case NeedServiceStarted, NeedServiceHealthy:
+			/**
+			 * Reuse the service if it's already running (like when user
+			 * started it with `act run -d`).
+			 */
+			var runId string
+
+			if info := findRunningInfo(need.Act); info != nil {
+				runId = info.Id
+			} else {
+				ctx.setCurrentStage(stage)
+				runId = actDetachExec(&actfile.Cmd{Act: need.Act, Detach: true}, ctx, nil)
+			}
+
+			if condition == NeedServiceHealthy && runId != "" {
+				waitServiceHealthy(need, runId, ctx)
+			}
+		default:
+			utils.FatalError(fmt.Sprintf("invalid condition %s for needed act %s", need.Condition, need.Act))
+			return
+		}
+	}
+}
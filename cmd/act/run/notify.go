@@ -0,0 +1,239 @@
+/**
+ * This file implements act notifications. When an act finishes (or
+ * its health checks flip) we post a json payload to a webhook and/or
+ * a message to a Slack incoming webhook as configured in the act
+ * notify block. This is useful for long daemonized acts nobody is
+ * watching. Long foreground runs can also fire a native desktop
+ * notification when they finish.
+ */
+
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the payload we post to notify urls.
+ */
+type notifyPayload struct {
+	Event      string `json:"event"`
+	RunId      string `json:"run_id"`
+	Act        string `json:"act"`
+	ActFile    string `json:"actfile"`
+	DurationMs int64  `json:"duration_ms"`
+	ExitCode   int    `json:"exit_code"`
+	FailedCmd  string `json:"failed_cmd,omitempty"`
+	Time       string `json:"time"`
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Events we can notify about.
+ */
+const (
+	NotifyEventFailure   = "failure"
+	NotifyEventSuccess   = "success"
+	NotifyEventUnhealthy = "unhealthy"
+	NotifyEventHealthy   = "healthy"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max time we wait for a notify url to respond.
+ */
+const notifyTimeout = 10 * time.Second
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Events we notify about when user don't specify any.
+ */
+var defaultNotifyEvents = []string{NotifyEventFailure, NotifyEventUnhealthy}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to post a json body to an url.
+ */
+func postNotifyJson(url string, body interface{}) error {
+	content, err := json.Marshal(body)
+
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(content))
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+/**
+ * This function going to check if an event is in a list of events.
+ */
+func hasNotifyEvent(events []string, event string) bool {
+	for _, val := range events {
+		if val == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to build the Slack message of a notification.
+ */
+func getSlackText(payload *notifyPayload) string {
+	var text string
+
+	switch payload.Event {
+	case NotifyEventFailure:
+		text = fmt.Sprintf(":x: act *%s* failed with exit code %d", payload.Act, payload.ExitCode)
+
+		if payload.FailedCmd != "" {
+			text += fmt.Sprintf(" (`%s`)", payload.FailedCmd)
+		}
+	case NotifyEventSuccess:
+		text = fmt.Sprintf(":white_check_mark: act *%s* succeeded", payload.Act)
+	case NotifyEventUnhealthy:
+		text = fmt.Sprintf(":warning: act *%s* is unhealthy", payload.Act)
+	case NotifyEventHealthy:
+		text = fmt.Sprintf(":white_check_mark: act *%s* is healthy again", payload.Act)
+	}
+
+	return fmt.Sprintf("%s after %s (run %s)", text, time.Duration(payload.DurationMs)*time.Millisecond, payload.RunId)
+}
+
+/**
+ * This function going to fire a desktop notification when a
+ * foreground run finishes (if user asked with the notify flag or the
+ * run took longer than the actfile notify_after).
+ */
+func notifyRunEnd() {
+	ctx := runCtx.ActCtx
+
+	// Only notify about acts which actually ran in foreground.
+	if ctx == nil || runCtx.IsDaemon || ctx.StartedAt.IsZero() {
+		return
+	}
+
+	notifyAfter := ctx.RunCtx.ActFile.NotifyAfter
+
+	if !runCtx.DesktopNotify && notifyAfter <= 0 {
+		return
+	}
+
+	duration := time.Since(ctx.StartedAt)
+
+	if duration < notifyAfter {
+		return
+	}
+
+	failed := ctx.Failed || utils.ExitCode != 0
+
+	// User interrupted the run so there is nothing to tell.
+	if !failed && runCtx.IsStopped() {
+		return
+	}
+
+	title := fmt.Sprintf("✅ act %s succeeded", ctx.CallId)
+	message := fmt.Sprintf("finished in %s", duration.Round(time.Second))
+
+	if failed {
+		title = fmt.Sprintf("❌ act %s failed", ctx.CallId)
+		message += fmt.Sprintf(" (exit code %d)", utils.ExitCode)
+	}
+
+	if err := utils.DesktopNotify(title, message); err != nil {
+		utils.LogDebug("could not fire desktop notification", err)
+	}
+}
+
+//############################################################
+// ActRunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to notify about an act event if the act has
+ * a notify block listening to it. Urls can use act vars (like
+ * `{{.SlackWebhookUrl}}`) so secrets can be kept in env files.
+ */
+func (ctx *ActRunCtx) Notify(event string) {
+	notify := ctx.Act.Notify
+
+	if notify == nil || (notify.Url == "" && notify.Slack == "") {
+		return
+	}
+
+	events := notify.Events
+
+	if len(events) == 0 {
+		events = defaultNotifyEvents
+	}
+
+	if !hasNotifyEvent(events, event) {
+		return
+	}
+
+	payload := &notifyPayload{
+		Event:     event,
+		RunId:     ctx.RunCtx.Info.Id,
+		Act:       ctx.CallId,
+		ActFile:   ctx.ActFile.LocationPath,
+		ExitCode:  ctx.ExitCode,
+		FailedCmd: ctx.FailedCmd,
+		Time:      time.Now().Format(time.RFC3339),
+	}
+
+	if !ctx.StartedAt.IsZero() {
+		payload.DurationMs = time.Since(ctx.StartedAt).Milliseconds()
+	}
+
+	vars := ctx.MergeVars()
+
+	if notify.Url != "" {
+		if err := postNotifyJson(utils.CompileTemplate(notify.Url, vars), payload); err != nil {
+			utils.LogWarn(fmt.Sprintf("could not notify %s event of act %s", event, ctx.CallId), err)
+		}
+	}
+
+	if notify.Slack != "" {
+		body := map[string]string{"text": getSlackText(payload)}
+
+		if err := postNotifyJson(utils.CompileTemplate(notify.Slack, vars), body); err != nil {
+			utils.LogWarn(fmt.Sprintf("could not notify %s event of act %s to slack", event, ctx.CallId), err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/**
+ * This file implements ownership verification of command process
+ * groups. Pgids recorded in run info can be recycled by the system
+ * for unrelated processes (like after a crash) so before signaling a
+ * process group we make sure its leader is still the process we
+ * started (comparing process start times).
+ */
+
+package run
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check if a process descends from another
+ * one by walking up the parent chain.
+ */
+func isDescendantOf(procs map[int]*procgroup.Proc, pid int, ancestorPid int) bool {
+	// We bound the walk so we never loop forever on inconsistent data.
+	for i := 0; i < len(procs) && pid > 1; i++ {
+		proc, ok := procs[pid]
+
+		if !ok {
+			return false
+		}
+
+		if proc.Ppid == ancestorPid {
+			return true
+		}
+
+		pid = proc.Ppid
+	}
+
+	return false
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function going to check if a process group still belongs to
+ * this act. Process groups with a recorded start time are checked
+ * against their leader process so this works even after the act
+ * process died (i.e., for orphan commands).
+ */
+func (info *Info) OwnsPgid(pgid int) bool {
+	info.mutex.Lock()
+	startTime, recorded := info.CmdPgidStarts[pgid]
+	info.mutex.Unlock()
+
+	if recorded {
+		currStartTime := procgroup.GetStartTime(pgid)
+
+		/**
+		 * When the leader exited the pgid can't be reused while the
+		 * group still has processes so any process left is ours.
+		 */
+		return currStartTime == "" || currStartTime == startTime
+	}
+
+	/**
+	 * Info saved by older versions has no start times so we fallback
+	 * to checking the group contains a descendant of the act process.
+	 */
+	return info.ownsPgidByTree(pgid)
+}
+
+/**
+ * This function going to check if a process group contains a
+ * descendant of the act process.
+ */
+func (info *Info) ownsPgidByTree(pgid int) bool {
+	/**
+	 * If act process pid was recycled then we can't trust its process
+	 * tree anymore.
+	 */
+	if info.Pid != os.Getpid() && !isActProcess(info.Pid) {
+		return false
+	}
+
+	procs := procgroup.List()
+
+	for pid, proc := range procs {
+		if proc.Pgid == pgid && isDescendantOf(procs, pid, info.Pid) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to check process group ownership before we
+ * signal it. Process groups we don't own anymore are forgotten.
+ */
+func (info *Info) verifyPgid(pgid int) bool {
+	if info.OwnsPgid(pgid) {
+		return true
+	}
+
+	utils.LogWarn(fmt.Sprintf("skipping process group %d : it does not belong to act %s anymore", pgid, info.GetNameIdOrId()))
+
+	info.RmCmdPgid(pgid)
+
+	return false
+}
@@ -0,0 +1,48 @@
+package run
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+/**
+ * Process groups are owned by comparing the start time of their
+ * leader even when the act process is not running anymore.
+ */
+func TestOwnsPgidStartTime(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	cmd.SysProcAttr = procgroup.NewSysProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		t.Skipf("could not start sleep: %v", err)
+	}
+
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	pgid := cmd.Process.Pid
+
+	// Use a pid which is surely not an act process.
+	info := &Info{Id: "test", Pid: -1, Dead: true}
+	info.CmdPgids = []int{pgid}
+	info.CmdPgidStarts = map[int]string{pgid: procgroup.GetStartTime(pgid)}
+
+	if !info.OwnsPgid(pgid) {
+		t.Errorf("process group %d should be owned", pgid)
+	}
+
+	if !info.HasOrphanCmds() {
+		t.Errorf("dead act with running commands should have orphans")
+	}
+
+	// A recycled pid has a different start time.
+	info.CmdPgidStarts[pgid] = "recycled"
+
+	if info.OwnsPgid(pgid) {
+		t.Errorf("recycled process group %d should not be owned", pgid)
+	}
+}
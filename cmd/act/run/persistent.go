@@ -0,0 +1,503 @@
+/**
+ * This file implements the persistent exec mode of stages (set with
+ * `exec_mode: persistent`) where commands of a stage run one after
+ * the other in a single shell process instead of spawning a new
+ * shell for each command. We feed commands to the shell through its
+ * stdin (each one quoted as a single eval argument so broken commands
+ * can't swallow what comes after them) and after each command the
+ * shell writes a status marker (with the command exit code) to a
+ * separate status pipe.
+ */
+
+package run
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+	"github.com/teris-io/shortid"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Exec modes of stages. In isolated mode (the default) each command
+ * runs in its own shell process while in persistent mode commands
+ * share a single shell process.
+ */
+const (
+	ExecModeIsolated   = "isolated"
+	ExecModePersistent = "persistent"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the error of a command run by a
+ * persistent shell which exited with an exit code other than zero.
+ */
+type ShellStatusError struct {
+	/**
+	 * Exit code of the command.
+	 */
+	ExitCode int
+}
+
+/**
+ * This struct going to hold a shell process shared by commands of a
+ * stage. The shell is started lazily (and restarted if a command
+ * makes it exit).
+ */
+type persistentShell struct {
+	/**
+	 * Act running the stage.
+	 */
+	ctx *ActRunCtx
+
+	/**
+	 * The running shell process along with the pipe we write commands
+	 * to and the pipe the shell writes status markers to.
+	 */
+	shCmd      *exec.Cmd
+	stdin      io.WriteCloser
+	statusFile *os.File
+
+	/**
+	 * Lines read from the status pipe (closed once the pipe hits
+	 * EOF) and channel closed once we release the shell so we stop
+	 * reading them.
+	 */
+	statusLines chan string
+	released    chan struct{}
+
+	/**
+	 * Channel closed once the shell process exits along with the
+	 * shell error.
+	 */
+	exited  chan struct{}
+	exitErr error
+
+	/**
+	 * Process group of the running shell.
+	 */
+	pgid int
+
+	/**
+	 * Flag indicating the shell pgid is tracked as a final pgid.
+	 */
+	isFinal bool
+
+	/**
+	 * Random token we use to tell status markers apart.
+	 */
+	token string
+
+	/**
+	 * Env vars the shell currently has so we only send the ones
+	 * changed between commands.
+	 */
+	envVars map[string]string
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Regex of env var names we can export in a posix shell.
+ */
+var shellVarNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to create a persistent shell for a stage. It
+ * returns nil (meaning commands run isolated) when the act shell is
+ * not a posix shell.
+ */
+func newPersistentShell(stage *actfile.ActExecStage, ctx *ActRunCtx) *persistentShell {
+	shell := getShell(nil, ctx)
+
+	if runtime.GOOS == "windows" || getShellKind(shell) != "sh" {
+		utils.LogWarn(fmt.Sprintf("persistent exec mode needs a posix shell : running commands of stage %s isolated (act %s)", stage.Name, ctx.CallId))
+		return nil
+	}
+
+	return &persistentShell{ctx: ctx}
+}
+
+/**
+ * This function going to check if a command can run in the stage
+ * persistent shell. Commands which need a process of their own (like
+ * commands with their own shell, dir, env vars or output handling)
+ * keep running isolated.
+ */
+func canRunPersistent(cmd *actfile.Cmd, ctx *ActRunCtx) bool {
+	if cmd.Script != "" || cmd.Shell != "" || len(cmd.ShellOpts) > 0 || cmd.Dir != "" || len(cmd.Env) > 0 {
+		return false
+	}
+
+	if cmd.Expect != nil || cmd.Quiet || cmd.Log || isCmdTty(cmd, ctx) || getCmdContainer(cmd, ctx) != nil {
+		return false
+	}
+
+	if user, umask, nice := getCmdProcAttrs(cmd, ctx); user != "" || umask != "" || nice != nil {
+		return false
+	}
+
+	if ctx.Act.Limits != nil || ctx.RunCtx.QuietSuccess {
+		return false
+	}
+
+	// Interactive daemons feed users input to start commands.
+	return ctx.RunCtx.Stdin == nil || ctx.CurrentStage != ctx.RunCtx.ActCtx.Act.Start
+}
+
+/**
+ * This function going to parse env vars (KEY=value) into a map.
+ */
+func parseEnvVars(envars []string) map[string]string {
+	envVars := make(map[string]string)
+
+	for _, kv := range envars {
+		if parts := strings.SplitN(kv, "=", 2); len(parts) == 2 {
+			envVars[parts[0]] = parts[1]
+		}
+	}
+
+	return envVars
+}
+
+//############################################################
+// ShellStatusError Struct Functions
+//############################################################
+
+/**
+ * This function going to get the error message.
+ */
+func (err *ShellStatusError) Error() string {
+	return fmt.Sprintf("exit status %d", err.ExitCode)
+}
+
+//############################################################
+// persistentShell Struct Functions
+//############################################################
+
+/**
+ * This function going to start the shell process.
+ */
+func (ps *persistentShell) start() error {
+	ctx := ps.ctx
+	shell := getShell(nil, ctx)
+	vars := ctx.MergeVars()
+	envars := ctx.GetEnvVars(vars)
+
+	statusR, statusW, err := os.Pipe()
+
+	if err != nil {
+		return err
+	}
+
+	shCmd := exec.Command(shell, append(getShellOptArgs(getShellOpts(nil, ctx)), "-s")...)
+	shCmd.Dir = filepath.Dir(ctx.ActFile.LocationPath)
+	shCmd.Env = envars
+	shCmd.SysProcAttr = procgroup.NewSysProcAttr()
+
+	// The shell writes status markers to fd 3.
+	shCmd.ExtraFiles = []*os.File{statusW}
+
+	stdin, err := shCmd.StdinPipe()
+
+	if err != nil {
+		statusR.Close()
+		statusW.Close()
+		return err
+	}
+
+	shCmd.Stdout, shCmd.Stderr = getCmdOutputs(&actfile.Cmd{}, ctx)
+
+	if err := shCmd.Start(); err != nil {
+		statusR.Close()
+		statusW.Close()
+		return err
+	}
+
+	// Only the shell should hold the writing side now.
+	statusW.Close()
+
+	profileMarkSpawn()
+
+	procgroup.Track(shCmd)
+
+	pgid, err := procgroup.Getpgid(shCmd.Process.Pid)
+
+	if err != nil {
+		utils.FatalError(fmt.Sprintf("could not get pgid for pid=%d", shCmd.Process.Pid), err)
+	}
+
+	ps.shCmd = shCmd
+	ps.stdin = stdin
+	ps.statusFile = statusR
+	ps.statusLines = make(chan string)
+	ps.released = make(chan struct{})
+	ps.exited = make(chan struct{})
+	ps.pgid = pgid
+	ps.token, _ = shortid.Generate()
+	ps.envVars = parseEnvVars(envars)
+	ps.isFinal = ctx.IsFinalizing()
+
+	ctx.RunCtx.TrackCmdPgid(pgid, ps.isFinal)
+
+	go func(lines chan string, released chan struct{}) {
+		status := bufio.NewReader(statusR)
+
+		for {
+			line, err := status.ReadString('\n')
+
+			if err != nil {
+				close(lines)
+				return
+			}
+
+			select {
+			case lines <- line:
+			case <-released:
+				return
+			}
+		}
+	}(ps.statusLines, ps.released)
+
+	go func(exited chan struct{}) {
+		ps.exitErr = shCmd.Wait()
+		close(exited)
+	}(ps.exited)
+
+	utils.LogDebug(fmt.Sprintf("persistentShell : started [act=%s] [pid=%d] [pgid=%d]", ctx.CallId, shCmd.Process.Pid, pgid))
+
+	return nil
+}
+
+/**
+ * This function going to get shell statements updating env vars of
+ * the shell to the ones of the next command (runtime vars can change
+ * between commands).
+ */
+func (ps *persistentShell) getEnvPrelude(envars []string) string {
+	envVars := parseEnvVars(envars)
+
+	var keys []string
+
+	for key := range envVars {
+		keys = append(keys, key)
+	}
+
+	for key := range ps.envVars {
+		if _, ok := envVars[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var prelude strings.Builder
+
+	for _, key := range keys {
+		if !shellVarNameRegex.MatchString(key) {
+			continue
+		}
+
+		val, ok := envVars[key]
+
+		if !ok {
+			fmt.Fprintf(&prelude, "unset %s\n", key)
+		} else if prevVal, hasPrev := ps.envVars[key]; !hasPrev || prevVal != val {
+			fmt.Fprintf(&prelude, "export %s=%s\n", key, utils.ShellQuote(val))
+		}
+	}
+
+	ps.envVars = envVars
+
+	return prelude.String()
+}
+
+/**
+ * This function going to wait the status marker of the command we
+ * sent to the shell returning the command error (if any). If the
+ * shell exits instead (like when the command calls exit or the act
+ * gets stopped) we return an error as well. We don't wait the marker
+ * once commands of the act are not allowed to run anymore.
+ */
+func (ps *persistentShell) waitStatus() error {
+	prefix := ps.token + ":"
+	exited := ps.exited
+
+	for {
+		var line string
+		var ok bool
+
+		select {
+		case line, ok = <-ps.statusLines:
+		case <-exited:
+			/**
+			 * The shell held the only writing side of the status pipe so
+			 * we just read what is left in it.
+			 */
+			exited = nil
+			continue
+		case <-ps.ctx.Done():
+			return errors.New("persistent shell stopped")
+		}
+
+		if !ok {
+			return ps.exitError()
+		}
+
+		line = strings.TrimSpace(line)
+
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+
+		exitCode, err := strconv.Atoi(strings.TrimPrefix(line, prefix))
+
+		if err != nil || exitCode == 0 {
+			return nil
+		}
+
+		return &ShellStatusError{ExitCode: exitCode}
+	}
+}
+
+/**
+ * This function going to release a shell which exited while running
+ * a command returning the error of the command.
+ */
+func (ps *persistentShell) exitError() error {
+	if err := ps.release(); err != nil {
+		return err
+	}
+
+	return errors.New("persistent shell exited while running command")
+}
+
+/**
+ * This function going to run a command in the shell (starting the
+ * shell if it's not running) and wait it to finish.
+ */
+func (ps *persistentShell) exec(cmd *actfile.Cmd, vars map[string]string) (string, error) {
+	ctx := ps.ctx
+	cmdLine := utils.CompileTemplate(cmd.Cmd, ctx.getShellLineVars(vars))
+
+	if ps.shCmd == nil {
+		if err := ps.start(); err != nil {
+			return cmdLine, err
+		}
+	}
+
+	utils.LogDebug(fmt.Sprintf("persistentShell : running command [act=%s] [source=%s]", ctx.CallId, getCmdSource(cmd, ctx)))
+	utils.LogVerbose(fmt.Sprintf("running %s [act=%s]", cmdLine, ctx.CallId))
+
+	/**
+	 * Act args are set as positional params (like isolated commands
+	 * get them) quoted so they reach commands as is.
+	 */
+	params := []string{"set", "--"}
+
+	for _, arg := range ctx.Args {
+		params = append(params, utils.ShellQuote(arg))
+	}
+
+	/**
+	 * Commands run in a group so the shell keeps state (like dir
+	 * changes) between them. Commands don't get the shell stdin (it's
+	 * where we write commands to) nor the status pipe. We eval them
+	 * quoted so syntax errors (like unbalanced quotes) are errors of
+	 * the command (`command` keeps eval from exiting the shell). The
+	 * token goes as a printf arg since it can start with a dash.
+	 */
+	script := fmt.Sprintf("%s%s%s\n{ command eval %s; } </dev/null 3>&-\nprintf '%%s:%%d\\n' %s \"$?\" >&3\n", ps.getEnvPrelude(ctx.GetEnvVars(vars)), getSourceComment(getCmdSource(cmd, ctx), ""), strings.Join(params, " "), utils.ShellQuote(cmdLine), utils.ShellQuote(ps.token))
+
+	stageName := ""
+
+	if ctx.CurrentStage != nil {
+		stageName = ctx.CurrentStage.Name
+	}
+
+	ctx.RunCtx.AddRunningCmd(&RunningCmd{
+		Act:       ctx.CallId,
+		Stage:     stageName,
+		Cmd:       cmdLine,
+		Source:    getCmdSource(cmd, ctx),
+		Pid:       ps.shCmd.Process.Pid,
+		Pgid:      ps.pgid,
+		StartedAt: time.Now(),
+	})
+
+	defer ctx.RunCtx.RmRunningCmd(ps.pgid)
+
+	if _, err := io.WriteString(ps.stdin, script); err != nil {
+		ps.release()
+		return cmdLine, err
+	}
+
+	return cmdLine, ps.waitStatus()
+}
+
+/**
+ * This function going to wait the shell process to exit and release
+ * everything related to it returning the shell error.
+ */
+func (ps *persistentShell) release() error {
+	if ps.shCmd == nil {
+		return nil
+	}
+
+	ps.stdin.Close()
+
+	<-ps.exited
+	err := ps.exitErr
+
+	close(ps.released)
+	ps.statusFile.Close()
+	flushMaskWriters(ps.shCmd.Stdout, ps.shCmd.Stderr)
+
+	if ps.isFinal {
+		ps.ctx.RunCtx.RmFinalPgid(ps.pgid)
+	} else {
+		ps.ctx.RunCtx.Info.RmCmdPgid(ps.pgid)
+	}
+
+	utils.LogDebug(fmt.Sprintf("persistentShell : exited [act=%s] [pgid=%d]", ps.ctx.CallId, ps.pgid), err)
+
+	ps.shCmd = nil
+
+	return err
+}
+
+/**
+ * This function going to close the shell once the stage finishes (it
+ * exits as soon as it reads the end of its stdin).
+ */
+func (ps *persistentShell) Close() {
+	ps.release()
+}
@@ -0,0 +1,138 @@
+package run
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+/**
+ * This function going to create a persistent shell for tests which
+ * is closed once the test finishes.
+ */
+func newTestPersistentShell(t *testing.T) (*persistentShell, *ActRunCtx) {
+	ctx := newTestRunningCtx(t)
+	ps := newPersistentShell(&actfile.ActExecStage{Name: "start"}, ctx)
+
+	if ps == nil {
+		t.Skip("persistent shell needs a posix shell")
+	}
+
+	t.Cleanup(ps.Close)
+
+	return ps, ctx
+}
+
+/**
+ * This function going to run a command in a persistent shell failing
+ * if it doesn't return promptly. We kill the shell and wait the
+ * command to return before failing so it doesn't race with closing
+ * the shell.
+ */
+func execTestPersistentCmd(t *testing.T, ps *persistentShell, cmdLine string) error {
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := ps.exec(&actfile.Cmd{Cmd: cmdLine}, ps.ctx.MergeVars())
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		procgroup.Signal(ps.pgid, syscall.SIGKILL)
+		<-done
+		t.Fatalf("command %q still running", cmdLine)
+		return nil
+	}
+}
+
+/**
+ * Broken commands (like unbalanced quotes, stray braces and
+ * unterminated heredocs) fail without hanging the shell which keeps
+ * running the next commands with its state.
+ */
+func TestPersistentShellBrokenCmds(t *testing.T) {
+	ps, _ := newTestPersistentShell(t)
+
+	if err := execTestPersistentCmd(t, ps, "cd /"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmdLine := range []string{`echo "unbalanced`, "}", "cat <<EOF\nno end"} {
+		execTestPersistentCmd(t, ps, cmdLine)
+
+		if err := execTestPersistentCmd(t, ps, `test "$PWD" = /`); err != nil {
+			t.Errorf("got %v after %q, want shell state kept", err, cmdLine)
+		}
+	}
+
+	if err := execTestPersistentCmd(t, ps, "}"); err == nil {
+		t.Error("got no error for a stray brace")
+	}
+}
+
+/**
+ * Status markers get written whatever the shell token looks like
+ * (random tokens can start with a dash).
+ */
+func TestPersistentShellDashToken(t *testing.T) {
+	ps, _ := newTestPersistentShell(t)
+
+	if err := execTestPersistentCmd(t, ps, "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	ps.token = "-dashtoken"
+
+	if err := execTestPersistentCmd(t, ps, "true"); err != nil {
+		t.Error(err)
+	}
+}
+
+/**
+ * Commands making the shell exit fail (even with exit code zero) and
+ * the next command gets a new shell.
+ */
+func TestPersistentShellExit(t *testing.T) {
+	ps, _ := newTestPersistentShell(t)
+
+	for _, cmdLine := range []string{"exit 0", "exit 3"} {
+		if err := execTestPersistentCmd(t, ps, cmdLine); err == nil {
+			t.Errorf("got no error for %q", cmdLine)
+		}
+
+		if err := execTestPersistentCmd(t, ps, "true"); err != nil {
+			t.Errorf("got %v after %q, want a new shell", err, cmdLine)
+		}
+	}
+}
+
+/**
+ * Waiting a command ends once commands are not allowed to run
+ * anymore.
+ */
+func TestPersistentShellCanceled(t *testing.T) {
+	ps, ctx := newTestPersistentShell(t)
+
+	if err := execTestPersistentCmd(t, ps, "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	pgid := ps.pgid
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		ctx.RunCtx.cancel()
+	}()
+
+	if err := execTestPersistentCmd(t, ps, "sleep 30"); err == nil {
+		t.Error("got no error for a canceled command")
+	}
+
+	procgroup.Signal(pgid, syscall.SIGKILL)
+}
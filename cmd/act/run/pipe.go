@@ -0,0 +1,269 @@
+/**
+ * This file implements pipelines (set with `pipe` on commands) which
+ * connect stdout of each command to stdin of the next one. Unlike a
+ * long `a | b | c` command line each command of the pipeline can
+ * have its own shell, dir and env vars and we report which command
+ * of the pipeline failed.
+ */
+
+package run
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the error of the pipeline command that
+ * failed.
+ */
+type PipeError struct {
+	/**
+	 * Position (starting at 1) of the command in the pipeline.
+	 */
+	Index int
+
+	/**
+	 * Command line of the command.
+	 */
+	CmdLine string
+
+	/**
+	 * Error the command failed with.
+	 */
+	Err error
+}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Exit code shells use for commands killed by SIGPIPE.
+ */
+const sigpipeExitCode = 128 + 13
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check if a pipeline command died because
+ * the next command stopped reading its output (which is not a
+ * failure since pipelines like `cat big.log | head` do that).
+ */
+func isBrokenPipeErr(err error) bool {
+	exiterr, ok := err.(*exec.ExitError)
+
+	if !ok {
+		return false
+	}
+
+	if status, ok := exiterr.Sys().(syscall.WaitStatus); ok && status.Signaled() && status.Signal() == syscall.SIGPIPE {
+		return true
+	}
+
+	return exiterr.ExitCode() == sigpipeExitCode
+}
+
+/**
+ * This function going to create the process of a pipeline command
+ * (script or command line).
+ */
+func newPipeSegmentCmd(seg *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) (*exec.Cmd, string) {
+	vars = getCmdVars(seg, vars)
+	shell := getShell(seg, ctx)
+
+	var shArgs []string
+	var cmdLine string
+
+	if seg.Script != "" {
+		cmdLine = utils.CompileTemplate(seg.Script, vars)
+
+		var cmdArgs []string
+
+		for _, arg := range seg.Args {
+			cmdArgs = append(cmdArgs, utils.CompileTemplate(arg, vars))
+		}
+
+		shArgs = getShellScriptArgs(shell, cmdLine, cmdArgs, getShellOpts(seg, ctx))
+	} else {
+		cmdLine = utils.CompileTemplate(seg.Cmd, ctx.getShellLineVars(vars))
+		shArgs = getShellArgs(shell, cmdLine, ctx.Args, getShellOpts(seg, ctx))
+	}
+
+	shBin, shBinArgs := getShellExecArgs(shell, shArgs)
+
+	shCmd := exec.Command(shBin, shBinArgs...)
+	shCmd.Dir = getCmdDir(seg, ctx, vars)
+	shCmd.Env = ctx.GetEnvVars(vars)
+	shCmd.SysProcAttr = procgroup.NewSysProcAttr()
+
+	return shCmd, cmdLine
+}
+
+/**
+ * This function going to run the commands of a pipeline connecting
+ * their stdio and wait all of them to finish. The pipeline fails with
+ * the error of the first command that failed.
+ */
+func cmdPipeExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) (string, error) {
+	vars = getCmdVars(cmd, vars)
+
+	shCmds := make([]*exec.Cmd, len(cmd.Pipe))
+	cmdLines := make([]string, len(cmd.Pipe))
+
+	for idx, seg := range cmd.Pipe {
+		shCmds[idx], cmdLines[idx] = newPipeSegmentCmd(seg, ctx, vars)
+	}
+
+	cmdLine := strings.Join(cmdLines, " | ")
+
+	if len(shCmds) == 0 {
+		return cmdLine, nil
+	}
+
+	stdout, stderr := getCmdOutputs(cmd, ctx)
+
+	if !ctx.RunCtx.IsDaemon && getLogMode(cmd, ctx) == "raw" {
+		shCmds[0].Stdin = os.Stdin
+	}
+
+	for _, shCmd := range shCmds {
+		shCmd.Stderr = stderr
+	}
+
+	shCmds[len(shCmds)-1].Stdout = stdout
+
+	/**
+	 * If command has output assertions then we need to capture the
+	 * pipeline output as well.
+	 */
+	var stdoutBuf bytes.Buffer
+
+	if cmd.Expect != nil {
+		if stdout != nil {
+			shCmds[len(shCmds)-1].Stdout = io.MultiWriter(stdout, &stdoutBuf)
+		} else {
+			shCmds[len(shCmds)-1].Stdout = &stdoutBuf
+		}
+	}
+
+	/**
+	 * Connect commands with os pipes (instead of letting go copy
+	 * data between them) so a command exiting closes the pipe for
+	 * its neighbours.
+	 */
+	var pipeFiles []*os.File
+
+	for idx := 0; idx < len(shCmds)-1; idx++ {
+		reader, writer, err := os.Pipe()
+
+		if err != nil {
+			for _, file := range pipeFiles {
+				file.Close()
+			}
+
+			return cmdLine, err
+		}
+
+		shCmds[idx].Stdout = writer
+		shCmds[idx+1].Stdin = reader
+
+		pipeFiles = append(pipeFiles, reader, writer)
+	}
+
+	utils.LogVerbose(fmt.Sprintf("running %s [act=%s]", cmdLine, ctx.CallId))
+
+	isFinal := ctx.IsFinalizing()
+	var pgids []int
+	var startErr error
+
+	for idx, shCmd := range shCmds {
+		if startErr = shCmd.Start(); startErr != nil {
+			startErr = fmt.Errorf("could not start command %d of pipeline (%s): %v", idx+1, cmdLines[idx], startErr)
+			break
+		}
+
+		procgroup.Track(shCmd)
+
+		pgid, err := procgroup.Getpgid(shCmd.Process.Pid)
+
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("could not get pgid for pid=%d", shCmd.Process.Pid), err)
+		}
+
+		pgids = append(pgids, pgid)
+
+		ctx.RunCtx.TrackCmdPgid(pgid, isFinal)
+	}
+
+	// Only pipeline commands should hold the pipes now.
+	for _, file := range pipeFiles {
+		file.Close()
+	}
+
+	/**
+	 * Wait all started commands. When a command failed to start the
+	 * ones already started get their input closed and finish.
+	 */
+	var err error
+
+	for idx, pgid := range pgids {
+		waitErr := shCmds[idx].Wait()
+
+		if isFinal {
+			ctx.RunCtx.RmFinalPgid(pgid)
+		} else {
+			ctx.RunCtx.Info.RmCmdPgid(pgid)
+		}
+
+		if waitErr != nil && err == nil && !isBrokenPipeErr(waitErr) {
+			utils.LogDebug(fmt.Sprintf("cmdPipeExec : command %d failed [act=%s]", idx+1, ctx.Act.Name), waitErr)
+			err = &PipeError{Index: idx + 1, CmdLine: cmdLines[idx], Err: waitErr}
+		}
+	}
+
+	flushMaskWriters(stdout, stderr)
+
+	if startErr != nil {
+		return cmdLine, startErr
+	}
+
+	if cmd.Expect != nil {
+		err = checkCmdExpect(cmd.Expect, err, stdoutBuf.String(), vars)
+	}
+
+	return cmdLine, err
+}
+
+//############################################################
+// PipeError Struct Functions
+//############################################################
+
+/**
+ * This function going to describe the pipeline failure.
+ */
+func (e *PipeError) Error() string {
+	return fmt.Sprintf("command %d of pipeline (%s) failed: %v", e.Index, e.CmdLine, e.Err)
+}
+
+/**
+ * This function going to get the error the command failed with.
+ */
+func (e *PipeError) Unwrap() error {
+	return e.Err
+}
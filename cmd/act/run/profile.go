@@ -0,0 +1,139 @@
+/**
+ * This file implements the startup profile (enabled with the internal
+ * `-profile` flag of run command) which reports how long each startup
+ * phase (like parsing actfiles, resolving the act to run and spawning
+ * the first command) took so we can keep act startup fast.
+ */
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold how long a startup phase took.
+ */
+type profilePhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+/**
+ * This struct going to hold the startup profile of a run.
+ */
+type startupProfile struct {
+	/**
+	 * Time when we started profiling and when the last phase ended.
+	 */
+	startedAt time.Time
+	markedAt  time.Time
+
+	/**
+	 * Phases in the order they ended.
+	 */
+	phases []*profilePhase
+
+	/**
+	 * Flag indicating we already marked the first command spawn.
+	 */
+	spawned bool
+
+	mutex sync.Mutex
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Startup profile of this run (nil when not profiling).
+ */
+var profile *startupProfile
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to start profiling startup phases.
+ */
+func startProfile(startedAt time.Time) {
+	profile = &startupProfile{startedAt: startedAt, markedAt: startedAt}
+}
+
+/**
+ * This function going to mark the end of a startup phase which
+ * started when the previous one ended.
+ */
+func profileMark(name string) {
+	if profile == nil {
+		return
+	}
+
+	profile.mutex.Lock()
+	defer profile.mutex.Unlock()
+
+	now := time.Now()
+
+	profile.phases = append(profile.phases, &profilePhase{Name: name, Duration: now.Sub(profile.markedAt)})
+	profile.markedAt = now
+}
+
+/**
+ * This function going to mark the spawn of the first command which
+ * ends the startup.
+ */
+func profileMarkSpawn() {
+	if profile == nil {
+		return
+	}
+
+	profile.mutex.Lock()
+	spawned := profile.spawned
+	profile.spawned = true
+	profile.mutex.Unlock()
+
+	if !spawned {
+		profileMark("spawn first command")
+	}
+}
+
+/**
+ * This function going to print the startup profile to stderr once
+ * the run finishes.
+ */
+func printRunProfile() {
+	if profile == nil {
+		return
+	}
+
+	profile.mutex.Lock()
+	defer profile.mutex.Unlock()
+
+	fmt.Fprintln(os.Stderr)
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "PHASE\tTIME")
+
+	var startup time.Duration
+
+	for _, phase := range profile.phases {
+		startup += phase.Duration
+		fmt.Fprintf(w, "%s\t%s\n", phase.Name, phase.Duration.Round(time.Microsecond))
+	}
+
+	fmt.Fprintf(w, "startup\t%s\n", startup.Round(time.Microsecond))
+	fmt.Fprintf(w, "total\t%s\n", time.Since(profile.startedAt).Round(time.Microsecond))
+
+	w.Flush()
+}
@@ -0,0 +1,153 @@
+/**
+ * This file implements act queues. Concurrent runs of a queued act
+ * are executed serially in submission order. Each run enqueues itself
+ * by creating a ticket file in the act queue dir (in the queues dir of
+ * the state dir) and waits until its ticket is the first one. Tickets of runs which
+ * died are skipped so a crash doesn't block the queue.
+ */
+
+package run
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Interval we poll the queue while waiting our turn.
+ */
+const QueuePollInterval = 500 * time.Millisecond
+
+/**
+ * Name of the dir (in the state dir) holding act queues. Queues live
+ * apart from project data dirs since dirs there are taken as run dirs.
+ */
+const QueuesDirName = "queues"
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Path of the queue ticket of this run (if any).
+ */
+var queueTicketPath string
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the queue dir path of an act.
+ */
+func getActQueueDirPath(ctx *ActRunCtx) string {
+	return filepath.Join(GetStateDirPath(), QueuesDirName, getThrottleKey(ctx))
+}
+
+/**
+ * This function going to get the queue ticket which is at the head
+ * of the queue removing tickets of runs which died.
+ */
+func getQueueHead(queueDirPath string) string {
+	files, err := ioutil.ReadDir(queueDirPath)
+
+	if err != nil {
+		return ""
+	}
+
+	var tickets []string
+
+	for _, f := range files {
+		tickets = append(tickets, f.Name())
+	}
+
+	// Ticket names start with the submission time.
+	sort.Strings(tickets)
+
+	for _, ticket := range tickets {
+		ticketPath := filepath.Join(queueDirPath, ticket)
+		content, err := ioutil.ReadFile(ticketPath)
+
+		if err != nil {
+			continue
+		}
+
+		pid, _ := strconv.Atoi(string(content))
+
+		if pid == os.Getpid() || (isProcessRunning(pid) && isActProcess(pid)) {
+			return ticketPath
+		}
+
+		utils.LogDebug("getQueueHead : removing dead ticket", ticket)
+		os.Remove(ticketPath)
+	}
+
+	return ""
+}
+
+/**
+ * This function going to remove the queue ticket of this run so the
+ * next queued run can start.
+ */
+func leaveActQueue() {
+	if queueTicketPath != "" {
+		os.Remove(queueTicketPath)
+		queueTicketPath = ""
+	}
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to enqueue a run of a queued act and wait
+ * until it's its turn to run. It returns false if the run was
+ * stopped while waiting.
+ */
+func WaitActQueue(ctx *ActRunCtx) bool {
+	if memoryOnly || !ctx.Act.Queue {
+		return true
+	}
+
+	queueDirPath := getActQueueDirPath(ctx)
+	os.MkdirAll(queueDirPath, 0755)
+
+	ticket := fmt.Sprintf("%020d-%s", time.Now().UnixNano(), ctx.RunCtx.Info.Id)
+	queueTicketPath = filepath.Join(queueDirPath, ticket)
+
+	if err := utils.WriteFileAtomic(queueTicketPath, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		utils.FatalError("could not enqueue act run", err)
+		return false
+	}
+
+	logged := false
+
+	for ctx.CanRun() {
+		if getQueueHead(queueDirPath) == queueTicketPath {
+			return true
+		}
+
+		if !logged {
+			utils.LogInfo(fmt.Sprintf("act %s queued : waiting previous runs to finish", ctx.CallId))
+			logged = true
+		}
+
+		ctx.Sleep(QueuePollInterval)
+	}
+
+	leaveActQueue()
+
+	return false
+}
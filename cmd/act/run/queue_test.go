@@ -0,0 +1,163 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+/**
+ * This function going to run a queued act the way an act process
+ * does when the test binary is run as a queued run helper. The run
+ * appends when it started and ended to a file and lists runs while
+ * running (like `act list` would).
+ */
+func runTestQueuedAct(t *testing.T, actFilePath string, outPath string) {
+	actCtx := &ActRunCtx{
+		CallId:  "queued",
+		Act:     &actfile.Act{Name: "queued", Queue: true},
+		ActFile: &actfile.ActFile{LocationPath: actFilePath},
+	}
+
+	ctx := &RunCtx{
+		ActFile: actCtx.ActFile,
+		ActCtx:  actCtx,
+		Info:    &Info{Id: fmt.Sprintf("run-%d", os.Getpid()), Pid: os.Getpid()},
+	}
+
+	ctx.Ctx, ctx.cancel = context.WithCancel(context.Background())
+	actCtx.RunCtx = ctx
+
+	if !WaitActQueue(actCtx) {
+		t.Fatal("queued run was stopped")
+	}
+
+	startedAt := time.Now().UnixNano()
+
+	GetAllInfo()
+	time.Sleep(500 * time.Millisecond)
+
+	endedAt := time.Now().UnixNano()
+
+	// Record the run before leaving the queue so records keep runs order.
+	if err := appendTestFile(outPath, fmt.Sprintf("%d %d\n", startedAt, endedAt)); err != nil {
+		t.Fatal(err)
+	}
+
+	leaveActQueue()
+}
+
+/**
+ * This function going to append content to a file.
+ */
+func appendTestFile(filePath string, content string) error {
+	out, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	_, err = out.WriteString(content)
+
+	return err
+}
+
+/**
+ * Helper running a queued act in its own process (it only does
+ * something when started by TestQueuedRunsRunSerially).
+ */
+func TestQueuedRunHelper(t *testing.T) {
+	outPath := os.Getenv("ACT_TEST_QUEUE_OUT")
+
+	if outPath == "" {
+		t.Skip("only run as helper process")
+	}
+
+	runTestQueuedAct(t, os.Getenv("ACT_TEST_QUEUE_ACTFILE"), outPath)
+}
+
+/**
+ * Runs of a queued act started together run one after the other even
+ * when runs are listed meanwhile (listing cleans up run dirs).
+ */
+func TestQueuedRunsRunSerially(t *testing.T) {
+	setupTestStateDir(t)
+
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "runs")
+
+	var cmds []*exec.Cmd
+
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestQueuedRunHelper$")
+		cmd.Env = append(os.Environ(),
+			"ACT_TEST_QUEUE_OUT="+outPath,
+			"ACT_TEST_QUEUE_ACTFILE="+filepath.Join(dir, "actfile.yml"),
+		)
+
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+
+		cmds = append(cmds, cmd)
+
+		// Let the first run take the queue.
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	done := make(chan bool)
+
+	go func() {
+		for _, cmd := range cmds {
+			cmd.Wait()
+		}
+
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		for _, cmd := range cmds {
+			cmd.Process.Kill()
+		}
+
+		t.Fatal("queued runs did not finish")
+	}
+
+	content, err := ioutil.ReadFile(outPath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("got runs %q, want 2", lines)
+	}
+
+	var times [2][2]int64
+
+	for i, line := range lines {
+		for j, field := range strings.Fields(line) {
+			times[i][j], _ = strconv.ParseInt(field, 10, 64)
+		}
+	}
+
+	// Runs are recorded in the order they ran.
+	if times[1][0] < times[0][1] {
+		t.Errorf("second run started at %d before first run ended at %d", times[1][0], times[0][1])
+	}
+}
@@ -0,0 +1,189 @@
+/**
+ * This file implements the per-user registry where we keep run info
+ * of all acts. The registry lives in the user state dir (as defined
+ * by XDG base directory spec) and run info is grouped by project
+ * (i.e., the directory where acts were run from) so we can list acts
+ * running in any project.
+ */
+
+package run
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the name of the act dir inside user state dir.
+ */
+const StateDirName = "act"
+
+/**
+ * This is the name of the registry dir where we keep a data dir
+ * for each project.
+ */
+const ProjectsDirName = "projects"
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Run dirs without run info older than this are leftovers of runs
+ * that died before saving info (newer ones can belong to runs which
+ * didn't save info yet).
+ */
+const orphanRunDirAge = 10 * time.Minute
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * We check the temp state dir only once per process.
+ */
+var tempStateDirOnce sync.Once
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to compute the key we use to name the data
+ * dir of a project. We use the project dir name (so it's easy to
+ * find) together with a hash of the full path (so it's unique).
+ */
+func getProjectKey(projectPath string) string {
+	hash := sha1.Sum([]byte(projectPath))
+
+	return fmt.Sprintf("%s-%x", filepath.Base(projectPath), hash[:6])
+}
+
+/**
+ * This function going to read all run info found in a data dir.
+ */
+func getDataDirInfos(dataDirPath string) []*Info {
+	var infos []*Info
+
+	if !utils.DoFileExists(dataDirPath) {
+		return infos
+	}
+
+	files, err := ioutil.ReadDir(dataDirPath)
+
+	if err != nil {
+		utils.FatalError("could not react act dir", err)
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			dirPath := filepath.Join(dataDirPath, f.Name())
+			jsonPath := filepath.Join(dirPath, InfoFileName)
+			info := loadInfoFromFile(jsonPath)
+
+			if info == nil {
+				// Remove folder of runs that are long gone.
+				if time.Since(f.ModTime()) > orphanRunDirAge {
+					os.RemoveAll(dirPath)
+				}
+			} else {
+				infos = append(infos, info)
+			}
+		}
+	}
+
+	return infos
+}
+
+/**
+ * This function get the per user dir we use in the temp dir (which
+ * is shared with other users).
+ */
+func getUserTempDirPath() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("act-%d", os.Getuid()))
+}
+
+/**
+ * This function going to make sure nobody else can access the state
+ * dir we keep in the temp dir. Otherwise other users could read our
+ * run info or plant run info that makes us signal our processes so
+ * we refuse to run.
+ */
+func checkTempStateDir(stateHome string) {
+	tempStateDirOnce.Do(func() {
+		if err := utils.MakePrivateDir(stateHome); err != nil {
+			utils.LogError("could not use temp dir to keep act state", err)
+			os.Exit(1)
+		}
+	})
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function get the user state dir where act keeps its state.
+ * It can be set with XDG_STATE_HOME env var and defaults to
+ * ~/.local/state. Without a home dir we use a private dir in the
+ * temp dir.
+ */
+func GetStateDirPath() string {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+
+	if stateHome == "" {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			stateHome = filepath.Join(homeDir, ".local", "state")
+		} else {
+			stateHome = getUserTempDirPath()
+			checkTempStateDir(stateHome)
+		}
+	}
+
+	return filepath.Join(stateHome, StateDirName)
+}
+
+/**
+ * This function get the data dir of a project in the registry.
+ */
+func GetProjectDataDirPath(projectPath string) string {
+	return filepath.Join(GetStateDirPath(), ProjectsDirName, getProjectKey(projectPath))
+}
+
+/**
+ * This function going to get run info of all projects.
+ */
+func GetAllProjectsInfo() []*Info {
+	var infos []*Info
+
+	projectsDirPath := filepath.Join(GetStateDirPath(), ProjectsDirName)
+
+	if !utils.DoFileExists(projectsDirPath) {
+		return infos
+	}
+
+	files, err := ioutil.ReadDir(projectsDirPath)
+
+	if err != nil {
+		utils.FatalError("could not read act registry dir", err)
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			infos = append(infos, getDataDirInfos(filepath.Join(projectsDirPath, f.Name()))...)
+		}
+	}
+
+	return infos
+}
@@ -0,0 +1,75 @@
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+/**
+ * Run dirs without run info are removed only when they are old (new
+ * ones can belong to runs which didn't save info yet).
+ */
+func TestDataDirInfosKeepsNewRunDirs(t *testing.T) {
+	dataDirPath := t.TempDir()
+	newDirPath := filepath.Join(dataDirPath, "new")
+	oldDirPath := filepath.Join(dataDirPath, "old")
+
+	os.Mkdir(newDirPath, 0755)
+	os.Mkdir(oldDirPath, 0755)
+
+	oldTime := time.Now().Add(-2 * orphanRunDirAge)
+	os.Chtimes(oldDirPath, oldTime, oldTime)
+
+	getDataDirInfos(dataDirPath)
+
+	if !utils.DoFileExists(newDirPath) {
+		t.Error("new run dir was removed")
+	}
+
+	if utils.DoFileExists(oldDirPath) {
+		t.Error("old run dir was kept")
+	}
+}
+
+/**
+ * Without a home dir we keep state in a temp dir only current user
+ * can access.
+ */
+func TestStateDirWithoutHome(t *testing.T) {
+	tempDir := t.TempDir()
+
+	for name, value := range map[string]string{"XDG_STATE_HOME": "", "HOME": "", "TMPDIR": tempDir} {
+		name := name
+		prevValue, hadValue := os.LookupEnv(name)
+
+		os.Setenv(name, value)
+
+		t.Cleanup(func() {
+			if hadValue {
+				os.Setenv(name, prevValue)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+
+	stateHome := getUserTempDirPath()
+
+	if want := filepath.Join(stateHome, StateDirName); GetStateDirPath() != want {
+		t.Fatalf("got state dir %s, want %s", GetStateDirPath(), want)
+	}
+
+	if stat, err := os.Stat(stateHome); err != nil || stat.Mode().Perm() != 0700 {
+		t.Errorf("got state home %v (%v), want private dir", stat, err)
+	}
+
+	os.Chmod(stateHome, 0755)
+
+	if err := utils.MakePrivateDir(stateHome); err == nil {
+		t.Error("got no error for a dir other users can access")
+	}
+}
@@ -0,0 +1,420 @@
+/**
+ * This file implements running commands on remote hosts over ssh
+ * (set with `host` on acts or commands or with the `host` flag of
+ * `act run`). We use the ssh client installed on the machine so user
+ * ssh config, agent and known hosts are honored. When there are many
+ * hosts the command runs on all of them in parallel.
+ */
+
+package run
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold a command running on a remote host. The
+ * remote shell writes its process group id to a file named after the
+ * token so we can signal it over ssh.
+ */
+type RemoteCmd struct {
+	Host  string
+	Token string
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get hosts a command should run on. Hosts
+ * given in command line have precedence over command hosts which
+ * have precedence over act hosts.
+ */
+func getCmdHosts(cmd *actfile.Cmd, ctx *ActRunCtx) []string {
+	if len(ctx.RunCtx.Hosts) > 0 {
+		return ctx.RunCtx.Hosts
+	}
+
+	if len(cmd.Hosts) > 0 {
+		return cmd.Hosts
+	}
+
+	return ctx.Act.Hosts
+}
+
+/**
+ * Script of the remote shell reading act env vars from stdin (one
+ * `KEY='value'` line per var until an empty line) before running
+ * the command. Newlines in values are sent as `$__act_nl`.
+ */
+const sshEnvPrelude = `__act_nl='
+'
+while IFS= read -r __act_kv && [ -n "$__act_kv" ]; do eval "export $__act_kv"; done
+exec "$@"`
+
+/**
+ * Script of the remote shell loading act env vars from a file we
+ * uploaded before (used by tty commands since their stdin is a
+ * terminal).
+ */
+const sshEnvFilePrelude = `. "$1"; rm -f "$1"; shift; exec "$@"`
+
+/**
+ * Script wrapping remote commands so the remote shell (which sshd
+ * makes a process group leader) records its pid in a file we remove
+ * once the command finishes.
+ */
+const sshPgidWrapper = `echo $$ > %[1]s; %[2]s
+__act_status=$?; rm -f %[1]s; exit $__act_status`
+
+/**
+ * Script signaling the process group of a remote command and killing
+ * it when it doesn't exit within the given seconds.
+ */
+const sshStopScript = `p=$(cat %[1]s 2>/dev/null) || exit 0
+kill -%[2]s -"$p" 2>/dev/null
+i=0
+while [ "$i" -lt %[3]d ] && kill -0 -"$p" 2>/dev/null; do sleep 1; i=$((i+1)); done
+kill -KILL -"$p" 2>/dev/null
+rm -f %[1]s`
+
+/**
+ * Env var names we can safely export in remote shells.
+ */
+var sshEnvNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+/**
+ * This function going to split the port out of a host like
+ * `deploy@web1:2222` or `deploy@[::1]:2222`. Bare ipv6 addresses
+ * (like `deploy@::1`) have no port.
+ */
+func splitSshHost(host string) (string, string) {
+	user := ""
+
+	if idx := strings.LastIndex(host, "@"); idx >= 0 {
+		user, host = host[:idx+1], host[idx+1:]
+	}
+
+	if strings.HasPrefix(host, "[") || strings.Count(host, ":") == 1 {
+		if addr, port, err := net.SplitHostPort(host); err == nil {
+			return user + addr, port
+		}
+	}
+
+	return user + strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), ""
+}
+
+/**
+ * This function going to build ssh args to connect to a host.
+ */
+func getSshHostArgs(host string) ([]string, string) {
+	host, port := splitSshHost(host)
+
+	if port != "" {
+		return []string{"-p", port}, host
+	}
+
+	return []string{}, host
+}
+
+/**
+ * This function going to get act env vars we send to remote hosts.
+ * Vars inherited from host environment (like PATH) are about the
+ * host.
+ */
+func getSshEnvVars(envars []string) []string {
+	var sshEnvars []string
+
+	for _, kv := range getOwnEnvVars(envars) {
+		if name := strings.SplitN(kv, "=", 2)[0]; sshEnvNameRegex.MatchString(name) {
+			sshEnvars = append(sshEnvars, kv)
+		} else {
+			utils.LogDebug(fmt.Sprintf("getSshEnvVars : skipping env var %s", name))
+		}
+	}
+
+	return sshEnvars
+}
+
+/**
+ * This function going to get the path (as a remote shell word) of
+ * the file holding the process group id of a remote command.
+ */
+func getRemotePgidFilePath(token string) string {
+	return `"${TMPDIR:-/tmp}"/` + utils.ShellQuote(fmt.Sprintf("act-%s.pgid", token))
+}
+
+/**
+ * This function going to build ssh args to run a shell command on a
+ * remote host. Hosts can set a port like `deploy@web1:2222`. Scripts
+ * don't exist on remote hosts so we send their content through stdin
+ * (in which case scriptPath is not empty and we drop it from shell
+ * args keeping shell options before it). Env var values are not
+ * passed in args (so they don't show up in process listings or ssh
+ * logs) but read by the remote shell from stdin or, for tty commands,
+ * from the file at envFilePath. With a token the remote shell records
+ * its process group so the command can be stopped later.
+ */
+func getSshArgs(host string, envars []string, shell string, shArgs []string, scriptPath string, tty bool, envFilePath string, token string) []string {
+	args, host := getSshHostArgs(host)
+
+	if tty {
+		args = append(args, "-tt")
+	}
+
+	// Remote shells need a single command line.
+	var words []string
+
+	if tty && envFilePath != "" {
+		words = append(words, "sh", "-c", utils.ShellQuote(sshEnvFilePrelude), "sh", utils.ShellQuote(envFilePath))
+	} else if !tty && len(getSshEnvVars(envars)) > 0 {
+		words = append(words, "sh", "-c", utils.ShellQuote(sshEnvPrelude), "sh")
+	}
+
+	words = append(words, utils.ShellQuote(shell))
+
+	if scriptPath != "" {
+		for idx, arg := range shArgs {
+			if arg == scriptPath {
+				for _, optArg := range shArgs[:idx] {
+					words = append(words, utils.ShellQuote(optArg))
+				}
+
+				words = append(words, "-s", "--")
+				shArgs = shArgs[idx+1:]
+				break
+			}
+		}
+	}
+
+	for _, arg := range shArgs {
+		words = append(words, utils.ShellQuote(arg))
+	}
+
+	remoteCmdLine := strings.Join(words, " ")
+
+	if token != "" {
+		remoteCmdLine = fmt.Sprintf(sshPgidWrapper, getRemotePgidFilePath(token), remoteCmdLine)
+	}
+
+	return append(args, "--", host, remoteCmdLine)
+}
+
+/**
+ * This function going to get stdin of an ssh command with act env
+ * vars (read by the remote shell prelude) followed by the command
+ * input. We write to the pipe ourselves so the command is not waited
+ * on input that never ends (like input of interactive daemons).
+ */
+func getSshEnvStdin(envars []string, stdin io.Reader) (*os.File, error) {
+	var prelude strings.Builder
+
+	for _, kv := range getSshEnvVars(envars) {
+		parts := strings.SplitN(kv, "=", 2)
+		prelude.WriteString(fmt.Sprintf("%s=%s\n", parts[0], strings.ReplaceAll(utils.ShellQuote(parts[1]), "\n", `'"$__act_nl"'`)))
+	}
+
+	prelude.WriteString("\n")
+
+	reader, writer, err := os.Pipe()
+
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer writer.Close()
+
+		if _, err := io.WriteString(writer, prelude.String()); err != nil {
+			return
+		}
+
+		if stdin != nil {
+			io.Copy(writer, stdin)
+		}
+	}()
+
+	return reader, nil
+}
+
+/**
+ * This function going to upload act env vars of a tty command to a
+ * private file on the remote host returning its path. The file is
+ * removed by the remote shell once loaded.
+ */
+func uploadSshEnv(host string, envars []string) (string, error) {
+	var content strings.Builder
+
+	for _, kv := range getSshEnvVars(envars) {
+		parts := strings.SplitN(kv, "=", 2)
+		content.WriteString(fmt.Sprintf("export %s=%s\n", parts[0], utils.ShellQuote(parts[1])))
+	}
+
+	args, host := getSshHostArgs(host)
+	args = append(args, "--", host, `f=$(mktemp) && cat > "$f" && printf %s "$f"`)
+
+	sshCmd := exec.Command("ssh", args...)
+	sshCmd.Stdin = strings.NewReader(content.String())
+	sshCmd.Stderr = os.Stderr
+
+	output, err := sshCmd.Output()
+
+	if err != nil {
+		return "", fmt.Errorf("could not send env vars to host %s: %v", host, err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+/**
+ * This function going to run a command on remote hosts (in parallel
+ * when there are many of them). It fails if the command fails on any
+ * host.
+ */
+func cmdRemoteExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string, hosts []string) (string, error) {
+	if len(hosts) == 1 {
+		return cmdProcExec(cmd, ctx, vars, hosts[0])
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var cmdLine string
+	var failedHosts []string
+
+	for _, host := range hosts {
+		wg.Add(1)
+
+		go func(host string) {
+			defer wg.Done()
+
+			hostCmdLine, err := cmdProcExec(cmd, ctx, vars, host)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			cmdLine = hostCmdLine
+
+			if err != nil {
+				utils.LogDebug(fmt.Sprintf("cmdRemoteExec : failed on host %s", host), err)
+				failedHosts = append(failedHosts, host)
+			}
+		}(host)
+	}
+
+	wg.Wait()
+
+	if len(failedHosts) > 0 {
+		return cmdLine, fmt.Errorf("command failed on %d of %d hosts (%s)", len(failedHosts), len(hosts), strings.Join(failedHosts, ", "))
+	}
+
+	return cmdLine, nil
+}
+
+/**
+ * This function going to open a script file we send to remote hosts
+ * through stdin.
+ */
+func openRemoteScript(scriptPath string, ctx *ActRunCtx) (*os.File, error) {
+	return os.Open(utils.ResolvePath(filepath.Dir(ctx.ActFile.LocationPath), scriptPath))
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function going to add a command running on a remote host and
+ * then save info back to file system.
+ */
+func (info *Info) AddRemoteCmd(remoteCmd *RemoteCmd) {
+	info.mutex.Lock()
+
+	info.RemoteCmds = append(info.RemoteCmds, remoteCmd)
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to remove a remote command that finished and
+ * then save info back to file system.
+ */
+func (info *Info) RmRemoteCmd(token string) {
+	info.mutex.Lock()
+
+	var remoteCmds []*RemoteCmd
+
+	for _, remoteCmd := range info.RemoteCmds {
+		if remoteCmd.Token != token {
+			remoteCmds = append(remoteCmds, remoteCmd)
+		}
+	}
+
+	info.RemoteCmds = remoteCmds
+	info.save()
+
+	info.mutex.Unlock()
+}
+
+/**
+ * This function going to stop commands running on remote hosts in
+ * background (signaling their process groups over ssh with the first
+ * signal of the stop timeline and killing them after the stop grace
+ * period). It returns a function which waits commands to be stopped.
+ */
+func (info *Info) stopRemoteCmds(timeline []*actfile.ActStopStep) func() {
+	var wg sync.WaitGroup
+
+	remoteCmds := make([]*RemoteCmd, len(info.RemoteCmds))
+	copy(remoteCmds, info.RemoteCmds)
+
+	sigName := "TERM"
+	waitSecs := int(info.GetStopGracePeriod().Seconds())
+
+	// Signal numbers work with kill as well.
+	if len(timeline) > 0 {
+		if _, err := utils.ParseSignal(timeline[0].Signal); err == nil {
+			sigName = strings.TrimPrefix(strings.ToUpper(timeline[0].Signal), "SIG")
+		}
+	}
+
+	if isKillTimeline(timeline) {
+		sigName = "KILL"
+		waitSecs = 0
+	}
+
+	for _, remoteCmd := range remoteCmds {
+		args, host := getSshHostArgs(remoteCmd.Host)
+		args = append(args, "--", host, fmt.Sprintf(sshStopScript, getRemotePgidFilePath(remoteCmd.Token), sigName, waitSecs))
+
+		wg.Add(1)
+
+		go func(args []string) {
+			defer wg.Done()
+
+			utils.LogDebug("stopRemoteCmds", args)
+
+			if output, err := exec.Command("ssh", args...).CombinedOutput(); err != nil {
+				utils.LogDebug("stopRemoteCmds : could not stop remote command", err, string(output))
+			}
+		}(args)
+	}
+
+	return wg.Wait
+}
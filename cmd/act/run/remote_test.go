@@ -0,0 +1,123 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+/**
+ * Ports are split out of hosts (ipv6 addresses included).
+ */
+func TestSplitSshHost(t *testing.T) {
+	cases := []struct {
+		host, addr, port string
+	}{
+		{"web1", "web1", ""},
+		{"deploy@web1:2222", "deploy@web1", "2222"},
+		{"deploy@[::1]:2222", "deploy@::1", "2222"},
+		{"[fe80::1]", "fe80::1", ""},
+		{"deploy@fe80::1", "deploy@fe80::1", ""},
+	}
+
+	for _, c := range cases {
+		if addr, port := splitSshHost(c.host); addr != c.addr || port != c.port {
+			t.Errorf("splitSshHost(%q) = %q, %q, want %q, %q", c.host, addr, port, c.addr, c.port)
+		}
+	}
+}
+
+/**
+ * Env var values never show up in ssh args.
+ */
+func TestSshArgsHideEnvValues(t *testing.T) {
+	envars := []string{"ACT_TEST_SSH_SECRET=topsecret"}
+
+	for _, tty := range []bool{false, true} {
+		args := getSshArgs("web1", envars, "sh", []string{"-c", "echo hi"}, "", tty, "/tmp/env", "")
+
+		if joined := strings.Join(args, " "); strings.Contains(joined, "topsecret") {
+			t.Errorf("got ssh args %q with env value (tty=%t)", joined, tty)
+		}
+	}
+}
+
+/**
+ * Remote shell prelude reads env vars from stdin and leaves the rest
+ * of stdin to the command.
+ */
+func TestSshEnvStdin(t *testing.T) {
+	value := "it's a\nmulti line $value"
+	stdin, err := getSshEnvStdin([]string{"ACT_TEST_SSH_VAL=" + value}, strings.NewReader("input"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer stdin.Close()
+
+	cmd := exec.Command("sh", "-c", sshEnvPrelude, "sh", "sh", "-c", `printf '%s|' "$ACT_TEST_SSH_VAL"; cat`)
+	cmd.Stdin = stdin
+
+	output, err := cmd.Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := string(output), value+"|input"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**
+ * Remote commands record their process group so the stop script can
+ * signal them (we run both scripts locally as sshd would, in a new
+ * session).
+ */
+func TestRemoteCmdStopScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("remote shells are posix shells")
+	}
+
+	os.Setenv("TMPDIR", t.TempDir())
+	defer os.Unsetenv("TMPDIR")
+
+	pgidFilePath := getRemotePgidFilePath("stoptest")
+	cmd := exec.Command("sh", "-c", fmt.Sprintf(sshPgidWrapper, pgidFilePath, "sleep 60; sleep 60"))
+	cmd.SysProcAttr = procgroup.NewSysProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- cmd.Wait() }()
+
+	pgidFile := filepath.Join(os.Getenv("TMPDIR"), "act-stoptest.pgid")
+	waitTestFile(t, pgidFile)
+
+	if output, err := exec.Command("sh", "-c", fmt.Sprintf(sshStopScript, pgidFilePath, "TERM", 5)).CombinedOutput(); err != nil {
+		t.Fatalf("stop script failed: %v %s", err, output)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		procgroup.Signal(cmd.Process.Pid, syscall.SIGKILL)
+		t.Fatal("remote command still running")
+	}
+
+	if utils.DoFileExists(pgidFile) {
+		t.Error("pgid file was not removed")
+	}
+}
@@ -0,0 +1,184 @@
+/**
+ * This file implements rotation of log files of acts running as
+ * daemon. Daemon output goes to the main log file through process
+ * stdout and stderr so when rotating it we rename the current log
+ * file (and older ones) and redirect stdout and stderr to a new log
+ * file. Other log files (like per act logs) are rotated the same way
+ * by the run log files (see logfile.go).
+ */
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the default number of rotated log files we keep.
+ */
+const DefaultLogMaxFiles = 5
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold log rotation settings along with the
+ * state of the main log file (size is tracked in memory so we don't
+ * stat the file on every line).
+ */
+type logRotator struct {
+	filePath string
+	maxSize  int64
+	maxAge   time.Duration
+	maxFiles int
+	size     int64
+	openedAt time.Time
+	mutex    sync.Mutex
+}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Log rotator of this process (if log rotation is enabled).
+ */
+var rotator *logRotator
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to write to console (which is the main log file
+ * for daemons) rotating the main log file first when it's due.
+ */
+func writeConsoleLog(p []byte) {
+	if rotator == nil {
+		os.Stdout.Write(p)
+		return
+	}
+
+	rotator.mutex.Lock()
+	defer rotator.mutex.Unlock()
+
+	if rotator.isDue(rotator.size, rotator.openedAt) {
+		rotator.rotate()
+	}
+
+	n, _ := os.Stdout.Write(p)
+	rotator.size += int64(n)
+}
+
+/**
+ * This function going to shift rotated log files (log.1 becomes
+ * log.2 and so on) and move current log file to log.1.
+ */
+func shiftLogFiles(filePath string, maxFiles int) error {
+	os.Remove(fmt.Sprintf("%s.%d", filePath, maxFiles))
+
+	for i := maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", filePath, i), fmt.Sprintf("%s.%d", filePath, i+1))
+	}
+
+	return os.Rename(filePath, fmt.Sprintf("%s.1", filePath))
+}
+
+//############################################################
+// logRotator Struct Functions
+//############################################################
+
+/**
+ * This function going to check if a log file with some size opened
+ * at some time is too big or too old.
+ */
+func (r *logRotator) isDue(size int64, openedAt time.Time) bool {
+	isTooBig := r.maxSize > 0 && size >= r.maxSize
+	isTooOld := r.maxAge > 0 && time.Since(openedAt) >= r.maxAge
+
+	return isTooBig || isTooOld
+}
+
+/**
+ * This function going to rotate the main log file and redirect
+ * output to a new log file.
+ */
+func (r *logRotator) rotate() {
+	if err := shiftLogFiles(r.filePath, r.maxFiles); err != nil {
+		utils.LogDebug("rotate : could not rename log file", err)
+		return
+	}
+
+	file, err := os.OpenFile(r.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		utils.LogDebug("rotate : could not create log file", err)
+		return
+	}
+
+	defer file.Close()
+
+	if err := utils.RedirectStdio(file); err != nil {
+		utils.LogDebug("rotate : could not redirect output", err)
+	}
+
+	r.size = 0
+	r.openedAt = time.Now()
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to enable rotation of log files of an act
+ * running as daemon based on act log settings.
+ */
+func SetupLogRotation(ctx *ActRunCtx) {
+	act := ctx.Act
+
+	if act.LogMaxSize == "" && act.LogMaxAge == 0 {
+		return
+	}
+
+	var maxSize int64
+
+	if act.LogMaxSize != "" {
+		size, err := utils.ParseBytes(act.LogMaxSize)
+
+		if err != nil {
+			utils.FatalError("invalid log_max_size", err)
+			return
+		}
+
+		maxSize = size
+	}
+
+	maxFiles := act.LogMaxFiles
+
+	if maxFiles <= 0 {
+		maxFiles = DefaultLogMaxFiles
+	}
+
+	rotator = &logRotator{
+		filePath: ctx.RunCtx.Info.GetLogFilePath(),
+		maxSize:  maxSize,
+		maxAge:   act.LogMaxAge,
+		maxFiles: maxFiles,
+		openedAt: time.Now(),
+	}
+
+	if stat, err := os.Stat(rotator.filePath); err == nil {
+		rotator.size = stat.Size()
+	}
+}
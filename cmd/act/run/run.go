@@ -1,16 +1,24 @@
 package run
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
-	"github.com/logrusorgru/aurora/v3"
 	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/config"
 	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
 	"github.com/teris-io/shortid"
 )
 
@@ -25,6 +33,54 @@ const (
 	ExecStateRunning = "running"
 )
 
+/**
+ * This is the default max time final stages of an act can take to
+ * run when the execution was stopped.
+ */
+const DefaultFinalTimeout = 30 * time.Second
+
+/**
+ * Max time we wait an act to stop when replacing it by another one
+ * with the same name.
+ */
+const ReplaceExitTimeout = time.Minute
+
+/**
+ * This struct going to hold info about a running command.
+ */
+type RunningCmd struct {
+	/**
+	 * Call id of the act running the command.
+	 */
+	Act string
+
+	/**
+	 * Stage of the act running the command.
+	 */
+	Stage string
+
+	/**
+	 * Command line being run.
+	 */
+	Cmd string
+
+	/**
+	 * Where the command was declared in the actfile.
+	 */
+	Source string
+
+	/**
+	 * Process id and process group id of the command.
+	 */
+	Pid  int
+	Pgid int
+
+	/**
+	 * When the command started.
+	 */
+	StartedAt time.Time
+}
+
 /**
  * This run context going to hold all global info we need to run
  * an act.
@@ -65,6 +121,13 @@ type RunCtx struct {
 	 */
 	ActVars map[string]string
 
+	/**
+	 * Local variables of the parent act when this process was spawned
+	 * by a detached act command. They work as the parent vars of the
+	 * act we run (the same way as for acts called in process).
+	 */
+	ParentVars map[string]string
+
 	/**
 	 * This stack going to hold all acts run contexts we have
 	 * active so far.
@@ -82,11 +145,30 @@ type RunCtx struct {
 	 */
 	IsDaemon bool
 
+	/**
+	 * Flag indicating this process only spawned the run as a daemon
+	 * so the daemon process owns the run data dir (and its cleanup).
+	 */
+	SpawnedDaemon bool
+
+	/**
+	 * Fifo used as stdin of start commands of interactive daemons.
+	 */
+	Stdin *os.File
+
 	/**
 	 * Flag indicating the state of the execution.
 	 */
 	State string
 
+	/**
+	 * Context of the execution which gets canceled when the execution
+	 * is stopped. Commands and waits going to check it instead of the
+	 * state so stopping is seen by everyone at once.
+	 */
+	Ctx    context.Context
+	cancel context.CancelFunc
+
 	/**
 	 * Flag indicating we are finishing the execution.
 	 */
@@ -101,6 +183,103 @@ type RunCtx struct {
 	 * Flag indicating we should supress all logs.
 	 */
 	Quiet bool
+
+	/**
+	 * Flag indicating we should buffer command output and print it
+	 * only when the command fails.
+	 */
+	QuietSuccess bool
+
+	/**
+	 * Flag indicating we should not print separators between
+	 * command outputs in raw log mode.
+	 */
+	NoSeparators bool
+
+	/**
+	 * Flag indicating only one run of the act can be running at a
+	 * time (like act lock field).
+	 */
+	Lock bool
+
+	/**
+	 * Flag indicating we should wait the act lock to be released
+	 * instead of failing.
+	 */
+	LockWait bool
+
+	/**
+	 * Flag indicating each act should run at most once in this run
+	 * (like when running all acts carrying a tag where acts can need
+	 * each other).
+	 */
+	RunActsOnce bool
+
+	/**
+	 * Flag indicating we should fire a desktop notification when the
+	 * run finishes (no matter how long it took).
+	 */
+	DesktopNotify bool
+
+	/**
+	 * Flag indicating we should not print the timing summary when
+	 * the run finishes.
+	 */
+	NoSummary bool
+
+	/**
+	 * Hosts given in command line where all commands run over ssh.
+	 */
+	Hosts []string
+
+	/**
+	 * Acts already run (or running) when running acts once.
+	 */
+	actOnces map[*actfile.Act]*sync.Once
+
+	/**
+	 * Secrets resolved so far in this run (kept only in memory).
+	 */
+	secrets runSecrets
+
+	/**
+	 * Env files parsed so far in this run.
+	 */
+	envFiles envFileCache
+
+	/**
+	 * Queue of log lines waiting to be written.
+	 */
+	logs logQueue
+
+	/**
+	 * Log files opened by this run.
+	 */
+	logFiles logFiles
+
+	/**
+	 * This is the list of process group ids of running final
+	 * commands. We keep them apart from other commands so they
+	 * don't get killed when the execution is stopped.
+	 */
+	FinalPgids []int
+
+	/**
+	 * Commands currently running indexed by their process group id.
+	 */
+	runningCmds map[int]*RunningCmd
+
+	/**
+	 * Mutex to prevent race conditions of parallel commands and
+	 * acts changing the same run context.
+	 */
+	mutex sync.Mutex
+
+	/**
+	 * This wait group tell us when an in progress stop finished
+	 * killing all running commands.
+	 */
+	stopWg sync.WaitGroup
 }
 
 //############################################################
@@ -114,6 +293,206 @@ func (ctx *RunCtx) Print() {
 	ctx.ActCtx.Print()
 }
 
+/**
+ * This function going to push an act context to the call stack.
+ */
+func (ctx *RunCtx) PushActCtx(actCtx *ActRunCtx) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.ActCtxCallStack = append(ctx.ActCtxCallStack, actCtx)
+}
+
+/**
+ * This function going to remove an act context from the call stack.
+ * Since acts can run in parallel the act context is not necessarily
+ * the last one in the stack.
+ */
+func (ctx *RunCtx) PopActCtx(actCtx *ActRunCtx) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	for i := len(ctx.ActCtxCallStack) - 1; i >= 0; i-- {
+		if ctx.ActCtxCallStack[i] == actCtx {
+			stack := make([]*ActRunCtx, 0, len(ctx.ActCtxCallStack)-1)
+			stack = append(stack, ctx.ActCtxCallStack[:i]...)
+
+			ctx.ActCtxCallStack = append(stack, ctx.ActCtxCallStack[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * This function get a copy of the call stack.
+ */
+func (ctx *RunCtx) GetCallStack() []*ActRunCtx {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	stack := make([]*ActRunCtx, len(ctx.ActCtxCallStack))
+	copy(stack, ctx.ActCtxCallStack)
+
+	return stack
+}
+
+/**
+ * This function get the number of act contexts in the call stack.
+ */
+func (ctx *RunCtx) GetCallStackSize() int {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return len(ctx.ActCtxCallStack)
+}
+
+/**
+ * This function going to add a running final command pgid.
+ */
+func (ctx *RunCtx) AddFinalPgid(pgid int) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.FinalPgids = append(ctx.FinalPgids, pgid)
+}
+
+/**
+ * This function going to remove a final command pgid.
+ */
+func (ctx *RunCtx) RmFinalPgid(pgid int) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	for i, val := range ctx.FinalPgids {
+		if val == pgid {
+			finalPgids := make([]int, 0, len(ctx.FinalPgids)-1)
+			finalPgids = append(finalPgids, ctx.FinalPgids[:i]...)
+
+			ctx.FinalPgids = append(finalPgids, ctx.FinalPgids[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * This function going to register a running command.
+ */
+func (ctx *RunCtx) AddRunningCmd(cmd *RunningCmd) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.runningCmds == nil {
+		ctx.runningCmds = make(map[int]*RunningCmd)
+	}
+
+	ctx.runningCmds[cmd.Pgid] = cmd
+}
+
+/**
+ * This function going to unregister a running command.
+ */
+func (ctx *RunCtx) RmRunningCmd(pgid int) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	delete(ctx.runningCmds, pgid)
+}
+
+/**
+ * This function get all running commands sorted by start time.
+ */
+func (ctx *RunCtx) GetRunningCmds() []*RunningCmd {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	var cmds []*RunningCmd
+
+	for _, cmd := range ctx.runningCmds {
+		cmds = append(cmds, cmd)
+	}
+
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].StartedAt.Before(cmds[j].StartedAt)
+	})
+
+	return cmds
+}
+
+/**
+ * This function going to kill all running final commands.
+ */
+func (ctx *RunCtx) KillFinalCmds() {
+	ctx.mutex.Lock()
+	finalPgids := make([]int, len(ctx.FinalPgids))
+	copy(finalPgids, ctx.FinalPgids)
+	ctx.mutex.Unlock()
+
+	for _, pgid := range finalPgids {
+		if err := procgroup.Signal(pgid, syscall.SIGKILL); err != nil {
+			utils.LogDebug(fmt.Sprintf("could not kill final command with process pgid=%d", pgid), err)
+		}
+	}
+}
+
+/**
+ * This function going to check if the execution was stopped.
+ */
+func (ctx *RunCtx) IsStopped() bool {
+	return ctx.Ctx.Err() != nil
+}
+
+/**
+ * This function going to track the process group of a command which
+ * just started. Stop going to kill commands tracked before it but a
+ * command can start while we are stopping so we kill it ourselves
+ * when the execution was stopped in the meantime.
+ */
+func (ctx *RunCtx) TrackCmdPgid(pgid int, isFinal bool) {
+	if isFinal {
+		ctx.AddFinalPgid(pgid)
+		return
+	}
+
+	ctx.Info.AddCmdPgid(pgid)
+
+	if ctx.IsStopped() {
+		utils.LogDebug(fmt.Sprintf("TrackCmdPgid : execution stopped while command was starting [pgid=%d]", pgid))
+
+		if err := procgroup.Signal(pgid, syscall.SIGKILL); err != nil {
+			utils.LogDebug(fmt.Sprintf("could not kill command with process pgid=%d", pgid), err)
+		}
+	}
+}
+
+/**
+ * This function going to execute an act context. When running acts
+ * once we skip acts already executed in this run (waiting them to
+ * finish if they are still running).
+ */
+func (ctx *RunCtx) ExecActCtx(actCtx *ActRunCtx) {
+	if !ctx.RunActsOnce {
+		actCtx.Exec()
+		return
+	}
+
+	ctx.mutex.Lock()
+
+	if ctx.actOnces == nil {
+		ctx.actOnces = make(map[*actfile.Act]*sync.Once)
+	}
+
+	once, ok := ctx.actOnces[actCtx.Act]
+
+	if !ok {
+		once = &sync.Once{}
+		ctx.actOnces[actCtx.Act] = once
+	}
+
+	ctx.mutex.Unlock()
+
+	once.Do(actCtx.Exec)
+}
+
 //############################################################
 // Internal Variables
 //############################################################
@@ -138,6 +517,8 @@ func createRunCtx(args []string, actFile *actfile.ActFile) *RunCtx {
 		Args:        	args[1:],
 	}
 
+	ctx.Ctx, ctx.cancel = context.WithCancel(context.Background())
+
 	// Create run info
 	var runId string
 
@@ -150,8 +531,38 @@ func createRunCtx(args []string, actFile *actfile.ActFile) *RunCtx {
 	}
 
 	ctx.Info = &Info{
-		Id:     runId,
-		NameId: nameId,
+		Id:        runId,
+		NameId:    nameId,
+		StartedAt: time.Now(),
+	}
+
+	/**
+	 * Detached act commands pass local vars of the calling act so the
+	 * act we run sees the same vars it would see running in process.
+	 */
+	if content, present := os.LookupEnv("ACT_PARENT_VARS"); present {
+		os.Unsetenv("ACT_PARENT_VARS")
+
+		if err := json.Unmarshal([]byte(content), &ctx.ParentVars); err != nil {
+			utils.LogDebug("createRunCtx : invalid parent vars", err)
+		}
+	}
+
+	/**
+	 * When the act is being restarted the restart command going to
+	 * tell us how many times it was restarted so far.
+	 */
+	if count, present := os.LookupEnv("ACT_RESTART_COUNT"); present {
+		os.Unsetenv("ACT_RESTART_COUNT")
+		ctx.Info.RestartCount, _ = strconv.Atoi(count)
+	}
+
+	/**
+	 * User can keep logs in the project dir (instead of in the user
+	 * registry). We keep the env var so child acts do the same.
+	 */
+	if localLogs, present := os.LookupEnv("ACT_LOCAL_LOGS"); present {
+		ctx.Info.LocalLogs = localLogs == "true" || localLogs == "1"
 	}
 
 	/**
@@ -187,7 +598,7 @@ func createRunCtx(args []string, actFile *actfile.ActFile) *RunCtx {
 
 	// Get process group id
 	pid := os.Getpid()
-	pgid, err := syscall.Getpgid(pid)
+	pgid, err := procgroup.Getpgid(pid)
 
 	if err != nil {
 		utils.FatalError("could not get main process groupd id", err)
@@ -209,16 +620,57 @@ func createRunCtx(args []string, actFile *actfile.ActFile) *RunCtx {
 	if actCtx != nil {
 		ctx.ActCtx = actCtx
 		ctx.ActCtx.Args = ctx.Args
+		ctx.Info.StopGracePeriod = actCtx.Act.StopGracePeriod
+		ctx.Info.StopTimeline = actCtx.Act.StopTimeline
+		ctx.Info.Desc = actCtx.Act.Desc
+		ctx.Info.ActFilePath = actCtx.ActFile.LocationPath
+		ctx.Info.ActCallId = actCtx.CallId
+
+		// Resolve secrets upfront so we fail before running anything.
+		if _, err := actCtx.GetSecretVars(); err != nil {
+			utils.FatalError(err)
+		}
 	}
 
 	return ctx
 }
 
+/**
+ * This function going to run final stages of an act context within
+ * the act final timeout. If final stages take longer than that we
+ * abort them killing all running final commands.
+ */
+func finalStageExecWithTimeout(ctx *ActRunCtx) {
+	done := make(chan bool)
+
+	go func() {
+		ctx.FinalStageExec()
+		close(done)
+	}()
+
+	timeout := ctx.GetFinalTimeout()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		utils.LogWarn(fmt.Sprintf("final stage of act %s timed out after %s", ctx.CallId, timeout))
+
+		ctx.FinalTimedOut = true
+		ctx.RunCtx.KillFinalCmds()
+
+		<-done
+	}
+}
+
+/**
+ * This function going to run final stages of all acts in the call
+ * stack after the execution was stopped.
+ */
 func cleanup() {
 	utils.LogDebug("cleanup")
 
 	if runCtx != nil && runCtx.ActCtx != nil {
-		stack := runCtx.ActCtxCallStack
+		stack := runCtx.GetCallStack()
 
 		utils.LogDebug("cleanup : stack size", len(stack))
 
@@ -229,12 +681,115 @@ func cleanup() {
 		for i := len(stack)-1; i >= 0; i-- {
 			ctx := stack[i]
 			utils.LogDebug("cleanup : running final steps", ctx.Act.Name)
-			ctx.FinalStageExec()
+			finalStageExecWithTimeout(ctx)
+			runCtx.PopActCtx(ctx)
 	 	}
 	}
 
 	// Now that we are done lets clean
-	runCtx.Info.RmDataDir()
+	closeRun()
+}
+
+/**
+ * This function going to release everything this run holds when it
+ * finishes (run info and queue ticket).
+ */
+func closeRun() {
+	runCtx.FlushLogs()
+
+	printRunSummary()
+	notifyRunEnd()
+
+	// Log files must be closed before info removes the data dir.
+	runCtx.closeLogFiles()
+
+	runCtx.Info.EmitEvent(EventRunExited, "", "", map[string]interface{}{"exit_code": utils.ExitCode})
+	runCtx.Info.Close(utils.ExitCode)
+	leaveActQueue()
+
+	printRunProfile()
+}
+
+/**
+ * This function going to make sure there is no act running with the
+ * name of this run stopping it when user wants to replace it. The
+ * check and the registration of our name happen under the index lock
+ * so concurrent runs with the same name can't both pass it. It
+ * returns false when the name is taken.
+ */
+func replaceRunningAct(info *Info, forceReplace bool) bool {
+	// There is no shared registry to check names against.
+	if memoryOnly {
+		return true
+	}
+
+	deadline := time.Now().Add(ReplaceExitTimeout)
+	stoppedId := ""
+
+	for {
+		entry, err := info.claimIndexEntry(func(entry IndexEntry) bool {
+			return entry.NameId == info.NameId
+		})
+
+		if err != nil {
+			utils.FatalError(fmt.Sprintf("could not check if act %s is already running", info.NameId), err)
+			return false
+		}
+
+		if entry == nil {
+			return true
+		}
+
+		if !forceReplace {
+			utils.FatalError(fmt.Sprintf("act %s is already running (use -force-replace to replace it)", info.NameId))
+			return false
+		}
+
+		if time.Now().After(deadline) {
+			utils.FatalError(fmt.Sprintf("timeout waiting act %s to stop", info.NameId))
+			return false
+		}
+
+		/**
+		 * Stop the old act once and wait its process to finish (running
+		 * its final stage) so the name is free again.
+		 */
+		if entry.Id != stoppedId {
+			if running := GetInfo(entry.Id); running != nil {
+				running.Stop()
+			}
+
+			stoppedId = entry.Id
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+/**
+ * This function going to save info of a daemon run and send its
+ * output to its log file. Daemons own their data dir so they do it
+ * before anything else (like waiting their turn) since data dirs
+ * without info are taken as broken and removed.
+ */
+func openDaemonLog() {
+	runCtx.Info.Save()
+
+	logFilePath := runCtx.Info.GetLogFilePath()
+	os.MkdirAll(filepath.Dir(logFilePath), 0755)
+
+	file, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+	if err != nil {
+		utils.FatalError("could not open log file", err)
+		return
+	}
+
+	defer file.Close()
+
+	if err := utils.RedirectStdio(file); err != nil {
+		utils.FatalError("could not redirect output to log file", err)
+	}
 }
 
 //############################################################
@@ -244,8 +799,10 @@ func cleanup() {
  * This function to execute run command.
  */
 func Exec(args []string) {
-	// Set default actfile path.
-	defaultActFilePath := "actfile.yml"
+	execStartedAt := time.Now()
+
+	// Set default actfile path (user config can change it).
+	defaultActFilePath := config.GetActFileName()
 
 	/**
 	 * We create a new flag set to allow this act subcommand to
@@ -265,35 +822,194 @@ func Exec(args []string) {
 	 */
 	quietPtr := cmdFlags.Bool("q", false, "Supress all logs")
 
+	/**
+	 * These flags increase verbosity. With v we log each command
+	 * before running it and with vv we log debug messages too.
+	 */
+	verbosePtr := cmdFlags.Bool("v", false, "Log commands before running them")
+	debugPtr := cmdFlags.Bool("vv", false, "Log debug messages")
+
+	/**
+	 * This flag allow user to print command output only when the
+	 * command fails.
+	 */
+	quietSuccessPtr := cmdFlags.Bool("quiet-success", false, "Print command output only if the command fails")
+
 	/**
 	 * This flag force raw output.
 	 */
 	logPtr := cmdFlags.String("l", "", "Log mode")
 
+	/**
+	 * This flag disable separators between command outputs.
+	 */
+	noSeparatorsPtr := cmdFlags.Bool("no-separators", false, "Don't print separators between command outputs in raw log mode")
+
+	/**
+	 * This flag disable colored output.
+	 */
+	noColorPtr := cmdFlags.Bool("no-color", false, "Disable colored output")
+
 	/**
 	 * This is the path to actfile to be used.
 	 */
 	actFilePathPtr := cmdFlags.String("f", defaultActFilePath, "Path to an actfile yaml file")
 
+	/**
+	 * This flag allow user to choose a stable name for the run which
+	 * can be used to stop it, get logs, etc.
+	 */
+	namePtr := cmdFlags.String("name", "", "Name of the run (defaults to act name)")
+
+	/**
+	 * This flag allow user to replace an act already running with
+	 * the same name.
+	 */
+	forceReplacePtr := cmdFlags.Bool("force-replace", false, "Stop act running with the same name before starting")
+
+	/**
+	 * This flag prevent act from running when another run of the
+	 * same act is already running in this project.
+	 */
+	lockPtr := cmdFlags.Bool("lock", false, "Fail if act is already running")
+
+	/**
+	 * This flag makes the run wait for the running one to finish
+	 * instead of failing.
+	 */
+	lockWaitPtr := cmdFlags.Bool("lock-wait", false, "Wait act already running to finish")
+
+	/**
+	 * This flag allows user to run all acts carrying a tag instead
+	 * of a single act.
+	 */
+	tagPtr := cmdFlags.String("tag", "", "Run all acts carrying a tag")
+
+	/**
+	 * This flag limits how many tagged acts can run at the same time.
+	 */
+	jobsPtr := cmdFlags.Int("j", 0, "Max number of tagged acts running at the same time (0 means no limit)")
+
+	/**
+	 * This flag going to fire a desktop notification when the run
+	 * finishes (useful for long builds running in the background
+	 * while we do something else).
+	 */
+	notifyPtr := cmdFlags.Bool("notify", false, "Fire a desktop notification when the run finishes")
+
+	/**
+	 * This flag going to expose Prometheus metrics of the run on an
+	 * address (like `:9464`).
+	 */
+	metricsPtr := cmdFlags.String("metrics", "", "Address to serve Prometheus metrics on")
+
+	/**
+	 * This flag disables the timing summary we print when the run
+	 * finishes.
+	 */
+	noSummaryPtr := cmdFlags.Bool("no-summary", false, "Don't print the timing summary when the run finishes")
+
+	/**
+	 * This flag going to run all commands over ssh on a host (or on
+	 * a comma separated list of hosts in parallel).
+	 */
+	hostPtr := cmdFlags.String("host", "", "Host (like user@server) or comma separated hosts to run commands on over ssh")
+
+	/**
+	 * This internal flag going to report how long each startup phase
+	 * took once the run finishes.
+	 */
+	profilePtr := cmdFlags.Bool("profile", false, "Report time spent in each startup phase")
+
 	/**
 	 * Parse the incoming args extracting defined flags if user
 	 * provided any.
 	 */
 	cmdFlags.Parse(args)
 
+	if *profilePtr {
+		startProfile(execStartedAt)
+		profileMark("parse flags")
+	}
+
 	/**
 	 * This are the command line arguments after extracting
 	 * the flags.
 	 */
 	cmdArgs := cmdFlags.Args()
 
+	if *noColorPtr {
+		utils.DisableColor()
+	}
+
+	switch {
+	case *debugPtr:
+		utils.SetVerbosity(utils.VerbosityDebug)
+	case *verbosePtr:
+		utils.SetVerbosity(utils.VerbosityVerbose)
+	case *quietPtr:
+		utils.SetVerbosity(utils.VerbosityQuiet)
+	}
+
+	// Make sure we can persist run info.
+	CheckDataDir()
+
+	// Flag info left behind by crashed act processes.
+	MarkStaleInfos()
+
+	profileMark("check run registry")
+
 	// We read/parse actfile.yml file from current working dir
 	wdir := utils.GetWd()
 	actFilePath := utils.ResolvePath(wdir, *actFilePathPtr)
 	actFile := actfile.ReadActFile(actFilePath)
 
+	// Included actfiles get parsed while we resolve the act to run.
+	go actfile.PreloadActFiles(actFile)
+
+	profileMark("parse actfiles")
+
+	/**
+	 * To run all acts carrying a tag we create an act which runs
+	 * them and run it instead.
+	 */
+	runCtxArgs := cmdArgs
+
+	if *tagPtr != "" {
+		tagAct, err := NewTagAct(actFile, *tagPtr, *jobsPtr)
+
+		if err != nil {
+			utils.FatalError(err)
+			return
+		}
+
+		actFile.Acts = append([]*actfile.Act{tagAct}, actFile.Acts...)
+		runCtxArgs = append([]string{fmt.Sprintf("%s%s", TagActNamePrefix, *tagPtr)}, cmdArgs...)
+	}
+
 	// Build run context
-	runCtx = createRunCtx(cmdArgs, actFile)
+	runCtx = createRunCtx(runCtxArgs, actFile)
+
+	profileMark("resolve act")
+
+	// Tagged acts can need each other so we run each one once.
+	runCtx.RunActsOnce = *tagPtr != ""
+
+	// Set desktop notification from command line
+	runCtx.DesktopNotify = *notifyPtr
+
+	// Set timing summary from command line
+	runCtx.NoSummary = *noSummaryPtr
+
+	// Set remote hosts from command line
+	if *hostPtr != "" {
+		runCtx.Hosts = strings.Split(*hostPtr, ",")
+	}
+
+	// User provided name overrides act name.
+	if *namePtr != "" {
+		runCtx.Info.NameId = *namePtr
+	}
 
 	// Set state as running
 	runCtx.State = ExecStateRunning
@@ -301,13 +1017,103 @@ func Exec(args []string) {
 	// Set quiet logs from command line
 	runCtx.Quiet = *quietPtr
 
+	// Set quiet success from command line
+	runCtx.QuietSuccess = *quietSuccessPtr
+
 	// Set raw logging mode
 	runCtx.Log = *logPtr
 
+	// Set separators from command line
+	runCtx.NoSeparators = *noSeparatorsPtr
+
+	// Set lock from command line
+	runCtx.Lock = *lockPtr || *lockWaitPtr
+	runCtx.LockWait = *lockWaitPtr
+
+	/**
+	 * Persist the canonical run args (without the daemon flag) so we
+	 * can spawn the daemon process and restart the act later with
+	 * exactly the same actfile, flags and args.
+	 */
+	runArgs := []string{
+		fmt.Sprintf("-f=%s", actFilePath),
+		fmt.Sprintf("-q=%t", *quietPtr),
+		fmt.Sprintf("-l=%s", *logPtr),
+		fmt.Sprintf("-no-separators=%t", *noSeparatorsPtr),
+	}
+
+	if *namePtr != "" {
+		runArgs = append(runArgs, fmt.Sprintf("-name=%s", *namePtr))
+	}
+
+	if *noColorPtr {
+		runArgs = append(runArgs, "-no-color")
+	}
+
+	if *verbosePtr || *debugPtr {
+		runArgs = append(runArgs, fmt.Sprintf("-v=%t", *verbosePtr), fmt.Sprintf("-vv=%t", *debugPtr))
+	}
+
+	if *quietSuccessPtr {
+		runArgs = append(runArgs, "-quiet-success")
+	}
+
+	if runCtx.Lock {
+		runArgs = append(runArgs, fmt.Sprintf("-lock=%t", *lockPtr), fmt.Sprintf("-lock-wait=%t", *lockWaitPtr))
+	}
+
+	if *tagPtr != "" {
+		runArgs = append(runArgs, fmt.Sprintf("-tag=%s", *tagPtr), fmt.Sprintf("-j=%d", *jobsPtr))
+	}
+
+	if *metricsPtr != "" {
+		runArgs = append(runArgs, fmt.Sprintf("-metrics=%s", *metricsPtr))
+	}
+
+	if *hostPtr != "" {
+		runArgs = append(runArgs, fmt.Sprintf("-host=%s", *hostPtr))
+	}
+
+	if *notifyPtr {
+		runArgs = append(runArgs, "-notify")
+	}
+
+	if *noSummaryPtr {
+		runArgs = append(runArgs, "-no-summary")
+	}
+
+	if *profilePtr {
+		runArgs = append(runArgs, "-profile")
+	}
+
+	runArgs = append(runArgs, cmdArgs...)
+
+	runCtx.Info.RunArgs = runArgs
+	runCtx.Info.Wd = wdir
+	runCtx.Info.IsDaemon = runCtx.IsDaemon
+
 	// To run this act in daemon we going to spawn act run.
 	if *daemonPtr {
-		cmdLineArgs := []string{"run", fmt.Sprintf("-f=%s", actFilePath), runCtx.Info.NameId}
-		cmdLineArgs = append(cmdLineArgs, runCtx.Args...)
+		if memoryOnly {
+			utils.FatalError("cannot run as daemon in memory-only mode")
+			return
+		}
+
+		/**
+		 * Names chosen by user must be unique so stop, log, etc are
+		 * not ambiguous. The spawned daemon checks it again when
+		 * taking over the name.
+		 */
+		if *namePtr != "" && !replaceRunningAct(runCtx.Info, *forceReplacePtr) {
+			return
+		}
+
+		// Fail fast when the act is locked by another run.
+		if runCtx.ActCtx != nil && !runCtx.LockWait && !CheckActLock(runCtx.ActCtx) {
+			return
+		}
+
+		cmdLineArgs := append([]string{"run"}, runCtx.Info.RunArgs...)
 
 		/**
 		 * Set environment variables that going to control
@@ -316,45 +1122,103 @@ func Exec(args []string) {
 		envars := []string{
 			fmt.Sprintf("ACT_RUN_ID=%s", runCtx.Info.Id),
 			"ACT_DAEMON=true",
+			fmt.Sprintf("ACT_RESTART_COUNT=%d", runCtx.Info.RestartCount),
 		}
 
-		shCmd := exec.Command("act", cmdLineArgs...)
+		// Keep parent vars of detached act daemons.
+		if runCtx.ParentVars != nil {
+			content, _ := json.Marshal(runCtx.ParentVars)
+			envars = append(envars, fmt.Sprintf("ACT_PARENT_VARS=%s", content))
+		}
+
+		shCmd := exec.Command(utils.GetActBin(), cmdLineArgs...)
 		shCmd.Dir = utils.GetWd()
 		shCmd.Env = append(os.Environ(), envars...)
 
-		// Ensure we create a new session for the new pocess (this means a new pgid)
-		shCmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		// Ensure we create a new process group for the new pocess.
+		shCmd.SysProcAttr = procgroup.NewSysProcAttr()
 
 		/**
-		 * Daemon processes going to log directly to a log file
-		 * instead of to stdout.
+		 * Start the process and don´t wait it since its a daemon. The
+		 * daemon opens its log file itself since it owns its data dir.
 		 */
-		os.MkdirAll(runCtx.Info.GetDataDirPath(), 0755)
+		if err := shCmd.Start(); err != nil {
+			utils.FatalError("could not start", err)
+		}
 
-		logFile, err := os.OpenFile(runCtx.Info.GetLogFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		runCtx.SpawnedDaemon = true
 
-		if err != nil {
-			utils.FatalError("could not open log file", err)
+		fmt.Printf("😎 started with id %s\n", utils.Color.Green(runCtx.Info.Id).Bold())
+	} else if runCtx.ActCtx != nil {
+		// Daemons log to their log file from the very beginning.
+		if runCtx.IsDaemon && runCtx.Info.ParentActId == "" {
+			openDaemonLog()
 		}
 
-		shCmd.Stdout = logFile
-		shCmd.Stderr = logFile
-
 		/**
-		 * Start the process and don´t wait it since its a daemon.
+		 * Skip the run if the act is being triggered too often (based
+		 * on act debounce and min interval settings).
 		 */
-		if err := shCmd.Start(); err != nil {
-			utils.FatalError("could not start", err)
+		if !ShouldRun(runCtx.ActCtx) {
+			return
+		}
+
+		// Names chosen by user must be unique (foreground runs too).
+		if *namePtr != "" && !replaceRunningAct(runCtx.Info, *forceReplacePtr) {
+			return
+		}
+
+		// Wait previous runs to finish (if queued).
+		if !WaitActQueue(runCtx.ActCtx) {
+			return
+		}
+
+		// Make sure this is the only run of the act (if locked).
+		if !AcquireActLock(runCtx.ActCtx) {
+			return
 		}
 
-		fmt.Printf("😎 started with id %s\n", aurora.Green(runCtx.Info.Id).Bold())
-	} else if runCtx.ActCtx != nil {
 		/**
 		 * We save info file just when we are running in not daemon mode because when we
 		 * run in daemon mode the only thing act going to do is to spawn another act run
-		 * command in the background (not daemon).
+		 * command in the background (not daemon). Foreground acts save it a bit later
+		 * so trivial acts finish without ever writing it. Daemons and detached acts are
+		 * looked up by other act processes right away so they save it now.
 		 */
-		runCtx.Info.Save()
+		if runCtx.IsDaemon || runCtx.Info.ParentActId != "" {
+			runCtx.Info.Save()
+		} else {
+			runCtx.Info.SaveLater()
+		}
+		runCtx.Info.EmitEvent(EventRunStarted, runCtx.ActCtx.CallId, "", map[string]interface{}{
+			"args":   runCtx.Info.RunArgs,
+			"daemon": runCtx.IsDaemon,
+		})
+
+		// Let other act commands inspect this run.
+		if !memoryOnly {
+			StartControlServer()
+		}
+
+		// Let Prometheus scrape this run.
+		if *metricsPtr != "" {
+			StartMetricsServer(*metricsPtr)
+		}
+
+		// Daemon output goes to the log file which can be rotated.
+		if runCtx.IsDaemon && runCtx.Info.ParentActId == "" {
+			SetupLogRotation(runCtx.ActCtx)
+		}
+
+		/**
+		 * Interactive daemons read input from a fifo so users can send
+		 * input to them with `act attach`.
+		 */
+		if runCtx.IsDaemon && runCtx.ActCtx.Act.Interactive {
+			OpenStdinFifo()
+		}
+
+		profileMark("prepare run")
 
 		// Now run the matched act
 		runCtx.ActCtx.Exec()
@@ -373,81 +1237,160 @@ func Exec(args []string) {
 	}
 }
 
+/**
+ * This function going to create and open the stdin fifo of an
+ * interactive daemon.
+ */
+func OpenStdinFifo() {
+	fifoPath := runCtx.Info.GetStdinFilePath()
+
+	os.Remove(fifoPath)
+
+	if err := utils.Mkfifo(fifoPath, 0600); err != nil {
+		utils.LogError("could not create stdin fifo", err)
+		return
+	}
+
+	/**
+	 * We open the fifo for reading and writing so opening doesn't
+	 * block waiting for a writer and commands don't get EOF when an
+	 * attached user detaches.
+	 */
+	file, err := os.OpenFile(fifoPath, os.O_RDWR, 0600)
+
+	if err != nil {
+		utils.LogError("could not open stdin fifo", err)
+		return
+	}
+
+	runCtx.Stdin = file
+	runCtx.Info.Interactive = true
+	runCtx.Info.Save()
+}
+
 /**
  * This function going to stop execution of current running
  * commands.
  */
 func Stop() {
+	if runCtx == nil {
+		return
+	}
+
 	utils.LogDebug(fmt.Sprintf("Stop [State=%s]", runCtx.State))
+	utils.Trace("run_stop", utils.TraceFields{"run_id": runCtx.Info.Id, "state": runCtx.State})
 
 	/**
-	 * Stop only if we are executing non final commands.
+	 * Stop only if we are executing non final commands. We flag the
+	 * execution as stopped first so commands exiting while we wait
+	 * them to terminate are not considered failures.
 	 */
-	if runCtx != nil && !runCtx.IsFinishing && runCtx.State == ExecStateRunning {
-		/**
-		 * If we have a running act let's kill it and all it's descendant
-		 * children (as part of killing the process group as a whole).
-		 */
-		if runCtx.ActCtx != nil {
-			// First we kill current running context.
-			runCtx.Info.KillChildren();
-		}
+	runCtx.mutex.Lock()
+
+	shouldStop := !runCtx.IsFinishing && runCtx.State == ExecStateRunning
 
+	if shouldStop {
 		runCtx.State = ExecStateStopped
+		runCtx.cancel()
+		runCtx.stopWg.Add(1)
 	}
+
+	runCtx.mutex.Unlock()
+
+	if !shouldStop {
+		return
+	}
+
+	/**
+	 * If we have a running act let's kill it and all it's descendant
+	 * children (as part of killing the process group as a whole). Final
+	 * commands are not affected since they are tracked apart.
+	 */
+	if runCtx.ActCtx != nil {
+		runCtx.Info.KillChildren();
+	}
+
+	runCtx.stopWg.Done()
+}
+
+/**
+ * This function going to forward a signal to all running commands.
+ */
+func Signal(sig syscall.Signal) {
+	if runCtx == nil || runCtx.ActCtx == nil {
+		return
+	}
+
+	runCtx.Info.Signal(sig)
 }
 
 /**
  * This function going to cleanup everything for this command on exit.
  */
 func Finish() {
-	utils.LogDebug(fmt.Sprintf("Finish [State=%d]", runCtx.State), runCtx.IsFinishing)
+	if runCtx == nil {
+		return
+	}
+
+	// The daemon we spawned owns the run (like its log file).
+	if runCtx.SpawnedDaemon {
+		return
+	}
+
+	utils.LogDebug(fmt.Sprintf("Finish [State=%s]", runCtx.State), runCtx.IsFinishing)
+	utils.Trace("run_finish", utils.TraceFields{"run_id": runCtx.Info.Id, "state": runCtx.State, "exit_code": utils.ExitCode})
+
+	runCtx.mutex.Lock()
 
 	/**
 	 * In case user tries to kill this process twice we going to
 	 * prevent running final actions multiple times.
 	 */
-	if runCtx == nil || runCtx.IsFinishing {
+	if runCtx.IsFinishing {
+		runCtx.mutex.Unlock()
 		return
 	}
 
+	/**
+	 * Set the flag isFinishing to run context so we can propagate
+	 * this information down to the process tree.
+	 */
+	runCtx.IsFinishing = true
+	isStopped := runCtx.IsStopped()
+
+	runCtx.mutex.Unlock()
+
 	/**
 	 * If we called Finish at the end of main process (i.e. in main.go)
 	 * then everything went fine and user didn't kill the process.
 	 * This way we can skip this finish process because the final step
 	 * was already done in act run ctx exec function.
 	 */
-	if runCtx.State == ExecStateRunning {
+	if !isStopped {
 		/**
 		 * We call KillChildren because we might have some dangling
 		 * detached child acts running and we want to kill them.
 		 */
 		runCtx.Info.KillChildren();
-		runCtx.Info.RmDataDir()
+		closeRun()
 		return
 	}
 
 	/**
-	 * Since we are reusing the same run context to run finishing
-	 * commands we need to resume the run context first to allow
-	 * new execution.
-	 */
-	runCtx.State = ExecStateRunning
-
-	/**
-	 * Set the flag isFinishing to run context so we can propagate
-	 * this information down to the process tree.
+	 * Wait the stop process to finish killing all start stage commands
+	 * before running final stages.
 	 */
-	runCtx.IsFinishing = true
+	runCtx.stopWg.Wait()
 
 	/**
-	 * If we have a running act let's kill it and all it's descendant
-	 * children (as part of killing the process group as a whole).
+	 * Run final stages of all acts in the call stack (each one exactly
+	 * once and within its final timeout).
 	 */
 	if runCtx.ActCtx != nil {
 		utils.LogDebug("Finish : cleanup call")
 
-		// If act has teardown commands let's run them before exit.
 		cleanup()
+	} else {
+		closeRun()
 	}
 }
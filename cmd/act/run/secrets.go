@@ -0,0 +1,314 @@
+/**
+ * This file implements act secrets. Secrets are declared (at actfile
+ * or act level) as env var names mapped to references like
+ * `vault:secret/data/app#password` where the prefix selects the
+ * secret provider. Secrets are resolved when the act runs, injected
+ * only in the environment of commands (they are not template vars
+ * and are never written to disk) and masked in command output.
+ */
+
+package run
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This interface must be implemented by secret providers (like
+ * HashiCorp Vault).
+ */
+type SecretProvider interface {
+	/**
+	 * This function going to fetch the value of a secret given its
+	 * reference (without the provider prefix) and the actfile
+	 * declaring it (for provider settings like aws region).
+	 */
+	GetSecret(ref string, actFile *actfile.ActFile) (string, error)
+}
+
+/**
+ * This struct going to hold resolved secrets of a run.
+ */
+type runSecrets struct {
+	/**
+	 * Resolved secret values by actfile and reference so we fetch
+	 * each secret only once per run.
+	 */
+	values map[string]string
+
+	mutex sync.Mutex
+}
+
+/**
+ * This struct implements io.Writer masking secret values before
+ * writing to the underlying writer. A secret can be split across
+ * writes so we hold back output ending with the beginning of a
+ * secret until the next write (or flush).
+ */
+type secretMaskWriter struct {
+	w       io.Writer
+	runCtx  *RunCtx
+	pending string
+	mutex   sync.Mutex
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Text we show in place of secret values.
+ */
+const SecretMask = "****"
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Secret values shorter than this are not masked since masking
+ * them would mangle unrelated output.
+ */
+const secretMaskMinLen = 4
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Registered secret providers by reference prefix.
+ */
+var secretProviders = map[string]SecretProvider{
+	"vault":   &VaultSecretProvider{},
+	"aws-ssm": &AwsSsmSecretProvider{},
+	"aws-sm":  &AwsSmSecretProvider{},
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to register a secret provider for references
+ * starting with `<prefix>:`.
+ */
+func RegisterSecretProvider(prefix string, provider SecretProvider) {
+	secretProviders[prefix] = provider
+}
+
+/**
+ * This function going to fetch the value of a secret reference
+ * using the provider selected by its prefix.
+ */
+func ResolveSecret(ref string, actFile *actfile.ActFile) (string, error) {
+	parts := strings.SplitN(ref, ":", 2)
+
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid secret reference %s (expected like vault:secret/data/app#password)", ref)
+	}
+
+	provider, ok := secretProviders[parts[0]]
+
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider %s", parts[0])
+	}
+
+	return provider.GetSecret(parts[1], actFile)
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to write output held back by masking writers
+ * among the provided ones.
+ */
+func flushMaskWriters(writers ...io.Writer) {
+	for _, w := range writers {
+		if m, ok := w.(*secretMaskWriter); ok {
+			m.Flush()
+		}
+	}
+}
+
+//############################################################
+// secretMaskWriter Struct Functions
+//############################################################
+
+/**
+ * This function implements io.Writer interface.
+ */
+func (m *secretMaskWriter) Write(p []byte) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	text := m.runCtx.MaskSecrets(m.pending + string(p))
+	heldLen := m.runCtx.getSecretPrefixLen(text)
+
+	m.pending = text[len(text)-heldLen:]
+
+	if heldLen < len(text) {
+		if _, err := m.w.Write([]byte(text[:len(text)-heldLen])); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+/**
+ * This function going to write output we held back (i.e., when
+ * the command finished).
+ */
+func (m *secretMaskWriter) Flush() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.pending == "" {
+		return nil
+	}
+
+	_, err := m.w.Write([]byte(m.pending))
+	m.pending = ""
+
+	return err
+}
+
+//############################################################
+// RunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to resolve a secret reference caching its
+ * value for the whole run.
+ */
+func (ctx *RunCtx) resolveSecret(ref string, actFile *actfile.ActFile) (string, error) {
+	ctx.secrets.mutex.Lock()
+	defer ctx.secrets.mutex.Unlock()
+
+	// Same reference can resolve differently with other actfile settings.
+	key := fmt.Sprintf("%s|%s", actFile.LocationPath, ref)
+
+	if val, ok := ctx.secrets.values[key]; ok {
+		return val, nil
+	}
+
+	val, err := ResolveSecret(ref, actFile)
+
+	if err != nil {
+		return "", err
+	}
+
+	if ctx.secrets.values == nil {
+		ctx.secrets.values = make(map[string]string)
+	}
+
+	ctx.secrets.values[key] = val
+
+	return val, nil
+}
+
+/**
+ * This function going to check if secrets were resolved in this run
+ * (so output needs to be masked).
+ */
+func (ctx *RunCtx) HasSecrets() bool {
+	ctx.secrets.mutex.Lock()
+	defer ctx.secrets.mutex.Unlock()
+
+	return len(ctx.secrets.values) > 0
+}
+
+/**
+ * This function going to replace secret values in a text by a mask.
+ */
+func (ctx *RunCtx) MaskSecrets(text string) string {
+	ctx.secrets.mutex.Lock()
+	defer ctx.secrets.mutex.Unlock()
+
+	for _, val := range ctx.secrets.values {
+		if len(val) >= secretMaskMinLen {
+			text = strings.ReplaceAll(text, val, SecretMask)
+		}
+	}
+
+	return text
+}
+
+/**
+ * This function going to get the length of the longest end of a text
+ * which is the beginning of a secret value (so we can't output it
+ * yet).
+ */
+func (ctx *RunCtx) getSecretPrefixLen(text string) int {
+	ctx.secrets.mutex.Lock()
+	defer ctx.secrets.mutex.Unlock()
+
+	prefixLen := 0
+
+	for _, val := range ctx.secrets.values {
+		if len(val) < secretMaskMinLen {
+			continue
+		}
+
+		for size := len(val) - 1; size > prefixLen; size-- {
+			if strings.HasSuffix(text, val[:size]) {
+				prefixLen = size
+				break
+			}
+		}
+	}
+
+	return prefixLen
+}
+
+/**
+ * This function going to wrap a writer so secret values get masked
+ * (if this run has any secrets at all).
+ */
+func (ctx *RunCtx) MaskWriter(w io.Writer) io.Writer {
+	if !ctx.HasSecrets() {
+		return w
+	}
+
+	return &secretMaskWriter{w: w, runCtx: ctx}
+}
+
+//############################################################
+// ActRunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to resolve secrets of this act (actfile
+ * secrets first and then act secrets which take precedence).
+ */
+func (ctx *ActRunCtx) GetSecretVars() (map[string]string, error) {
+	vars := make(map[string]string)
+
+	for _, secrets := range []map[string]string{ctx.ActFile.Secrets, ctx.Act.Secrets} {
+		for name, ref := range secrets {
+			val, err := ctx.RunCtx.resolveSecret(ref, ctx.ActFile)
+
+			if err != nil {
+				return nil, fmt.Errorf("could not resolve secret %s: %v", name, err)
+			}
+
+			vars[name] = val
+		}
+	}
+
+	utils.LogDebug(fmt.Sprintf("GetSecretVars [act=%s] : resolved %d secrets", ctx.Act.Name, len(vars)))
+
+	return vars, nil
+}
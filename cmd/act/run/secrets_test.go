@@ -0,0 +1,55 @@
+package run
+
+import (
+	"bytes"
+	"testing"
+)
+
+/**
+ * Secrets split across writes must still be masked.
+ */
+func TestMaskWriterSplitSecret(t *testing.T) {
+	ctx := &RunCtx{}
+	ctx.secrets.values = map[string]string{"key": "supersecret"}
+
+	var out bytes.Buffer
+	w := ctx.MaskWriter(&out)
+
+	for _, chunk := range []string{"token=su", "per", "secret\n"} {
+		w.Write([]byte(chunk))
+	}
+
+	if got, want := out.String(), "token="+SecretMask+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+/**
+ * Output not looking like the beginning of a secret is written right
+ * away (like prompts) and held output is written on flush.
+ */
+func TestMaskWriterHoldBack(t *testing.T) {
+	ctx := &RunCtx{}
+	ctx.secrets.values = map[string]string{"key": "supersecret"}
+
+	var out bytes.Buffer
+	w := ctx.MaskWriter(&out)
+
+	w.Write([]byte("password: "))
+
+	if got := out.String(); got != "password: " {
+		t.Errorf("got %q before flush, want prompt right away", got)
+	}
+
+	w.Write([]byte("sup"))
+
+	if got := out.String(); got != "password: " {
+		t.Errorf("got %q, want secret beginning held back", got)
+	}
+
+	flushMaskWriters(w)
+
+	if got := out.String(); got != "password: sup" {
+		t.Errorf("got %q after flush, want held output", got)
+	}
+}
@@ -0,0 +1,146 @@
+/**
+ * This file implements detection of stale run info. When an act
+ * process crashes (or gets killed with SIGKILL) it can't cleanup its
+ * info so we check recorded pids are still alive and that they still
+ * belong to act processes (pids get recycled by the system) and flag
+ * dead entries so they can be pruned.
+ */
+
+package run
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/nosebit/act/internal/procgroup"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Name of the act binary as we spawn it for daemons.
+ */
+const actBinName = "act"
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check if a pid belongs to an act process.
+ */
+func isActProcess(pid int) bool {
+	// Binaries have the exe extension on windows.
+	binName := strings.TrimSuffix(procgroup.GetBinName(pid), ".exe")
+
+	if binName == actBinName {
+		return true
+	}
+
+	// Act binary might have been installed with another name.
+	if execPath, err := os.Executable(); err == nil {
+		return binName == strings.TrimSuffix(filepath.Base(execPath), ".exe")
+	}
+
+	return false
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function going to get command process groups of a dead act
+ * which are still running (so users can stop them later).
+ */
+func (info *Info) getOrphanPgids() []int {
+	var pgids []int
+
+	for _, pgid := range info.CmdPgids {
+		if pgid > 0 && isProcessGroupRunning(pgid) && info.OwnsPgid(pgid) {
+			pgids = append(pgids, pgid)
+		}
+	}
+
+	return pgids
+}
+
+/**
+ * This function going to check if the act process died without
+ * recording its exit.
+ */
+func (info *Info) IsStale() bool {
+	if info.Exited || info.Dead || info.Pid <= 0 {
+		return false
+	}
+
+	// Our own info is never stale.
+	if info.Pid == os.Getpid() {
+		return false
+	}
+
+	if !isProcessRunning(info.Pid) || !isActProcess(info.Pid) {
+		return true
+	}
+
+	/**
+	 * A recycled pid could belong to another act process so we check
+	 * process group as well.
+	 */
+	if pgid, err := procgroup.Getpgid(info.Pid); err != nil || pgid != info.Pgid {
+		return true
+	}
+
+	return false
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to flag info of act processes which died
+ * without recording their exit as dead.
+ */
+func MarkStaleInfos() {
+	dataDirPath := GetActDataDirPath()
+
+	if memoryOnly || !utils.DoFileExists(dataDirPath) {
+		return
+	}
+
+	var infos []*Info
+
+	/**
+	 * With an index we only need to load infos of runs which are not
+	 * finished yet (most runs are).
+	 */
+	if entries, ok := readIndex(dataDirPath); ok {
+		for _, entry := range entries {
+			if entry.Exited || entry.Dead {
+				continue
+			}
+
+			if info := loadInfoFromFile(filepath.Join(dataDirPath, entry.Id, InfoFileName)); info != nil {
+				infos = append(infos, info)
+			}
+		}
+	} else {
+		infos = GetAllInfo()
+	}
+
+	for _, info := range infos {
+		if info.IsStale() {
+			utils.LogDebug(fmt.Sprintf("MarkStaleInfos : act %s [pid=%d] is dead", info.Id, info.Pid))
+
+			info.Dead = true
+			info.CmdPgids = info.getOrphanPgids()
+			info.Save()
+		}
+	}
+}
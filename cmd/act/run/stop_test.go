@@ -0,0 +1,171 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"go.uber.org/goleak"
+)
+
+/**
+ * This function going to create a running act context for tests
+ * which is set as the current run (so Stop can stop it).
+ */
+func newTestRunningCtx(t *testing.T) *ActRunCtx {
+	setupTestStateDir(t)
+
+	dir := t.TempDir()
+
+	actFile := &actfile.ActFile{LocationPath: filepath.Join(dir, "actfile.yml")}
+	actCtx := &ActRunCtx{
+		CallId:  "test",
+		Act:     &actfile.Act{Name: "test", Log: "raw", Quiet: true},
+		ActFile: actFile,
+	}
+
+	ctx := &RunCtx{
+		ActFile: actFile,
+		ActCtx:  actCtx,
+		State:   ExecStateRunning,
+		Info:    &Info{Id: "test", NameId: "test", Pid: os.Getpid(), StartedAt: time.Now()},
+	}
+
+	ctx.Ctx, ctx.cancel = context.WithCancel(context.Background())
+	actCtx.RunCtx = ctx
+
+	prevRunCtx := runCtx
+	runCtx = ctx
+
+	t.Cleanup(func() {
+		runCtx = prevRunCtx
+	})
+
+	return actCtx
+}
+
+/**
+ * This function going to get process groups of running commands.
+ */
+func getTestCmdPgids(ctx *ActRunCtx) []int {
+	info := ctx.RunCtx.Info
+
+	info.mutex.Lock()
+	defer info.mutex.Unlock()
+
+	return append([]int{}, info.CmdPgids...)
+}
+
+/**
+ * This function going to fail when goroutines started by the test
+ * are still running (the log queue writer lives as long as the run
+ * so we ignore it).
+ */
+func verifyNoTestLeaks(t *testing.T, opts ...goleak.Option) {
+	opts = append(opts, goleak.IgnoreTopFunction("github.com/nosebit/act/cmd/act/run.(*logQueue).run"))
+
+	goleak.VerifyNone(t, opts...)
+}
+
+/**
+ * This function going to run a stage in background and then stop
+ * the run failing if the stage doesn't return promptly.
+ */
+func assertStageStopsPromptly(t *testing.T, stage *actfile.ActExecStage, ctx *ActRunCtx) {
+	done := make(chan bool)
+
+	go func() {
+		StageCmdsExec(stage, ctx)
+		close(done)
+	}()
+
+	// Let commands start before stopping.
+	for i := 0; i < 100 && len(getTestCmdPgids(ctx)) == 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if len(getTestCmdPgids(ctx)) == 0 {
+		t.Fatal("commands did not start")
+	}
+
+	stoppedAt := time.Now()
+	Stop()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stage still running %s after stop", time.Since(stoppedAt))
+	}
+
+	if pgids := getTestCmdPgids(ctx); len(pgids) > 0 {
+		t.Errorf("commands %v still tracked after stop", pgids)
+	}
+}
+
+/**
+ * Stopping a run interrupts sequential commands right away (without
+ * leaving goroutines behind).
+ */
+func TestStopInterruptsCmds(t *testing.T) {
+	defer verifyNoTestLeaks(t, goleak.IgnoreCurrent())
+
+	ctx := newTestRunningCtx(t)
+
+	stage := &actfile.ActExecStage{
+		Name: "start",
+		Cmds: []*actfile.Cmd{{Cmd: "sleep 30"}, {Cmd: "sleep 30"}},
+	}
+
+	assertStageStopsPromptly(t, stage, ctx)
+}
+
+/**
+ * Stopping a run interrupts parallel commands, commands waiting a
+ * free slot and waits (without leaving goroutines behind).
+ */
+func TestStopInterruptsParallelCmdsAndWaits(t *testing.T) {
+	defer verifyNoTestLeaks(t, goleak.IgnoreCurrent())
+
+	ctx := newTestRunningCtx(t)
+	filePath := filepath.Join(t.TempDir(), "never")
+
+	stage := &actfile.ActExecStage{
+		Name:        "start",
+		Parallel:    true,
+		MaxParallel: 2,
+		Cmds: []*actfile.Cmd{
+			{Cmd: "sleep 30"},
+			{WaitFor: &actfile.CmdWaitFor{File: filePath, Timeout: time.Minute, Interval: 10 * time.Second}},
+			{Cmd: "sleep 30"},
+		},
+	}
+
+	assertStageStopsPromptly(t, stage, ctx)
+}
+
+/**
+ * Custom stop timelines not ending with a kill get one so commands
+ * never outlive the stop.
+ */
+func TestStopTimelineEndsWithKill(t *testing.T) {
+	grace := 3 * time.Second
+	info := &Info{
+		StopGracePeriod: &grace,
+		StopTimeline:    []*actfile.ActStopStep{{Signal: "INT"}, {Signal: "TERM", After: 5 * time.Second}},
+	}
+
+	timeline := info.GetStopTimeline()
+
+	if len(timeline) != 3 || timeline[2].Signal != "KILL" || timeline[2].After != 8*time.Second {
+		t.Errorf("got last step %v, want KILL after 8s", timeline[len(timeline)-1])
+	}
+
+	info.StopTimeline = []*actfile.ActStopStep{{Signal: "INT"}, {Signal: "SIGKILL", After: time.Second}}
+
+	if timeline := info.GetStopTimeline(); len(timeline) != 2 {
+		t.Errorf("got %d steps, want timeline kept", len(timeline))
+	}
+}
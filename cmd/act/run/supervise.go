@@ -0,0 +1,137 @@
+/**
+ * This file implements supervision of acts running as daemon. Based
+ * on the act restart policy we going to restart the start stage when
+ * it finishes (or fails) instead of letting the daemon die.
+ */
+
+package run
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Restart policies.
+ */
+const (
+	RestartNever     string = "never"
+	RestartOnFailure        = "on-failure"
+	RestartAlways           = "always"
+)
+
+/**
+ * This is the default time we wait before the first restart.
+ */
+const DefaultRestartBackoff = 1 * time.Second
+
+/**
+ * This is the max time we wait between restarts.
+ */
+const MaxRestartBackoff = 1 * time.Minute
+
+//############################################################
+// ActRunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to check if the execution is supervised (i.e.,
+ * we are running as daemon and the root act has a restart policy).
+ */
+func (ctx *ActRunCtx) IsSupervised() bool {
+	rootCtx := ctx.RunCtx.ActCtx
+
+	if !ctx.RunCtx.IsDaemon || rootCtx == nil {
+		return false
+	}
+
+	return rootCtx.Act.Restart == RestartOnFailure || rootCtx.Act.Restart == RestartAlways
+}
+
+/**
+ * This function going to check if start stage should be restarted
+ * after it finished.
+ */
+func (ctx *ActRunCtx) shouldRestart(restarts int) bool {
+	if ctx != ctx.RunCtx.ActCtx || !ctx.IsSupervised() || !ctx.CanRun() {
+		return false
+	}
+
+	if ctx.Act.MaxRestarts > 0 && restarts >= ctx.Act.MaxRestarts {
+		utils.LogError(fmt.Sprintf("act %s reached max restarts (%d)", ctx.CallId, ctx.Act.MaxRestarts))
+		return false
+	}
+
+	return ctx.Act.Restart == RestartAlways || ctx.Failed
+}
+
+/**
+ * This function going to execute the start stage (watching act checks
+ * while it runs) and restart it according to the act restart policy.
+ */
+func (ctx *ActRunCtx) StartStageExec() {
+	backoff := ctx.Act.RestartBackoff
+
+	if backoff <= 0 {
+		backoff = DefaultRestartBackoff
+	}
+
+	for restarts := 0; ; restarts++ {
+		/**
+		 * If act has checks we run them periodically while start commands
+		 * are running so we can track act health and run the after stage
+		 * once the act gets ready.
+		 */
+		startDone := make(chan bool)
+
+		if ctx.Act.Check != nil {
+			go ctx.WatchHealth(startDone)
+		}
+
+		StageCmdsExec(ctx.Act.Start, ctx)
+
+		close(startDone)
+
+		if !ctx.shouldRestart(restarts) {
+			/**
+			 * Failures of supervised executions are not fatal so we
+			 * need to set the exit code ourselves.
+			 */
+			if ctx.Failed && ctx.IsSupervised() {
+				utils.ExitCode = ctx.ExitCode
+			}
+
+			return
+		}
+
+		if ctx.Failed {
+			utils.LogError(fmt.Sprintf("act %s failed with code %d : restarting in %s", ctx.CallId, ctx.ExitCode, backoff))
+		} else {
+			utils.LogInfo(fmt.Sprintf("act %s finished : restarting in %s", ctx.CallId, backoff))
+		}
+
+		// Execution might be stopped while we are waiting.
+		if !ctx.Sleep(backoff) {
+			return
+		}
+
+		if backoff *= 2; backoff > MaxRestartBackoff {
+			backoff = MaxRestartBackoff
+		}
+
+		// Clear failure state so the new run starts clean.
+		ctx.RunCtx.mutex.Lock()
+		ctx.Failed = false
+		ctx.ExitCode = 0
+		ctx.FailedCmd = ""
+		ctx.RunCtx.mutex.Unlock()
+
+		ctx.RunCtx.Info.IncRestartCount()
+	}
+}
@@ -0,0 +1,145 @@
+/**
+ * This file implements tag based execution. Acts can carry tags
+ * (like `tags: [lint, ci]`) and `act run -tag ci` going to run all
+ * acts carrying the tag in parallel (acts they need run first and
+ * every act runs only once).
+ */
+
+package run
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * This is the prefix of the name of the act we create to run all
+ * acts carrying a tag (like `tag:ci`).
+ */
+const TagActNamePrefix = "tag:"
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to check if an act carries a tag.
+ */
+func hasTag(act *actfile.Act, tag string) bool {
+	for _, actTag := range act.Tags {
+		if actTag == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to find call ids of all acts (and subacts)
+ * carrying a tag. Acts with regex names can't be called by their
+ * names so we skip them.
+ */
+func findTaggedActs(acts []*actfile.Act, parentCallId string, tag string) ([]string, map[string]*actfile.Act) {
+	var callIds []string
+	actsByCallId := make(map[string]*actfile.Act)
+
+	for _, act := range acts {
+		if regexp.QuoteMeta(act.Name) != act.Name {
+			continue
+		}
+
+		callId := act.Name
+
+		if parentCallId != "" {
+			callId = fmt.Sprintf("%s%s%s", parentCallId, ActCallIdSeparator, act.Name)
+		}
+
+		if hasTag(act, tag) {
+			callIds = append(callIds, callId)
+			actsByCallId[callId] = act
+		}
+
+		subCallIds, subActs := findTaggedActs(act.Acts, callId, tag)
+		callIds = append(callIds, subCallIds...)
+
+		for subCallId, subAct := range subActs {
+			actsByCallId[subCallId] = subAct
+		}
+	}
+
+	return callIds, actsByCallId
+}
+
+/**
+ * This function going to sort call ids so acts needed by other
+ * tagged acts come first (keeping the actfile order otherwise).
+ */
+func sortByNeeds(callIds []string, actsByCallId map[string]*actfile.Act) []string {
+	var sorted []string
+	visited := make(map[string]bool)
+
+	var visit func(callId string)
+
+	visit = func(callId string) {
+		if visited[callId] {
+			return
+		}
+
+		visited[callId] = true
+
+		for _, need := range actsByCallId[callId].Needs {
+			if _, ok := actsByCallId[need.Act]; ok {
+				visit(need.Act)
+			}
+		}
+
+		sorted = append(sorted, callId)
+	}
+
+	for _, callId := range callIds {
+		visit(callId)
+	}
+
+	return sorted
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to create an act which runs all acts of an
+ * actfile carrying a tag in parallel (running at most maxParallel
+ * acts at the same time when maxParallel is greater than zero).
+ */
+func NewTagAct(actFile *actfile.ActFile, tag string, maxParallel int) (*actfile.Act, error) {
+	callIds, actsByCallId := findTaggedActs(actFile.Acts, "", tag)
+
+	if len(callIds) == 0 {
+		return nil, fmt.Errorf("no acts tagged with %s", tag)
+	}
+
+	var cmds []*actfile.Cmd
+
+	for _, callId := range sortByNeeds(callIds, actsByCallId) {
+		cmds = append(cmds, &actfile.Cmd{Act: callId})
+	}
+
+	return &actfile.Act{
+		Name: regexp.QuoteMeta(fmt.Sprintf("%s%s", TagActNamePrefix, tag)),
+		Desc: fmt.Sprintf("Run all acts tagged with %s", tag),
+		Start: &actfile.ActExecStage{
+			Name:        "start",
+			Parallel:    true,
+			MaxParallel: maxParallel,
+			Cmds:        cmds,
+		},
+	}, nil
+}
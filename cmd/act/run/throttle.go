@@ -0,0 +1,186 @@
+/**
+ * This file implements throttling and deduplication of act runs.
+ * This is useful when acts are triggered by external events (like
+ * git hooks or file watchers) which usually fire in bursts. State
+ * is kept in the act data dir so it's shared between act processes.
+ */
+
+package run
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to generate a key that uniquely identify
+ * an act across actfiles so we can name throttling state files.
+ */
+func getThrottleKey(ctx *ActRunCtx) string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s:%s", ctx.ActFile.LocationPath, ctx.CallId)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+/**
+ * This function going to debounce an act run. We register this run
+ * as the latest one, wait the debounce period and then check if no
+ * other run registered itself in the meantime. It returns false if
+ * this run was superseded by a newer one.
+ */
+func debounceRun(ctx *ActRunCtx) bool {
+	dataDirPath := GetActDataDirPath()
+	filePath := filepath.Join(dataDirPath, fmt.Sprintf("%s.debounce", getThrottleKey(ctx)))
+	runId := ctx.RunCtx.Info.Id
+
+	os.MkdirAll(dataDirPath, 0755)
+
+	if err := ioutil.WriteFile(filePath, []byte(runId), 0644); err != nil {
+		utils.LogError("could not write debounce file", err)
+		return true
+	}
+
+	time.Sleep(ctx.Act.Debounce)
+
+	content, err := ioutil.ReadFile(filePath)
+
+	if err != nil || string(content) != runId {
+		return false
+	}
+
+	os.Remove(filePath)
+
+	return true
+}
+
+/**
+ * This function going to check if the min interval between runs
+ * of an act has elapsed. If so it records this run as the latest
+ * one and returns true.
+ */
+func checkMinInterval(ctx *ActRunCtx) bool {
+	dataDirPath := GetActDataDirPath()
+	filePath := filepath.Join(dataDirPath, fmt.Sprintf("%s.last", getThrottleKey(ctx)))
+
+	if content, err := ioutil.ReadFile(filePath); err == nil {
+		last, err := time.Parse(time.RFC3339Nano, string(content))
+
+		if err == nil && time.Since(last) < ctx.Act.MinInterval {
+			return false
+		}
+	}
+
+	os.MkdirAll(dataDirPath, 0755)
+
+	now := time.Now().Format(time.RFC3339Nano)
+
+	if err := ioutil.WriteFile(filePath, []byte(now), 0644); err != nil {
+		utils.LogError("could not write min interval file", err)
+	}
+
+	return true
+}
+
+/**
+ * This function going to compute the input fingerprint of an act
+ * run which is a checksum of act identity, cli args and content of
+ * all source files.
+ */
+func getRunFingerprint(ctx *ActRunCtx) string {
+	hash := md5.New()
+
+	fmt.Fprintf(hash, "%s:%s:%s\n", ctx.ActFile.LocationPath, ctx.CallId, strings.Join(ctx.Args, " "))
+
+	baseDir := filepath.Dir(ctx.ActFile.LocationPath)
+	var filePaths []string
+
+	for _, source := range ctx.Act.Sources {
+		paths, err := filepath.Glob(utils.ResolvePath(baseDir, source))
+
+		if err != nil {
+			utils.FatalError("sources glob error", err)
+		}
+
+		filePaths = append(filePaths, paths...)
+	}
+
+	// Keep fingerprint stable regardless of glob order.
+	sort.Strings(filePaths)
+
+	for _, filePath := range filePaths {
+		content, err := ioutil.ReadFile(filePath)
+
+		if err != nil {
+			continue
+		}
+
+		fmt.Fprintf(hash, "%s:", filePath)
+		hash.Write(content)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to check if an act run should proceed based
+ * on the act debounce, min interval and dedupe settings.
+ */
+func ShouldRun(ctx *ActRunCtx) bool {
+	/**
+	 * Throttling state can't be persisted in memory-only mode so
+	 * we just let the run proceed.
+	 */
+	if memoryOnly {
+		return true
+	}
+
+	if ctx.Act.Debounce > 0 && !debounceRun(ctx) {
+		utils.LogInfo(fmt.Sprintf("act %s skipped : superseded by a newer run", ctx.CallId))
+		return false
+	}
+
+	if ctx.Act.MinInterval > 0 && !checkMinInterval(ctx) {
+		utils.LogInfo(fmt.Sprintf("act %s skipped : last run was less than %s ago", ctx.CallId, ctx.Act.MinInterval))
+		return false
+	}
+
+	/**
+	 * We check for duplicates and record our fingerprint in the index
+	 * at once so runs starting together (whose info is not saved yet)
+	 * still see each other.
+	 */
+	if ctx.Act.Dedupe {
+		info := ctx.RunCtx.Info
+		info.Fingerprint = getRunFingerprint(ctx)
+
+		dupEntry, err := info.claimIndexEntry(func(entry IndexEntry) bool {
+			return entry.Fingerprint == info.Fingerprint
+		})
+
+		if err != nil {
+			utils.LogWarn("could not check for duplicate runs", err)
+		} else if dupEntry != nil {
+			utils.LogInfo(fmt.Sprintf("act %s skipped : duplicate of run %s", ctx.CallId, dupEntry.Id))
+			return false
+		}
+	}
+
+	return true
+}
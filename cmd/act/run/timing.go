@@ -0,0 +1,227 @@
+/**
+ * This file implements the timing summary of a run. We record how
+ * long each command took (and how it ended) in run info so we can
+ * print a summary table when the run finishes (or later with
+ * `act stats` for daemons).
+ */
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+	"github.com/olekukonko/tablewriter"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold timing of a command run.
+ */
+type CmdTiming struct {
+	/**
+	 * Call id of the act running the command.
+	 */
+	Act string
+
+	/**
+	 * Stage running the command.
+	 */
+	Stage string
+
+	/**
+	 * Command line (first line only) of the command.
+	 */
+	Cmd string
+
+	/**
+	 * When the command started.
+	 */
+	StartedAt time.Time
+
+	/**
+	 * For how long the command ran.
+	 */
+	Duration time.Duration
+
+	/**
+	 * How the command ended (ok, failed or stopped).
+	 */
+	Status string
+}
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Status of a timed command.
+ */
+const (
+	CmdStatusOk        = "ok"
+	CmdStatusFailed    = "failed"
+	CmdStatusStopped   = "stopped"
+	CmdStatusOomKilled = "oom_killed"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max length of command lines shown in the timing summary.
+ */
+const timingCmdMaxLen = 60
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to shorten a command line so it fits in the
+ * timing summary.
+ */
+func shortenTimingCmd(cmdLine string) string {
+	cmdLine = strings.TrimSpace(cmdLine)
+
+	if lines := strings.Split(cmdLine, "\n"); len(lines) > 1 {
+		cmdLine = fmt.Sprintf("%s ...", lines[0])
+	}
+
+	if len(cmdLine) > timingCmdMaxLen {
+		cmdLine = fmt.Sprintf("%s...", cmdLine[:timingCmdMaxLen-3])
+	}
+
+	return cmdLine
+}
+
+/**
+ * This function going to print the timing summary of a foreground
+ * run when it finishes. Runs of a single command don't get a summary
+ * since it would just repeat what user already saw.
+ */
+func printRunSummary() {
+	if runCtx.IsDaemon || runCtx.NoSummary || runCtx.Quiet || len(runCtx.Info.Timings) < 2 {
+		return
+	}
+
+	fmt.Println()
+	PrintTimingSummary(os.Stdout, runCtx.Info.Timings)
+}
+
+//############################################################
+// Info Struct Functions
+//############################################################
+
+/**
+ * This function going to add a command timing and then schedule a
+ * save of info back to file system.
+ */
+func (info *Info) AddCmdTiming(timing *CmdTiming) {
+	info.mutex.Lock()
+
+	info.Timings = append(info.Timings, timing)
+	info.saveLater()
+
+	info.mutex.Unlock()
+}
+
+//############################################################
+// ActRunCtx Struct Functions
+//############################################################
+
+/**
+ * This function going to record timing of a command of this act.
+ */
+func (ctx *ActRunCtx) recordCmdTiming(cmdLine string, startedAt time.Time, err error) {
+	status := CmdStatusOk
+
+	if err != nil {
+		status = CmdStatusFailed
+
+		if errors.Is(err, ErrOomKilled) {
+			status = CmdStatusOomKilled
+		} else if ctx.RunCtx.IsFinishing || ctx.RunCtx.IsStopped() {
+			// Commands killed because the run was stopped didn't fail.
+			status = CmdStatusStopped
+		}
+	}
+
+	stage := ""
+
+	if ctx.CurrentStage != nil {
+		stage = ctx.CurrentStage.Name
+	}
+
+	timing := &CmdTiming{
+		Act:       ctx.CallId,
+		Stage:     stage,
+		Cmd:       shortenTimingCmd(cmdLine),
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+		Status:    status,
+	}
+
+	ctx.RunCtx.Info.AddCmdTiming(timing)
+
+	ctx.EmitEvent(EventCmdFinished, map[string]interface{}{
+		"cmd":         timing.Cmd,
+		"status":      status,
+		"duration_ms": timing.Duration.Milliseconds(),
+	})
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to print a summary table of command timings.
+ */
+func PrintTimingSummary(w io.Writer, timings []*CmdTiming) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Act", "Stage", "Command", "Duration", "Status"})
+
+	var startedAt, endedAt time.Time
+
+	for _, timing := range timings {
+		status := timing.Status
+
+		switch status {
+		case CmdStatusOk:
+			status = utils.Color.Green(status).String()
+		case CmdStatusFailed, CmdStatusOomKilled:
+			status = utils.Color.Red(status).String()
+		default:
+			status = utils.Color.Yellow(status).String()
+		}
+
+		// Commands can run in parallel so total is the wall-clock time.
+		if startedAt.IsZero() || timing.StartedAt.Before(startedAt) {
+			startedAt = timing.StartedAt
+		}
+
+		if timingEndedAt := timing.StartedAt.Add(timing.Duration); timingEndedAt.After(endedAt) {
+			endedAt = timingEndedAt
+		}
+
+		table.Append([]string{
+			timing.Act,
+			timing.Stage,
+			timing.Cmd,
+			timing.Duration.Round(time.Millisecond).String(),
+			status,
+		})
+	}
+
+	table.SetFooter([]string{"", "", "Total", endedAt.Sub(startedAt).Round(time.Millisecond).String(), ""})
+	table.Render()
+}
@@ -0,0 +1,266 @@
+/**
+ * This file implements sampling of resource usage (cpu and memory)
+ * of running acts. On linux we read process stats from /proc and on
+ * other systems (like macOS) we fallback to the ps command.
+ */
+
+package run
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Number of clock ticks per second used by /proc cpu times. This is
+ * 100 in virtually all linux systems.
+ */
+const clockTicksPerSec = 100
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the resource usage of a running act.
+ */
+type Usage struct {
+	/**
+	 * Cpu usage percentage (100 means one cpu core fully used).
+	 */
+	Cpu float64
+
+	/**
+	 * Resident memory in bytes.
+	 */
+	Rss uint64
+}
+
+/**
+ * This struct going to hold usage sampled for a process.
+ */
+type procSample struct {
+	/**
+	 * Process group id of the process.
+	 */
+	Pgid int
+
+	/**
+	 * Total cpu time (in clock ticks) used by the process. This is
+	 * only available when reading from /proc.
+	 */
+	CpuTicks uint64
+
+	/**
+	 * Cpu usage percentage as reported by ps.
+	 */
+	Cpu float64
+
+	/**
+	 * Resident memory in bytes.
+	 */
+	Rss uint64
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to sample usage of all processes (by pid)
+ * reading stats from /proc.
+ */
+func sampleProcFs() map[int]*procSample {
+	samples := make(map[int]*procSample)
+	pageSize := uint64(os.Getpagesize())
+
+	entries, err := ioutil.ReadDir("/proc")
+
+	if err != nil {
+		return samples
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+
+		if err != nil {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+
+		if err != nil {
+			continue
+		}
+
+		/**
+		 * Process name (second field) can contain spaces so we parse
+		 * fields after the closing parenthesis. From there the fields
+		 * are: state(3) ppid(4) pgrp(5) ... utime(14) stime(15) ...
+		 * rss(24).
+		 */
+		stat := string(content)
+		fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+
+		if len(fields) < 22 {
+			continue
+		}
+
+		pgid, _ := strconv.Atoi(fields[2])
+		utime, _ := strconv.ParseUint(fields[11], 10, 64)
+		stime, _ := strconv.ParseUint(fields[12], 10, 64)
+		rss, _ := strconv.ParseUint(fields[21], 10, 64)
+
+		samples[pid] = &procSample{
+			Pgid:     pgid,
+			CpuTicks: utime + stime,
+			Rss:      rss * pageSize,
+		}
+	}
+
+	return samples
+}
+
+/**
+ * This function going to sample usage of all processes (by pid)
+ * using the ps command.
+ */
+func samplePs() map[int]*procSample {
+	samples := make(map[int]*procSample)
+
+	output, err := exec.Command("ps", "-A", "-o", "pid=,pgid=,rss=,%cpu=").Output()
+
+	if err != nil {
+		utils.LogDebug("samplePs : could not run ps", err)
+		return samples
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) < 4 {
+			continue
+		}
+
+		pid, _ := strconv.Atoi(fields[0])
+		pgid, _ := strconv.Atoi(fields[1])
+		rss, _ := strconv.ParseUint(fields[2], 10, 64)
+		cpu, _ := strconv.ParseFloat(fields[3], 64)
+
+		// Ps report rss in kilobytes.
+		samples[pid] = &procSample{Pgid: pgid, Rss: rss * 1024, Cpu: cpu}
+	}
+
+	return samples
+}
+
+/**
+ * This function going to check if a process belongs to an act. We
+ * count the act process itself and processes of the command process
+ * groups act recorded but not the whole act process group (acts
+ * running in foreground share it with unrelated processes like the
+ * other commands of a shell pipeline).
+ */
+func isInfoProc(info *Info, pid int, sample *procSample) bool {
+	if pid == info.Pid {
+		return true
+	}
+
+	for _, pgid := range info.CmdPgids {
+		if pgid > 0 && pgid == sample.Pgid {
+			return true
+		}
+	}
+
+	return false
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to sample resource usage of a list of running
+ * acts. Cpu usage is computed over the sampling interval so this
+ * function blocks for that long (no matter how usage is sampled).
+ */
+func GetUsages(infos []*Info, interval time.Duration) map[string]*Usage {
+	usages := make(map[string]*Usage)
+	hasProcFs := utils.DoFileExists("/proc/self/stat")
+
+	var before map[int]*procSample
+
+	if hasProcFs {
+		before = sampleProcFs()
+	}
+
+	/**
+	 * Ps already reports cpu usage but we still wait for the interval
+	 * so callers sampling in a loop (like top) don't busy loop.
+	 */
+	time.Sleep(interval)
+
+	var after map[int]*procSample
+
+	if hasProcFs {
+		after = sampleProcFs()
+	} else {
+		after = samplePs()
+	}
+
+	for _, info := range infos {
+		usage := &Usage{}
+
+		for pid, sample := range after {
+			if !isInfoProc(info, pid, sample) {
+				continue
+			}
+
+			usage.Rss += sample.Rss
+
+			if !hasProcFs {
+				usage.Cpu += sample.Cpu
+			} else if prev, ok := before[pid]; ok && sample.CpuTicks >= prev.CpuTicks {
+				ticks := float64(sample.CpuTicks - prev.CpuTicks)
+				usage.Cpu += ticks / (clockTicksPerSec * interval.Seconds()) * 100
+			}
+		}
+
+		usages[info.Id] = usage
+	}
+
+	return usages
+}
+
+/**
+ * This function going to format an amount of bytes in a human
+ * friendly way (like 12.3M).
+ */
+func FormatBytes(bytes uint64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+	value := float64(bytes)
+	idx := 0
+
+	for value >= 1024 && idx < len(units)-1 {
+		value /= 1024
+		idx++
+	}
+
+	if idx == 0 {
+		return fmt.Sprintf("%d%s", bytes, units[idx])
+	}
+
+	return fmt.Sprintf("%.1f%s", value, units[idx])
+}
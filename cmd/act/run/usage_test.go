@@ -0,0 +1,28 @@
+package run
+
+import (
+	"testing"
+)
+
+/**
+ * Usage of an act counts the act process and its command process
+ * groups but not other processes in the act process group.
+ */
+func TestIsInfoProc(t *testing.T) {
+	info := &Info{Pid: 100, Pgid: 90, CmdPgids: []int{200, -1}}
+
+	for _, c := range []struct {
+		pid  int
+		pgid int
+		want bool
+	}{
+		{100, 90, true},
+		{91, 90, false},
+		{201, 200, true},
+		{300, -1, false},
+	} {
+		if got := isInfoProc(info, c.pid, &procSample{Pgid: c.pgid}); got != c.want {
+			t.Errorf("isInfoProc(%d, pgid %d) = %t, want %t", c.pid, c.pgid, got, c.want)
+		}
+	}
+}
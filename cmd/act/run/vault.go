@@ -0,0 +1,156 @@
+/**
+ * This file implements the HashiCorp Vault secret provider. We use
+ * the Vault HTTP API directly (configured with the standard VAULT_ADDR,
+ * VAULT_TOKEN and VAULT_NAMESPACE env vars) so we don't need the
+ * Vault client library. Both KV version 1 and version 2 engines are
+ * supported.
+ */
+
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct implements SecretProvider interface for HashiCorp
+ * Vault. References look like `secret/data/app#password` where the
+ * part before `#` is the secret path and the part after is the key.
+ */
+type VaultSecretProvider struct{}
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Max time we wait for Vault to answer.
+ */
+const vaultTimeout = 10 * time.Second
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the Vault token from env or from the
+ * token file written by `vault login`.
+ */
+func getVaultToken() string {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token
+	}
+
+	homeDir, err := os.UserHomeDir()
+
+	if err != nil {
+		return ""
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(homeDir, ".vault-token"))
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(content))
+}
+
+//############################################################
+// VaultSecretProvider Struct Functions
+//############################################################
+
+/**
+ * This function going to fetch a secret from Vault.
+ */
+func (p *VaultSecretProvider) GetSecret(ref string, actFile *actfile.ActFile) (string, error) {
+	parts := strings.SplitN(ref, "#", 2)
+
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid vault reference %s (expected like secret/data/app#password)", ref)
+	}
+
+	secretPath, key := strings.Trim(parts[0], "/"), parts[1]
+
+	addr := os.Getenv("VAULT_ADDR")
+
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR is not set")
+	}
+
+	token := getVaultToken()
+
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN is not set and no ~/.vault-token file found")
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(addr, "/"), secretPath), nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("X-Vault-Token", token)
+
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	client := http.Client{Timeout: vaultTimeout}
+	resp, err := client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded with status %d for %s", resp.StatusCode, secretPath)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	data := body.Data
+
+	// KV version 2 engines nest secret data in another data field.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := data["metadata"]; hasMetadata {
+			data = nested
+		}
+	}
+
+	val, ok := data[key]
+
+	if !ok {
+		return "", fmt.Errorf("key %s not found in %s", key, secretPath)
+	}
+
+	if str, ok := val.(string); ok {
+		return str, nil
+	}
+
+	// Non string values are given as json.
+	content, _ := json.Marshal(val)
+
+	return string(content), nil
+}
@@ -0,0 +1,139 @@
+/**
+ * This file implements `wait_for` commands which wait for a condition
+ * (tcp port accepting connections, http endpoint answering, file
+ * existing or process running) before moving on to the next command,
+ * replacing the usual `until nc -z ...` shell loops.
+ */
+
+package run
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/nosebit/act/cmd/act/actfile"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Exported Constants
+//############################################################
+
+/**
+ * Default max time we wait for a condition.
+ */
+const DefaultWaitForTimeout = 60 * time.Second
+
+/**
+ * Default interval between attempts to check a condition.
+ */
+const DefaultWaitForInterval = 500 * time.Millisecond
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to describe a wait condition (which we use as
+ * the command line of the wait command).
+ */
+func describeWaitFor(waitFor *actfile.CmdWaitFor) string {
+	switch {
+	case waitFor.Tcp != "":
+		return fmt.Sprintf("wait_for tcp %s", waitFor.Tcp)
+	case waitFor.Http != "":
+		return fmt.Sprintf("wait_for http %s", waitFor.Http)
+	case waitFor.File != "":
+		return fmt.Sprintf("wait_for file %s", waitFor.File)
+	case waitFor.Process != "":
+		return fmt.Sprintf("wait_for process %s", waitFor.Process)
+	}
+
+	return "wait_for"
+}
+
+/**
+ * This function going to check a wait condition once.
+ */
+func waitForProbeExec(waitFor *actfile.CmdWaitFor, timeout time.Duration, baseDir string) error {
+	switch {
+	case waitFor.Tcp != "":
+		return tcpProbeExec(waitFor.Tcp, timeout)
+	case waitFor.Http != "":
+		return httpProbeExec(waitFor.Http, waitFor.Status, timeout)
+	case waitFor.File != "":
+		if !utils.DoFileExists(utils.ResolvePath(baseDir, waitFor.File)) {
+			return fmt.Errorf("file %s does not exist", waitFor.File)
+		}
+
+		return nil
+	case waitFor.Process != "":
+		return processProbeExec(waitFor.Process, baseDir)
+	}
+
+	return errors.New("wait_for has no condition")
+}
+
+/**
+ * This function going to wait for a condition to hold checking it
+ * at regular intervals. It fails when the condition doesn't hold
+ * within the timeout or the act is stopped.
+ */
+func cmdWaitForExec(cmd *actfile.Cmd, ctx *ActRunCtx, vars map[string]string) (string, error) {
+	waitFor := &actfile.CmdWaitFor{
+		Tcp:     utils.CompileTemplate(cmd.WaitFor.Tcp, vars),
+		Http:    utils.CompileTemplate(cmd.WaitFor.Http, vars),
+		Status:  cmd.WaitFor.Status,
+		File:    utils.CompileTemplate(cmd.WaitFor.File, vars),
+		Process: utils.CompileTemplate(cmd.WaitFor.Process, vars),
+	}
+
+	cmdLine := describeWaitFor(waitFor)
+
+	timeout := cmd.WaitFor.Timeout
+
+	if timeout <= 0 {
+		timeout = DefaultWaitForTimeout
+	}
+
+	interval := cmd.WaitFor.Interval
+
+	if interval <= 0 {
+		interval = DefaultWaitForInterval
+	}
+
+	// A single attempt can't take longer than the interval.
+	probeTimeout := DefaultProbeTimeout
+
+	if interval < probeTimeout {
+		probeTimeout = interval
+	}
+
+	baseDir := filepath.Dir(ctx.ActFile.LocationPath)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := waitForProbeExec(waitFor, probeTimeout, baseDir)
+
+		if err == nil {
+			utils.LogDebug(fmt.Sprintf("cmdWaitForExec : condition holds [act=%s]", ctx.Act.Name), cmdLine)
+			return cmdLine, nil
+		}
+
+		if !ctx.CanRun() {
+			return cmdLine, errors.New("act stopped")
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return cmdLine, fmt.Errorf("timed out after %s (%v)", timeout, err)
+		}
+
+		utils.LogDebug(fmt.Sprintf("cmdWaitForExec : condition does not hold yet [act=%s]", ctx.Act.Name), err)
+
+		if !ctx.Sleep(interval) {
+			return cmdLine, errors.New("act stopped")
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/nosebit/act/cmd/act/cmd"
+	"github.com/nosebit/act/cmd/act/utils"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to forward signals used to control running
+ * processes (like reloading config) to the current execution.
+ */
+func scheduleSignalForwarding() {
+	sigs := make(chan os.Signal, 1)
+
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		for sig := range sigs {
+			utils.LogDebug("Received signal to forward", sig)
+
+			cmd.Signal(sig.(syscall.Signal))
+		}
+	}()
+}
+
+/**
+ * This function going to dump the execution state (goroutines, act
+ * call stack and running commands) when we receive SIGQUIT so users
+ * can diagnose hangs without stopping the execution.
+ */
+func scheduleDumpOnQuit() {
+	sigs := make(chan os.Signal, 1)
+
+	signal.Notify(sigs, syscall.SIGQUIT)
+
+	go func() {
+		for range sigs {
+			utils.LogDebug("Received dump signal")
+
+			cmd.Dump()
+		}
+	}()
+}
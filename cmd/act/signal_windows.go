@@ -0,0 +1,17 @@
+package main
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * Windows has no signals to control running processes (like SIGHUP)
+ * so there is nothing to forward.
+ */
+func scheduleSignalForwarding() {}
+
+/**
+ * Windows has no SIGQUIT so we can't dump the execution state on
+ * demand.
+ */
+func scheduleDumpOnQuit() {}
@@ -0,0 +1,36 @@
+/**
+ * This file expose functions to find the act binary so we can spawn
+ * act itself (like when running acts as daemons) without relying on
+ * act being on PATH.
+ */
+
+package utils
+
+import (
+	"os"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to get the path to the act binary we use for
+ * self invocations. User can override it with ACT_BIN env var and
+ * otherwise we use the path of the current executable (so renamed
+ * binaries and multiple installed versions work as expected).
+ */
+func GetActBin() string {
+	if actBin := os.Getenv("ACT_BIN"); actBin != "" {
+		return actBin
+	}
+
+	actBin, err := os.Executable()
+
+	if err != nil {
+		LogDebug("could not get act executable path", err)
+		return "act"
+	}
+
+	return actBin
+}
@@ -0,0 +1,125 @@
+/**
+ * This file expose functions to colorize output. Colors can be
+ * disabled with NO_COLOR env var (see https://no-color.org) or with
+ * the no-color flag.
+ */
+
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+//############################################################
+// Exposed Variables
+//############################################################
+
+/**
+ * Colorizer all output should use so colors can be disabled.
+ */
+var Color = aurora.NewAurora(os.Getenv("NO_COLOR") == "")
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Names of colors we use to colorize texts by key. Red is not in the
+ * palette because we use it for errors.
+ */
+var palette = []string{
+	"yellow",
+	"cyan",
+	"green",
+	"magenta",
+	"blue",
+	"bright-yellow",
+	"bright-cyan",
+	"bright-green",
+	"bright-magenta",
+	"bright-blue",
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get a colorizer function by color name.
+ */
+func getColorizer(name string) func(interface{}) aurora.Value {
+	colorizers := map[string]func(interface{}) aurora.Value{
+		"black":          Color.Black,
+		"red":            Color.Red,
+		"green":          Color.Green,
+		"yellow":         Color.Yellow,
+		"blue":           Color.Blue,
+		"magenta":        Color.Magenta,
+		"cyan":           Color.Cyan,
+		"white":          Color.White,
+		"bright-black":   Color.BrightBlack,
+		"bright-red":     Color.BrightRed,
+		"bright-green":   Color.BrightGreen,
+		"bright-yellow":  Color.BrightYellow,
+		"bright-blue":    Color.BrightBlue,
+		"bright-magenta": Color.BrightMagenta,
+		"bright-cyan":    Color.BrightCyan,
+		"bright-white":   Color.BrightWhite,
+	}
+
+	return colorizers[name]
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to disable colors in all output (including
+ * output of child act processes).
+ */
+func DisableColor() {
+	os.Setenv("NO_COLOR", "1")
+
+	Color = aurora.NewAurora(false)
+
+	// Recreate loggers so their prefixes are not colored.
+	initLoggers()
+	createLoggers()
+}
+
+/**
+ * This function going to set the palette of colors used to colorize
+ * texts by key (like act prefixes).
+ */
+func SetPalette(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	for _, name := range names {
+		if getColorizer(name) == nil {
+			return fmt.Errorf("unknown color %s", name)
+		}
+	}
+
+	palette = names
+
+	return nil
+}
+
+/**
+ * This function going to colorize a text with a color picked from a
+ * palette based on a key (like an act call id) so the same key gets
+ * always the same color.
+ */
+func PaletteColor(key string, x interface{}) aurora.Value {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+
+	return getColorizer(palette[hash.Sum32()%uint32(len(palette))])(x)
+}
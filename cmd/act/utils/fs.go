@@ -5,11 +5,13 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
+	"strings"
 )
 
 //############################################################
@@ -86,7 +88,7 @@ func WriteToFile(filePath string, text string) {
  * This function resolves a path relatively to working dir.
  */
 func ResolvePathFromWd(aPath string) string {
-	return path.Join(GetWd(), aPath)
+	return filepath.Join(GetWd(), aPath)
 }
 
 /**
@@ -100,8 +102,141 @@ func ResolvePath(baseDir string, targetPath string) string {
 	if filepath.IsAbs(targetPath) {
 		thePath = targetPath
 	} else {
-		thePath = path.Join(baseDir, targetPath)
+		thePath = filepath.Join(baseDir, targetPath)
 	}
 
 	return thePath
 }
+
+/**
+ * This function going to check if a path is inside a dir. Symlinks
+ * are resolved first so links pointing outside the dir don't pass.
+ */
+func IsPathInDir(targetPath string, dirPath string) bool {
+	if resolved, err := filepath.EvalSymlinks(targetPath); err == nil {
+		targetPath = resolved
+	}
+
+	if resolved, err := filepath.EvalSymlinks(dirPath); err == nil {
+		dirPath = resolved
+	}
+
+	relPath, err := filepath.Rel(dirPath, targetPath)
+
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
+
+/**
+ * This function going to write a file atomically. We write content
+ * to a temp file in the same dir and then rename it so readers never
+ * see a partially written file (even if we crash mid-write).
+ */
+func WriteFileAtomic(filePath string, content []byte, perm os.FileMode) error {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filePath), fmt.Sprintf(".%s.tmp", filepath.Base(filePath)))
+
+	if err != nil {
+		return err
+	}
+
+	tmpFilePath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFilePath)
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFilePath)
+		return err
+	}
+
+	if err := os.Chmod(tmpFilePath, perm); err != nil {
+		os.Remove(tmpFilePath)
+		return err
+	}
+
+	if err := os.Rename(tmpFilePath, filePath); err != nil {
+		os.Remove(tmpFilePath)
+		return err
+	}
+
+	return nil
+}
+
+/**
+ * This function going to acquire an advisory lock on a lock file
+ * which can be shared (for readers) or exclusive (for writers). It
+ * blocks until the lock is acquired and returns a function to
+ * release it.
+ */
+func LockFile(lockFilePath string, exclusive bool) (func(), error) {
+	return lockFile(lockFilePath, exclusive, true)
+}
+
+/**
+ * This function going to try to acquire an exclusive advisory lock
+ * on a lock file without blocking. It returns syscall.EWOULDBLOCK
+ * error when the lock is held by someone else.
+ */
+func TryLockFile(lockFilePath string) (func(), error) {
+	return lockFile(lockFilePath, true, false)
+}
+
+/**
+ * This function going to read the last n lines of a file. We scan
+ * the file backwards in chunks so we don't need to read big files
+ * entirely.
+ */
+func ReadLastLines(filePath string, n int) ([]string, error) {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	stat, err := file.Stat()
+
+	if err != nil {
+		return nil, err
+	}
+
+	const chunkSize = 4096
+
+	var content []byte
+	pos := stat.Size()
+
+	// We need n+1 line breaks (the last one ends the file).
+	for pos > 0 && bytes.Count(content, []byte("\n")) <= n {
+		size := int64(chunkSize)
+
+		if pos < size {
+			size = pos
+		}
+
+		pos -= size
+		chunk := make([]byte, size)
+
+		if _, err := file.ReadAt(chunk, pos); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		content = append(chunk, content...)
+	}
+
+	text := strings.TrimSuffix(string(content), "\n")
+
+	if text == "" {
+		return nil, nil
+	}
+
+	lines := strings.Split(text, "\n")
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines, nil
+}
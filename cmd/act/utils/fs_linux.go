@@ -0,0 +1,11 @@
+package utils
+
+import "syscall"
+
+/**
+ * This function going to make a file descriptor a copy of another
+ * one (closing it first if needed).
+ */
+func dupFd(oldFd int, newFd int) error {
+	return syscall.Dup3(oldFd, newFd, 0)
+}
@@ -0,0 +1,99 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to acquire a flock on a lock file.
+ */
+func lockFile(lockFilePath string, exclusive bool, block bool) (func(), error) {
+	how := syscall.LOCK_SH
+
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	if !block {
+		how |= syscall.LOCK_NB
+	}
+
+	file, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), how); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to redirect stdout and stderr of current
+ * process to a file.
+ */
+func RedirectStdio(file *os.File) error {
+	if err := dupFd(int(file.Fd()), int(os.Stdout.Fd())); err != nil {
+		return err
+	}
+
+	return dupFd(int(file.Fd()), int(os.Stderr.Fd()))
+}
+
+/**
+ * This function going to create a named pipe.
+ */
+func Mkfifo(fifoPath string, mode uint32) error {
+	return syscall.Mkfifo(fifoPath, mode)
+}
+
+/**
+ * This function going to create a dir only current user can access
+ * (like in shared temp dirs). An existing dir must be a real dir we
+ * own which nobody else can access, otherwise other users could read
+ * or plant files in there.
+ */
+func MakePrivateDir(dirPath string) error {
+	if err := os.MkdirAll(dirPath, 0700); err != nil {
+		return err
+	}
+
+	stat, err := os.Lstat(dirPath)
+
+	if err != nil {
+		return err
+	}
+
+	if !stat.IsDir() {
+		return fmt.Errorf("%s is not a directory", dirPath)
+	}
+
+	if sysStat, ok := stat.Sys().(*syscall.Stat_t); !ok || int(sysStat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is not owned by current user", dirPath)
+	}
+
+	if stat.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s can be accessed by other users", dirPath)
+	}
+
+	return nil
+}
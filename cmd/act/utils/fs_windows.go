@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to acquire a lock on a lock file. We lock the
+ * first byte of the file which is enough since all act processes
+ * lock the same way.
+ */
+func lockFile(lockFilePath string, exclusive bool, block bool) (func(), error) {
+	var flags uint32
+
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+
+	if !block {
+		flags |= windows.LOCKFILE_FAIL_IMMEDIATELY
+	}
+
+	file, err := os.OpenFile(lockFilePath, os.O_CREATE|os.O_RDWR, 0644)
+
+	if err != nil {
+		return nil, err
+	}
+
+	handle := windows.Handle(file.Fd())
+	overlapped := &windows.Overlapped{}
+
+	if err := windows.LockFileEx(handle, flags, 0, 1, 0, overlapped); err != nil {
+		file.Close()
+
+		// Callers check for EWOULDBLOCK the same way in all platforms.
+		if err == windows.ERROR_LOCK_VIOLATION {
+			return nil, syscall.EWOULDBLOCK
+		}
+
+		return nil, err
+	}
+
+	return func() {
+		windows.UnlockFileEx(handle, 0, 1, 0, overlapped)
+		file.Close()
+	}, nil
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to redirect stdout and stderr of current
+ * process to a file.
+ */
+func RedirectStdio(file *os.File) error {
+	if err := windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(file.Fd())); err != nil {
+		return err
+	}
+
+	if err := windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(file.Fd())); err != nil {
+		return err
+	}
+
+	os.Stdout = file
+	os.Stderr = file
+
+	return nil
+}
+
+/**
+ * Named pipes (fifos) are not supported on windows.
+ */
+func Mkfifo(fifoPath string, mode uint32) error {
+	return errors.New("fifos are not supported on windows")
+}
+
+/**
+ * This function going to create a dir only current user can access.
+ * Windows temp dirs are already per user so we only check we got a
+ * real dir.
+ */
+func MakePrivateDir(dirPath string) error {
+	if err := os.MkdirAll(dirPath, 0700); err != nil {
+		return err
+	}
+
+	stat, err := os.Lstat(dirPath)
+
+	if err != nil {
+		return err
+	}
+
+	if !stat.IsDir() {
+		return fmt.Errorf("%s is not a directory", dirPath)
+	}
+
+	return nil
+}
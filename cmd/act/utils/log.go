@@ -4,9 +4,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"sync"
 	"syscall"
+)
 
-	"github.com/logrusorgru/aurora/v3"
+//############################################################
+// Exposed Constants
+//############################################################
+
+/**
+ * Verbosity levels. Quiet suppresses info logs, verbose logs each
+ * command before running it and debug logs everything.
+ */
+const (
+	VerbosityQuiet   = -1
+	VerbosityNormal  = 0
+	VerbosityVerbose = 1
+	VerbosityDebug   = 2
 )
 
 //############################################################
@@ -18,14 +33,32 @@ var (
 	errorLogger *log.Logger
 	debugLogger *log.Logger
 	infoLogger  *log.Logger
+	warnLogger  *log.Logger
 )
 
+/**
+ * Loggers are created on first use so commands which never log
+ * don't pay for it.
+ */
+var loggersOnce sync.Once
+
+/**
+ * Flag indicating debug logs were enabled through ACT_DEBUG env var.
+ */
+var debugEnv bool
+
 //############################################################
 // Exposed Variables
 //############################################################
 var ExitCode int = 0
 var KillInProgress bool
 
+/**
+ * Current verbosity level. It's inherited by child act processes
+ * through ACT_VERBOSITY env var.
+ */
+var Verbosity int = VerbosityNormal
+
 //############################################################
 // Internal Functions
 //############################################################
@@ -39,10 +72,29 @@ func exitGracefully() {
 	}
 
 	KillInProgress = true
-	pid := os.Getegid()
 
-	// Send kill signal.
-	syscall.Kill(pid, syscall.SIGQUIT)
+	/**
+	 * Send terminate signal. We don't use SIGQUIT here because it's
+	 * reserved to dump the execution state.
+	 */
+	signalSelf(syscall.SIGTERM)
+}
+
+/**
+ * This function going to make sure custom loggers were created.
+ */
+func initLoggers() {
+	loggersOnce.Do(createLoggers)
+}
+
+/**
+ * This function going to create all custom loggers.
+ */
+func createLoggers() {
+	errorLogger = log.New(os.Stderr, fmt.Sprintf("%s", Color.Red("[ERROR] ").Bold()), log.Ldate|log.Ltime)
+	debugLogger = log.New(os.Stdout, fmt.Sprintf("%s", Color.Gray(8-1, "[DEBUG] ").Bold()), log.Ldate|log.Ltime|log.Lshortfile)
+	infoLogger = log.New(os.Stdout, fmt.Sprintf("%s", Color.Cyan("[INFO] ").Bold()), log.Ldate|log.Ltime)
+	warnLogger = log.New(os.Stderr, fmt.Sprintf("%s", Color.Yellow("[WARN] ").Bold()), log.Ldate|log.Ltime)
 }
 
 //############################################################
@@ -56,11 +108,21 @@ func SetSupressErrors() {
 	supressErrors = true
 }
 
+/**
+ * This function going to set the verbosity level of this process and
+ * of child act processes.
+ */
+func SetVerbosity(level int) {
+	Verbosity = level
+	os.Setenv("ACT_VERBOSITY", strconv.Itoa(level))
+}
+
 /**
  * This function going to log an error.
  */
 func LogError(args ...interface{}) {
 	if !supressErrors {
+		initLoggers()
 		errorLogger.Println(args...)
 	}
 }
@@ -69,7 +131,8 @@ func LogError(args ...interface{}) {
  * This function log debug messages.
  */
 func LogDebug(args ...interface{}) {
-	if _, present := os.LookupEnv("ACT_DEBUG"); present {
+	if debugEnv || Verbosity >= VerbosityDebug {
+		initLoggers()
 		debugLogger.Println(args...)
 	}
 }
@@ -78,7 +141,28 @@ func LogDebug(args ...interface{}) {
  * This function going to log an info message.
  */
 func LogInfo(args ...interface{}) {
-	infoLogger.Println(args...)
+	if Verbosity > VerbosityQuiet {
+		initLoggers()
+		infoLogger.Println(args...)
+	}
+}
+
+/**
+ * This function going to log an info message only in verbose mode.
+ */
+func LogVerbose(args ...interface{}) {
+	if Verbosity >= VerbosityVerbose {
+		initLoggers()
+		infoLogger.Println(args...)
+	}
+}
+
+/**
+ * This function going to log a warning message.
+ */
+func LogWarn(args ...interface{}) {
+	initLoggers()
+	warnLogger.Println(args...)
 }
 
 /**
@@ -108,10 +192,13 @@ func FatalErrorWithCode(code int, args ...interface{}) {
 //############################################################
 
 /**
- * On init we going to create all custom loggers.
+ * On init we going to read log settings from env vars (loggers are
+ * created lazily).
  */
 func init() {
-	errorLogger = log.New(os.Stderr, fmt.Sprintf("%s", aurora.Red("[ERROR] ").Bold()), log.Ldate|log.Ltime)
-	debugLogger = log.New(os.Stdout, fmt.Sprintf("%s", aurora.Gray(8-1, "[DEBUG] ").Bold()), log.Ldate|log.Ltime|log.Lshortfile)
-	infoLogger = log.New(os.Stdout, fmt.Sprintf("%s", aurora.Cyan("[INFO] ").Bold()), log.Ldate|log.Ltime)
+	if level, err := strconv.Atoi(os.Getenv("ACT_VERBOSITY")); err == nil {
+		Verbosity = level
+	}
+
+	_, debugEnv = os.LookupEnv("ACT_DEBUG")
 }
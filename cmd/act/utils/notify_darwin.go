@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/**
+ * This function going to quote a string as an AppleScript string.
+ */
+func quoteAppleScript(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(text, `"`, `\"`))
+}
+
+/**
+ * This function going to show a native desktop notification (using
+ * osascript).
+ */
+func DesktopNotify(title string, message string) error {
+	script := fmt.Sprintf("display notification %s with title %s", quoteAppleScript(message), quoteAppleScript(title))
+
+	return exec.Command("osascript", "-e", script).Run()
+}
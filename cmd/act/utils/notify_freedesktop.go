@@ -0,0 +1,13 @@
+// +build linux freebsd openbsd netbsd dragonfly
+
+package utils
+
+import "os/exec"
+
+/**
+ * This function going to show a native desktop notification (using
+ * notify-send).
+ */
+func DesktopNotify(title string, message string) error {
+	return exec.Command("notify-send", "-a", "act", title, message).Run()
+}
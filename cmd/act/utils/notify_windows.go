@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/**
+ * This function going to quote a string as a PowerShell string.
+ */
+func quotePowerShell(text string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(text, "'", "''"))
+}
+
+/**
+ * This function going to show a native desktop notification (a
+ * balloon tip shown with PowerShell).
+ */
+func DesktopNotify(title string, message string) error {
+	script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, %s, %s, [System.Windows.Forms.ToolTipIcon]::None)
+Start-Sleep -Seconds 5
+$n.Dispose()`, quotePowerShell(title), quotePowerShell(message))
+
+	return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script).Run()
+}
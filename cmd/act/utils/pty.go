@@ -0,0 +1,144 @@
+// +build linux darwin
+
+/**
+ * This file expose functions to allocate pseudo-terminals so
+ * commands which behave differently when not attached to a terminal
+ * (like dropping colors or progress bars) can run as if they were.
+ * Pseudo-terminals are not supported on windows (see pty_windows.go).
+ */
+
+package utils
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * How long (in milliseconds) we wait input before checking if we
+ * should stop copying input to a pseudo-terminal.
+ */
+const ptyInputPollTimeout = 50
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold terminal window size.
+ */
+type winsize struct {
+	Rows   uint16
+	Cols   uint16
+	Xpixel uint16
+	Ypixel uint16
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to run an ioctl request on a file descriptor.
+ */
+func ioctl(fd uintptr, req uintptr, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, arg); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to allocate a pseudo-terminal returning its
+ * master side (which we read from and write to) and its slave side
+ * (which we give to the command as stdin, stdout and stderr).
+ */
+func OpenPty() (*os.File, *os.File, error) {
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ptsName, err := unlockPty(ptm)
+
+	if err != nil {
+		ptm.Close()
+		return nil, nil, err
+	}
+
+	pts, err := os.OpenFile(ptsName, os.O_RDWR|syscall.O_NOCTTY, 0)
+
+	if err != nil {
+		ptm.Close()
+		return nil, nil, err
+	}
+
+	return ptm, pts, nil
+}
+
+/**
+ * This function going to copy input to a pseudo-terminal until done
+ * channel gets closed. We wait input with poll (instead of blocking
+ * on read) so the copy stops as soon as the command exits and input
+ * meant for next commands is never consumed here.
+ */
+func CopyPtyInput(ptm *os.File, input *os.File, done <-chan bool) {
+	fd := int(input.Fd())
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	buf := make([]byte, 4096)
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		ready, err := unix.Poll(fds, ptyInputPollTimeout)
+
+		if err == unix.EINTR || (err == nil && ready == 0) {
+			continue
+		} else if err != nil {
+			return
+		}
+
+		count, err := syscall.Read(fd, buf)
+
+		if err == syscall.EINTR || err == syscall.EAGAIN {
+			continue
+		} else if err != nil || count <= 0 {
+			return
+		}
+
+		if _, err := ptm.Write(buf[:count]); err != nil {
+			return
+		}
+	}
+}
+
+/**
+ * This function going to copy the window size of a terminal to a
+ * pseudo-terminal (if the source is a terminal at all).
+ */
+func CopyWinsize(fromFd int, pty *os.File) {
+	size := &winsize{}
+
+	if err := ioctl(uintptr(fromFd), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(size))); err != nil {
+		return
+	}
+
+	ioctl(pty.Fd(), syscall.TIOCSWINSZ, uintptr(unsafe.Pointer(size)))
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+/**
+ * This function going to unlock the slave side of a pseudo-terminal
+ * and get its path.
+ */
+func unlockPty(ptm *os.File) (string, error) {
+	if err := ioctl(ptm.Fd(), syscall.TIOCPTYGRANT, 0); err != nil {
+		return "", err
+	}
+
+	if err := ioctl(ptm.Fd(), syscall.TIOCPTYUNLK, 0); err != nil {
+		return "", err
+	}
+
+	// Name buffer size is defined by the ioctl request (128 bytes).
+	name := make([]byte, 128)
+
+	if err := ioctl(ptm.Fd(), syscall.TIOCPTYGNAME, uintptr(unsafe.Pointer(&name[0]))); err != nil {
+		return "", err
+	}
+
+	if idx := bytes.IndexByte(name, 0); idx >= 0 {
+		name = name[:idx]
+	}
+
+	return string(name), nil
+}
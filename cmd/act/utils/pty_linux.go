@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+/**
+ * This function going to unlock the slave side of a pseudo-terminal
+ * and get its path.
+ */
+func unlockPty(ptm *os.File) (string, error) {
+	var unlock int32
+
+	if err := ioctl(ptm.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		return "", err
+	}
+
+	var ptsNum uint32
+
+	if err := ioctl(ptm.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&ptsNum))); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("/dev/pts/%d", ptsNum), nil
+}
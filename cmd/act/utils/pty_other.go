@@ -0,0 +1,32 @@
+// +build !linux,!darwin,!windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * Pseudo-terminals are only supported on linux and macOS so commands
+ * asking for a tty fallback to regular pipes on other systems.
+ */
+func OpenPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported on this system")
+}
+
+/**
+ * Commands never get a pseudo-terminal on this system so there is
+ * no input to copy.
+ */
+func CopyPtyInput(ptm *os.File, input *os.File, done <-chan bool) {}
+
+/**
+ * This function does nothing since we have no pseudo-terminals on
+ * this system.
+ */
+func CopyWinsize(fromFd int, pty *os.File) {}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"errors"
+	"os"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * Pseudo-terminals are not supported on windows so commands asking
+ * for a tty fallback to regular pipes.
+ */
+func OpenPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("pseudo-terminals are not supported on windows")
+}
+
+/**
+ * Commands never get a pseudo-terminal on windows so there is no
+ * input to copy.
+ */
+func CopyPtyInput(ptm *os.File, input *os.File, done <-chan bool) {}
+
+/**
+ * This function does nothing on windows since we have no
+ * pseudo-terminals.
+ */
+func CopyWinsize(fromFd int, pty *os.File) {}
@@ -0,0 +1,15 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package utils
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to get the shell we use to run commands when
+ * none is set.
+ */
+func GetDefaultShell() string {
+	return "bash"
+}
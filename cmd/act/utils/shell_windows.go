@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"os/exec"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to get the shell we use to run commands when
+ * none is set. We prefer powershell and fallback to cmd.
+ */
+func GetDefaultShell() string {
+	if _, err := exec.LookPath("powershell"); err == nil {
+		return "powershell"
+	}
+
+	return "cmd"
+}
@@ -0,0 +1,37 @@
+/**
+ * This file expose functions to handle process signals. Signals
+ * available depend on the platform (see signal_unix.go and
+ * signal_windows.go).
+ */
+
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to parse a signal provided by the user. The
+ * signal can be specified by name (like `SIGHUP` or `HUP`) or by
+ * number (like `1`).
+ */
+func ParseSignal(name string) (syscall.Signal, error) {
+	if num, err := strconv.Atoi(name); err == nil {
+		return syscall.Signal(num), nil
+	}
+
+	key := strings.TrimPrefix(strings.ToUpper(name), "SIG")
+
+	if sig, present := signalsByName[key]; present {
+		return sig, nil
+	}
+
+	return 0, fmt.Errorf("unknown signal %s", name)
+}
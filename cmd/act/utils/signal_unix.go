@@ -0,0 +1,63 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+//############################################################
+// Exposed Constants
+//############################################################
+
+/**
+ * Signals to pause and resume processes.
+ */
+const (
+	SIGCONT = syscall.SIGCONT
+	SIGSTOP = syscall.SIGSTOP
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Signals users can refer to by name.
+ */
+var signalsByName = map[string]syscall.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"KILL":  syscall.SIGKILL,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"TERM":  syscall.SIGTERM,
+	"CONT":  syscall.SIGCONT,
+	"STOP":  syscall.SIGSTOP,
+	"TSTP":  syscall.SIGTSTP,
+	"WINCH": syscall.SIGWINCH,
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to send a signal to current process.
+ */
+func signalSelf(sig syscall.Signal) {
+	syscall.Kill(os.Getpid(), sig)
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to register a channel to receive signals we
+ * send to ourselves. On unix these signals are delivered by the
+ * system (use signal.Notify) so this does nothing.
+ */
+func NotifySelf(c chan<- os.Signal) {}
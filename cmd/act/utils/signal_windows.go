@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+//############################################################
+// Exposed Constants
+//############################################################
+
+/**
+ * Signals to pause and resume processes. Windows can't pause
+ * processes so we use the same numbers as linux only to tell them
+ * apart (sending them fails).
+ */
+const (
+	SIGCONT = syscall.Signal(0x12)
+	SIGSTOP = syscall.Signal(0x13)
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Signals users can refer to by name.
+ */
+var signalsByName = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"CONT": SIGCONT,
+	"STOP": SIGSTOP,
+}
+
+/**
+ * Channels registered to receive signals we send to ourselves.
+ */
+var selfSignalChans []chan<- os.Signal
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to send a signal to current process. Windows
+ * can't signal processes so we deliver it to registered channels.
+ */
+func signalSelf(sig syscall.Signal) {
+	for _, c := range selfSignalChans {
+		select {
+		case c <- sig:
+		default:
+		}
+	}
+}
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to register a channel to receive signals we
+ * send to ourselves (like when exiting on fatal errors).
+ */
+func NotifySelf(c chan<- os.Signal) {
+	selfSignalChans = append(selfSignalChans, c)
+}
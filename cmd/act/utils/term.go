@@ -0,0 +1,167 @@
+/**
+ * This file expose functions to handle terminal settings. How we get
+ * and change terminal settings depends on the platform (see
+ * term_unix.go and term_windows.go).
+ */
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Terminal settings saved at startup.
+ */
+var savedTermState *TermState
+
+/**
+ * Flags indicating we changed terminal state that needs to be
+ * restored on exit.
+ */
+var cursorHidden bool
+var inAltScreen bool
+
+/**
+ * Mutex to prevent concurrent changes of terminal state.
+ */
+var termMutex sync.Mutex
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to check if a file descriptor is a terminal.
+ */
+func IsTerminal(fd int) bool {
+	_, err := getTermState(fd)
+	return err == nil
+}
+
+/**
+ * This function going to put the terminal in a mode where input is
+ * available byte by byte (no line buffering and no flow control) so
+ * we can handle key sequences like Ctrl+P Ctrl+Q. Output processing
+ * and signal keys (like Ctrl+C) are kept. It returns the previous
+ * terminal settings so they can be restored.
+ */
+func MakeInputRaw(fd int) (*TermState, error) {
+	oldState, err := getTermState(fd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setTermState(fd, makeInputRawState(oldState)); err != nil {
+		return nil, err
+	}
+
+	return oldState, nil
+}
+
+/**
+ * This function going to put the terminal in input raw mode (see
+ * MakeInputRaw) without echo while we forward input to a
+ * pseudo-terminal (which echoes input by itself). It returns the
+ * previous terminal settings so they can be restored.
+ */
+func MakePtyInputRaw(fd int) (*TermState, error) {
+	oldState, err := getTermState(fd)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setTermState(fd, makeNoEchoState(makeInputRawState(oldState))); err != nil {
+		return nil, err
+	}
+
+	return oldState, nil
+}
+
+/**
+ * This function going to restore terminal settings.
+ */
+func RestoreTerm(fd int, state *TermState) error {
+	return setTermState(fd, state)
+}
+
+/**
+ * This function going to save the current terminal state so it can
+ * be restored on exit (no matter how we exit).
+ */
+func SaveTermState() {
+	termMutex.Lock()
+	defer termMutex.Unlock()
+
+	if state, err := getTermState(int(os.Stdin.Fd())); err == nil {
+		savedTermState = state
+	}
+}
+
+/**
+ * This function going to restore the terminal state saved at startup
+ * (terminal settings, cursor visibility and alternate screen).
+ */
+func RestoreTermState() {
+	termMutex.Lock()
+	defer termMutex.Unlock()
+
+	if inAltScreen {
+		fmt.Fprint(os.Stdout, "\033[?1049l")
+		inAltScreen = false
+	}
+
+	if cursorHidden {
+		fmt.Fprint(os.Stdout, "\033[?25h")
+		cursorHidden = false
+	}
+
+	if savedTermState != nil {
+		setTermState(int(os.Stdin.Fd()), savedTermState)
+	}
+}
+
+/**
+ * This function going to restore the terminal when the goroutine
+ * deferring it panics (and then keep panicking). A panic in any
+ * goroutine crashes the process without running deferred functions
+ * of other goroutines so goroutines touching the terminal (or
+ * writing output) must defer it themselves.
+ */
+func RestoreTermOnPanic() {
+	if err := recover(); err != nil {
+		RestoreTermState()
+		panic(err)
+	}
+}
+
+/**
+ * This function going to hide the terminal cursor.
+ */
+func HideCursor() {
+	termMutex.Lock()
+	defer termMutex.Unlock()
+
+	fmt.Fprint(os.Stdout, "\033[?25l")
+	cursorHidden = true
+}
+
+/**
+ * This function going to switch to the terminal alternate screen so
+ * full screen output doesn't mess with user terminal history.
+ */
+func EnterAltScreen() {
+	termMutex.Lock()
+	defer termMutex.Unlock()
+
+	fmt.Fprint(os.Stdout, "\033[?1049h")
+	inAltScreen = true
+}
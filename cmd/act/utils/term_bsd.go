@@ -0,0 +1,11 @@
+// +build darwin freebsd openbsd netbsd dragonfly
+
+package utils
+
+import "syscall"
+
+/**
+ * Ioctl requests to get/set terminal settings on macOS and BSDs.
+ */
+const ioctlGetTermios = syscall.TIOCGETA
+const ioctlSetTermios = syscall.TIOCSETA
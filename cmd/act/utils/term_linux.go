@@ -0,0 +1,9 @@
+package utils
+
+import "syscall"
+
+/**
+ * Ioctl requests to get/set terminal settings on linux.
+ */
+const ioctlGetTermios = syscall.TCGETS
+const ioctlSetTermios = syscall.TCSETS
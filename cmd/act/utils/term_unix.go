@@ -0,0 +1,75 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold terminal settings.
+ */
+type TermState struct {
+	termios syscall.Termios
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the terminal settings of a file
+ * descriptor.
+ */
+func getTermState(fd int) (*TermState, error) {
+	state := &TermState{}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlGetTermios, uintptr(unsafe.Pointer(&state.termios))); errno != 0 {
+		return nil, errno
+	}
+
+	return state, nil
+}
+
+/**
+ * This function going to set the terminal settings of a file
+ * descriptor.
+ */
+func setTermState(fd int, state *TermState) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), ioctlSetTermios, uintptr(unsafe.Pointer(&state.termios))); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+/**
+ * This function going to get terminal settings where input is
+ * available byte by byte (no line buffering and no flow control).
+ * Output processing and signal keys (like Ctrl+C) are kept.
+ */
+func makeInputRawState(state *TermState) *TermState {
+	rawState := *state
+	rawState.termios.Lflag &^= syscall.ICANON
+	rawState.termios.Iflag &^= syscall.IXON
+	rawState.termios.Cc[syscall.VMIN] = 1
+	rawState.termios.Cc[syscall.VTIME] = 0
+
+	return &rawState
+}
+
+/**
+ * This function going to get terminal settings where input is not
+ * echoed.
+ */
+func makeNoEchoState(state *TermState) *TermState {
+	noEchoState := *state
+	noEchoState.termios.Lflag &^= syscall.ECHO | syscall.ECHONL
+
+	return &noEchoState
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold terminal (console) settings.
+ */
+type TermState struct {
+	mode uint32
+}
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the console mode of a file descriptor.
+ */
+func getTermState(fd int) (*TermState, error) {
+	state := &TermState{}
+
+	if err := windows.GetConsoleMode(windows.Handle(fd), &state.mode); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+/**
+ * This function going to set the console mode of a file descriptor.
+ */
+func setTermState(fd int, state *TermState) error {
+	return windows.SetConsoleMode(windows.Handle(fd), state.mode)
+}
+
+/**
+ * This function going to get console mode where input is available
+ * byte by byte. Windows only echoes input in line mode so we disable
+ * echo as well.
+ */
+func makeInputRawState(state *TermState) *TermState {
+	return &TermState{mode: state.mode &^ (windows.ENABLE_LINE_INPUT | windows.ENABLE_ECHO_INPUT)}
+}
+
+/**
+ * This function going to get console mode where input is not echoed.
+ */
+func makeNoEchoState(state *TermState) *TermState {
+	return &TermState{mode: state.mode &^ windows.ENABLE_ECHO_INPUT}
+}
@@ -2,7 +2,9 @@ package utils
 
 import (
 	"bytes"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -12,6 +14,18 @@ import (
 //############################################################
 var matchFirstCap = regexp.MustCompile("(.)([A-Z][a-z]+)")
 var matchAllCap = regexp.MustCompile("([a-z0-9])([A-Z])")
+var matchByteSize = regexp.MustCompile(`^\s*([0-9.]+)\s*([KMGT]?)(I?B)?\s*$`)
+
+/**
+ * Multipliers of byte size units.
+ */
+var byteUnits = map[string]float64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
 
 //############################################################
 // Exposed Functions
@@ -46,3 +60,31 @@ func CompileTemplate(text string, vars map[string]string) string {
 
 	return buff.String()
 }
+
+/**
+ * This function going to parse a human readable byte size (like
+ * `50MB` or `1G`) to number of bytes.
+ */
+func ParseBytes(str string) (int64, error) {
+	match := matchByteSize.FindStringSubmatch(strings.ToUpper(str))
+
+	if match == nil {
+		return 0, fmt.Errorf("invalid size %s", str)
+	}
+
+	value, err := strconv.ParseFloat(match[1], 64)
+
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %s", str)
+	}
+
+	return int64(value * byteUnits[match[2]]), nil
+}
+
+/**
+ * This function going to quote a text so a posix shell takes it as
+ * a single word.
+ */
+func ShellQuote(text string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(text, "'", `'\''`))
+}
@@ -0,0 +1,109 @@
+/**
+ * This file implements a structured trace emitter. When ACT_TRACE
+ * env var is set to `jsonl` we going to emit one json object per
+ * line describing execution events (act/stage/command start and end)
+ * so tooling and bug reports can analyze an execution. Traces are
+ * written to stderr or to the file pointed by ACT_TRACE_FILE.
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * Extra fields of a trace event.
+ */
+type TraceFields map[string]interface{}
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Where trace events going to be written (nil when tracing is
+ * disabled).
+ */
+var traceWriter io.Writer
+
+/**
+ * Mutex to prevent events emitted by parallel commands from being
+ * interleaved.
+ */
+var traceMutex sync.Mutex
+
+//############################################################
+// Exposed Functions
+//############################################################
+
+/**
+ * This function going to check if structured tracing is enabled.
+ */
+func IsTraceEnabled() bool {
+	return traceWriter != nil
+}
+
+/**
+ * This function going to emit a trace event.
+ */
+func Trace(event string, fields TraceFields) {
+	if traceWriter == nil {
+		return
+	}
+
+	entry := TraceFields{
+		"ts":    time.Now().Format(time.RFC3339Nano),
+		"event": event,
+		"pid":   os.Getpid(),
+	}
+
+	for key, val := range fields {
+		entry[key] = val
+	}
+
+	content, err := json.Marshal(entry)
+
+	if err != nil {
+		LogDebug("Trace : could not marshal event", event, err)
+		return
+	}
+
+	traceMutex.Lock()
+	traceWriter.Write(append(content, '\n'))
+	traceMutex.Unlock()
+}
+
+//############################################################
+// Lifecycle Functions
+//############################################################
+
+/**
+ * On init we going to setup the trace writer if tracing is enabled.
+ */
+func init() {
+	if os.Getenv("ACT_TRACE") != "jsonl" {
+		return
+	}
+
+	traceWriter = os.Stderr
+
+	if filePath := os.Getenv("ACT_TRACE_FILE"); filePath != "" {
+		file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+
+		if err != nil {
+			LogError("could not open trace file", err)
+			return
+		}
+
+		traceWriter = file
+	}
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -19,28 +20,52 @@ var cmd *exec.Cmd
  * be actfile/actfile.go.
  */
 
-func scheduleQuitCleanup() {
+/**
+ * This function going to forward quit signals to the act process so
+ * it can gracefully stop (running final commands) before we exit.
+ * The act process runs in our process group so signals sent by the
+ * terminal (like Ctrl+C) reach it as well which is fine because act
+ * handles repeated stop signals.
+ */
+func scheduleSignalForwarding() {
 	sigs := make(chan os.Signal, 1)
 
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
 	/**
-	 * Run our cleanup function as a go routine (i.e., in parallel) so
-	 * we don't block the main execution since we need to wait for
-	 * a quit event to do the cleanup job.
+	 * Run our forwarding function as a go routine (i.e., in parallel)
+	 * so we don't block the main execution.
 	 */
-	 go func() {
-		/**
-		 * This going to block the execution until sigs channel
-		 * receive a quit signal.
-		 */
-		<-sigs
-
-		// Wait command to gracefully exit.
-		cmd.Wait()
+	go func() {
+		for sig := range sigs {
+			cmd.Process.Signal(sig)
+		}
 	}()
 }
 
+/**
+ * This function going to get the exit code of the act process. When
+ * act was killed by a signal we follow the shell convention and use
+ * 128 + signal number.
+ */
+func getExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+
+	if !ok {
+		return 1
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return exitErr.ExitCode()
+}
+
 //############################################################
 // Main Entrypoint
 //############################################################
@@ -55,10 +80,17 @@ func main() {
 
 	args = append(args, os.Args[1:]...)
 
-	// Command to spawn.
-	cmd = exec.Command("act", args...)
+	/**
+	 * Command to spawn. User can point to a specific act binary with
+	 * ACT_BIN env var (otherwise we look up act in PATH).
+	 */
+	actBin := os.Getenv("ACT_BIN")
 
-	scheduleQuitCleanup()
+	if actBin == "" {
+		actBin = "act"
+	}
+
+	cmd = exec.Command(actBin, args...)
 
 	// Set all env vars to shell command.
 	cmd.Env = os.Environ()
@@ -68,5 +100,13 @@ func main() {
 	cmd.Stdin = os.Stdin
 
 	// Start and wait
-	cmd.Run()
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "could not run %s: %s\n", actBin, err)
+		os.Exit(127)
+	}
+
+	scheduleSignalForwarding()
+
+	// Exit with the same code as act so actr can be chained.
+	os.Exit(getExitCode(cmd.Wait()))
 }
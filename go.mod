@@ -3,6 +3,11 @@ module github.com/nosebit/act
 go 1.16
 
 require (
+	github.com/aws/aws-sdk-go-v2 v1.26.1
+	github.com/aws/aws-sdk-go-v2/config v1.27.9
+	github.com/aws/aws-sdk-go-v2/service/secretsmanager v1.28.6
+	github.com/aws/aws-sdk-go-v2/service/ssm v1.44.7
+	github.com/charmbracelet/bubbletea v0.20.0
 	github.com/fatih/color v1.12.0 // indirect
 	github.com/gookit/color v1.4.2 // indirect
 	github.com/hpcloud/tail v1.0.0
@@ -13,8 +18,10 @@ require (
 	github.com/mitchellh/mapstructure v1.4.1 // indirect
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/teris-io/shortid v0.0.0-20201117134242-e59966efd125
-	golang.org/x/sys v0.0.0-20210616094352-59db8d763f22 // indirect
+	go.uber.org/goleak v1.1.12
+	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c
 	gopkg.in/fsnotify.v1 v1.4.7 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+	mvdan.cc/sh/v3 v3.3.1
 )
@@ -0,0 +1,74 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package procgroup
+
+import (
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to set process attributes so the process runs
+ * as a user (name or uid) with its primary and supplementary groups.
+ */
+func SetUser(attr *syscall.SysProcAttr, userName string) error {
+	u, err := user.Lookup(userName)
+
+	if err != nil {
+		if u, err = user.LookupId(userName); err != nil {
+			return err
+		}
+	}
+
+	uid, _ := strconv.ParseUint(u.Uid, 10, 32)
+	gid, _ := strconv.ParseUint(u.Gid, 10, 32)
+
+	var groups []uint32
+
+	if groupIds, err := u.GroupIds(); err == nil {
+		for _, groupId := range groupIds {
+			if val, err := strconv.ParseUint(groupId, 10, 32); err == nil {
+				groups = append(groups, uint32(val))
+			}
+		}
+	}
+
+	attr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}
+
+	return nil
+}
+
+/**
+ * This function going to make a process start with a umask. Umask is
+ * process wide and Go gives us no hook to run between fork and exec
+ * so instead of changing ours (which other goroutines creating files
+ * would get) we start the process through a shell which sets the
+ * umask and then execs the process.
+ */
+func SetUmask(cmd *exec.Cmd, umask int) error {
+	shPath, err := exec.LookPath("sh")
+
+	if err != nil {
+		return err
+	}
+
+	cmd.Args = append([]string{"sh", "-c", `umask "$1" && shift && exec "$@"`, "sh", fmt.Sprintf("%03o", umask), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = shPath
+
+	return nil
+}
+
+/**
+ * This function going to set the nice level of all processes of a
+ * process group (processes spawned later inherit it).
+ */
+func SetNice(pgid int, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PGRP, pgid, nice)
+}
@@ -0,0 +1,32 @@
+package procgroup
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * Running processes as other users is not supported on windows.
+ */
+func SetUser(attr *syscall.SysProcAttr, userName string) error {
+	return errors.New("running commands as other users is not supported on windows")
+}
+
+/**
+ * Windows has no umask so we leave the process as is.
+ */
+func SetUmask(cmd *exec.Cmd, umask int) error {
+	return nil
+}
+
+/**
+ * Nice levels are not supported on windows.
+ */
+func SetNice(pgid int, nice int) error {
+	return errors.New("nice levels are not supported on windows")
+}
@@ -0,0 +1,329 @@
+package procgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Mount point of the cgroups v2 unified hierarchy.
+ */
+const cgroupRoot = "/sys/fs/cgroup"
+
+/**
+ * Cpu period (in microseconds) we use to set cpu quotas.
+ */
+const cgroupCpuPeriod = 100000
+
+/**
+ * Name of the leaf cgroup we move processes of our own cgroup into so
+ * we can enable controllers for cgroups we create.
+ */
+const cgroupSelfName = "act-self"
+
+/**
+ * How long we wait for processes of a cgroup to die when removing it.
+ */
+const cgroupKillTimeout = 5 * time.Second
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to get the cgroup path of this process.
+ */
+func getOwnCgroupPath() (string, error) {
+	content, err := ioutil.ReadFile("/proc/self/cgroup")
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			return filepath.Join(cgroupRoot, strings.TrimPrefix(line, "0::")), nil
+		}
+	}
+
+	return "", fmt.Errorf("cgroups v2 not available")
+}
+
+/**
+ * This function going to get the cgroup we create cgroups under with
+ * cpu, memory and pids controllers enabled. Cgroups v2 don't allow
+ * enabling controllers for children of a cgroup having processes (the
+ * no internal processes rule) so we first move processes of our own
+ * cgroup (act and commands it started) into a leaf cgroup.
+ */
+func getParentCgroupPath() (string, error) {
+	ownPath, err := getOwnCgroupPath()
+
+	if err != nil {
+		return "", err
+	}
+
+	// We already moved ourselves into the leaf cgroup.
+	if filepath.Base(ownPath) == cgroupSelfName {
+		return filepath.Dir(ownPath), nil
+	}
+
+	controlPath := filepath.Join(ownPath, "cgroup.subtree_control")
+	controllers := []byte("+cpu +memory +pids")
+
+	err = ioutil.WriteFile(controlPath, controllers, 0644)
+
+	if err == nil || !errors.Is(err, syscall.EBUSY) {
+		return ownPath, err
+	}
+
+	selfPath := filepath.Join(ownPath, cgroupSelfName)
+
+	if err := os.Mkdir(selfPath, 0755); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(ownPath, "cgroup.procs"))
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, pid := range strings.Fields(string(content)) {
+		// Processes might exit meanwhile so we only fail on enabling controllers.
+		ioutil.WriteFile(filepath.Join(selfPath, "cgroup.procs"), []byte(pid), 0644)
+	}
+
+	if err := ioutil.WriteFile(controlPath, controllers, 0644); err != nil {
+		return "", err
+	}
+
+	return ownPath, nil
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to create a cgroup (v2) enforcing cpu, memory
+ * and processes limits. We create it under the cgroup of this
+ * process so it only works when we own it (like when act runs as root
+ * or in a systemd unit with delegation). When memory is limited the
+ * whole cgroup gets killed on OOM.
+ */
+func NewCgroup(name string, limits *Limits) (*Cgroup, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroups v2 not available")
+	}
+
+	parentPath, err := getParentCgroupPath()
+
+	if err != nil {
+		return nil, err
+	}
+
+	cg := &Cgroup{Path: filepath.Join(parentPath, name)}
+
+	if err := os.Mkdir(cg.Path, 0755); err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+
+	if limits.Cpu > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d %d", int64(limits.Cpu*cgroupCpuPeriod), cgroupCpuPeriod)
+	}
+
+	if limits.Memory > 0 {
+		files["memory.max"] = fmt.Sprintf("%d", limits.Memory)
+		files["memory.oom.group"] = "1"
+	}
+
+	if limits.Processes > 0 {
+		files["pids.max"] = fmt.Sprintf("%d", limits.Processes)
+	}
+
+	for fileName, value := range files {
+		if err := ioutil.WriteFile(filepath.Join(cg.Path, fileName), []byte(value), 0644); err != nil {
+			cg.Remove()
+			return nil, err
+		}
+	}
+
+	return cg, nil
+}
+
+/**
+ * This function going to make a process start with limits. Go gives
+ * us no hook to run between fork and exec (the cgroup one needs a
+ * newer Go) so we start the process through a shell which joins the
+ * cgroup (if any) and sets rlimits before it execs the process. This
+ * way neither the process nor processes it spawns ever run outside
+ * of limits. We open the cgroup procs file ourselves and pass it
+ * down so processes running as other users can join the cgroup too.
+ * Cpu, memory and processes can only be limited with a cgroup (their
+ * rlimits count virtual memory and all processes of the user) so we
+ * return ErrUnenforcedLimits without one (the process still gets the
+ * open files limit).
+ */
+func SetLimits(cmd *exec.Cmd, limits *Limits, cg *Cgroup) error {
+	shPath, err := exec.LookPath("sh")
+
+	if err != nil {
+		return err
+	}
+
+	var stmts []string
+
+	if cg != nil {
+		procsFile, err := os.OpenFile(filepath.Join(cg.Path, "cgroup.procs"), os.O_WRONLY, 0)
+
+		if err != nil {
+			return err
+		}
+
+		cg.procsFile = procsFile
+
+		// Extra files start at fd 3 (we close it before exec).
+		fd := 3 + len(cmd.ExtraFiles)
+		cmd.ExtraFiles = append(cmd.ExtraFiles, procsFile)
+
+		stmts = append(stmts, fmt.Sprintf("echo $$ >&%d", fd), fmt.Sprintf("exec %d>&-", fd))
+	}
+
+	if limits.OpenFiles > 0 {
+		stmts = append(stmts, fmt.Sprintf("ulimit -n %d", limits.OpenFiles))
+	}
+
+	if len(stmts) > 0 {
+		cmd.Args = append([]string{"sh", "-c", strings.Join(append(stmts, `exec "$@"`), " && "), "sh", cmd.Path}, cmd.Args[1:]...)
+		cmd.Path = shPath
+	}
+
+	if cg == nil && (limits.Cpu > 0 || limits.Memory > 0 || limits.Processes > 0) {
+		return ErrUnenforcedLimits
+	}
+
+	return nil
+}
+
+//############################################################
+// Cgroup Struct Functions
+//############################################################
+
+/**
+ * This function going to get how many times processes of the cgroup
+ * were killed because they ran out of memory.
+ */
+func (cg *Cgroup) OomKills() int {
+	file, err := os.Open(filepath.Join(cg.Path, "memory.events"))
+
+	if err != nil {
+		return 0
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			count, _ := strconv.Atoi(fields[1])
+			return count
+		}
+	}
+
+	return 0
+}
+
+/**
+ * This function going to check if any process is still running in
+ * the cgroup.
+ */
+func (cg *Cgroup) isPopulated() bool {
+	content, err := ioutil.ReadFile(filepath.Join(cg.Path, "cgroup.events"))
+
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line == "populated 1" {
+			return true
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to kill every process of the cgroup. Kernels
+ * older than 5.14 have no cgroup.kill file so we kill processes one
+ * by one instead.
+ */
+func (cg *Cgroup) kill() error {
+	err := ioutil.WriteFile(filepath.Join(cg.Path, "cgroup.kill"), []byte("1"), 0644)
+
+	if err == nil || !os.IsNotExist(err) {
+		return err
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(cg.Path, "cgroup.procs"))
+
+	if err != nil {
+		return err
+	}
+
+	for _, field := range strings.Fields(string(content)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * This function going to remove the cgroup. Processes still running
+ * in it (like ones a command left in background) get killed first
+ * since a cgroup can't be removed until it's empty.
+ */
+func (cg *Cgroup) Remove() error {
+	if cg.procsFile != nil {
+		cg.procsFile.Close()
+	}
+
+	if cg.isPopulated() {
+		if err := cg.kill(); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(cgroupKillTimeout)
+
+		for cg.isPopulated() {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("processes of cgroup %s did not die", cg.Path)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	return os.Remove(cg.Path)
+}
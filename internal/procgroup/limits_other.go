@@ -0,0 +1,41 @@
+// +build !linux
+
+package procgroup
+
+import "os/exec"
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * Cgroups only exist on linux.
+ */
+func NewCgroup(name string, limits *Limits) (*Cgroup, error) {
+	return nil, ErrUnsupportedLimits
+}
+
+/**
+ * We only enforce limits on linux.
+ */
+func SetLimits(cmd *exec.Cmd, limits *Limits, cg *Cgroup) error {
+	return ErrUnsupportedLimits
+}
+
+//############################################################
+// Cgroup Struct Functions
+//############################################################
+
+/**
+ * Cgroups only exist on linux.
+ */
+func (cg *Cgroup) OomKills() int {
+	return 0
+}
+
+/**
+ * Cgroups only exist on linux.
+ */
+func (cg *Cgroup) Remove() error {
+	return ErrUnsupportedLimits
+}
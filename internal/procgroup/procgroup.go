@@ -0,0 +1,75 @@
+/**
+ * This package going to hold everything we need to control the
+ * processes we spawn in a platform independent way. Each command we
+ * run gets its own process group (a new session on unix and a new
+ * process group plus a job object on windows) so we can signal the
+ * command together with every process it spawned.
+ *
+ * On windows we can only stop and kill process groups (other signals
+ * like STOP and CONT are not supported) and the process group id of
+ * a command is the pid of the command itself.
+ */
+
+package procgroup
+
+import (
+	"errors"
+	"os"
+)
+
+//############################################################
+// Types
+//############################################################
+
+/**
+ * This struct going to hold the process tree info we need about a
+ * process.
+ */
+type Proc struct {
+	Ppid int
+	Pgid int
+}
+
+/**
+ * This struct going to hold resource limits of a process group (zero
+ * values mean no limit).
+ */
+type Limits struct {
+	Cpu       float64
+	Memory    int64
+	OpenFiles uint64
+	Processes uint64
+}
+
+/**
+ * This struct going to represent a cgroup (v2) we create to enforce
+ * limits of a process group.
+ */
+type Cgroup struct {
+	Path string
+
+	// Procs file of the cgroup processes join it through.
+	procsFile *os.File
+}
+
+//############################################################
+// Exported Variables
+//############################################################
+
+/**
+ * Error we return when trying to send a signal the platform don't
+ * support.
+ */
+var ErrUnsupportedSignal = errors.New("signal not supported on this platform")
+
+/**
+ * Error we return when resource limits can't be enforced on this
+ * platform.
+ */
+var ErrUnsupportedLimits = errors.New("resource limits not supported on this platform")
+
+/**
+ * Error we return when cpu, memory and processes limits can't be
+ * enforced because there is no cgroup.
+ */
+var ErrUnenforcedLimits = errors.New("cpu, memory and processes limits are not enforced without cgroups v2")
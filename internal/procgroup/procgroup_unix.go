@@ -0,0 +1,213 @@
+// +build linux darwin freebsd openbsd netbsd dragonfly
+
+package procgroup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to get process attributes to start a process
+ * in a new process group.
+ *
+ * @NOTE : For some reason using SysProcAttr.Setpgid give us some
+ * weird behaviors at least in MacOS. Using SysProcAttr.Setsid seems
+ * to have the same end result (creating different pgid for child
+ * process). Based on the following:
+ *
+ * https://stackoverflow.com/questions/43364958/start-command-with-new-process-group-id-golang
+ */
+func NewSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+/**
+ * This function going to get process attributes to start a process
+ * in a new process group with stdin (a pseudo-terminal) as its
+ * controlling terminal.
+ */
+func NewTtySysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+}
+
+/**
+ * This function going to track a started process group. On unix the
+ * kernel already tracks process groups for us.
+ */
+func Track(cmd *exec.Cmd) {}
+
+/**
+ * This function going to get the process group id of a process.
+ */
+func Getpgid(pid int) (int, error) {
+	return syscall.Getpgid(pid)
+}
+
+/**
+ * This function going to send a signal to all processes of a
+ * process group.
+ */
+func Signal(pgid int, sig syscall.Signal) error {
+	return syscall.Kill(-pgid, sig)
+}
+
+/**
+ * This function going to check if a process group still has any
+ * process running.
+ */
+func IsGroupRunning(pgid int) bool {
+	return syscall.Kill(-pgid, syscall.Signal(0)) == nil
+}
+
+/**
+ * This function going to check if process is up and running.
+ */
+func IsRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+
+	if err != nil {
+		return false
+	}
+
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+/**
+ * This function going to list all processes reading them from /proc
+ * or using the ps command on systems without /proc (like macOS).
+ */
+func List() map[int]*Proc {
+	procs := make(map[int]*Proc)
+
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		entries, err := ioutil.ReadDir("/proc")
+
+		if err != nil {
+			return procs
+		}
+
+		for _, entry := range entries {
+			pid, err := strconv.Atoi(entry.Name())
+
+			if err != nil {
+				continue
+			}
+
+			content, err := ioutil.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+
+			if err != nil {
+				continue
+			}
+
+			/**
+			 * Process name can contain spaces so we parse fields after
+			 * the closing parenthesis: state(3) ppid(4) pgrp(5).
+			 */
+			stat := string(content)
+			fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+
+			if len(fields) < 3 {
+				continue
+			}
+
+			ppid, _ := strconv.Atoi(fields[1])
+			pgid, _ := strconv.Atoi(fields[2])
+
+			procs[pid] = &Proc{Ppid: ppid, Pgid: pgid}
+		}
+
+		return procs
+	}
+
+	output, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=").Output()
+
+	if err != nil {
+		return procs
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) < 3 {
+			continue
+		}
+
+		pid, _ := strconv.Atoi(fields[0])
+		ppid, _ := strconv.Atoi(fields[1])
+		pgid, _ := strconv.Atoi(fields[2])
+
+		procs[pid] = &Proc{Ppid: ppid, Pgid: pgid}
+	}
+
+	return procs
+}
+
+/**
+ * This function going to get the start time of a process so we can
+ * tell it apart from a later process reusing its pid. The value is
+ * opaque (clock ticks since boot on linux and the ps start date on
+ * other systems) and it's empty when the process is not running.
+ */
+func GetStartTime(pid int) string {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		content, err := ioutil.ReadFile(filepath.Join("/proc", fmt.Sprintf("%d", pid), "stat"))
+
+		if err != nil {
+			return ""
+		}
+
+		// Fields after process name: state(3) ... starttime(22).
+		stat := string(content)
+		fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+
+		if len(fields) < 20 {
+			return ""
+		}
+
+		return fields[19]
+	}
+
+	output, err := exec.Command("ps", "-o", "lstart=", "-p", fmt.Sprintf("%d", pid)).Output()
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+/**
+ * This function going to get the name of the binary running in a
+ * process. On linux we read it from /proc and on other systems (like
+ * macOS) we fallback to the ps command.
+ */
+func GetBinName(pid int) string {
+	if _, err := os.Stat("/proc/self/cmdline"); err == nil {
+		content, err := ioutil.ReadFile(filepath.Join("/proc", fmt.Sprintf("%d", pid), "cmdline"))
+
+		if err != nil {
+			return ""
+		}
+
+		return filepath.Base(strings.Split(string(content), "\x00")[0])
+	}
+
+	output, err := exec.Command("ps", "-o", "comm=", "-p", fmt.Sprintf("%d", pid)).Output()
+
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(strings.TrimSpace(string(output)))
+}
@@ -0,0 +1,280 @@
+package procgroup
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+//############################################################
+// Internal Constants
+//############################################################
+
+/**
+ * Exit code windows reports for processes still running.
+ */
+const stillActive = 259
+
+//############################################################
+// Internal Variables
+//############################################################
+
+/**
+ * Job objects of process groups started by this process. Killing a
+ * job object kills every process of the group at once. Process
+ * groups started by other processes are killed with taskkill.
+ */
+var jobs = make(map[int]windows.Handle)
+
+/**
+ * Mutex to access job objects.
+ */
+var jobsMutex sync.Mutex
+
+//############################################################
+// Internal Functions
+//############################################################
+
+/**
+ * This function going to walk over all processes in a snapshot.
+ */
+func walkProcesses(fn func(entry *windows.ProcessEntry32)) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+
+	if err != nil {
+		return
+	}
+
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+
+	for err = windows.Process32First(snapshot, &entry); err == nil; err = windows.Process32Next(snapshot, &entry) {
+		fn(&entry)
+	}
+}
+
+/**
+ * This function going to kill a process group with taskkill (which
+ * kills the whole process tree).
+ */
+func taskKill(pgid int, force bool) error {
+	args := []string{"/T", "/PID", strconv.Itoa(pgid)}
+
+	if force {
+		args = append([]string{"/F"}, args...)
+	}
+
+	return exec.Command("taskkill", args...).Run()
+}
+
+//############################################################
+// Exported Functions
+//############################################################
+
+/**
+ * This function going to get process attributes to start a process
+ * in a new process group.
+ */
+func NewSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP}
+}
+
+/**
+ * This function going to get process attributes to start a process
+ * with a controlling terminal. Pseudo-terminals are not supported on
+ * windows so this is the same as a regular process group.
+ */
+func NewTtySysProcAttr() *syscall.SysProcAttr {
+	return NewSysProcAttr()
+}
+
+/**
+ * This function going to track a started process group assigning it
+ * to a job object so every process it spawns can be killed together.
+ */
+func Track(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	job, err := windows.CreateJobObject(nil, nil)
+
+	if err != nil {
+		return
+	}
+
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(cmd.Process.Pid))
+
+	if err != nil {
+		windows.CloseHandle(job)
+		return
+	}
+
+	defer windows.CloseHandle(process)
+
+	if err := windows.AssignProcessToJobObject(job, process); err != nil {
+		windows.CloseHandle(job)
+		return
+	}
+
+	jobsMutex.Lock()
+	jobs[cmd.Process.Pid] = job
+	jobsMutex.Unlock()
+}
+
+/**
+ * This function going to get the process group id of a process which
+ * on windows is the pid of the process that started the group.
+ */
+func Getpgid(pid int) (int, error) {
+	return pid, nil
+}
+
+/**
+ * This function going to send a signal to all processes of a
+ * process group. We ask processes to stop (INT, TERM and HUP) with
+ * a ctrl+break event and kill them (KILL) with the job object of the
+ * group. Other signals are not supported.
+ */
+func Signal(pgid int, sig syscall.Signal) error {
+	switch sig {
+	case syscall.Signal(0):
+		if !IsGroupRunning(pgid) {
+			return errors.New("process group not running")
+		}
+
+		return nil
+	case syscall.SIGKILL:
+		jobsMutex.Lock()
+		job, ok := jobs[pgid]
+		delete(jobs, pgid)
+		jobsMutex.Unlock()
+
+		if ok {
+			defer windows.CloseHandle(job)
+			return windows.TerminateJobObject(job, 1)
+		}
+
+		return taskKill(pgid, true)
+	case syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP:
+		if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(pgid)); err == nil {
+			return nil
+		}
+
+		return taskKill(pgid, false)
+	}
+
+	return ErrUnsupportedSignal
+}
+
+/**
+ * This function going to check if a process group still has any
+ * process running (the process that started it or any descendant).
+ */
+func IsGroupRunning(pgid int) bool {
+	if IsRunning(pgid) {
+		return true
+	}
+
+	procs := List()
+
+	for pid := range procs {
+		// We bound the walk so we never loop forever on inconsistent data.
+		for i, ppid := 0, procs[pid].Ppid; i < len(procs) && ppid > 0; i++ {
+			if ppid == pgid {
+				return true
+			}
+
+			parent, ok := procs[ppid]
+
+			if !ok {
+				break
+			}
+
+			ppid = parent.Ppid
+		}
+	}
+
+	return false
+}
+
+/**
+ * This function going to check if process is up and running.
+ */
+func IsRunning(pid int) bool {
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+
+	if err != nil {
+		return false
+	}
+
+	defer windows.CloseHandle(process)
+
+	var exitCode uint32
+
+	if err := windows.GetExitCodeProcess(process, &exitCode); err != nil {
+		return false
+	}
+
+	return exitCode == stillActive
+}
+
+/**
+ * This function going to get the start time of a process so we can
+ * tell it apart from a later process reusing its pid. It's empty
+ * when the process is not running.
+ */
+func GetStartTime(pid int) string {
+	process, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+
+	if err != nil {
+		return ""
+	}
+
+	defer windows.CloseHandle(process)
+
+	var creationTime, exitTime, kernelTime, userTime windows.Filetime
+
+	if err := windows.GetProcessTimes(process, &creationTime, &exitTime, &kernelTime, &userTime); err != nil {
+		return ""
+	}
+
+	return strconv.FormatInt(creationTime.Nanoseconds(), 10)
+}
+
+/**
+ * This function going to list all processes.
+ */
+func List() map[int]*Proc {
+	procs := make(map[int]*Proc)
+
+	walkProcesses(func(entry *windows.ProcessEntry32) {
+		pid := int(entry.ProcessID)
+		procs[pid] = &Proc{Ppid: int(entry.ParentProcessID), Pgid: pid}
+	})
+
+	return procs
+}
+
+/**
+ * This function going to get the name of the binary running in a
+ * process.
+ */
+func GetBinName(pid int) string {
+	var binName string
+
+	walkProcesses(func(entry *windows.ProcessEntry32) {
+		if int(entry.ProcessID) == pid {
+			binName = windows.UTF16ToString(entry.ExeFile[:])
+		}
+	})
+
+	return binName
+}